@@ -4,10 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
 )
 
 // Result describes the outcome of a capture operation.
@@ -44,6 +47,10 @@ type ScreenshotOptions struct {
 
 	// OptimizeForSpeed slightly reduces image size accuracy for faster encoding.
 	OptimizeForSpeed bool
+
+	// Progress, when set, is called after each viewport of a Scroll capture
+	// with the number of viewports captured so far and the expected total.
+	Progress func(done, total int)
 }
 
 // PDFOptions mirrors proto.PagePrintToPDF and expresses optional float fields as pointers.
@@ -110,13 +117,7 @@ func CaptureScreenshot(page *rod.Page, opts ScreenshotOptions) (*Result, error)
 			return nil, fmt.Errorf("capture screenshot: element: %w", err)
 		}
 	case opts.Scroll:
-		scrollOpts := &rod.ScrollScreenshotOptions{
-			Format: protoFormat,
-		}
-		if opts.Quality != nil {
-			scrollOpts.Quality = opts.Quality
-		}
-		data, err = page.ScrollScreenshot(scrollOpts)
+		data, err = scrollScreenshot(page, protoFormat, opts.Quality, opts.Progress)
 		if err != nil {
 			return nil, fmt.Errorf("capture screenshot: scroll: %w", err)
 		}
@@ -146,6 +147,71 @@ func CaptureScreenshot(page *rod.Page, opts ScreenshotOptions) (*Result, error)
 	return &Result{Data: data, MimeType: mimeType}, nil
 }
 
+// scrollScreenshot follows rod's Page.ScrollScreenshot (capture a viewport,
+// scroll, wait for the DOM to settle, stitch) and reports each step to
+// progress.
+func scrollScreenshot(page *rod.Page, format proto.PageCaptureScreenshotFormat, quality *int, progress func(done, total int)) ([]byte, error) {
+	const waitPerScroll = 300 * time.Millisecond
+
+	metrics, err := proto.PageGetLayoutMetrics{}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+	if metrics.CSSContentSize == nil || metrics.CSSVisualViewport == nil {
+		return nil, errors.New("failed to get css content size")
+	}
+	viewportHeight := metrics.CSSVisualViewport.ClientHeight
+	contentHeight := metrics.CSSContentSize.Height
+	if viewportHeight <= 0 {
+		return nil, errors.New("viewport has no height")
+	}
+	total := int(math.Ceil(contentHeight / viewportHeight))
+	if total < 1 {
+		total = 1
+	}
+
+	var (
+		scrollTop float64
+		images    []utils.ImgWithBox
+	)
+	for {
+		clip := &proto.PageViewport{
+			Y:      scrollTop,
+			Width:  metrics.CSSVisualViewport.ClientWidth,
+			Height: viewportHeight,
+			Scale:  1,
+		}
+		if scrollTop+viewportHeight > contentHeight {
+			clip.Height = contentHeight - scrollTop
+		}
+		shot, err := proto.PageCaptureScreenshot{Format: format, Quality: quality, Clip: clip}.Call(page)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, utils.ImgWithBox{Img: shot.Data})
+		if progress != nil {
+			progress(len(images), total)
+		}
+
+		scrollTop += viewportHeight
+		if scrollTop >= contentHeight {
+			break
+		}
+		if err := page.Mouse.Scroll(0, viewportHeight, 1); err != nil {
+			return nil, fmt.Errorf("scroll error: %w", err)
+		}
+		if err := page.WaitDOMStable(waitPerScroll, 0); err != nil {
+			return nil, fmt.Errorf("WaitDOMStable error: %w", err)
+		}
+	}
+
+	var imgOption *utils.ImgOption
+	if quality != nil {
+		imgOption = &utils.ImgOption{Quality: *quality}
+	}
+	return utils.SplicePngVertical(images, format, imgOption)
+}
+
 // CapturePDF renders the current page into a PDF using supplied options.
 func CapturePDF(page *rod.Page, opts PDFOptions) (*Result, error) {
 	if page == nil {
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
@@ -18,14 +20,19 @@ import (
 	"roderik/internal/ai/history"
 	"roderik/internal/ai/llm"
 	"roderik/internal/ai/llm/openai"
+	"roderik/internal/ai/mcpclient"
 	"roderik/internal/ai/profile"
 	aitools "roderik/internal/ai/tools"
+	"roderik/internal/ai/usage"
+	"roderik/internal/ai/vision"
 )
 
 const (
 	defaultAIModel           = "gpt-5"
 	defaultAIHistoryWindow   = 16
 	defaultAIRequestTimeout  = 90 * time.Second
+	defaultAIMaxIterations   = 8
+	defaultAIToolTimeout     = 60 * time.Second
 	systemPromptHeader       = "You are Roderik's integrated AI assistant. Use the provided browser tools to inspect pages, gather evidence, and complete tasks carefully."
 	systemPromptGuidelines   = "Guidelines:\n- Prefer calling tools to inspect the live browser when information is uncertain.\n- Confirm before performing destructive or irreversible actions.\n- Keep responses concise when no further action is required.\n- When a tool call returns data, summarize the key points before continuing.\n- Default to the currently loaded page for evidence; only use external search tools (e.g., duck) when the user explicitly requests web search.\n- Tools operate on the currently focused element; use parent/child/head/next or reload the page to broaden scope before summarizing full-page content.\n- After navigation, Roderik auto-focuses the first visible heading; verify or adjust the selection before assuming page-wide context."
 	systemPromptContextIntro = "Current browser context:"
@@ -46,7 +53,7 @@ var (
 		Use:          "ai [message]",
 		Aliases:      []string{"chat"},
 		Short:        "Chat with the integrated AI assistant",
-		Long:         "Send a prompt to the built-in AI assistant. The assistant can call Roderik tools to interact with the active browser session.",
+		Long:         "Send a prompt to the built-in AI assistant. The assistant can call Roderik tools to interact with the active browser session, plus the tools of external MCP servers declared in --mcp-config (namespaced server__tool).",
 		Args:         cobra.ArbitraryArgs,
 		RunE:         runAICommand,
 		SilenceUsage: true,
@@ -54,21 +61,35 @@ var (
 )
 
 func init() {
-	aiCmd.Flags().IntVar(&aiHistoryWindow, "history-window", defaultAIHistoryWindow, "Number of recent AI chat messages to retain (0 keeps the full history)")
-	aiCmd.Flags().StringVarP(&aiModelProfile, "model", "m", "", "Model profile to use for the AI assistant (defaults to config or environment)")
+	aiCmd.PersistentFlags().IntVar(&aiHistoryWindow, "history-window", defaultAIHistoryWindow, "Number of recent AI chat messages to retain (0 keeps the full history)")
+	aiCmd.PersistentFlags().StringVarP(&aiModelProfile, "model", "m", "", "Model profile to use for the AI assistant (defaults to config or environment)")
 	aiCmd.Flags().BoolVar(&aiPrintConfigPath, "print-config-path", false, "Print the resolved AI profile config file path and exit")
 	RootCmd.AddCommand(aiCmd)
 }
 
 type ChatSession struct {
 	provider              llm.Provider
+	mcpServers            *mcpclient.Manager
 	tools                 []llm.Tool
 	toolRegistry          map[string]aitools.Definition
 	history               []llm.Message
 	historyWindow         int
 	baseSystemPrompt      string
+	maxIterations         int
+	turnTimeout           time.Duration
+	toolTimeout           time.Duration
+	approver              toolApprover
+	vision                bool
+	visionMaxDimension    int
+	profileName           string
+	model                 string
+	price                 *usage.Price
+	budget                usage.Budget
+	sessionID             string
+	usagePath             string
 	totalPromptTokens     int64
 	totalCompletionTokens int64
+	totalCost             float64
 }
 
 func runAICommand(cmd *cobra.Command, args []string) error {
@@ -95,12 +116,19 @@ func runAICommand(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	defer closeChatSessionOnExit()
 	session.SetHistoryWindow(aiHistoryWindow)
 
-	ctx, cancel := context.WithTimeout(ctx, defaultAIRequestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, session.turnTimeout)
 	defer cancel()
 
 	reply, err := session.Send(ctx, input)
+	var timeout *modelTimeoutError
+	var budget *budgetStopError
+	if errors.As(err, &timeout) || errors.As(err, &budget) {
+		// the operator can retry or raise the budget; neither fails the command
+		reply, err = err.Error(), nil
+	}
 	if err != nil {
 		return err
 	}
@@ -149,7 +177,10 @@ func ensureChatSession() (*ChatSession, error) {
 	}
 
 	tools, mapping := aitools.LLMTools("roderik")
+	external := connectAIMCPServers()
+	tools, mapping = withExternalMCPTools(external, tools, mapping)
 	provider := openai.NewProvider(apiKey, modelProfile.BaseURL, modelProfile.Model, modelProfile.SystemPrompt, modelProfile.MaxTokens)
+	provider.SetVision(modelProfile.Vision)
 	if Verbose {
 		provider.SetDebugLogger(func(msg string) {
 			fmt.Fprintln(os.Stderr, msg)
@@ -157,18 +188,32 @@ func ensureChatSession() (*ChatSession, error) {
 	}
 
 	chatSession = &ChatSession{
-		provider:         provider,
-		tools:            tools,
-		toolRegistry:     mapping,
-		historyWindow:    aiHistoryWindow,
-		baseSystemPrompt: strings.TrimSpace(modelProfile.SystemPrompt),
-	}
+		provider:           provider,
+		mcpServers:         external,
+		tools:              tools,
+		toolRegistry:       mapping,
+		historyWindow:      aiHistoryWindow,
+		baseSystemPrompt:   strings.TrimSpace(modelProfile.SystemPrompt),
+		maxIterations:      modelProfile.MaxIterations,
+		turnTimeout:        modelProfile.TurnTimeout(),
+		toolTimeout:        modelProfile.ToolTimeout(),
+		approver:           interactiveToolApprover(),
+		vision:             modelProfile.Vision,
+		visionMaxDimension: modelProfile.VisionMaxDimension,
+		profileName:        modelProfile.Name,
+		model:              modelProfile.Model,
+		price:              modelProfile.Price,
+		budget:             modelProfile.Budget(),
+		sessionID:          newAISessionID(),
+		usagePath:          usage.DefaultPath(),
+	}
+	chatSession.applyLoopDefaults()
 	logAI("Ready with profile %s (%s via %s)",
 		profileNameOrDefault(modelProfile.Name),
 		modelProfile.Model,
 		providerName,
 	)
-	debugAI("session initialized profile=%s provider=%s model=%s base_url=%s max_tokens=%d tools=%d history_window=%d",
+	debugAI("session initialized profile=%s provider=%s model=%s base_url=%s max_tokens=%d tools=%d history_window=%d max_iterations=%d turn_timeout=%s tool_timeout=%s vision=%t",
 		profileNameOrDefault(modelProfile.Name),
 		providerName,
 		modelProfile.Model,
@@ -176,10 +221,56 @@ func ensureChatSession() (*ChatSession, error) {
 		modelProfile.MaxTokens,
 		len(tools),
 		aiHistoryWindow,
+		chatSession.maxIterations,
+		chatSession.turnTimeout,
+		chatSession.toolTimeout,
+		chatSession.vision,
 	)
 	return chatSession, nil
 }
 
+// Close stops the session's external MCP servers.
+func (s *ChatSession) Close() error {
+	if s.mcpServers == nil {
+		return nil
+	}
+	err := s.mcpServers.Close()
+	s.mcpServers = nil
+	return err
+}
+
+// closeChatSession ends the chat session, if any.
+func closeChatSession() {
+	if chatSession == nil {
+		return
+	}
+	if err := chatSession.Close(); err != nil {
+		logAI("✖ %v", err)
+	}
+	chatSession = nil
+}
+
+// closeChatSessionOnExit ends the chat session when no REPL keeps the process
+// and the session alive after the current command.
+func closeChatSessionOnExit() {
+	if !(Interactive && StdinIsTerminal() && StdoutIsTerminal()) {
+		closeChatSession()
+	}
+}
+
+// applyLoopDefaults fills in agent loop limits the model profile left unset.
+func (s *ChatSession) applyLoopDefaults() {
+	if s.maxIterations <= 0 {
+		s.maxIterations = defaultAIMaxIterations
+	}
+	if s.turnTimeout <= 0 {
+		s.turnTimeout = defaultAIRequestTimeout
+	}
+	if s.toolTimeout <= 0 {
+		s.toolTimeout = defaultAIToolTimeout
+	}
+}
+
 func (s *ChatSession) SetHistoryWindow(n int) {
 	s.historyWindow = n
 	s.prune()
@@ -191,16 +282,22 @@ func (s *ChatSession) Send(ctx context.Context, input string) (string, error) {
 		return "", fmt.Errorf("prompt cannot be empty")
 	}
 
+	if reason, over := s.budget.Exceeded(s.totalTokens(), s.totalCost); over {
+		logAI("✖ %s", reason)
+		return "", &budgetStopError{reason: reason}
+	}
+
 	userMsg := history.NewUserMessage(input)
 	s.history = append(s.history, userMsg)
 	s.prune()
 
-	const maxIterations = 8
+	s.applyLoopDefaults()
 	var lastToolSummary string
 	turnSteps := make([]string, 0, 8)
 	turnPromptTokens := 0
 	turnCompletionTokens := 0
-	for i := 0; i < maxIterations; i++ {
+	turnCost := 0.0
+	for i := 0; i < s.maxIterations; i++ {
 		focusHint := focusAwareHint()
 		toolsForCall := s.toolsWithFocusHint(focusHint)
 		fullPrompt := buildSystemPrompt(toolsForCall)
@@ -217,13 +314,12 @@ func (s *ChatSession) Send(ctx context.Context, input string) (string, error) {
 					s.history = s.history[:len(s.history)-1]
 				}
 				s.prune()
-				return "Timed out waiting for the model (90s). Please retry or simplify the request.", nil
+				return "", &modelTimeoutError{timeout: s.turnTimeout}
 			}
 			return "", err
 		}
 		promptTokens, completionTokens := resp.GetUsage()
-		s.totalPromptTokens += int64(promptTokens)
-		s.totalCompletionTokens += int64(completionTokens)
+		turnCost += s.recordUsage(promptTokens, completionTokens)
 		turnPromptTokens += promptTokens
 		turnCompletionTokens += completionTokens
 		debugAI(
@@ -249,45 +345,60 @@ func (s *ChatSession) Send(ctx context.Context, input string) (string, error) {
 		}
 		if len(toolCalls) == 0 {
 			debugAI("assistant response iteration=%d", i+1)
-			logTurnSummary(turnSteps, turnPromptTokens, turnCompletionTokens, s.totalPromptTokens, s.totalCompletionTokens)
+			s.logTurnSummary(turnSteps, turnPromptTokens, turnCompletionTokens, turnCost)
 			return resp.GetContent(), nil
 		}
 
+		if reason, over := s.budget.Exceeded(s.totalTokens(), s.totalCost); over {
+			logAI("✖ %s; stopping before %d tool call(s)", reason, len(toolCalls))
+			// drop the unanswered tool-call message so the history stays valid
+			if n := len(s.history); n > 0 && len(s.history[n-1].GetToolCalls()) > 0 {
+				s.history = s.history[:n-1]
+			}
+			s.logTurnSummary(turnSteps, turnPromptTokens, turnCompletionTokens, turnCost)
+			return "", &budgetStopError{reason: reason}
+		}
+
 		debugAI("assistant requested %d tool call(s)", len(toolCalls))
-		for _, call := range toolCalls {
+		var images []llm.Image
+		var imageSources []string
+		for _, outcome := range s.executeToolCalls(ctx, toolCalls) {
+			call := outcome.call
 			sanitized := call.GetName()
-			def, ok := s.toolRegistry[sanitized]
 			var resultContent interface{}
 			var callErr error
 			stepLabel := sanitized
 			stepSummary := ""
-			argsLabel := formatToolArgs(call.GetArguments())
-			if !ok {
+			if !outcome.known {
 				callErr = fmt.Errorf("tool %q is not registered", sanitized)
 				stepSummary = "tool not registered"
 				logAI("✖ unable to use %s: tool not registered", sanitized)
 			} else {
-				stepLabel = def.Name
-				if argsLabel != "" {
-					logAI("▶ %s %s", def.Name, argsLabel)
+				stepLabel = outcome.def.Name
+				if outcome.err != nil {
+					callErr = outcome.err
+					stepSummary = outcome.err.Error()
+					logAI("✖ %s → %v", outcome.def.Name, outcome.err)
+				} else if img, ok := s.toolResultImage(outcome.result); ok {
+					images = append(images, img)
+					imageSources = append(imageSources, outcome.def.Name)
+					resultContent = imageToolResultPayload(outcome.result)
+					stepSummary = summarizeToolResult(outcome.result)
+					logAI("✔ %s → %s (image attached)", outcome.def.Name, truncateForLog(stepSummary, 256))
 				} else {
-					logAI("▶ %s", def.Name)
-				}
-				result, err := aitools.Call(ctx, def.Name, call.GetArguments())
-				if err != nil {
-					callErr = err
-					stepSummary = err.Error()
-					logAI("✖ %s → %v", def.Name, err)
-				} else {
-					resultContent = toolResultPayload(result)
-					stepSummary = summarizeToolResult(result)
-					logAI("✔ %s → %s", def.Name, truncateForLog(stepSummary, 256))
+					resultContent = toolResultPayload(outcome.result)
+					stepSummary = summarizeToolResult(outcome.result)
+					logAI("✔ %s → %s", outcome.def.Name, truncateForLog(stepSummary, 256))
 				}
 			}
 
 			if callErr != nil {
-				resultContent = map[string]interface{}{
-					"error": callErr.Error(),
+				if denial, ok := toolDenialPayload(callErr); ok {
+					resultContent = denial
+				} else {
+					resultContent = map[string]interface{}{
+						"error": callErr.Error(),
+					}
 				}
 				errMsg := truncateForLog(stepSummary, 80)
 				turnSteps = append(turnSteps, fmt.Sprintf("%s (error: %s)", stepLabel, errMsg))
@@ -309,10 +420,11 @@ func (s *ChatSession) Send(ctx context.Context, input string) (string, error) {
 			}
 			s.prune()
 		}
+		s.attachImages(imageSources, images)
 	}
 
 	debugAI("assistant hit tool iteration limit; returning fallback message")
-	logTurnSummary(turnSteps, turnPromptTokens, turnCompletionTokens, s.totalPromptTokens, s.totalCompletionTokens)
+	s.logTurnSummary(turnSteps, turnPromptTokens, turnCompletionTokens, turnCost)
 	if lastToolSummary != "" {
 		message := fmt.Sprintf("I ran multiple tools but still couldn't finish. Most recent result: %s", truncateForLog(lastToolSummary, 256))
 		return message, nil
@@ -687,7 +799,7 @@ func sanitizeHistory(messages []llm.Message) []llm.Message {
 	return clean
 }
 
-func logTurnSummary(steps []string, turnPrompt, turnCompletion int, totalPrompt, totalCompletion int64) {
+func (s *ChatSession) logTurnSummary(steps []string, turnPrompt, turnCompletion int, turnCost float64) {
 	summary := "no tools used"
 	if len(steps) > 0 {
 		summary = strings.Join(steps, " → ")
@@ -695,16 +807,84 @@ func logTurnSummary(steps []string, turnPrompt, turnCompletion int, totalPrompt,
 
 	turnPromptHuman := formatTokensHuman(int64(turnPrompt))
 	turnCompletionHuman := formatTokensHuman(int64(turnCompletion))
-	totalHuman := formatTokensHuman(totalPrompt + totalCompletion)
+	totalHuman := formatTokensHuman(s.totalTokens())
 
-	logAI("Summary: %s | tokens this turn %s prompt / %s completion (total %s)",
+	costLabel := ""
+	if s.price != nil {
+		costLabel = fmt.Sprintf(" | cost ~%s this turn / ~%s session", usage.FormatCost(turnCost), usage.FormatCost(s.totalCost))
+	}
+
+	logAI("Summary: %s | tokens this turn %s prompt / %s completion (total %s)%s",
 		summary,
 		turnPromptHuman,
 		turnCompletionHuman,
 		totalHuman,
+		costLabel,
 	)
 }
 
+func (s *ChatSession) totalTokens() int64 {
+	return s.totalPromptTokens + s.totalCompletionTokens
+}
+
+// recordUsage adds one model call to the session totals, persists it to the
+// usage log and returns its estimated cost (zero when the model has no price).
+func (s *ChatSession) recordUsage(promptTokens, completionTokens int) float64 {
+	s.totalPromptTokens += int64(promptTokens)
+	s.totalCompletionTokens += int64(completionTokens)
+
+	cost := 0.0
+	if s.price != nil {
+		cost = s.price.Cost(promptTokens, completionTokens)
+		s.totalCost += cost
+	}
+
+	if s.usagePath == "" || (promptTokens == 0 && completionTokens == 0) {
+		return cost
+	}
+	rec := usage.Record{
+		Time:             time.Now(),
+		SessionID:        s.sessionID,
+		Profile:          s.profileName,
+		Model:            s.model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Cost:             cost,
+		Priced:           s.price != nil,
+	}
+	if err := usage.Append(s.usagePath, rec); err != nil {
+		debugAI("usage log write failed: %v", err)
+	}
+	return cost
+}
+
+// budgetStopError is returned by Send once the session budget is spent.
+type budgetStopError struct {
+	reason string
+}
+
+func (e *budgetStopError) Error() string {
+	return fmt.Sprintf("Stopped: %s. Raise max_session_tokens/max_session_cost in the model profile or start a new session to continue.", e.reason)
+}
+
+// modelTimeoutError is returned by Send when the model does not answer in
+// time. The prompt of the turn is dropped from the history.
+type modelTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *modelTimeoutError) Error() string {
+	return fmt.Sprintf("Timed out waiting for the model (%s). Please retry or simplify the request.", e.timeout)
+}
+
+func newAISessionID() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102-150405")
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf)
+}
+
 func summarizeStep(name, detail string) string {
 	detail = strings.TrimSpace(detail)
 	if detail == "" {
@@ -832,6 +1012,55 @@ func isTimeoutError(err error) bool {
 	return strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded")
 }
 
+// toolResultImage returns the image a tool produced, downscaled for the model,
+// when the session's profile enables vision.
+func (s *ChatSession) toolResultImage(res aitools.Result) (llm.Image, bool) {
+	if !s.vision || len(res.Binary) == 0 || !vision.IsImage(res.ContentType) {
+		return llm.Image{}, false
+	}
+	img, err := vision.Downscale(llm.Image{MIMEType: res.ContentType, Data: res.Binary}, s.visionMaxDimension)
+	if err != nil {
+		debugAI("image downscale failed, sending original: %v", err)
+	}
+	return img, true
+}
+
+// attachImages adds tool images to the history as a user message (tool
+// messages cannot carry images). Earlier images are replaced by placeholders
+// so only the latest screenshots are re-sent on later turns.
+func (s *ChatSession) attachImages(sources []string, images []llm.Image) {
+	if len(images) == 0 {
+		return
+	}
+	for i, msg := range s.history {
+		s.history[i] = history.WithoutImages(msg)
+	}
+	caption := fmt.Sprintf("Image output from %s (attached below).", strings.Join(sources, ", "))
+	s.history = append(s.history, history.NewImageMessage(caption, images))
+	s.prune()
+	debugAI("attached %d image(s) from %s", len(images), strings.Join(sources, ", "))
+}
+
+// imageToolResultPayload describes an image result without inlining its
+// bytes; the image itself follows as a separate message part.
+func imageToolResultPayload(res aitools.Result) interface{} {
+	payload := map[string]interface{}{
+		"content_type":   res.ContentType,
+		"bytes":          len(res.Binary),
+		"image_attached": true,
+	}
+	if res.Text != "" {
+		payload["text"] = res.Text
+	}
+	if res.FilePath != "" {
+		payload["file_path"] = res.FilePath
+	}
+	if res.Data != nil {
+		payload["data"] = res.Data
+	}
+	return payload
+}
+
 func toolResultPayload(res aitools.Result) interface{} {
 	payload := map[string]interface{}{}
 	if res.Text != "" {
@@ -849,6 +1078,9 @@ func toolResultPayload(res aitools.Result) interface{} {
 	if res.InlineURI != "" {
 		payload["inline_uri"] = res.InlineURI
 	}
+	if res.Data != nil {
+		payload["data"] = res.Data
+	}
 
 	switch len(payload) {
 	case 0:
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -13,12 +12,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-rod/rod/lib/proto"
 	"github.com/mark3labs/mcp-go/mcp"
 	"roderik/browser"
 	duckduck "roderik/duckduck"
 	aitools "roderik/internal/ai/tools"
 	"roderik/internal/appdirs"
+	"roderik/internal/subtitles"
 )
 
 var registerHandlersOnce sync.Once
@@ -55,10 +54,14 @@ func registerHandlers() {
 		aitools.RegisterHandler("describe", describeHandler)
 		aitools.RegisterHandler("xpath", xpathHandler)
 		aitools.RegisterHandler("duck", duckHandler)
+		aitools.RegisterHandler("research", researchHandler)
 		aitools.RegisterHandler("yttrans", yttransHandler)
 		aitools.RegisterHandler("network_list", networkListHandler)
 		aitools.RegisterHandler("network_save", networkSaveHandler)
+		aitools.RegisterHandler("network_get", networkGetHandler)
+		aitools.RegisterHandler("network_ws_frames", networkWSFramesHandler)
 		aitools.RegisterHandler("network_set_logging", networkSetLoggingHandler)
+		aitools.RegisterHandler("transcripts", transcriptsHandler)
 		// Additional tool handlers will be registered here as they migrate.
 	})
 }
@@ -77,8 +80,8 @@ func loadURLHandler(ctx context.Context, args map[string]interface{}) (aitools.R
 
 	toolDebug("[TOOLS] load_url CALLED args=%#v", args)
 
-	res, err := withPage(func() (aitools.Result, error) {
-		page, err := LoadURL(url)
+	res, err := withPageContext(ctx, func() (aitools.Result, error) {
+		page, err := LoadURLContext(ctx, url)
 		if err != nil {
 			return aitools.Result{}, fmt.Errorf("load_url failed: %w", err)
 		}
@@ -114,7 +117,7 @@ func textHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 		}
 	}
 
-	res, err := withPage(func() (aitools.Result, error) {
+	res, err := withPageContext(ctx, func() (aitools.Result, error) {
 		text, err := mcpText(lengthPtr)
 		if err != nil {
 			return aitools.Result{}, err
@@ -132,7 +135,7 @@ func textHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 func getHTMLHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] get_html CALLED args=%#v", args)
 
-	res, err := withPage(func() (aitools.Result, error) {
+	res, err := withPageContext(ctx, func() (aitools.Result, error) {
 		var rawURL string
 		if args != nil {
 			if v, ok := args["url"].(string); ok {
@@ -141,14 +144,14 @@ func getHTMLHandler(ctx context.Context, args map[string]interface{}) (aitools.R
 		}
 
 		if rawURL != "" {
-			buf, ctype, looksHTML, err := probeURL(rawURL, 32*1024)
+			buf, ctype, looksHTML, err := probeURL(ctx, rawURL, 32*1024)
 			if err != nil {
 				return aitools.Result{}, fmt.Errorf("get_html probe error: %w", err)
 			}
 			if !looksHTML {
 				data := buf
 				if len(data) == 0 {
-					resp, err := http.Get(rawURL)
+					resp, err := fetchURL(ctx, rawURL)
 					if err != nil {
 						return aitools.Result{}, fmt.Errorf("get_html fetch error: %w", err)
 					}
@@ -164,7 +167,7 @@ func getHTMLHandler(ctx context.Context, args map[string]interface{}) (aitools.R
 				return aitools.Result{Text: text}, nil
 			}
 
-			page, err := LoadURL(rawURL)
+			page, err := LoadURLContext(ctx, rawURL)
 			if err != nil {
 				return aitools.Result{}, fmt.Errorf("get_html failed to load url %q: %w", rawURL, err)
 			}
@@ -195,10 +198,10 @@ func getHTMLHandler(ctx context.Context, args map[string]interface{}) (aitools.R
 func captureScreenshotHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] capture_screenshot CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		rawURL := mcp.ExtractString(args, "url")
 		if strings.TrimSpace(rawURL) != "" {
-			if _, err := LoadURL(rawURL); err != nil {
+			if _, err := LoadURLContext(ctx, rawURL); err != nil {
 				return aitools.Result{}, fmt.Errorf("capture_screenshot load url %q: %w", rawURL, err)
 			}
 		}
@@ -237,7 +240,14 @@ func captureScreenshotHandler(ctx context.Context, args map[string]interface{})
 			Format:   format,
 			Quality:  qualityPtr,
 		}
-		result, err := captureScreenshotFunc(Page, opts)
+		if scroll {
+			opts.Progress = func(done, total int) {
+				aitools.ReportProgress(ctx, float64(done), float64(total), fmt.Sprintf("stitching %d/%d", done, total))
+			}
+		} else {
+			aitools.ReportProgress(ctx, 0, 0, "capturing screenshot")
+		}
+		result, err := captureScreenshotFunc(pageContext(Page, ctx), opts)
 		if err != nil {
 			return aitools.Result{}, err
 		}
@@ -265,13 +275,14 @@ func captureScreenshotHandler(ctx context.Context, args map[string]interface{})
 		switch delivery {
 		case "file":
 			output := mcp.ExtractString(args, "output")
-			path, err := resolveOutputPath(output, "", "", "screenshot", formatExt)
+			path, err := resolveOutputPath(output, mcpCaptureOutputDir(ctx), "", "screenshot", formatExt)
 			if err != nil {
 				return aitools.Result{}, err
 			}
 			if err := os.WriteFile(path, result.Data, 0644); err != nil {
 				return aitools.Result{}, fmt.Errorf("capture_screenshot write file: %w", err)
 			}
+			announceCaptureWritten(ctx, path)
 			toolDebug("[TOOLS] capture_screenshot RESULT saved=%s bytes=%d", path, len(result.Data))
 			return aitools.Result{
 				Text:        fmt.Sprintf("%s Saved to %s.", caption, path),
@@ -293,10 +304,10 @@ func captureScreenshotHandler(ctx context.Context, args map[string]interface{})
 func capturePDFHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] capture_pdf CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		rawURL := mcp.ExtractString(args, "url")
 		if strings.TrimSpace(rawURL) != "" {
-			if _, err := LoadURL(rawURL); err != nil {
+			if _, err := LoadURLContext(ctx, rawURL); err != nil {
 				return aitools.Result{}, fmt.Errorf("capture_pdf load url %q: %w", rawURL, err)
 			}
 		}
@@ -345,7 +356,8 @@ func capturePDFHandler(ctx context.Context, args map[string]interface{}) (aitool
 			opts.MarginRight = &val
 		}
 
-		result, err := capturePDFFunc(Page, opts)
+		aitools.ReportProgress(ctx, 0, 0, "rendering PDF")
+		result, err := capturePDFFunc(pageContext(Page, ctx), opts)
 		if err != nil {
 			return aitools.Result{}, err
 		}
@@ -366,13 +378,14 @@ func capturePDFHandler(ctx context.Context, args map[string]interface{}) (aitool
 		switch delivery {
 		case "file":
 			output := mcp.ExtractString(args, "output")
-			path, err := resolveOutputPath(output, "", "", "document", "pdf")
+			path, err := resolveOutputPath(output, mcpCaptureOutputDir(ctx), "", "document", "pdf")
 			if err != nil {
 				return aitools.Result{}, err
 			}
 			if err := os.WriteFile(path, result.Data, 0644); err != nil {
 				return aitools.Result{}, fmt.Errorf("capture_pdf write file: %w", err)
 			}
+			announceCaptureWritten(ctx, path)
 			toolDebug("[TOOLS] capture_pdf RESULT saved=%s bytes=%d", path, len(result.Data))
 			return aitools.Result{
 				Text:        fmt.Sprintf("%s Saved to %s.", caption, path),
@@ -395,7 +408,7 @@ func capturePDFHandler(ctx context.Context, args map[string]interface{}) (aitool
 func toMarkdownHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] to_markdown CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		var rawURL string
 		if args != nil {
 			if v, ok := args["url"].(string); ok {
@@ -404,14 +417,14 @@ func toMarkdownHandler(ctx context.Context, args map[string]interface{}) (aitool
 		}
 
 		if rawURL != "" {
-			buf, ctype, looksHTML, err := probeURL(rawURL, 32*1024)
+			buf, ctype, looksHTML, err := probeURL(ctx, rawURL, 32*1024)
 			if err != nil {
 				return aitools.Result{}, fmt.Errorf("to_markdown probe error: %w", err)
 			}
 			if !looksHTML {
 				data := buf
 				if len(data) == 0 {
-					resp, err := http.Get(rawURL)
+					resp, err := fetchURL(ctx, rawURL)
 					if err != nil {
 						return aitools.Result{}, fmt.Errorf("to_markdown fetch error: %w", err)
 					}
@@ -427,7 +440,7 @@ func toMarkdownHandler(ctx context.Context, args map[string]interface{}) (aitool
 				return aitools.Result{Text: text}, nil
 			}
 
-			page, err := LoadURL(rawURL)
+			page, err := LoadURLContext(ctx, rawURL)
 			if err != nil {
 				return aitools.Result{}, fmt.Errorf("to_markdown failed to load url %q: %w", rawURL, err)
 			}
@@ -446,21 +459,10 @@ func toMarkdownHandler(ctx context.Context, args map[string]interface{}) (aitool
 			Page.Timeout(10 * time.Second).WaitLoad()
 		}
 
-		if err := (proto.AccessibilityEnable{}).Call(Page); err != nil {
-			return aitools.Result{}, fmt.Errorf("accessibility enable failed: %w", err)
-		}
-
-		props, err := CurrentElement.Describe(0, false)
-		if err != nil {
-			return aitools.Result{}, fmt.Errorf("describe element failed: %w", err)
-		}
-
-		tree, err := proto.AccessibilityQueryAXTree{BackendNodeID: props.BackendNodeID}.Call(Page)
+		md, err := elementMarkdown(Page, CurrentElement)
 		if err != nil {
-			return aitools.Result{}, fmt.Errorf("accessibility query failed: %w", err)
+			return aitools.Result{}, err
 		}
-
-		md := convertAXTreeToMarkdown(tree, Page)
 		toolDebug("[TOOLS] to_markdown RESULT length=%d", len(md))
 		return aitools.Result{Text: md}, nil
 	})
@@ -469,7 +471,7 @@ func toMarkdownHandler(ctx context.Context, args map[string]interface{}) (aitool
 func runJSHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] run_js CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		var showErrors bool
 		if args != nil {
 			if v, ok := args["showErrors"].(bool); ok {
@@ -513,22 +515,6 @@ func runJSHandler(ctx context.Context, args map[string]interface{}) (aitools.Res
 	})
 }
 
-var (
-	duckClientOnce sync.Once
-	duckClient     duckduck.SearchClient
-)
-
-func ensureDuckClient() duckduck.SearchClient {
-	duckClientOnce.Do(func() {
-		client := duckduck.NewDuckDuckGoSearchClient()
-		client.InitialDelay = 0
-		client.Backoff = 2 * time.Second
-		client.MaxRetries = 2
-		duckClient = client
-	})
-	return duckClient
-}
-
 func duckHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] duck CALLED args=%#v", args)
 
@@ -544,8 +530,11 @@ func duckHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 		}
 	}
 
-	client := ensureDuckClient()
-	results, err := client.SearchLimited(query, limit)
+	engine := strings.TrimSpace(mcp.ExtractString(args, "engine"))
+	opts := searchOptionsFromArgs(args)
+	opts.Context = ctx
+	aitools.ReportProgress(ctx, 0, 0, fmt.Sprintf("searching for %q", query))
+	results, used, err := webSearch(query, limit, engine, opts, true)
 	if err != nil {
 		return aitools.Result{}, fmt.Errorf("duck search failed: %w", err)
 	}
@@ -554,6 +543,9 @@ func duckHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 	}
 
 	var b strings.Builder
+	if used != "" && used != duckduck.EngineDuckDuckGo {
+		fmt.Fprintf(&b, "Results via %s:\n", used)
+	}
 	for i, res := range results {
 		if i >= limit && limit > 0 {
 			break
@@ -567,6 +559,16 @@ func duckHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 		if snippet != "" {
 			snippet = truncateContextText(snippet, 220)
 		}
+		var tags []string
+		if res.Type != "" && res.Type != duckduck.ResultTypeWeb {
+			tags = append(tags, res.Type)
+		}
+		if res.Date != "" {
+			tags = append(tags, res.Date)
+		}
+		if len(tags) > 0 {
+			title += " (" + strings.Join(tags, ", ") + ")"
+		}
 		fmt.Fprintf(&b, "%d. %s\n", i+1, title)
 		if url != "" {
 			fmt.Fprintf(&b, "   %s\n", url)
@@ -579,6 +581,35 @@ func duckHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 	return aitools.Result{Text: b.String()}, nil
 }
 
+func researchHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+	toolDebug("[TOOLS] research CALLED args=%#v", args)
+
+	opts := researchOptions{
+		Query:    strings.TrimSpace(mcp.ExtractString(args, "query")),
+		Pages:    defaultResearchPages,
+		MaxChars: defaultResearchMaxChars,
+		Engine:   strings.TrimSpace(mcp.ExtractString(args, "engine")),
+		Quick:    true,
+	}
+	if opts.Query == "" {
+		return aitools.Result{}, fmt.Errorf("research: query argument is required")
+	}
+	if n, ok := toInt(args["num"]); ok && n > 0 {
+		opts.Pages = n
+	}
+	if n, ok := toInt(args["max_chars"]); ok && n >= 0 {
+		opts.MaxChars = n
+	}
+
+	bundle, err := runResearch(ctx, opts, researchFetch)
+	if err != nil {
+		return aitools.Result{}, err
+	}
+	text := bundle.Markdown()
+	toolDebug("[TOOLS] research RESULT sources=%d length=%d", len(bundle.Sources), len(text))
+	return aitools.Result{Text: text}, nil
+}
+
 func yttransHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] yttrans CALLED args=%#v", args)
 
@@ -604,11 +635,14 @@ func yttransHandler(ctx context.Context, args map[string]interface{}) (aitools.R
 		Language:     language,
 		URL:          url,
 		OutputFolder: outputFolder,
+		Format:       strings.TrimSpace(mcp.ExtractString(args, "format")),
+		Source:       strings.TrimSpace(mcp.ExtractString(args, "source")),
+		Context:      ctx,
 	})
 	if err != nil {
 		return aitools.Result{}, err
 	}
-	summary := fmt.Sprintf("Transcript saved to %s (video=%s, language=%s).", res.TextPath, res.VideoID, res.Language)
+	summary := fmt.Sprintf("Transcript saved to %s (video=%s, language=%s, source=%s).", res.TextPath, res.VideoID, res.Language, res.Source)
 	toolDebug("[TOOLS] yttrans RESULT chars=%d path=%s", len(res.Text), res.TextPath)
 	return aitools.Result{Text: summary + "\n\n" + res.Text}, nil
 }
@@ -616,7 +650,7 @@ func yttransHandler(ctx context.Context, args map[string]interface{}) (aitools.R
 func searchHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] search CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		selector := strings.TrimSpace(mcp.ExtractString(args, "selector"))
 		if selector == "" {
 			return aitools.Result{}, fmt.Errorf("search: selector is required")
@@ -625,27 +659,27 @@ func searchHandler(ctx context.Context, args map[string]interface{}) (aitools.Re
 		if err != nil {
 			return aitools.Result{}, err
 		}
-		return aitools.Result{Text: msg}, nil
+		return aitools.Result{Text: msg, Data: currentNavigationData(true)}, nil
 	})
 }
 
 func headHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] head CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		level := mcp.ExtractString(args, "level")
 		msg, err := mcpHead(level)
 		if err != nil {
 			return aitools.Result{}, err
 		}
-		return aitools.Result{Text: msg}, nil
+		return aitools.Result{Text: msg, Data: currentNavigationData(true)}, nil
 	})
 }
 
 func nextHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] next CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		var idxPtr *int
 		if args != nil {
 			if v, ok := args["index"]; ok {
@@ -658,14 +692,14 @@ func nextHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 		if err != nil {
 			return aitools.Result{}, err
 		}
-		return aitools.Result{Text: msg}, nil
+		return aitools.Result{Text: msg, Data: currentNavigationData(false)}, nil
 	})
 }
 
 func prevHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] prev CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		var idxPtr *int
 		if args != nil {
 			if v, ok := args["index"]; ok {
@@ -678,14 +712,14 @@ func prevHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 		if err != nil {
 			return aitools.Result{}, err
 		}
-		return aitools.Result{Text: msg}, nil
+		return aitools.Result{Text: msg, Data: currentNavigationData(false)}, nil
 	})
 }
 
 func elemHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] elem CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		selector := strings.TrimSpace(mcp.ExtractString(args, "selector"))
 		if selector == "" {
 			return aitools.Result{}, fmt.Errorf("elem: selector is required")
@@ -694,38 +728,38 @@ func elemHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 		if err != nil {
 			return aitools.Result{}, err
 		}
-		return aitools.Result{Text: msg}, nil
+		return aitools.Result{Text: msg, Data: currentNavigationData(true)}, nil
 	})
 }
 
 func childHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] child CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		msg, err := mcpChild()
 		if err != nil {
 			return aitools.Result{}, err
 		}
-		return aitools.Result{Text: msg}, nil
+		return aitools.Result{Text: msg, Data: currentNavigationData(false)}, nil
 	})
 }
 
 func parentHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] parent CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		msg, err := mcpParent()
 		if err != nil {
 			return aitools.Result{}, err
 		}
-		return aitools.Result{Text: msg}, nil
+		return aitools.Result{Text: msg, Data: currentNavigationData(false)}, nil
 	})
 }
 
 func htmlHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] html CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		html, err := mcpHTML()
 		if err != nil {
 			return aitools.Result{}, err
@@ -737,7 +771,7 @@ func htmlHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 func clickHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] click CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		msg, err := mcpClick()
 		if err != nil {
 			return aitools.Result{}, err
@@ -749,7 +783,7 @@ func clickHandler(ctx context.Context, args map[string]interface{}) (aitools.Res
 func typeHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] type CALLED args=%#v", args)
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		text := strings.TrimSpace(mcp.ExtractString(args, "text"))
 		if text == "" {
 			return aitools.Result{}, fmt.Errorf("type: text argument is required")
@@ -765,7 +799,7 @@ func typeHandler(ctx context.Context, args map[string]interface{}) (aitools.Resu
 func boxHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] box CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		msg, err := mcpBox()
 		if err != nil {
 			return aitools.Result{}, err
@@ -777,7 +811,7 @@ func boxHandler(ctx context.Context, args map[string]interface{}) (aitools.Resul
 func computedStylesHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] computedstyles CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		msg, err := mcpComputedStyles()
 		if err != nil {
 			return aitools.Result{}, err
@@ -789,7 +823,7 @@ func computedStylesHandler(ctx context.Context, args map[string]interface{}) (ai
 func describeHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] describe CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		msg, err := mcpDescribe()
 		if err != nil {
 			return aitools.Result{}, err
@@ -801,7 +835,7 @@ func describeHandler(ctx context.Context, args map[string]interface{}) (aitools.
 func xpathHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] xpath CALLED")
 
-	return withPage(func() (aitools.Result, error) {
+	return withPageContext(ctx, func() (aitools.Result, error) {
 		msg, err := mcpXPath()
 		if err != nil {
 			return aitools.Result{}, err
@@ -826,6 +860,9 @@ type networkEntrySummary struct {
 	Canceled     bool   `json:"canceled,omitempty"`
 	HasBody      bool   `json:"has_body"`
 	Retrieved    bool   `json:"retrieved,omitempty"`
+	GraphQLOp    string `json:"graphql_op,omitempty"`
+	Session      string `json:"session,omitempty"`
+	Navigation   int    `json:"navigation,omitempty"`
 }
 
 type networkListResponse struct {
@@ -841,17 +878,31 @@ type networkListResponse struct {
 func networkListHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] network_list CALLED args=%#v", args)
 
-	log := getActiveEventLog()
-	if log == nil {
-		return aitools.Result{}, fmt.Errorf("network_list: no active network log")
-	}
-
 	filter, err := networkFilterFromArgs(args)
 	if err != nil {
 		return aitools.Result{}, err
 	}
 
-	entries := log.FilterEntries(filter)
+	var entries []*NetworkLogEntry
+	session := strings.TrimSpace(mcp.ExtractString(args, "session"))
+	rawSince := strings.TrimSpace(mcp.ExtractString(args, "since"))
+	if session != "" || rawSince != "" {
+		since, err := parseNetlogSince(rawSince, time.Now())
+		if err != nil {
+			return aitools.Result{}, fmt.Errorf("network_list: %w", err)
+		}
+		stored, err := storedNetworkEntries(session, since)
+		if err != nil {
+			return aitools.Result{}, fmt.Errorf("network_list: %w", err)
+		}
+		entries = filterNetworkEntries(stored, filter)
+	} else {
+		log := scopedEventLog(ctx)
+		if log == nil {
+			return aitools.Result{}, fmt.Errorf("network_list: no active network log")
+		}
+		entries = log.FilterEntries(filter)
+	}
 	limit := networkListDefaultLimit
 	if raw, ok := args["limit"]; ok {
 		value, okInt := toInt(raw)
@@ -952,6 +1003,86 @@ func networkListHandler(ctx context.Context, args map[string]interface{}) (aitoo
 	return aitools.Result{Text: string(payload)}, nil
 }
 
+func networkGetHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+	toolDebug("[TOOLS] network_get CALLED args=%#v", args)
+
+	reqID := strings.TrimSpace(mcp.ExtractString(args, "request_id"))
+	if reqID == "" {
+		return aitools.Result{}, fmt.Errorf("network_get: request_id is required")
+	}
+	includeBody := true
+	if v, ok := toBool(args["include_body"]); ok {
+		includeBody = v
+	}
+	maxChars := 8000
+	if n, ok := toInt(args["max_chars"]); ok && n >= 0 {
+		maxChars = n
+	}
+
+	detail, err := lookupNetworkDetail(ctx, reqID, strings.TrimSpace(mcp.ExtractString(args, "session")), includeBody)
+	if err != nil {
+		return aitools.Result{}, fmt.Errorf("network_get: %w", err)
+	}
+	toolDebug("[TOOLS] network_get RESULT url=%s body=%d", detail.Entry.URL, len(detail.Body))
+	return aitools.Result{Text: formatNetworkDetail(detail, maxChars)}, nil
+}
+
+func networkWSFramesHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+	toolDebug("[TOOLS] network_ws_frames CALLED args=%#v", args)
+
+	log := scopedEventLog(ctx)
+	if log == nil {
+		return aitools.Result{}, fmt.Errorf("network_ws_frames: no active network log")
+	}
+	id := strings.TrimSpace(mcp.ExtractString(args, "conn"))
+	if id == "" {
+		conns := log.Connections()
+		if len(conns) == 0 {
+			return aitools.Result{Text: "no WebSocket or EventSource connections captured"}, nil
+		}
+		var b strings.Builder
+		printConnections(&b, conns)
+		return aitools.Result{Text: strings.TrimRight(b.String(), "\n")}, nil
+	}
+	conn, ok := log.ConnectionByID(id)
+	if !ok {
+		return aitools.Result{}, fmt.Errorf("network_ws_frames: connection %s not found", id)
+	}
+
+	limit := 50
+	if n, ok := toInt(args["limit"]); ok && n > 0 {
+		limit = n
+	}
+	decode := true
+	if v, ok := toBool(args["decode_json"]); ok {
+		decode = v
+	}
+	frames := filterFrames(conn.Frames, normalizeStrings(extractStringSlice(args, "contains")))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s (%s): %d matching frames", conn.Kind, conn.URL, connectionState(conn), len(frames))
+	if conn.Dropped > 0 {
+		fmt.Fprintf(&b, ", %d older frames dropped", conn.Dropped)
+	}
+	if v, ok := toBool(args["export"]); ok && v {
+		path, err := exportFramesToFile(conn, frames, "", strings.TrimSpace(mcp.ExtractString(args, "save_dir")))
+		if err != nil {
+			return aitools.Result{}, fmt.Errorf("network_ws_frames: export: %w", err)
+		}
+		fmt.Fprintf(&b, "\nexported to %s", path)
+	}
+	shown := frames
+	if len(shown) > limit {
+		shown = shown[len(shown)-limit:]
+		fmt.Fprintf(&b, "\nshowing the newest %d", limit)
+	}
+	for _, f := range shown {
+		fmt.Fprintf(&b, "\n%s %s: %s", f.Timestamp.Local().Format("15:04:05.000"), frameDirectionLabel(f), truncateContextText(frameDisplayData(f, decode, false), 2000))
+	}
+	toolDebug("[TOOLS] network_ws_frames RESULT conn=%s frames=%d", id, len(shown))
+	return aitools.Result{Text: b.String()}, nil
+}
+
 func networkSaveHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] network_save CALLED args=%#v", args)
 
@@ -977,33 +1108,58 @@ func networkSaveHandler(ctx context.Context, args map[string]interface{}) (aitoo
 		}
 	}
 
-	log := getActiveEventLog()
-	if log == nil {
-		return aitools.Result{}, fmt.Errorf("network_save: no active network log")
-	}
-
-	entry, ok := log.EntryByID(reqID)
-	if !ok {
-		return aitools.Result{}, fmt.Errorf("network_save: request %s not found", reqID)
-	}
+	var entry *NetworkLogEntry
+	var data []byte
+	if session := strings.TrimSpace(mcp.ExtractString(args, "session")); session != "" {
+		stored, err := storedNetworkEntries(session, time.Time{})
+		if err != nil {
+			return aitools.Result{}, fmt.Errorf("network_save: %w", err)
+		}
+		for _, candidate := range stored {
+			if candidate.RequestID == reqID {
+				entry = candidate
+			}
+		}
+		if entry == nil {
+			return aitools.Result{}, fmt.Errorf("network_save: request %s not found in session %s", reqID, session)
+		}
+		if entry.BodyBlob == "" {
+			return aitools.Result{}, fmt.Errorf("network_save: body of request %s was not persisted", reqID)
+		}
+		if data, err = storedNetworkBody(entry); err != nil {
+			return aitools.Result{}, fmt.Errorf("network_save: %w", err)
+		}
+	} else {
+		log := scopedEventLog(ctx)
+		if log == nil {
+			return aitools.Result{}, fmt.Errorf("network_save: no active network log")
+		}
 
-	data, err := withPage(func() ([]byte, error) {
-		if Page == nil {
-			return nil, fmt.Errorf("network_save: no page loaded – call load_url first")
+		found, ok := log.EntryByID(reqID)
+		if !ok {
+			return aitools.Result{}, fmt.Errorf("network_save: request %s not found", reqID)
 		}
-		bytes, err := retrieveNetworkBody(Page, entry)
+		entry = found
+
+		var err error
+		data, err = withPageContext(ctx, func() ([]byte, error) {
+			if Page == nil {
+				return nil, fmt.Errorf("network_save: no page loaded – call load_url first")
+			}
+			bytes, err := retrieveNetworkBody(Page, entry)
+			if err != nil {
+				return nil, err
+			}
+			return bytes, nil
+		})
 		if err != nil {
-			return nil, err
+			return aitools.Result{}, err
 		}
-		return bytes, nil
-	})
-	if err != nil {
-		return aitools.Result{}, err
-	}
 
-	updatedEntry, ok := log.EntryByID(reqID)
-	if ok {
-		entry = updatedEntry
+		updatedEntry, ok := log.EntryByID(reqID)
+		if ok {
+			entry = updatedEntry
+		}
 	}
 
 	mimeType := ""
@@ -1050,6 +1206,74 @@ func networkSaveHandler(ctx context.Context, args map[string]interface{}) (aitoo
 	}
 }
 
+func transcriptsHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+	toolDebug("[TOOLS] transcripts CALLED args=%#v", args)
+
+	log := scopedEventLog(ctx)
+	if log == nil {
+		return aitools.Result{}, fmt.Errorf("transcripts: no active network log")
+	}
+	opts := transcriptOptions{
+		Format:     strings.TrimSpace(mcp.ExtractString(args, "format")),
+		RequestIDs: extractStringSlice(args, "request_id"),
+		Naming: FileNamingOptions{
+			Prefix:          strings.TrimSpace(mcp.ExtractString(args, "filename_prefix")),
+			Suffix:          strings.TrimSpace(mcp.ExtractString(args, "filename_suffix")),
+			TimestampFormat: strings.TrimSpace(mcp.ExtractString(args, "timestamp_format")),
+		},
+	}
+	if opts.Format == "" {
+		opts.Format = subtitles.FormatMarkdown
+	}
+	if v, ok := toBool(args["filename_timestamp"]); ok {
+		opts.Naming.IncludeTimestamp = v
+	}
+	save := true
+	if v, ok := toBool(args["save"]); ok {
+		save = v
+	}
+	if save {
+		opts.SaveDir = strings.TrimSpace(mcp.ExtractString(args, "save_dir"))
+		if opts.SaveDir == "" {
+			opts.SaveDir = defaultDownloadsDir()
+		}
+	}
+	maxChars := 4000
+	if n, ok := toInt(args["max_chars"]); ok && n >= 0 {
+		maxChars = n
+	}
+
+	found, err := extractTranscripts(log.Entries(), opts, func(entry *NetworkLogEntry) ([]byte, error) {
+		return fetchPageBody(ctx, entry)
+	})
+	if err != nil {
+		return aitools.Result{}, err
+	}
+	if len(found) == 0 {
+		return aitools.Result{Text: "no caption responses found in the network log; start playback with captions enabled and try again"}, nil
+	}
+
+	var b strings.Builder
+	for i, t := range found {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "## %s (request %s)\n", t.Entry.URL, t.Entry.RequestID)
+		if t.Err != nil {
+			fmt.Fprintf(&b, "error: %v\n", t.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "format: %s, cues: %d, duration: %s\n", t.Kind, t.Cues, subtitles.Timestamp(t.Duration))
+		if t.Path != "" {
+			fmt.Fprintf(&b, "saved: %s\n", t.Path)
+		}
+		b.WriteString("\n")
+		b.WriteString(truncateContextText(t.Text, maxChars))
+	}
+	toolDebug("[TOOLS] transcripts RESULT count=%d", len(found))
+	return aitools.Result{Text: b.String()}, nil
+}
+
 func networkSetLoggingHandler(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
 	toolDebug("[TOOLS] network_set_logging CALLED args=%#v", args)
 
@@ -1097,10 +1321,13 @@ func summarizeNetworkEntry(entry *NetworkLogEntry) networkEntrySummary {
 		summary.Failure = entry.Failure.ErrorText
 		summary.Canceled = entry.Failure.Canceled
 	}
-	if entry.Body != nil {
+	if entry.Body != nil || entry.BodyBlob != "" {
 		summary.HasBody = true
 		summary.Retrieved = true
 	}
+	summary.GraphQLOp = entry.GraphQLOperation
+	summary.Session = entry.SessionID
+	summary.Navigation = entry.NavigationID
 	return summary
 }
 
@@ -1112,6 +1339,7 @@ func networkFilterFromArgs(args map[string]interface{}) (NetworkLogFilter, error
 	filter.Methods = normalizeStrings(extractStringSlice(args, "method"))
 	filter.Domains = normalizeStrings(extractStringSlice(args, "domain"))
 	filter.StatusCodes = extractIntSlice(args, "status")
+	filter.GraphQLOps = normalizeStrings(extractStringSlice(args, "graphql_op"))
 	if types := extractStringSlice(args, "type"); len(types) > 0 {
 		rts, err := parseResourceTypes(types)
 		if err != nil {
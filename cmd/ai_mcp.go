@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"roderik/internal/ai/llm"
+	"roderik/internal/ai/mcpclient"
+	aitools "roderik/internal/ai/tools"
+)
+
+// mcpConnectTimeout bounds starting and initializing all external servers.
+const mcpConnectTimeout = 30 * time.Second
+
+var (
+	aiMCPConfigPath string
+	aiNoMCP         bool
+)
+
+func init() {
+	aiCmd.PersistentFlags().StringVar(&aiMCPConfigPath, "mcp-config", mcpclient.DefaultPath(), "Path to the external MCP servers whose tools the AI assistant may use")
+	aiCmd.PersistentFlags().BoolVar(&aiNoMCP, "no-mcp", false, "Do not connect to the external MCP servers from --mcp-config")
+}
+
+// connectAIMCPServers starts the external MCP servers of the config. Servers
+// that fail are reported and skipped; a broken config only loses the
+// external tools, not the session.
+func connectAIMCPServers() *mcpclient.Manager {
+	if aiNoMCP {
+		return nil
+	}
+	cfg, err := mcpclient.LoadConfig(aiMCPConfigPath)
+	if err != nil {
+		logAI("✖ external MCP servers disabled: %v", err)
+		return nil
+	}
+	if len(cfg.Servers) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mcpConnectTimeout)
+	defer cancel()
+	manager, errs := mcpclient.Connect(ctx, cfg, "1.0.0")
+	for _, err := range errs {
+		logAI("✖ %v", err)
+	}
+	for _, srv := range manager.Servers() {
+		debugAI("connected MCP server %s tools=%d resources=%d", srv.Name, len(srv.Tools), len(srv.Resources))
+	}
+	return manager
+}
+
+// withExternalMCPTools appends the tools of the connected servers to the
+// session's tool list and routes their names to the servers. Names are
+// namespaced server__tool like roderik's own roderik__tool.
+func withExternalMCPTools(manager *mcpclient.Manager, tools []llm.Tool, mapping map[string]aitools.Definition) ([]llm.Tool, map[string]aitools.Definition) {
+	if manager == nil {
+		return tools, mapping
+	}
+	manager.RegisterHandlers()
+	external, externalMapping := manager.LLMTools()
+	for _, tool := range external {
+		if _, taken := mapping[tool.Name]; taken {
+			continue
+		}
+		tools = append(tools, tool)
+		mapping[tool.Name] = externalMapping[tool.Name]
+	}
+	if len(external) > 0 {
+		logAI("Using %d tool(s) from %d external MCP server(s)", len(external), len(manager.Servers()))
+	}
+	return tools, mapping
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"roderik/internal/ai/llm"
+	"roderik/internal/ai/mcpclient"
+	"roderik/internal/ai/policy"
+	aitools "roderik/internal/ai/tools"
+)
+
+func TestChatSessionCallsExternalMCPTools(t *testing.T) {
+	ext := server.NewMCPServer("tickets", "test", server.WithToolCapabilities(false))
+	ext.AddTool(mcp.NewTool("get_ticket", mcp.WithString("id", mcp.Required())), func(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ticket " + req.Params.Arguments["id"].(string) + ": login page broken"), nil
+	})
+	c, err := client.NewInProcessClient(ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := &mcpclient.Manager{}
+	if err := manager.Attach(context.Background(), "tickets", c, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, mapping := withExternalMCPTools(manager, nil, map[string]aitools.Definition{})
+	if len(tools) != 1 || tools[0].Name != "tickets__get_ticket" {
+		t.Fatalf("tools = %+v", tools)
+	}
+
+	// Approval policies match the qualified server/tool name.
+	installTestPolicy(t, policy.Config{Rules: []policy.Rule{{Tool: "tickets/get_ticket", Arg: "id", Pattern: "^13$", Action: policy.ActionDeny}}})
+
+	session := &ChatSession{mcpServers: manager, toolRegistry: mapping, toolTimeout: 5 * time.Second}
+	defer session.Close()
+	outcomes := session.executeToolCalls(context.Background(), []llm.ToolCall{
+		inlineToolCall{id: "1", name: "tickets__get_ticket", args: map[string]interface{}{"id": "42"}},
+		inlineToolCall{id: "2", name: "tickets__get_ticket", args: map[string]interface{}{"id": "13"}},
+	})
+	if !outcomes[0].known || outcomes[0].err != nil {
+		t.Fatalf("outcome = %+v", outcomes[0])
+	}
+	if got := outcomes[0].result.Text; got != "ticket 42: login page broken" {
+		t.Fatalf("result = %q", got)
+	}
+	if !policy.IsDenied(outcomes[1].err) {
+		t.Fatalf("denied call = %+v", outcomes[1])
+	}
+
+	if err := session.Close(); err != nil || len(manager.Servers()) != 0 {
+		t.Fatalf("Close() = %v, servers left %d", err, len(manager.Servers()))
+	}
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"roderik/internal/ai/schema"
+)
+
+const defaultAITaskAttempts = 3
+
+var (
+	aiTaskGoal        string
+	aiTaskSchemaPath  string
+	aiTaskMaxAttempts int
+)
+
+var aiTaskCmd = &cobra.Command{
+	Use:   "task",
+	Short: "Run the AI agent until it returns JSON matching a schema",
+	Long: `Give the AI agent a goal and a JSON Schema. The agent plans and calls tools as
+usual, and its final answer is validated against the schema. Validation errors
+are fed back to the model, which retries up to --max-attempts times. On success
+only the validated JSON is written to stdout, so the command can be used as an
+extraction step in pipelines; progress and tool logs go to stderr.`,
+	Example:      `  roderik ai task --goal "list the product names and prices on this page" --schema products.schema.json`,
+	Args:         cobra.NoArgs,
+	RunE:         runAITaskCommand,
+	SilenceUsage: true,
+}
+
+func init() {
+	aiTaskCmd.Flags().StringVar(&aiTaskGoal, "goal", "", "What the agent should accomplish")
+	aiTaskCmd.Flags().StringVar(&aiTaskSchemaPath, "schema", "", "Path to the JSON Schema the final answer must satisfy")
+	aiTaskCmd.Flags().IntVar(&aiTaskMaxAttempts, "max-attempts", defaultAITaskAttempts, "Maximum number of answers to validate before giving up")
+	_ = aiTaskCmd.MarkFlagRequired("goal")
+	_ = aiTaskCmd.MarkFlagRequired("schema")
+	aiCmd.AddCommand(aiTaskCmd)
+}
+
+// taskSender is the subset of ChatSession used by runAITask (swapped in tests).
+type taskSender interface {
+	Send(ctx context.Context, input string) (string, error)
+}
+
+func runAITaskCommand(cmd *cobra.Command, args []string) error {
+	goal := strings.TrimSpace(aiTaskGoal)
+	if goal == "" {
+		return fmt.Errorf("ai task requires a non-empty --goal")
+	}
+	rawSchema, err := os.ReadFile(aiTaskSchemaPath)
+	if err != nil {
+		return fmt.Errorf("read schema: %w", err)
+	}
+	compiled, err := schema.Compile(rawSchema)
+	if err != nil {
+		return fmt.Errorf("%s: %w", aiTaskSchemaPath, err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	session, err := ensureChatSession()
+	if err != nil {
+		return err
+	}
+	defer closeChatSessionOnExit()
+	session.SetHistoryWindow(aiHistoryWindow)
+
+	out, err := runAITask(ctx, session, session.turnTimeout, goal, rawSchema, compiled, aiTaskMaxAttempts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// runAITask drives the agent loop until the model answers with JSON that
+// validates against the schema, returning the compacted document.
+func runAITask(ctx context.Context, sender taskSender, turnTimeout time.Duration, goal string, rawSchema []byte, compiled *schema.Schema, maxAttempts int) ([]byte, error) {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultAITaskAttempts
+	}
+
+	prompt := buildTaskPrompt(goal, rawSchema)
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		reply, err := sendWithTimeout(ctx, sender, turnTimeout, prompt)
+		var timeout *modelTimeoutError
+		if errors.As(err, &timeout) {
+			// the timed-out prompt is gone from the history, so start over
+			lastErr = err
+			logAI("✖ model timed out (attempt %d/%d)", attempt, maxAttempts)
+			prompt = buildTaskPrompt(goal, rawSchema)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := validateTaskAnswer(reply, compiled)
+		if err == nil {
+			logAI("✔ task answer validated (attempt %d/%d)", attempt, maxAttempts)
+			return doc, nil
+		}
+		lastErr = err
+		logAI("✖ task answer rejected (attempt %d/%d): %s", attempt, maxAttempts, truncateForLog(err.Error(), 256))
+		prompt = buildTaskRetryPrompt(err)
+	}
+	return nil, fmt.Errorf("no schema-conforming answer after %d attempt(s): %w", maxAttempts, lastErr)
+}
+
+func sendWithTimeout(ctx context.Context, sender taskSender, timeout time.Duration, prompt string) (string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return sender.Send(ctx, prompt)
+}
+
+func buildTaskPrompt(goal string, rawSchema []byte) string {
+	var b strings.Builder
+	b.WriteString("Task goal: ")
+	b.WriteString(goal)
+	b.WriteString("\n\nWork autonomously: plan the steps you need, use the available tools to gather evidence, and do not ask the user questions.")
+	b.WriteString(" When you are done, reply with ONLY a JSON document (no prose, no code fences) that conforms to this JSON Schema:\n")
+	b.Write(bytes.TrimSpace(rawSchema))
+	return b.String()
+}
+
+func buildTaskRetryPrompt(err error) string {
+	return fmt.Sprintf("Your answer was rejected: %s\nFix the problems (call more tools if data is missing) and reply with ONLY the corrected JSON document.", truncateForLog(err.Error(), 2000))
+}
+
+// validateTaskAnswer extracts the JSON document from a model reply, validates
+// it and returns it compacted.
+func validateTaskAnswer(reply string, compiled *schema.Schema) ([]byte, error) {
+	raw, err := extractJSONAnswer(reply)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := compiled.ValidateJSON(raw); err != nil {
+		var verrs schema.Errors
+		if errors.As(err, &verrs) {
+			return nil, fmt.Errorf("schema validation failed: %w", err)
+		}
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Compact(&out, raw); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// extractJSONAnswer pulls the JSON document out of a reply, tolerating code
+// fences and short prose around it.
+func extractJSONAnswer(reply string) ([]byte, error) {
+	text := strings.TrimSpace(reply)
+	if text == "" {
+		return nil, fmt.Errorf("the answer was empty; expected a JSON document")
+	}
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if nl := strings.IndexByte(text, '\n'); nl >= 0 {
+			text = text[nl+1:]
+		}
+		if end := strings.LastIndex(text, "```"); end >= 0 {
+			text = text[:end]
+		}
+		text = strings.TrimSpace(text)
+	}
+	if json.Valid([]byte(text)) {
+		return []byte(text), nil
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start >= 0 {
+		closer := "}"
+		if text[start] == '[' {
+			closer = "]"
+		}
+		if end := strings.LastIndex(text, closer); end > start {
+			candidate := text[start : end+1]
+			if json.Valid([]byte(candidate)) {
+				return []byte(candidate), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("the answer is not valid JSON; reply with the JSON document only")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"roderik/internal/ai/schema"
+)
+
+type scriptedSender struct {
+	replies []string
+	// errs, when set, fails the matching turn instead of replying
+	errs    []error
+	prompts []string
+}
+
+func (s *scriptedSender) Send(ctx context.Context, input string) (string, error) {
+	s.prompts = append(s.prompts, input)
+	if len(s.replies) == 0 {
+		return "", nil
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return "", err
+		}
+	}
+	return reply, nil
+}
+
+const taskTestSchema = `{"type":"object","required":["title"],"properties":{"title":{"type":"string"},"count":{"type":"integer"}}}`
+
+func TestRunAITaskRetriesWithValidationFeedback(t *testing.T) {
+	compiled, err := schema.Compile([]byte(taskTestSchema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	sender := &scriptedSender{replies: []string{
+		"I could not find it yet.",
+		`{"count": "three"}`,
+		"```json\n{\"title\": \"Example\", \"count\": 3}\n```",
+	}}
+
+	out, err := runAITask(context.Background(), sender, 0, "find the title", []byte(taskTestSchema), compiled, 3)
+	if err != nil {
+		t.Fatalf("runAITask() error = %v", err)
+	}
+	if string(out) != `{"title":"Example","count":3}` {
+		t.Fatalf("unexpected output %s", out)
+	}
+	if len(sender.prompts) != 3 {
+		t.Fatalf("expected 3 prompts, got %d", len(sender.prompts))
+	}
+	if !strings.Contains(sender.prompts[0], "find the title") || !strings.Contains(sender.prompts[0], `"required":["title"]`) {
+		t.Fatalf("first prompt should carry the goal and schema: %q", sender.prompts[0])
+	}
+	if !strings.Contains(sender.prompts[1], "not valid JSON") {
+		t.Fatalf("expected JSON feedback, got %q", sender.prompts[1])
+	}
+	if !strings.Contains(sender.prompts[2], `missing required property "title"`) || !strings.Contains(sender.prompts[2], "/count") {
+		t.Fatalf("expected schema feedback, got %q", sender.prompts[2])
+	}
+}
+
+func TestRunAITaskGivesUpAfterMaxAttempts(t *testing.T) {
+	compiled, err := schema.Compile([]byte(taskTestSchema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	sender := &scriptedSender{replies: []string{`{}`, `{}`}}
+
+	_, err = runAITask(context.Background(), sender, 0, "goal", []byte(taskTestSchema), compiled, 2)
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempt(s)") {
+		t.Fatalf("expected give-up error, got %v", err)
+	}
+}
+
+func TestRunAITaskResendsGoalAfterModelTimeout(t *testing.T) {
+	compiled, err := schema.Compile([]byte(taskTestSchema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	sender := &scriptedSender{
+		replies: []string{"", `{"title": "Example"}`},
+		errs:    []error{&modelTimeoutError{timeout: time.Minute}},
+	}
+
+	out, err := runAITask(context.Background(), sender, 0, "find the title", []byte(taskTestSchema), compiled, 3)
+	if err != nil || string(out) != `{"title":"Example"}` {
+		t.Fatalf("runAITask() = %s, %v", out, err)
+	}
+	if len(sender.prompts) != 2 || sender.prompts[1] != sender.prompts[0] {
+		t.Fatalf("expected the goal prompt to be sent again after the timeout, got %q", sender.prompts)
+	}
+}
+
+func TestRunAITaskAbortsWhenBudgetIsSpent(t *testing.T) {
+	compiled, err := schema.Compile([]byte(taskTestSchema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	sender := &scriptedSender{
+		replies: []string{"", `{"title": "Example"}`},
+		errs:    []error{&budgetStopError{reason: "session token budget reached"}},
+	}
+
+	_, err = runAITask(context.Background(), sender, 0, "find the title", []byte(taskTestSchema), compiled, 3)
+	var stop *budgetStopError
+	if !errors.As(err, &stop) {
+		t.Fatalf("expected budget stop error, got %v", err)
+	}
+	if len(sender.prompts) != 1 {
+		t.Fatalf("expected no retry after a budget stop, got %d prompts", len(sender.prompts))
+	}
+}
+
+func TestExtractJSONAnswerToleratesProse(t *testing.T) {
+	raw, err := extractJSONAnswer("Here is the result:\n[1, 2, 3]\nDone.")
+	if err != nil {
+		t.Fatalf("extractJSONAnswer() error = %v", err)
+	}
+	if string(raw) != "[1, 2, 3]" {
+		t.Fatalf("unexpected extraction %q", raw)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"roderik/internal/ai/llm"
+	aitools "roderik/internal/ai/tools"
+)
+
+// toolCallOutcome captures the result of one tool call requested by the model.
+type toolCallOutcome struct {
+	call     llm.ToolCall
+	def      aitools.Definition
+	known    bool
+	args     map[string]interface{}
+	result   aitools.Result
+	err      error
+	duration time.Duration
+}
+
+// executeToolCalls runs the tool calls from a single assistant turn. Independent
+// tools (those that never touch the shared page) run concurrently, while
+// browser-bound tools run one after another because withPage serialises them
+// anyway. Browser-bound calls are checked against the tool policy right before
+// they run, so focus rules see the focus left by the calls before them.
+// Outcomes are returned in the order the model requested them.
+func (s *ChatSession) executeToolCalls(ctx context.Context, calls []llm.ToolCall) []toolCallOutcome {
+	outcomes := make([]toolCallOutcome, len(calls))
+	var wg sync.WaitGroup
+	var sequential []int
+
+	for i, call := range calls {
+		outcomes[i].call = call
+		def, ok := s.toolRegistry[call.GetName()]
+		if !ok {
+			continue
+		}
+		outcomes[i].def = def
+		outcomes[i].known = true
+		logToolStart(def.Name, call.GetArguments())
+
+		if !def.Independent {
+			sequential = append(sequential, i)
+			continue
+		}
+		// approval prompts are interactive, so independent calls are checked
+		// before any of them is dispatched
+		if !s.authorizeToolCall(ctx, &outcomes[i]) {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			s.runToolCall(ctx, &outcomes[idx])
+		}(i)
+	}
+
+	for _, idx := range sequential {
+		if s.authorizeToolCall(ctx, &outcomes[idx]) {
+			s.runToolCall(ctx, &outcomes[idx])
+		}
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// authorizeToolCall applies the tool policy to out, recording a denial as its
+// error and the possibly edited arguments otherwise.
+func (s *ChatSession) authorizeToolCall(ctx context.Context, out *toolCallOutcome) bool {
+	args, err := enforceToolPolicy(ctx, out.def.Name, out.call.GetArguments(), s.approver)
+	if err != nil {
+		out.err = err
+		return false
+	}
+	out.args = args
+	return true
+}
+
+func (s *ChatSession) runToolCall(ctx context.Context, out *toolCallOutcome) {
+	started := time.Now()
+	out.result, out.err = callToolWithTimeout(ctx, out.def.Name, out.args, s.toolTimeout)
+	out.duration = time.Since(started)
+	debugAI("tool %s finished in %s independent=%t err=%v", out.def.Name, out.duration.Round(time.Millisecond), out.def.Independent, out.err)
+}
+
+// callToolWithTimeout invokes a tool handler with a context that ends once
+// the per-call timeout elapses or the turn context is cancelled. Handlers
+// stop at that deadline (page tools through lockPage), so none is left
+// running, and holding the page lock, after the call returns.
+func callToolWithTimeout(ctx context.Context, name string, args map[string]interface{}, timeout time.Duration) (aitools.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return aitools.Result{}, fmt.Errorf("%s skipped: %w", name, err)
+	}
+
+	callCtx := ctx
+	cancel := func() {}
+	if timeout > 0 {
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	res, err := aitools.Call(callCtx, name, args)
+	if ctxErr := callCtx.Err(); err != nil && ctxErr != nil {
+		if errors.Is(ctxErr, context.DeadlineExceeded) && ctx.Err() == nil {
+			return aitools.Result{}, fmt.Errorf("%s timed out after %s", name, timeout)
+		}
+		return aitools.Result{}, fmt.Errorf("%s cancelled: %w", name, ctxErr)
+	}
+	return res, err
+}
+
+func logToolStart(name string, args map[string]interface{}) {
+	if argsLabel := formatToolArgs(args); argsLabel != "" {
+		logAI("▶ %s %s", name, argsLabel)
+		return
+	}
+	logAI("▶ %s", name)
+}
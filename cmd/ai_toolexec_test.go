@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"roderik/internal/ai/llm"
+	aitools "roderik/internal/ai/tools"
+)
+
+func TestExecuteToolCallsRunsIndependentToolsConcurrently(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	barrier := func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		started.Done()
+		waitCh := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(waitCh)
+		}()
+		select {
+		case <-waitCh:
+			return aitools.Result{Text: "ok"}, nil
+		case <-time.After(2 * time.Second):
+			return aitools.Result{}, context.DeadlineExceeded
+		}
+	}
+	aitools.RegisterHandler("test_parallel_a", barrier)
+	aitools.RegisterHandler("test_parallel_b", barrier)
+
+	session := &ChatSession{
+		toolRegistry: map[string]aitools.Definition{
+			"roderik__test_parallel_a": {Name: "test_parallel_a", Independent: true},
+			"roderik__test_parallel_b": {Name: "test_parallel_b", Independent: true},
+		},
+		toolTimeout: 5 * time.Second,
+	}
+
+	calls := []llm.ToolCall{
+		inlineToolCall{id: "1", name: "roderik__test_parallel_a"},
+		inlineToolCall{id: "2", name: "roderik__test_parallel_b"},
+	}
+	outcomes := session.executeToolCalls(context.Background(), calls)
+	if len(outcomes) != 2 {
+		t.Fatalf("expected 2 outcomes, got %d", len(outcomes))
+	}
+	for i, out := range outcomes {
+		if out.err != nil {
+			t.Fatalf("outcome %d returned error (calls did not overlap?): %v", i, out.err)
+		}
+		if out.call.GetID() != calls[i].GetID() {
+			t.Fatalf("expected outcome order to match request order, got id %q at %d", out.call.GetID(), i)
+		}
+	}
+}
+
+func TestExecuteToolCallsAppliesPerCallTimeout(t *testing.T) {
+	aitools.RegisterHandler("test_slow_tool", func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		select {
+		case <-time.After(500 * time.Millisecond):
+			return aitools.Result{Text: "late"}, nil
+		case <-ctx.Done():
+			return aitools.Result{}, ctx.Err()
+		}
+	})
+
+	session := &ChatSession{
+		toolRegistry: map[string]aitools.Definition{
+			"roderik__test_slow_tool": {Name: "test_slow_tool"},
+		},
+		toolTimeout: 20 * time.Millisecond,
+	}
+
+	outcomes := session.executeToolCalls(context.Background(), []llm.ToolCall{
+		inlineToolCall{id: "1", name: "roderik__test_slow_tool"},
+		inlineToolCall{id: "2", name: "roderik__missing"},
+	})
+	if outcomes[0].err == nil || !strings.Contains(outcomes[0].err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", outcomes[0].err)
+	}
+	if outcomes[1].known {
+		t.Fatalf("expected unregistered tool to be reported as unknown")
+	}
+}
+
+func TestCallToolWithTimeoutReleasesPageLock(t *testing.T) {
+	aitools.RegisterHandler("test_page_tool", func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		return inspectPageContext(ctx, func() (aitools.Result, error) {
+			<-ctx.Done()
+			return aitools.Result{}, ctx.Err()
+		})
+	})
+
+	_, err := callToolWithTimeout(context.Background(), "test_page_tool", nil, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	// The handler has returned, so the next call gets the page right away.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := inspectPageContext(ctx, func() (bool, error) { return true, nil }); err != nil {
+		t.Fatalf("page still locked after timeout: %v", err)
+	}
+}
+
+func TestCallToolWithTimeoutHonoursCancelledTurn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := callToolWithTimeout(ctx, "test_slow_tool", nil, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "skipped") {
+		t.Fatalf("expected skipped error for cancelled turn, got %v", err)
+	}
+}
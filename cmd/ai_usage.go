@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"roderik/internal/ai/usage"
+)
+
+var (
+	aiUsageSince string
+	aiUsageBy    string
+	aiUsageJSON  bool
+	aiUsageFile  string
+)
+
+var aiUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Summarise recorded AI token usage and estimated cost",
+	Long: `Every model call made by the AI assistant is appended to a usage log
+(<logs>/ai-usage.jsonl). This command aggregates those records so spend can be
+tracked across runs. Costs are estimates based on the price tables in
+ai-profiles.json at the time of each call.`,
+	Example: `  roderik ai usage
+  roderik ai usage --since 7d --by model
+  roderik ai usage --since 2026-01-01 --json`,
+	Args:         cobra.NoArgs,
+	RunE:         runAIUsageCommand,
+	SilenceUsage: true,
+}
+
+func init() {
+	aiUsageCmd.Flags().StringVar(&aiUsageSince, "since", "", "Only include records newer than a duration (e.g. 24h, 7d) or a date (YYYY-MM-DD)")
+	aiUsageCmd.Flags().StringVar(&aiUsageBy, "by", "day", "Group records by "+strings.Join(usage.GroupKeys, ", "))
+	aiUsageCmd.Flags().BoolVar(&aiUsageJSON, "json", false, "Print the summary as JSON")
+	aiUsageCmd.Flags().StringVar(&aiUsageFile, "file", "", "Usage log to read (defaults to the standard log location)")
+	aiCmd.AddCommand(aiUsageCmd)
+}
+
+func runAIUsageCommand(cmd *cobra.Command, args []string) error {
+	since, err := parseUsageSince(aiUsageSince, time.Now())
+	if err != nil {
+		return err
+	}
+	path := strings.TrimSpace(aiUsageFile)
+	if path == "" {
+		path = usage.DefaultPath()
+	}
+	if path == "" {
+		return fmt.Errorf("unable to determine the usage log location; pass --file")
+	}
+
+	records, err := usage.Read(path, since)
+	if err != nil {
+		return err
+	}
+	summaries, err := usage.Summarize(records, aiUsageBy)
+	if err != nil {
+		return err
+	}
+
+	if aiUsageJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	}
+	if len(summaries) == 0 {
+		fmt.Fprintf(os.Stderr, "no usage recorded in %s\n", path)
+		return nil
+	}
+	printUsageSummaries(os.Stdout, aiUsageBy, summaries)
+	return nil
+}
+
+func printUsageSummaries(w io.Writer, groupBy string, summaries []usage.Summary) {
+	tw := tabwriter.NewWriter(w, 4, 2, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "%s\tCALLS\tSESSIONS\tPROMPT\tCOMPLETION\tCOST\n", strings.ToUpper(groupBy))
+
+	var total usage.Summary
+	sessions := 0
+	for _, sum := range summaries {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\n",
+			sum.Key,
+			sum.Calls,
+			sum.Sessions,
+			formatTokensHuman(sum.PromptTokens),
+			formatTokensHuman(sum.CompletionTokens),
+			usageCostLabel(sum),
+		)
+		total.Calls += sum.Calls
+		total.PromptTokens += sum.PromptTokens
+		total.CompletionTokens += sum.CompletionTokens
+		total.Cost += sum.Cost
+		total.Unpriced += sum.Unpriced
+		sessions += sum.Sessions
+	}
+	sessionsLabel := strconv.Itoa(sessions)
+	if groupBy != "session" {
+		// a session can span several groups, so the per-group counts do not add up
+		sessionsLabel = "-"
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%s\t%s\t%s\t%s\n",
+		total.Calls,
+		sessionsLabel,
+		formatTokensHuman(total.PromptTokens),
+		formatTokensHuman(total.CompletionTokens),
+		usageCostLabel(total),
+	)
+}
+
+func usageCostLabel(sum usage.Summary) string {
+	label := usage.FormatCost(sum.Cost)
+	if sum.Unpriced > 0 {
+		label += fmt.Sprintf(" (+%d unpriced)", sum.Unpriced)
+	}
+	return label
+}
+
+// parseUsageSince accepts Go durations, a day suffix ("7d") or a date.
+func parseUsageSince(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if strings.HasSuffix(raw, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(raw, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 24h or 7d, or a date like 2026-01-31", raw)
+}
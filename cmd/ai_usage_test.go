@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"roderik/internal/ai/llm"
+	"roderik/internal/ai/llm/openai"
+	"roderik/internal/ai/usage"
+)
+
+// toolCallingProvider always answers with a tool call and fixed token usage.
+type toolCallingProvider struct {
+	calls int
+}
+
+func (p *toolCallingProvider) CreateMessage(ctx context.Context, prompt string, messages []llm.Message, tools []llm.Tool) (llm.Message, error) {
+	p.calls++
+	resp := &openai.APIResponse{
+		Usage: openai.Usage{PromptTokens: 100, CompletionTokens: 50},
+		Choices: []openai.Choice{{Message: openai.MessageParam{
+			Role: "assistant",
+			ToolCalls: []openai.ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: openai.FunctionCall{Name: "roderik__missing", Arguments: "{}"},
+			}},
+		}}},
+	}
+	return &openai.Message{Resp: resp, Choice: &resp.Choices[0]}, nil
+}
+
+func (p *toolCallingProvider) CreateToolResponse(toolCallID string, content interface{}) (llm.Message, error) {
+	return openai.NewProvider("", "", "", "", 0).CreateToolResponse(toolCallID, content)
+}
+
+func (p *toolCallingProvider) SetSystemPrompt(string) {}
+func (p *toolCallingProvider) SupportsTools() bool    { return true }
+func (p *toolCallingProvider) Name() string           { return "fake" }
+
+func TestSendStopsAtSessionBudgetAndRecordsUsage(t *testing.T) {
+	usagePath := filepath.Join(t.TempDir(), "ai-usage.jsonl")
+	provider := &toolCallingProvider{}
+	session := &ChatSession{
+		provider:  provider,
+		sessionID: "test-session",
+		model:     "gpt-test",
+		price:     &usage.Price{InputPerMillion: 10, OutputPerMillion: 20},
+		budget:    usage.Budget{MaxTokens: 120},
+		usagePath: usagePath,
+	}
+
+	_, err := session.Send(context.Background(), "do something expensive")
+	var stop *budgetStopError
+	if !errors.As(err, &stop) || !strings.HasPrefix(err.Error(), "Stopped: session token budget") {
+		t.Fatalf("expected budget stop error, got %v", err)
+	}
+	if provider.calls != 1 {
+		t.Fatalf("expected the loop to stop after one model call, got %d", provider.calls)
+	}
+	if n := len(session.history); n == 0 || len(session.history[n-1].GetToolCalls()) > 0 {
+		t.Fatalf("unanswered tool call should not remain in history")
+	}
+
+	records, err := usage.Read(usagePath, time.Time{})
+	if err != nil || len(records) != 1 {
+		t.Fatalf("expected one usage record, got %d (%v)", len(records), err)
+	}
+	if rec := records[0]; rec.SessionID != "test-session" || !rec.Priced || rec.Cost != 0.002 {
+		t.Fatalf("unexpected usage record %#v", rec)
+	}
+
+	_, err = session.Send(context.Background(), "and again")
+	if !errors.As(err, &stop) || provider.calls != 1 {
+		t.Fatalf("expected exhausted session to refuse new turns, got calls=%d err=%v", provider.calls, err)
+	}
+}
+
+func TestParseUsageSince(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.Local)
+	cases := map[string]time.Time{
+		"":           {},
+		"7d":         now.AddDate(0, 0, -7),
+		"36h":        now.Add(-36 * time.Hour),
+		"2026-05-01": time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local),
+	}
+	for raw, want := range cases {
+		got, err := parseUsageSince(raw, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("parseUsageSince(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	if _, err := parseUsageSince("last week", now); err == nil {
+		t.Fatalf("expected invalid value to fail")
+	}
+}
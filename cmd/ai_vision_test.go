@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"roderik/internal/ai/history"
+	"roderik/internal/ai/llm"
+	aitools "roderik/internal/ai/tools"
+)
+
+func TestToolResultImageRequiresVision(t *testing.T) {
+	res := aitools.Result{Binary: []byte{1, 2, 3}, ContentType: "image/png"}
+
+	if _, ok := (&ChatSession{}).toolResultImage(res); ok {
+		t.Fatalf("expected no image without vision")
+	}
+	img, ok := (&ChatSession{vision: true}).toolResultImage(res)
+	if !ok || img.MIMEType != "image/png" {
+		t.Fatalf("expected image to be attached, got %#v ok=%t", img, ok)
+	}
+	if _, ok := (&ChatSession{vision: true}).toolResultImage(aitools.Result{Binary: []byte("%PDF"), ContentType: "application/pdf"}); ok {
+		t.Fatalf("expected non-image binaries to be ignored")
+	}
+
+	payload := imageToolResultPayload(res).(map[string]interface{})
+	if _, inlined := payload["binary_base64"]; inlined {
+		t.Fatalf("image payload should not inline base64 data")
+	}
+}
+
+func TestAttachImagesKeepsOnlyLatestImages(t *testing.T) {
+	session := &ChatSession{historyWindow: 0}
+	session.history = []llm.Message{history.NewUserMessage("look at the page")}
+
+	session.attachImages([]string{"capture_screenshot"}, []llm.Image{{MIMEType: "image/png", Data: []byte{1}}})
+	session.attachImages([]string{"capture_screenshot"}, []llm.Image{{MIMEType: "image/png", Data: []byte{2}}})
+
+	var withImages int
+	for _, msg := range session.history {
+		if len(msg.GetImages()) > 0 {
+			withImages++
+		}
+	}
+	if withImages != 1 {
+		t.Fatalf("expected exactly one message with images, got %d", withImages)
+	}
+	last := session.history[len(session.history)-1].GetImages()
+	if len(last) != 1 || last[0].Data[0] != 2 {
+		t.Fatalf("expected latest image to be kept, got %#v", last)
+	}
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+type browserScopeKey struct{}
+
+// withBrowserScope makes page access through ctx use the isolated scope
+// named key. The empty key is the shared state the CLI uses.
+func withBrowserScope(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, browserScopeKey{}, key)
+}
+
+func browserScopeFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(browserScopeKey{}).(string)
+	return key
+}
+
+// browserScope is the page state of one isolated client: an incognito
+// browser context with its own cookies, tab, focus list and network log.
+// While a scope is active its state lives in the usual globals (Browser,
+// Page, CurrentElement, elementList, currentIndex and the active event log),
+// so handlers work unchanged; lockPage swaps scopes while holding pageMu.
+type browserScope struct {
+	key      string
+	browser  *rod.Browser
+	page     *rod.Page
+	element  *rod.Element
+	elements []*rod.Element
+	index    int
+	eventLog *NetworkEventLog
+	// lastUsed is when a call last entered the scope.
+	lastUsed time.Time
+}
+
+// browserScopes holds the isolated scopes. The lock also guards active, which
+// page event handlers read to route events of background pages.
+var browserScopes = struct {
+	sync.Mutex
+	byKey map[string]*browserScope
+	// active is the scope loaded into the globals; nil means the shared state.
+	active *browserScope
+	// shared keeps the shared state while a scope is active.
+	shared browserScope
+}{byKey: make(map[string]*browserScope)}
+
+func (sc *browserScope) save() {
+	sc.browser, sc.page, sc.element = Browser, Page, CurrentElement
+	sc.elements, sc.index = elementList, currentIndex
+	sc.eventLog = getActiveEventLog()
+}
+
+func (sc *browserScope) load() {
+	Browser, Page, CurrentElement = sc.browser, sc.page, sc.element
+	elementList, currentIndex = sc.elements, sc.index
+	swapActiveEventLog(sc.eventLog)
+}
+
+// open creates the scope's incognito context and tab in root.
+func (sc *browserScope) open(root *rod.Browser) error {
+	incognito, err := root.Incognito()
+	if err != nil {
+		return fmt.Errorf("create browser context for %s: %w", sc.key, err)
+	}
+	page, err := newPageForBrowser(incognito)
+	if err != nil {
+		_ = incognito.Close()
+		return fmt.Errorf("open tab for %s: %w", sc.key, err)
+	}
+	sc.browser, sc.page = incognito, page
+	sc.element, sc.elements, sc.index = nil, nil, 0
+	sc.eventLog = newNetworkEventLog()
+	attachNetStore(sc.eventLog)
+	// ensurePageEventHandlers tracks the shared page only.
+	registerPageEvents(page)
+	return nil
+}
+
+func (sc *browserScope) close() {
+	if sc.browser != nil {
+		if err := sc.browser.Close(); err != nil && Verbose {
+			fmt.Printf("warning: closing browser context %s: %v\n", sc.key, err)
+		}
+	}
+	if sc.eventLog != nil {
+		bodyMemory.forget(sc.eventLog)
+	}
+	sc.browser, sc.page, sc.element, sc.elements = nil, nil, nil, nil
+}
+
+// enterBrowserScope loads the scope named key into the globals, creating its
+// browser context on first use when ready is set. Callers hold pageMu.
+func enterBrowserScope(key string, ready bool) error {
+	browserScopes.Lock()
+	defer browserScopes.Unlock()
+	if key == "" {
+		restoreSharedScopeLocked()
+		return nil
+	}
+	sc := browserScopes.byKey[key]
+	if sc == nil {
+		sc = &browserScope{key: key}
+		browserScopes.byKey[key] = sc
+	}
+	sc.lastUsed = time.Now()
+	if browserScopes.active == sc && (Page != nil || !ready) {
+		return nil
+	}
+	restoreSharedScopeLocked()
+	if ready && sc.page == nil {
+		// The incognito context lives in the shared browser.
+		if err := ensurePageReady(); err != nil {
+			return err
+		}
+		if err := sc.open(Browser); err != nil {
+			return err
+		}
+	}
+	browserScopes.shared.save()
+	sc.load()
+	browserScopes.active = sc
+	return nil
+}
+
+func restoreSharedScopeLocked() {
+	if browserScopes.active == nil {
+		return
+	}
+	browserScopes.active.save()
+	browserScopes.shared.load()
+	browserScopes.active = nil
+}
+
+// scopeOwningLocked returns the isolated scope whose tab is p, or nil for
+// pages of the shared state.
+func scopeOwningLocked(p *rod.Page) *browserScope {
+	if p == nil {
+		return nil
+	}
+	for _, sc := range browserScopes.byKey {
+		if sc.page != nil && (sc.page == p || p.TargetID != "" && sc.page.TargetID == p.TargetID) {
+			return sc
+		}
+	}
+	return nil
+}
+
+func isScopedPage(p *rod.Page) bool {
+	browserScopes.Lock()
+	defer browserScopes.Unlock()
+	return scopeOwningLocked(p) != nil
+}
+
+// pageScopeKey returns the key of the isolated scope whose tab is p, or ""
+// for pages of the shared state.
+func pageScopeKey(p *rod.Page) string {
+	browserScopes.Lock()
+	defer browserScopes.Unlock()
+	if sc := scopeOwningLocked(p); sc != nil {
+		return sc.key
+	}
+	return ""
+}
+
+// pageEventLog returns the network log that events of p belong to, which is
+// not the active one when p is a tab of a scope in the background.
+func pageEventLog(p *rod.Page) *NetworkEventLog {
+	browserScopes.Lock()
+	defer browserScopes.Unlock()
+	owner := scopeOwningLocked(p)
+	switch {
+	case owner == browserScopes.active:
+		return getActiveEventLog()
+	case owner == nil:
+		return browserScopes.shared.eventLog
+	default:
+		return owner.eventLog
+	}
+}
+
+// scopedEventLog returns the network log of the browser scope selected by
+// ctx, whether or not that scope is loaded right now.
+func scopedEventLog(ctx context.Context) *NetworkEventLog {
+	key := browserScopeFromContext(ctx)
+	browserScopes.Lock()
+	defer browserScopes.Unlock()
+	var sc *browserScope
+	if key != "" {
+		if sc = browserScopes.byKey[key]; sc == nil {
+			return nil
+		}
+	}
+	switch {
+	case sc == browserScopes.active:
+		return getActiveEventLog()
+	case sc == nil:
+		return browserScopes.shared.eventLog
+	default:
+		return sc.eventLog
+	}
+}
+
+// eventLogScope returns the key of the browser scope log belongs to.
+func eventLogScope(log *NetworkEventLog) string {
+	browserScopes.Lock()
+	defer browserScopes.Unlock()
+	if log == getActiveEventLog() {
+		if browserScopes.active != nil {
+			return browserScopes.active.key
+		}
+		return ""
+	}
+	for key, sc := range browserScopes.byKey {
+		if sc.eventLog == log {
+			return key
+		}
+	}
+	return ""
+}
+
+// resetPageFocus points the focus of p's scope at el after a navigation.
+func resetPageFocus(p *rod.Page, el *rod.Element) {
+	browserScopes.Lock()
+	defer browserScopes.Unlock()
+	owner := scopeOwningLocked(p)
+	if owner == browserScopes.active {
+		CurrentElement = el
+		elementList = nil
+		currentIndex = 0
+		return
+	}
+	if owner == nil {
+		owner = &browserScopes.shared
+	}
+	owner.element, owner.elements, owner.index = el, nil, 0
+}
+
+// closeBrowserScopes disposes of the scopes match selects and returns their
+// keys.
+func closeBrowserScopes(match func(*browserScope) bool) []string {
+	browserScopes.Lock()
+	empty := len(browserScopes.byKey) == 0
+	browserScopes.Unlock()
+	if empty {
+		return nil
+	}
+	closed, _ := inspectPage(func() ([]string, error) {
+		browserScopes.Lock()
+		defer browserScopes.Unlock()
+		var keys []string
+		for key, sc := range browserScopes.byKey {
+			if match(sc) {
+				sc.close()
+				delete(browserScopes.byKey, key)
+				keys = append(keys, key)
+			}
+		}
+		return keys, nil
+	})
+	return closed
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func resetBrowserScopes(t *testing.T) {
+	t.Helper()
+	prevPage, prevElement, prevLog := Page, CurrentElement, getActiveEventLog()
+	t.Cleanup(func() {
+		browserScopes.Lock()
+		browserScopes.byKey = make(map[string]*browserScope)
+		browserScopes.active = nil
+		browserScopes.shared = browserScope{}
+		browserScopes.Unlock()
+		Page, CurrentElement = prevPage, prevElement
+		swapActiveEventLog(prevLog)
+	})
+}
+
+func TestBrowserScopesSwapPageState(t *testing.T) {
+	resetBrowserScopes(t)
+
+	sharedPage := &rod.Page{TargetID: "shared"}
+	sharedLog := newNetworkEventLog()
+	Page, CurrentElement, elementList, currentIndex = sharedPage, nil, nil, 0
+	swapActiveEventLog(sharedLog)
+
+	scopedPage := &rod.Page{TargetID: "scoped"}
+	scopedLog := newNetworkEventLog()
+	browserScopes.Lock()
+	browserScopes.byKey["a"] = &browserScope{key: "a", page: scopedPage, eventLog: scopedLog}
+	browserScopes.Unlock()
+
+	ctxA := withBrowserScope(context.Background(), "a")
+	got, err := inspectPageContext(ctxA, func() (*rod.Page, error) {
+		if getActiveEventLog() != scopedLog {
+			t.Error("scope a did not load its network log")
+		}
+		currentIndex = 3
+		return Page, nil
+	})
+	if err != nil || got != scopedPage {
+		t.Fatalf("scope a page = %v, %v; want the scoped tab", got, err)
+	}
+
+	// Events of the background tab keep going to its own log and focus.
+	if pageEventLog(scopedPage) != scopedLog || scopedEventLog(ctxA) != scopedLog {
+		t.Fatal("scoped events are not routed to the scope's log")
+	}
+	body := &rod.Element{}
+	resetPageFocus(scopedPage, body)
+
+	got, _ = inspectPage(func() (*rod.Page, error) { return Page, nil })
+	if got != sharedPage || getActiveEventLog() != sharedLog || currentIndex != 0 {
+		t.Fatalf("shared state not restored: page=%v index=%d", got, currentIndex)
+	}
+	if pageEventLog(scopedPage) != scopedLog || pageEventLog(sharedPage) != sharedLog {
+		t.Fatal("events routed to the wrong log after switching back")
+	}
+	if CurrentElement == body {
+		t.Fatal("navigation in a background scope moved the shared focus")
+	}
+
+	focus, _ := inspectPageContext(ctxA, func() (*rod.Element, error) { return CurrentElement, nil })
+	if focus != body {
+		t.Fatal("scope a lost the focus reset by its navigation")
+	}
+
+	closed := closeBrowserScopes(func(sc *browserScope) bool { return sc.key == "a" })
+	if len(closed) != 1 || closed[0] != "a" {
+		t.Fatalf("closed = %v", closed)
+	}
+	if Page != sharedPage {
+		t.Fatal("closing a scope did not leave the shared page loaded")
+	}
+}
+
+func TestMCPIsolationMiddlewareSelectsScope(t *testing.T) {
+	prev := mcpIsolation
+	t.Cleanup(func() { mcpIsolation = prev })
+
+	var gotScope string
+	var gotArgs map[string]interface{}
+	handler := mcpIsolationMiddleware(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		gotScope = browserScopeFromContext(ctx)
+		gotArgs = req.Params.Arguments
+		return nil, nil
+	})
+	call := func(args map[string]interface{}) {
+		var req mcp.CallToolRequest
+		req.Params.Arguments = args
+		ctx := withTestMCPSession(context.Background(), "abc")
+		if _, err := handler(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mcpIsolation = mcpIsolationShared
+	call(map[string]interface{}{"url": "https://example.com"})
+	if gotScope != "" {
+		t.Fatalf("shared mode selected scope %q", gotScope)
+	}
+
+	mcpIsolation = mcpIsolationSession
+	call(map[string]interface{}{"url": "https://example.com"})
+	if gotScope != mcpSessionScopeKey("abc") {
+		t.Fatalf("session mode scope = %q", gotScope)
+	}
+	call(map[string]interface{}{"url": "https://example.com", mcpScopeArg: "checkout"})
+	if gotScope != "named:checkout" {
+		t.Fatalf("explicit scope = %q", gotScope)
+	}
+	if _, ok := gotArgs[mcpScopeArg]; ok || gotArgs["url"] != "https://example.com" {
+		t.Fatalf("handler args = %v", gotArgs)
+	}
+}
+
+func TestNetworkWatchSeesOnlyItsScope(t *testing.T) {
+	shared := networkWatchers.watch(NetworkLogFilter{}, "")
+	scoped := networkWatchers.watch(NetworkLogFilter{}, "session:abc")
+	defer networkWatchers.cancel(shared.ID)
+	defer networkWatchers.cancel(scoped.ID)
+
+	networkWatchers.publish(&NetworkLogEntry{RequestID: "1", URL: "https://example.com/"}, "session:abc")
+	select {
+	case e := <-scoped.C:
+		if e.RequestID != "1" {
+			t.Fatalf("scoped watch got %v", e)
+		}
+	default:
+		t.Fatal("scoped watch missed its entry")
+	}
+	select {
+	case e := <-shared.C:
+		t.Fatalf("shared watch saw another scope's entry %v", e)
+	default:
+	}
+}
+
+func withTestMCPSession(ctx context.Context, id string) context.Context {
+	return server.NewMCPServer("roderik", "test").WithContext(ctx, newMCPSession(id))
+}
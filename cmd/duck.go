@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -9,7 +10,12 @@ import (
 )
 
 var (
-	numResults int
+	numResults     int
+	duckEngine     string
+	duckRegion     string
+	duckSafeSearch string
+	duckTimeRange  string
+	duckAds        bool
 	// DuckCmd queries DuckDuckGo for keyword search and prints formatted results.
 	DuckCmd = &cobra.Command{
 		Use:   "duck [flags] <search terms>",
@@ -22,12 +28,29 @@ the top N results in a simple markdown-style snippet:
   title:   DuckDuckGo — Privacy, simplified.
   snippet: The search engine that doesn’t track you.
 
-You can override how many results to return with --num (default 20).`,
+Results that carry a publication date or are news/ads get extra "date:" and
+"type:" lines. You can override how many results to return with --num
+(default 20); when one page is not enough further result pages are fetched.
+
+--region (DuckDuckGo kl code such as us-en, de-de, wt-wt), --safe
+(strict|moderate|off) and --time (day|week|month|year) narrow the search.
+Sponsored results are dropped unless --ads is given.
+
+--engine picks the search backend (ddg, searxng, brave, ddg-browser). The
+default, auto, tries the engines listed under "failover" in
+<config-base>/search-engines.json in order, moving on when one fails (for
+example on a DuckDuckGo bot challenge).`,
 		Example: `  # Search for “golang cobra”
   roderik duck cobra golang
 
   # Limit to 5 results
-  roderik duck -m 5 privacy`,
+  roderik duck -m 5 privacy
+
+  # Query a SearXNG instance directly
+  roderik duck --engine searxng privacy
+
+  # German results from the past week
+  roderik duck --region de-de --time week bundestag`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: runDuck,
 	}
@@ -37,10 +60,21 @@ func init() {
 	RootCmd.AddCommand(DuckCmd)
 	flags := DuckCmd.Flags()
 	flags.IntVarP(&numResults, "num", "m", 20, "number of results to return")
+	flags.StringVar(&duckEngine, "engine", engineAuto, "search engine: "+strings.Join(searchEngineNames(), ", "))
+	flags.StringVar(&duckRegion, "region", "", "region code, e.g. us-en, de-de or wt-wt (no region)")
+	flags.StringVar(&duckSafeSearch, "safe", "", "safe search: strict, moderate or off")
+	flags.StringVar(&duckTimeRange, "time", "", "only results from the past day, week, month or year")
+	flags.BoolVar(&duckAds, "ads", false, "keep sponsored results")
 }
 func runDuck(cmd *cobra.Command, args []string) error {
 	query := strings.Join(args, " ")
-	result, err := searchDuck(query, numResults)
+	opts := client.SearchOptions{
+		Region:     duckRegion,
+		SafeSearch: duckSafeSearch,
+		TimeRange:  duckTimeRange,
+		IncludeAds: duckAds,
+	}
+	result, err := searchDuck(query, numResults, duckEngine, opts)
 	if err != nil {
 		if client.IsChallengeError(err) {
 			return err
@@ -50,9 +84,8 @@ func runDuck(cmd *cobra.Command, args []string) error {
 	cmd.Println(result)
 	return nil
 }
-func searchDuck(query string, num int) (string, error) {
-	ddg := client.NewDuckDuckGoSearchClient()
-	res, err := ddg.SearchLimited(query, num)
+func searchDuck(query string, num int, engine string, opts client.SearchOptions) (string, error) {
+	res, used, err := webSearch(query, num, engine, opts, false)
 	if err != nil {
 		var code int
 		if _, scanErr := fmt.Sscanf(err.Error(), "%*[^0-9]%d", &code); scanErr == nil && code >= 200 && code < 300 {
@@ -62,12 +95,21 @@ func searchDuck(query string, num int) (string, error) {
 			return "", err
 		}
 	}
+	if Verbose && used != "" {
+		fmt.Fprintf(os.Stderr, "search engine: %s\n", used)
+	}
 	var sb strings.Builder
 	for i, r := range res {
 		sb.WriteString(fmt.Sprintf("## RESULT %d\n", i+1))
 		sb.WriteString(fmt.Sprintf("url:     %s\n", r.FormattedUrl))
 		sb.WriteString(fmt.Sprintf("title:   %s\n", r.Title))
 		sb.WriteString(fmt.Sprintf("snippet: %s\n", r.Snippet))
+		if r.Date != "" {
+			sb.WriteString(fmt.Sprintf("date:    %s\n", r.Date))
+		}
+		if r.Type != "" && r.Type != client.ResultTypeWeb {
+			sb.WriteString(fmt.Sprintf("type:    %s\n", r.Type))
+		}
 	}
 	return sb.String(), nil
 }
@@ -8,6 +8,26 @@ import (
 	"github.com/go-rod/rod/lib/proto"
 )
 
+// elementMarkdown renders el (and its subtree) on page as Markdown using the
+// accessibility tree.
+func elementMarkdown(page *rod.Page, el *rod.Element) (string, error) {
+	if err := (proto.AccessibilityEnable{}).Call(page); err != nil {
+		return "", fmt.Errorf("accessibility enable failed: %w", err)
+	}
+
+	props, err := el.Describe(0, false)
+	if err != nil {
+		return "", fmt.Errorf("describe element failed: %w", err)
+	}
+
+	tree, err := proto.AccessibilityQueryAXTree{BackendNodeID: props.BackendNodeID}.Call(page)
+	if err != nil {
+		return "", fmt.Errorf("accessibility query failed: %w", err)
+	}
+
+	return convertAXTreeToMarkdown(tree, page), nil
+}
+
 // convertAXTreeToMarkdown walks the accessibility tree and emits a
 // standards-compliant Markdown document.
 func convertAXTreeToMarkdown(tree *proto.AccessibilityQueryAXTreeResult, page *rod.Page) string {
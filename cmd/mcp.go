@@ -10,8 +10,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -52,13 +50,60 @@ type HTMLArgs struct{}
 // mcpCmd is the cobra subcommand which will start our MCP server.
 var mcpCmd = &cobra.Command{
 	Use:   "mcp",
-	Short: "Run Roderik in MCP‐server mode over stdio",
-	Run:   runMCP,
+	Short: "Run Roderik in MCP‐server mode over stdio, HTTP or SSE",
+	Long: `Run Roderik as an MCP server.
+
+By default the server speaks MCP over stdio to a single client. With
+--transport http (streamable HTTP on /mcp) or --transport sse (/sse and
+/message) it listens on --listen so several clients, local or remote, can
+share one long-lived browser. Network transports require a bearer token,
+taken from --token or RODERIK_MCP_TOKEN, or generated and printed at startup.
+
+Each network client gets its own session ID; notifications such as
+network_watch updates go only to the session that asked for them. Tool calls
+from different clients run concurrently, but calls that touch the page take
+the shared page lock one at a time, so clients see each other's navigation.
+
+With --isolation session every client instead gets its own incognito browser
+context, created on its first page call, with separate cookies, tab, focus
+list and network log. Tools then accept a browser_session argument to use a
+named context instead, for example to share one between clients. Contexts
+close when their session ends or after --idle-timeout without calls.
+
+--tools limits the server to a capability profile: read-only (inspect the
+current page), navigation (also load URLs, click, type and search the web),
+network-forensics (inspect, navigate and the network log tools) or full
+(the default). --allow-tools and --deny-tools add or remove single tools, and
+mcp-tools.json in the roderik home directory can set the profile, the lists
+and custom profiles. In profiles with the admin capability the
+tool_capabilities tool switches capabilities at runtime and clients get
+tools/list_changed.
+
+Each client session is audited to a JSONL log in --audit-dir; see
+roderik mcp audit.`,
+	Example: `  roderik mcp
+  roderik mcp --transport http --listen :8765 --token secret
+  roderik mcp --transport sse --listen 0.0.0.0:8765
+  roderik mcp --transport http --isolation session --idle-timeout 10m
+  roderik mcp --tools read-only --allow-tools load_url
+  roderik mcp --tools network-forensics --deny-tools network_save
+  roderik mcp --audit-thumbnails`,
+	Run: runMCP,
 }
 
 func init() {
 	RootCmd.AddCommand(mcpCmd)
 	mcpCmd.Flags().StringVar(&mcpLogPath, "log", defaultMCPLogPath(), "path to the MCP debug log file")
+	mcpCmd.Flags().StringVar(&mcpTransport, "transport", mcpTransportStdio, "transport: stdio, http or sse")
+	mcpCmd.Flags().StringVar(&mcpListen, "listen", mcpDefaultListen, "address to listen on for the http and sse transports")
+	mcpCmd.Flags().StringVar(&mcpToken, "token", "", "bearer token required by the http and sse transports (default $"+mcpTokenEnv+" or generated)")
+	mcpCmd.Flags().DurationVar(&mcpSessionIdle, "session-timeout", mcpDefaultSessionIdle, "end an http session after this long without requests and no open event stream (0 keeps sessions until DELETE)")
+	mcpCmd.Flags().StringVar(&mcpIsolation, "isolation", mcpIsolationShared, "browser isolation: shared (one page for all clients) or session (an incognito context per client)")
+	mcpCmd.Flags().DurationVar(&mcpScopeIdle, "idle-timeout", mcpDefaultScopeIdle, "close an isolated browser context after this long without calls")
+	mcpCmd.Flags().StringVar(&mcpToolsProfile, "tools", "", "tool profile: read-only, navigation, network-forensics, full or one from the tools config (default from config, else full)")
+	mcpCmd.Flags().StringSliceVar(&mcpAllowTools, "allow-tools", nil, "offer these tools in addition to the profile (repeatable)")
+	mcpCmd.Flags().StringSliceVar(&mcpDenyTools, "deny-tools", nil, "never offer these tools (repeatable)")
+	mcpCmd.Flags().StringVar(&mcpToolsConfigPath, "tools-config", defaultMCPToolsConfigPath(), "path to the MCP tools config (profile, allow and deny lists, custom profiles)")
 
 	// ensure cobra’s own help/errors go to stderr
 	mcpCmd.SetOut(os.Stderr)
@@ -108,572 +153,101 @@ func runMCP(cmd *cobra.Command, args []string) {
 		log.SetOutput(io.MultiWriter(os.Stderr, f))
 	}
 
-	s := server.NewMCPServer(
-		"roderik",
-		"1.0.0",
-		server.WithToolCapabilities(true),
-	)
-
-	if loadURLToolEnabled() {
-		s.AddTool(
-			mcp.NewTool(
-				"load_url",
-				mcp.WithDescription("Load a webpage at the given URL and set it as the current page for subsequent tools"),
-				mcp.WithString("url", mcp.Required(), mcp.Description("the URL of the webpage to load")),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL load_url CALLED args=%#v", req.Params.Arguments)
-				res, err := aitools.Call(ctx, "load_url", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				out, err := resultToMCP(res)
-				if err != nil {
-					return nil, err
-				}
-				log.Printf("[MCP] TOOL load_url RESULT: %q", res.Text)
-				return out, nil
-			},
-		)
+	if err := validateMCPIsolation(mcpIsolation); err != nil {
+		log.Printf("MCP server error: %v", err)
+		return
 	}
+	if err := configureMCPToolset(); err != nil {
+		log.Printf("MCP server error: %v", err)
+		return
+	}
+	log.Printf("[MCP] tool profile %s", mcpToolset.profile)
 
-	s.AddTool(
-		mcp.NewTool(
-			"get_html",
-			mcp.WithDescription(
-				"Get the raw HTML of the current element (or an optional URL). "+
-					"Beware: this returns the full source and can be very large. "+
-					"In most cases, use \"to_markdown\" for a more concise, token-efficient output.",
-			),
-			mcp.WithString(
-				"url",
-				mcp.Description("optional URL to load first; overrides the current element"),
-			),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL get_html CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "get_html", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			out, err := resultToMCP(res)
-			if err != nil {
-				return nil, err
-			}
-			log.Printf("[MCP] TOOL get_html RESULT length=%d", len(res.Text))
-			return out, nil
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"text",
-			mcp.WithDescription("Print the text of the current element, optionally truncating to a specified length."),
-			mcp.WithNumber("length", mcp.Description("optional maximum number of characters to return")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL text CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "text", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			out, err := resultToMCP(res)
-			if err != nil {
-				return nil, err
-			}
-			log.Printf("[MCP] TOOL text RESULT length=%d", len(res.Text))
-			return out, nil
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"capture_screenshot",
-			mcp.WithDescription("Capture a screenshot of the current page or an optional URL."),
-			mcp.WithString("url", mcp.Description("optional URL to load before capturing the screenshot")),
-			mcp.WithString("selector", mcp.Description("optional CSS selector to capture a specific element")),
-			mcp.WithBoolean("full_page", mcp.Description("capture the entire page by resizing the viewport")),
-			mcp.WithBoolean("scroll", mcp.Description("scroll and stitch the entire page without resizing the viewport")),
-			mcp.WithString("format", mcp.Description("image format: png or jpeg (default png)"), mcp.Enum("png", "jpeg", "jpg"), mcp.DefaultString("png")),
-			mcp.WithNumber("quality", mcp.Description("JPEG quality (0-100)")),
-			mcp.WithString("return", mcp.Description("delivery mode: binary (inline) or file (writes to disk and returns resource)"), mcp.Enum("binary", "file"), mcp.DefaultString("binary")),
-			mcp.WithString("output", mcp.Description("optional path to save the capture on disk when return=file")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL capture_screenshot CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "capture_screenshot", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"capture_pdf",
-			mcp.WithDescription("Render the current page or an optional URL to PDF."),
-			mcp.WithString("url", mcp.Description("optional URL to load before generating the PDF")),
-			mcp.WithBoolean("landscape", mcp.Description("render pages in landscape orientation")),
-			mcp.WithBoolean("header_footer", mcp.Description("display header and footer templates")),
-			mcp.WithBoolean("background", mcp.Description("print background graphics")),
-			mcp.WithNumber("scale", mcp.Description("scale factor for rendering (default 1.0)")),
-			mcp.WithNumber("paper_width", mcp.Description("paper width in inches")),
-			mcp.WithNumber("paper_height", mcp.Description("paper height in inches")),
-			mcp.WithNumber("margin_top", mcp.Description("top margin in inches")),
-			mcp.WithNumber("margin_bottom", mcp.Description("bottom margin in inches")),
-			mcp.WithNumber("margin_left", mcp.Description("left margin in inches")),
-			mcp.WithNumber("margin_right", mcp.Description("right margin in inches")),
-			mcp.WithString("page_ranges", mcp.Description("page ranges to print, e.g. '1-5,8'")),
-			mcp.WithString("header_template", mcp.Description("HTML template for the header")),
-			mcp.WithString("footer_template", mcp.Description("HTML template for the footer")),
-			mcp.WithBoolean("prefer_css_page_size", mcp.Description("prefer CSS-defined page size")),
-			mcp.WithBoolean("tagged", mcp.Description("generate tagged (accessible) PDF")),
-			mcp.WithBoolean("outline", mcp.Description("embed document outline in the PDF")),
-			mcp.WithString("return", mcp.Description("delivery mode: binary (embedded) or file (writes to disk)"), mcp.Enum("binary", "file"), mcp.DefaultString("binary")),
-			mcp.WithString("output", mcp.Description("optional path to save the PDF on disk when return=file")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL capture_pdf CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "capture_pdf", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"network_list",
-			mcp.WithDescription("List captured network activity entries with optional filters."),
-			mcp.WithArray("mime", mcp.Items(map[string]interface{}{"type": "string"})),
-			mcp.WithArray("suffix", mcp.Items(map[string]interface{}{"type": "string"})),
-			mcp.WithArray("status", mcp.Items(map[string]interface{}{"type": "integer"})),
-			mcp.WithArray("contains", mcp.Items(map[string]interface{}{"type": "string"})),
-			mcp.WithArray("method", mcp.Items(map[string]interface{}{"type": "string"})),
-			mcp.WithArray("domain", mcp.Items(map[string]interface{}{"type": "string"})),
-			mcp.WithArray("type", mcp.Items(map[string]interface{}{"type": "string"})),
-			mcp.WithNumber("limit", mcp.Description("maximum number of entries to return (default 20, capped at 1000)")),
-			mcp.WithNumber("offset", mcp.Description("number of matching entries to skip before returning results")),
-			mcp.WithBoolean("tail", mcp.Description("when true (default) return the newest matching entries")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL network_list CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "network_list", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			out, err := resultToMCP(res)
-			if err != nil {
-				return nil, err
-			}
-			log.Printf("[MCP] TOOL network_list RESULT length=%d", len(res.Text))
-			return out, nil
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"network_save",
-			mcp.WithDescription("Retrieve or persist the response body for a captured network request."),
-			mcp.WithString("request_id", mcp.Required(), mcp.Description("request identifier returned by network_list")),
-			mcp.WithString("return", mcp.Description("delivery mode: file (default) saves on the server, binary streams the payload, save aliases file"), mcp.Enum("file", "binary", "save"), mcp.DefaultString("file")),
-			mcp.WithString("save_dir", mcp.Description("optional directory to write the file when return=file")),
-			mcp.WithString("filename", mcp.Description("optional filename override when saving to disk")),
-			mcp.WithString("filename_prefix", mcp.Description("optional prefix prepended to generated filenames")),
-			mcp.WithString("filename_suffix", mcp.Description("optional suffix appended before the file extension")),
-			mcp.WithBoolean("filename_timestamp", mcp.Description("include a timestamp in the filename")),
-			mcp.WithString("timestamp_format", mcp.Description("time format used when filename_timestamp is true (Go layout)")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL network_save CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "network_save", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			out, err := resultToMCP(res)
-			if err != nil {
-				return nil, err
-			}
-			if res.Binary != nil {
-				log.Printf("[MCP] TOOL network_save RESULT binary length=%d", len(res.Binary))
-			} else {
-				log.Printf("[MCP] TOOL network_save RESULT %q", res.Text)
-			}
-			return out, nil
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"network_set_logging",
-			mcp.WithDescription("Enable, disable, or query network activity logging without restarting Roderik."),
-			mcp.WithBoolean("enabled", mcp.Description("optional flag; when provided sets logging state to the given value")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL network_set_logging CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "network_set_logging", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			out, err := resultToMCP(res)
-			if err != nil {
-				return nil, err
-			}
-			log.Printf("[MCP] TOOL network_set_logging RESULT %q", res.Text)
-			return out, nil
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"box",
-			mcp.WithDescription("Get the bounding box of the current element."),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL box CALLED")
-			res, err := aitools.Call(ctx, "box", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"computedstyles",
-			mcp.WithDescription("Output the computed styles of the current element in JSON format."),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL computedstyles CALLED")
-			res, err := aitools.Call(ctx, "computedstyles", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"describe",
-			mcp.WithDescription("Describe the current element as formatted JSON."),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL describe CALLED")
-			res, err := aitools.Call(ctx, "describe", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
-	)
-
-	s.AddTool(
-		mcp.NewTool(
-			"xpath",
-			mcp.WithDescription("Get the optimized XPath of the current element."),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL xpath CALLED")
-			res, err := aitools.Call(ctx, "xpath", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
-	)
+	s := newMCPServer()
+	if mcpIsolation == mcpIsolationSession {
+		log.Printf("[MCP] isolating browser contexts per session idle-timeout=%s", mcpScopeIdle)
+		startBrowserScopeReaper(mcpScopeIdle)
+	}
 
-	var shutdownOnce sync.Once
-
-	s.AddTool(
-		mcp.NewTool("shutdown", mcp.WithDescription("Shut down the MCP server")),
-		func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			shutdownOnce.Do(func() {
-				go func() {
-					time.Sleep(200 * time.Millisecond) // allow stdio response to flush
-					log.Printf("[MCP] shutdown requested – exiting")
-					os.Exit(0)
-				}()
-			})
-			return mcp.NewToolResultText("shutting down"), nil
-		},
-	)
+	registerMCPTools(s)
+	registerNetworkWatchMCP(s)
+	registerMCPResources(s)
+	registerMCPPrompts(s)
+	registerMCPCancellation(s)
 
-	// === DuckDuckGo keyword search ===
-	s.AddTool(
-		mcp.NewTool(
-			"duck",
-			mcp.WithDescription("Search DuckDuckGo and return top N results"),
-			mcp.WithString("query", mcp.Required(), mcp.Description("the search terms")),
-			mcp.WithNumber("num", mcp.Description("how many results to return (default 20)")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL duck CALLED args=%#v", req.Params.Arguments)
-			// unwrap arguments
-			q, _ := req.Params.Arguments["query"].(string)
-			n := numResults
-			if v, ok := req.Params.Arguments["num"].(float64); ok {
-				n = int(v)
-			}
-			// invoke the same logic as the CLI
-			out, err := searchDuck(q, n)
-			if err != nil {
-				log.Printf("[MCP] TOOL duck ERROR: %v", err)
-				return nil, fmt.Errorf("duck tool failed: %w", err)
-			}
-			log.Printf("[MCP] TOOL duck RESULT first100=%q", out[:min(len(out), 100)])
-			return mcp.NewToolResultText(out), nil
-		},
-	)
+	if err := serveMCP(s); err != nil {
+		log.Printf("MCP server error: %v", err)
+	}
+}
 
-	// === YouTube transcript download ===
-	s.AddTool(
-		mcp.NewTool(
-			"yttrans",
-			mcp.WithDescription("Download a YouTube transcript via yt-dlp, cache it locally, and return cleaned text."),
-			mcp.WithString("url", mcp.Required(), mcp.Description("YouTube video URL")),
-			mcp.WithString("language", mcp.Description("Transcript language code (default en)")),
-			mcp.WithString("output_folder", mcp.Description("Folder for cached transcripts (default ./yttrans-cache)")),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL yttrans CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "yttrans", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
+// newMCPServer creates the server with its capabilities, tool middlewares
+// and session hooks but no tools. mcp-go wraps the tool handlers in the
+// middlewares in the order given, the first outermost: the audit log sees
+// every call as the client sent it, refusals included, and the policy runs
+// in the client's browser scope so focus rules judge its own focus.
+func newMCPServer() *server.MCPServer {
+	return server.NewMCPServer(
+		"roderik",
+		"1.0.0",
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(false, true),
+		server.WithPromptCapabilities(false),
+		server.WithToolHandlerMiddleware(mcpAuditMiddleware),
+		server.WithToolHandlerMiddleware(mcpProgressMiddleware),
+		server.WithToolHandlerMiddleware(mcpIsolationMiddleware),
+		server.WithToolHandlerMiddleware(mcpToolPolicyMiddleware),
+		server.WithToolHandlerMiddleware(mcpCapabilityMiddleware),
+		server.WithHooks(mcpAuditHooks(mcpIsolationHooks())),
 	)
+}
 
-	// === Convert page to Markdown document ===
-	s.AddTool(
-		mcp.NewTool(
-			"to_markdown",
-			mcp.WithDescription(
-				"Convert the current page/element (or an optional URL) into a structured Markdown document. "+
-					"This produces a well-formatted, token-efficient summary. "+
-					"Use this instead of \"get_html\" unless you specifically need raw HTML.",
-			),
-			mcp.WithString(
-				"url",
-				mcp.Description("optional URL to load first; overrides the current element"),
-			),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL to_markdown CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "to_markdown", req.Params.Arguments)
-			if err != nil {
+// mcpToolPolicyMiddleware applies the tool approval policy to every MCP call.
+// There is no interactive operator behind an MCP client, so "ask" is treated
+// as deny and the denial is reported as a tool error the client can read.
+func mcpToolPolicyMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := enforceToolPolicy(ctx, req.Params.Name, req.Params.Arguments, nil)
+		if err != nil {
+			payload, ok := toolDenialPayload(err)
+			if !ok {
 				return nil, err
 			}
-			return resultToMCP(res)
-		},
-	)
-
-	if navigationToolsEnabled() {
-		// === DOM navigation helpers ===
-		s.AddTool(
-			mcp.NewTool(
-				"search",
-				mcp.WithDescription("Search for elements matching a CSS selector, focus the first match, and return a numbered list for subsequent navigation commands."),
-				mcp.WithString("selector", mcp.Required(), mcp.Description("CSS selector to query")),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL search CALLED args=%#v", req.Params.Arguments)
-				res, err := aitools.Call(ctx, "search", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"head",
-				mcp.WithDescription("List page headings (optionally by level), focus the first match, and return a numbered index."),
-				mcp.WithString("level", mcp.Description("Heading level number (1-6)")),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL head CALLED args=%#v", req.Params.Arguments)
-				res, err := aitools.Call(ctx, "head", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"next",
-				mcp.WithDescription("Advance to the next element in the active search/head list or jump to a specific index."),
-				mcp.WithNumber("index", mcp.Description("optional index to jump to")),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL next CALLED args=%#v", req.Params.Arguments)
-				res, err := aitools.Call(ctx, "next", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"prev",
-				mcp.WithDescription("Move to the previous element in the active search/head list or jump to a specific index."),
-				mcp.WithNumber("index", mcp.Description("optional index to jump to")),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL prev CALLED args=%#v", req.Params.Arguments)
-				res, err := aitools.Call(ctx, "prev", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"elem",
-				mcp.WithDescription("Match elements by selector (scoped to the current element, falling back to the page), focus the best match, and return a numbered list."),
-				mcp.WithString("selector", mcp.Required(), mcp.Description("CSS selector to resolve")),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL elem CALLED args=%#v", req.Params.Arguments)
-				res, err := aitools.Call(ctx, "elem", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"child",
-				mcp.WithDescription("Focus the first child element of the current selection."),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL child CALLED")
-				res, err := aitools.Call(ctx, "child", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"parent",
-				mcp.WithDescription("Focus the parent element of the current selection."),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL parent CALLED")
-				res, err := aitools.Call(ctx, "parent", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"html",
-				mcp.WithDescription("Return the outer HTML of the current element that prior navigation selected."),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL html CALLED")
-				res, err := aitools.Call(ctx, "html", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"click",
-				mcp.WithDescription("Click the currently focused element; falls back to href navigation or synthetic click on failure."),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL click CALLED")
-				res, err := aitools.Call(ctx, "click", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
-
-		s.AddTool(
-			mcp.NewTool(
-				"type",
-				mcp.WithDescription("Type text into the currently focused element; trims optional quotes and falls back to JavaScript value injection."),
-				mcp.WithString("text", mcp.Required(), mcp.Description("Text to type")),
-			),
-			func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				log.Printf("[MCP] TOOL type CALLED args=%#v", req.Params.Arguments)
-				res, err := aitools.Call(ctx, "type", req.Params.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				return resultToMCP(res)
-			},
-		)
+			log.Printf("[MCP] TOOL %s DENIED: %v", req.Params.Name, err)
+			encoded, _ := json.Marshal(payload)
+			return mcp.NewToolResultError(string(encoded)), nil
+		}
+		req.Params.Arguments = args
+		return next(ctx, req)
 	}
+}
 
-	// === Execute arbitrary JS on the page and return JSON results ===
-	s.AddTool(
-		mcp.NewTool(
-			"run_js",
-			mcp.WithDescription(
-				`Execute JavaScript on the current page and return the result as JSON.
-Wrap your code in an IIFE that returns a JSON‐serializable value. Example:
-
-  (() => {
-    // Extract all anchor links
-    const links = Array.from(document.querySelectorAll('a')).map(a => ({
-      href: a.href,
-      text: a.textContent.trim(),
-    }));
-    return links;
-  })()
-`,
-			),
-			mcp.WithString(
-				"script",
-				mcp.Required(), mcp.Description("JavaScript code to execute in the page context"),
-			),
-			mcp.WithBoolean(
-				"showErrors",
-				mcp.Description("if true, return any evaluation errors in the tool result text"),
-			),
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			log.Printf("[MCP] TOOL run_js CALLED args=%#v", req.Params.Arguments)
-			res, err := aitools.Call(ctx, "run_js", req.Params.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			return resultToMCP(res)
-		},
-	)
+// mcpDataURI identifies the JSON form of a tool result among its contents.
+const mcpDataURI = "inline:application/json"
 
-	if err := server.ServeStdio(s); err != nil {
-		log.Printf("MCP server error: %v", err)
+// resultToMCP converts a tool result. When it carries Data, the JSON follows
+// the human-readable contents as an application/json resource, so clients
+// can read fields instead of parsing the text.
+func resultToMCP(res aitools.Result) (*mcp.CallToolResult, error) {
+	out, err := contentResultToMCP(res)
+	if err != nil || res.Data == nil {
+		return out, err
 	}
+	data, err := json.Marshal(res.Data)
+	if err != nil {
+		return nil, fmt.Errorf("encode result data: %w", err)
+	}
+	out.Content = append(out.Content, mcp.EmbeddedResource{
+		Type: "resource",
+		Resource: mcp.TextResourceContents{
+			URI:      mcpDataURI,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	})
+	return out, nil
 }
 
-func resultToMCP(res aitools.Result) (*mcp.CallToolResult, error) {
+func contentResultToMCP(res aitools.Result) (*mcp.CallToolResult, error) {
 	if len(res.Binary) > 0 {
 		if res.ContentType == "" {
 			return nil, fmt.Errorf("binary result missing content type")
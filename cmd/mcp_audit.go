@@ -0,0 +1,534 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cobra"
+	"roderik/browser"
+	"roderik/internal/ai/llm"
+	aitools "roderik/internal/ai/tools"
+	"roderik/internal/ai/vision"
+	"roderik/internal/mcpaudit"
+)
+
+const (
+	// mcpAuditThumbnailSize is the longest side of audit thumbnails.
+	mcpAuditThumbnailSize = 320
+	// mcpAuditArgLimit caps the arguments printed per call by audit show.
+	mcpAuditArgLimit = 160
+)
+
+var (
+	mcpAuditEnabled    = true
+	mcpAuditDir        = mcpaudit.DefaultDir()
+	mcpAuditThumbnails bool
+
+	mcpAuditReplayTimeout time.Duration
+)
+
+// mcpAuditPageURLFunc reads the page URL around audited and replayed calls.
+var mcpAuditPageURLFunc = mcpAuditPageURL
+
+// mcpAuditLogs holds the open audit log of every MCP session. A nil entry
+// means the log could not be created; the session then runs unaudited.
+var mcpAuditLogs = struct {
+	sync.Mutex
+	bySession map[string]*mcpaudit.Log
+}{bySession: make(map[string]*mcpaudit.Log)}
+
+var mcpAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "List, show and replay the audit logs of MCP sessions",
+	Long: `roderik mcp writes a structured audit log per client session to
+--audit-dir: the client, then every tool call with its arguments, duration,
+result size, error and the page URL before and after, plus a thumbnail of the
+page with --audit-thumbnails. Sessions are named after their start time and
+session ID; commands accept a full name, a unique prefix or "last".
+
+replay re-executes a session's tool calls in a fresh incognito browser
+context and reports calls whose outcome or resulting URL differ from the
+recording, which helps to tell agent mistakes from flaky pages.`,
+	Example: `  roderik mcp audit list
+  roderik mcp audit show last
+  roderik mcp audit replay 20260301-120000-stdio`,
+	// Reading logs needs no browser; replay launches one on its first call.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := ensureLoggingSetup(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	},
+}
+
+var mcpAuditListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List recorded MCP sessions, newest first",
+	Args:         cobra.NoArgs,
+	RunE:         runMCPAuditList,
+	SilenceUsage: true,
+}
+
+var mcpAuditShowCmd = &cobra.Command{
+	Use:          "show <session>",
+	Short:        "Print the tool calls of a recorded MCP session",
+	Args:         cobra.ExactArgs(1),
+	RunE:         runMCPAuditShow,
+	SilenceUsage: true,
+}
+
+var mcpAuditReplayCmd = &cobra.Command{
+	Use:          "replay <session>",
+	Short:        "Re-execute the tool calls of a recorded MCP session in a fresh browser context",
+	Args:         cobra.ExactArgs(1),
+	RunE:         runMCPAuditReplay,
+	SilenceUsage: true,
+}
+
+func init() {
+	mcpCmd.Flags().BoolVar(&mcpAuditEnabled, "audit", true, "write a JSONL audit log of every client session to --audit-dir")
+	mcpCmd.Flags().BoolVar(&mcpAuditThumbnails, "audit-thumbnails", false, "store a page thumbnail after every audited call that touches the page")
+	mcpCmd.PersistentFlags().StringVar(&mcpAuditDir, "audit-dir", mcpaudit.DefaultDir(), "directory of the MCP session audit logs")
+	mcpAuditReplayCmd.Flags().DurationVar(&mcpAuditReplayTimeout, "timeout", 2*time.Minute, "give up on a replayed call after this long")
+
+	mcpAuditCmd.AddCommand(mcpAuditListCmd, mcpAuditShowCmd, mcpAuditReplayCmd)
+	mcpCmd.AddCommand(mcpAuditCmd)
+}
+
+// mcpAuditLog returns the audit log of the session ctx belongs to, creating
+// it on first use. It returns nil when auditing is off or failed.
+func mcpAuditLog(ctx context.Context) *mcpaudit.Log {
+	if !mcpAuditEnabled {
+		return nil
+	}
+	var id string
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		id = cs.SessionID()
+	}
+	mcpAuditLogs.Lock()
+	defer mcpAuditLogs.Unlock()
+	if l, ok := mcpAuditLogs.bySession[id]; ok {
+		return l
+	}
+	l, err := mcpaudit.Create(mcpAuditDir, id, time.Now())
+	if err != nil {
+		log.Printf("[MCP] audit disabled for session %s: %v", id, err)
+		l = nil
+	} else {
+		log.Printf("[MCP] auditing session %s to %s", id, l.Path())
+	}
+	mcpAuditLogs.bySession[id] = l
+	return l
+}
+
+func closeMCPAuditLog(id string) {
+	mcpAuditLogs.Lock()
+	l := mcpAuditLogs.bySession[id]
+	delete(mcpAuditLogs.bySession, id)
+	mcpAuditLogs.Unlock()
+	if l == nil {
+		return
+	}
+	if err := l.Close(); err != nil {
+		log.Printf("[MCP] audit log %s: %v", l.Name(), err)
+	}
+}
+
+// mcpAuditHooks adds to hooks the recording of the client once a session
+// has initialized and the closing of its log when it ends.
+func mcpAuditHooks(hooks *server.Hooks) *server.Hooks {
+	hooks.AddAfterInitialize(func(ctx context.Context, _ any, req *mcp.InitializeRequest, _ *mcp.InitializeResult) {
+		l := mcpAuditLog(ctx)
+		if l == nil {
+			return
+		}
+		client := mcpaudit.Client{Name: req.Params.ClientInfo.Name, Version: req.Params.ClientInfo.Version}
+		rec := mcpaudit.Record{Kind: mcpaudit.KindSession, Transport: mcpTransport, Client: &client, Protocol: req.Params.ProtocolVersion}
+		if err := l.Append(rec); err != nil {
+			log.Printf("[MCP] audit log %s: %v", l.Name(), err)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		closeMCPAuditLog(session.SessionID())
+	})
+	return hooks
+}
+
+// mcpAuditMiddleware records every tool call in the session's audit log.
+// newMCPServer installs it outermost, so it sees the arguments as the client
+// sent them, browser_session included, and calls refused by the policy or
+// the tool profile too.
+func mcpAuditMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		l := mcpAuditLog(ctx)
+		if l == nil {
+			return next(ctx, req)
+		}
+		rec := mcpaudit.Record{Kind: mcpaudit.KindCall, Seq: l.NextSeq(), Time: time.Now(), Tool: req.Params.Name, Arguments: redactMCPAuditArguments(req.Params.Name, req.Params.Arguments)}
+		explicit, _ := req.Params.Arguments[mcpScopeArg].(string)
+		scoped := mcpScopeContext(ctx, explicit)
+		// Independent tools never touch the page, so its URL is not theirs.
+		touchesPage := !aitools.IsIndependent(req.Params.Name)
+		if touchesPage {
+			rec.URLBefore = redactURLCredentials(mcpAuditPageURLFunc(scoped))
+		}
+
+		res, err := next(ctx, req)
+
+		rec.DurationMS = time.Since(rec.Time).Milliseconds()
+		rec.ResultBytes = mcpResultBytes(res)
+		switch {
+		case err != nil:
+			rec.IsError, rec.Error = true, err.Error()
+		case res != nil && res.IsError:
+			rec.IsError, rec.Error = true, mcpResultText(res)
+		}
+		if touchesPage {
+			rec.URLAfter = redactURLCredentials(mcpAuditPageURLFunc(scoped))
+			if mcpAuditThumbnails {
+				rec.Thumbnail = saveMCPAuditThumbnail(scoped, l, rec.Seq)
+			}
+		}
+		if werr := l.Append(rec); werr != nil {
+			log.Printf("[MCP] audit log %s: %v", l.Name(), werr)
+		}
+		return res, err
+	}
+}
+
+// redactMCPAuditArguments returns a copy of a call's arguments that is safe
+// to keep on disk. Text typed into the page, sensitive fields, credential
+// headers and credentials in URLs are masked like persisted request bodies.
+func redactMCPAuditArguments(tool string, args map[string]interface{}) map[string]interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	out, _ := redactAuditValue(args).(map[string]interface{})
+	if _, ok := out["text"]; ok && tool == "type" {
+		out["text"] = netlogRedacted
+	}
+	return out
+}
+
+func redactAuditValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, child := range t {
+			// browser_session names a scope, it is no session credential
+			if k != mcpScopeArg && (netlogSensitiveField.MatchString(k) || netlogCredentialHeaders[strings.ToLower(k)]) {
+				out[k] = netlogRedacted
+				continue
+			}
+			out[k] = redactAuditValue(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, child := range t {
+			out[i] = redactAuditValue(child)
+		}
+		return out
+	case string:
+		return redactURLCredentials(t)
+	}
+	return v
+}
+
+// redactURLCredentials masks the password and the sensitive query
+// parameters of an absolute URL; other strings are returned as is.
+func redactURLCredentials(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return s
+	}
+	changed := false
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), netlogRedacted)
+		changed = true
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		masked := false
+		for k, vs := range query {
+			if netlogSensitiveField.MatchString(k) {
+				for i := range vs {
+					vs[i] = netlogRedacted
+				}
+				masked = true
+			}
+		}
+		if masked {
+			u.RawQuery = query.Encode()
+			changed = true
+		}
+	}
+	if !changed {
+		return s
+	}
+	return u.String()
+}
+
+// mcpAuditPageURL returns the URL of the page of ctx's browser scope, or ""
+// when no page is open or it does not answer.
+func mcpAuditPageURL(ctx context.Context) string {
+	url, _ := inspectPageContext(ctx, func() (string, error) {
+		if Page == nil {
+			return "", nil
+		}
+		info, err := Page.Timeout(5 * time.Second).Info()
+		if err != nil {
+			return "", err
+		}
+		return info.URL, nil
+	})
+	return url
+}
+
+// saveMCPAuditThumbnail stores a small JPEG of the viewport for call seq and
+// returns its path in the audit directory, or "" when there is no page.
+func saveMCPAuditThumbnail(ctx context.Context, l *mcpaudit.Log, seq int) string {
+	shot, err := inspectPageContext(ctx, func() (*browser.Result, error) {
+		if Page == nil {
+			return nil, nil
+		}
+		return captureScreenshotFunc(Page.Timeout(5*time.Second), browser.ScreenshotOptions{Format: "jpeg"})
+	})
+	if err != nil || shot == nil {
+		return ""
+	}
+	img, err := vision.Downscale(llm.Image{MIMEType: shot.MimeType, Data: shot.Data}, mcpAuditThumbnailSize)
+	if err != nil {
+		return ""
+	}
+	rel, err := l.SaveThumbnail(seq, img.Data)
+	if err != nil {
+		log.Printf("[MCP] audit thumbnail: %v", err)
+		return ""
+	}
+	return rel
+}
+
+// mcpResultBytes is the size of a tool result's payloads: text as is,
+// images and blobs base64-encoded as they were sent.
+func mcpResultBytes(res *mcp.CallToolResult) int {
+	if res == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range res.Content {
+		switch c := c.(type) {
+		case mcp.TextContent:
+			n += len(c.Text)
+		case mcp.ImageContent:
+			n += len(c.Data)
+		case mcp.EmbeddedResource:
+			switch rc := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				n += len(rc.Text)
+			case mcp.BlobResourceContents:
+				n += len(rc.Blob)
+			}
+		}
+	}
+	return n
+}
+
+// mcpResultText joins the text contents of a result.
+func mcpResultText(res *mcp.CallToolResult) string {
+	var texts []string
+	for _, c := range res.Content {
+		if text, ok := c.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func runMCPAuditList(cmd *cobra.Command, args []string) error {
+	sessions, err := mcpaudit.List(mcpAuditDir)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Fprintf(os.Stderr, "no MCP sessions recorded in %s\n", mcpAuditDir)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tCLIENT\tTRANSPORT\tCALLS\tERRORS\tDURATION")
+	for _, s := range sessions {
+		duration := s.End.Sub(s.Start).Round(time.Second).String()
+		if !s.Ended {
+			duration += " (open)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", s.Name, mcpAuditClientName(s.Client), orDash(s.Transport), s.Calls, s.Errors, duration)
+	}
+	return w.Flush()
+}
+
+func runMCPAuditShow(cmd *cobra.Command, args []string) error {
+	path, err := mcpaudit.Resolve(mcpAuditDir, args[0])
+	if err != nil {
+		return err
+	}
+	records, err := mcpaudit.Load(path)
+	if err != nil {
+		return err
+	}
+	printMCPAuditSession(os.Stdout, path, records)
+	return nil
+}
+
+func printMCPAuditSession(out io.Writer, path string, records []mcpaudit.Record) {
+	sum := mcpaudit.Summarize(path, records)
+	fmt.Fprintf(out, "Session:   %s (%s)\n", sum.Name, orDash(sum.Session))
+	fmt.Fprintf(out, "Client:    %s over %s\n", mcpAuditClientName(sum.Client), orDash(sum.Transport))
+	fmt.Fprintf(out, "Started:   %s, %s, %d call(s), %d error(s)\n", sum.Start.Local().Format("2006-01-02 15:04:05"), sum.End.Sub(sum.Start).Round(time.Second), sum.Calls, sum.Errors)
+	fmt.Fprintf(out, "Log:       %s\n", path)
+	for _, call := range mcpaudit.Calls(records) {
+		status := "ok"
+		if call.IsError {
+			status = "error"
+		}
+		fmt.Fprintf(out, "\n#%d %s %s %s %s, %d bytes\n", call.Seq, call.Time.Local().Format("15:04:05"), call.Tool, status, time.Duration(call.DurationMS)*time.Millisecond, call.ResultBytes)
+		if len(call.Arguments) > 0 {
+			encoded, _ := json.Marshal(call.Arguments)
+			fmt.Fprintf(out, "   args:  %s\n", truncateContextText(string(encoded), mcpAuditArgLimit))
+		}
+		if call.URLBefore != "" || call.URLAfter != "" {
+			if call.URLBefore == call.URLAfter {
+				fmt.Fprintf(out, "   url:   %s\n", call.URLAfter)
+			} else {
+				fmt.Fprintf(out, "   url:   %s -> %s\n", orDash(call.URLBefore), orDash(call.URLAfter))
+			}
+		}
+		if call.Error != "" {
+			fmt.Fprintf(out, "   error: %s\n", truncateContextText(call.Error, mcpAuditArgLimit))
+		}
+		if call.Thumbnail != "" {
+			fmt.Fprintf(out, "   thumb: %s\n", filepath.Join(filepath.Dir(path), call.Thumbnail))
+		}
+	}
+}
+
+func mcpAuditClientName(c mcpaudit.Client) string {
+	if c.Name == "" {
+		return "-"
+	}
+	if c.Version == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Version
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
+
+func runMCPAuditReplay(cmd *cobra.Command, args []string) error {
+	path, err := mcpaudit.Resolve(mcpAuditDir, args[0])
+	if err != nil {
+		return err
+	}
+	records, err := mcpaudit.Load(path)
+	if err != nil {
+		return err
+	}
+	calls := mcpaudit.Calls(records)
+	if len(calls) == 0 {
+		return fmt.Errorf("%s has no tool calls to replay", path)
+	}
+
+	// A scope of its own gives the replay fresh cookies, tab and focus.
+	scope := "replay:" + filepath.Base(path)
+	defer closeBrowserScopes(func(sc *browserScope) bool { return sc.key == scope })
+	ctx := withBrowserScope(cmd.Context(), scope)
+
+	fmt.Fprintf(os.Stdout, "Replaying %d call(s) from %s\n", len(calls), path)
+	diverged := replayMCPCalls(ctx, os.Stdout, calls, mcpAuditReplayTimeout)
+	if diverged > 0 {
+		return fmt.Errorf("%d of %d call(s) diverged from the recording", diverged, len(calls))
+	}
+	fmt.Fprintln(os.Stdout, "Replay matched the recording.")
+	return nil
+}
+
+// replayMCPCalls runs the recorded calls in order through the tool
+// dispatcher and the approval policy, printing each outcome. It returns how
+// many calls failed or succeeded unlike their recording, or left the page at
+// another URL. MCP-only tools cannot run outside a server and are skipped.
+func replayMCPCalls(ctx context.Context, out io.Writer, calls []mcpaudit.Record, timeout time.Duration) int {
+	diverged := 0
+	for _, call := range calls {
+		fmt.Fprintf(out, "#%d %s ", call.Seq, call.Tool)
+		def, known := aitools.Lookup(call.Tool)
+		if (known && def.MCPOnly) || !aitools.HasHandler(call.Tool) {
+			fmt.Fprintln(out, "skipped (not replayable outside an MCP server)")
+			continue
+		}
+
+		args := make(map[string]interface{}, len(call.Arguments))
+		for k, v := range call.Arguments {
+			if k != mcpScopeArg {
+				args[k] = v
+			}
+		}
+		start := time.Now()
+		args, err := enforceToolPolicy(ctx, call.Tool, args, nil)
+		if err == nil {
+			callCtx, cancel := context.WithTimeout(ctx, timeout)
+			_, err = aitools.Call(callCtx, call.Tool, args)
+			cancel()
+		}
+		var url string
+		if !def.Independent {
+			url = mcpAuditPageURLFunc(ctx)
+		}
+
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		fmt.Fprintf(out, "%s %s", status, time.Since(start).Round(time.Millisecond))
+		var diffs []string
+		if (err != nil) != call.IsError {
+			recorded := "ok"
+			if call.IsError {
+				recorded = "error: " + truncateContextText(call.Error, mcpAuditArgLimit)
+			}
+			diffs = append(diffs, "recorded "+recorded)
+		}
+		if call.URLAfter != "" && url != call.URLAfter {
+			diffs = append(diffs, fmt.Sprintf("url %s, recorded %s", orDash(url), call.URLAfter))
+		}
+		if len(diffs) > 0 {
+			diverged++
+			fmt.Fprint(out, " DIVERGED")
+		}
+		fmt.Fprintln(out)
+		if err != nil {
+			fmt.Fprintf(out, "   error: %s\n", truncateContextText(err.Error(), mcpAuditArgLimit))
+		}
+		for _, diff := range diffs {
+			fmt.Fprintf(out, "   %s\n", diff)
+		}
+	}
+	return diverged
+}
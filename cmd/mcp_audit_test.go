@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"roderik/internal/ai/policy"
+	aitools "roderik/internal/ai/tools"
+	"roderik/internal/mcpaudit"
+)
+
+func swapMCPAudit(t *testing.T) {
+	t.Helper()
+	prevDir, prevEnabled, prevURL := mcpAuditDir, mcpAuditEnabled, mcpAuditPageURLFunc
+	mcpAuditDir, mcpAuditEnabled = t.TempDir(), true
+	t.Cleanup(func() { mcpAuditDir, mcpAuditEnabled, mcpAuditPageURLFunc = prevDir, prevEnabled, prevURL })
+}
+
+func TestMCPAuditRecordsCallsThroughServerChain(t *testing.T) {
+	swapMCPAudit(t)
+	// The URL names the browser scope the call was audited in.
+	mcpAuditPageURLFunc = func(ctx context.Context) string { return "scope:" + browserScopeFromContext(ctx) }
+	prevIsolation := mcpIsolation
+	mcpIsolation = mcpIsolationSession
+	t.Cleanup(func() { mcpIsolation = prevIsolation })
+	swapMCPToolset(t, mustToolset(mcpProfileReadOnly, builtinToolProfiles[mcpProfileReadOnly]))
+	installTestPolicy(t, policy.Config{Rules: []policy.Rule{{Tool: "test_audit_echo", Arg: "query", Pattern: "^secret$", Action: policy.ActionDeny}}})
+
+	var seen []map[string]interface{}
+	s := newMCPServer()
+	echo := func(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seen = append(seen, req.Params.Arguments)
+		return mcp.NewToolResultText("echo"), nil
+	}
+	s.AddTool(mcp.NewTool("test_audit_echo"), echo)
+	s.AddTool(mcp.NewTool("click"), echo)
+	s.AddTool(mcp.NewTool("type"), echo)
+
+	sess := newMCPSession("abc")
+	if err := s.RegisterSession(context.Background(), sess); err != nil {
+		t.Fatal(err)
+	}
+	ctx := s.WithContext(context.Background(), sess)
+	rpc := func(method string, params any) {
+		t.Helper()
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		if resp, ok := s.HandleMessage(ctx, msg).(mcp.JSONRPCError); ok {
+			t.Fatalf("%s: %+v", method, resp.Error)
+		}
+	}
+	rpc("initialize", map[string]any{"protocolVersion": "2025-03-26", "capabilities": map[string]any{}, "clientInfo": map[string]any{"name": "agent", "version": "2"}})
+	call := func(name string, args map[string]any) {
+		rpc(string(mcp.MethodToolsCall), map[string]any{"name": name, "arguments": args})
+	}
+	call("test_audit_echo", map[string]any{"query": "h1", mcpScopeArg: "shared-login"})
+	call("test_audit_echo", map[string]any{"query": "secret"})
+	call("click", nil)
+	call("type", map[string]any{"text": "hunter2"})
+	call("test_audit_echo", map[string]any{"url": "https://me:pw@example.com/cb?access_token=abc&q=1", "headers": map[string]any{"Authorization": "Bearer abc", "Accept": "*/*"}})
+	s.UnregisterSession(context.Background(), sess.SessionID())
+
+	if len(seen) != 2 || seen[0]["query"] != "h1" || seen[0][mcpScopeArg] != nil || seen[1]["url"] != "https://me:pw@example.com/cb?access_token=abc&q=1" {
+		t.Fatalf("handler arguments = %v", seen)
+	}
+	path, err := mcpaudit.Resolve(mcpAuditDir, "last")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := mcpaudit.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := mcpaudit.Summarize(path, records)
+	if sum.Client.Name != "agent" || sum.Session != "abc" || sum.Calls != 5 || sum.Errors != 3 || !sum.Ended {
+		t.Fatalf("summary = %+v", sum)
+	}
+	calls := mcpaudit.Calls(records)
+	named := calls[0]
+	if named.Arguments[mcpScopeArg] != "shared-login" || named.URLBefore != "scope:named:shared-login" || named.URLAfter != "scope:named:shared-login" || named.ResultBytes != len("echo") {
+		t.Fatalf("named scope call = %+v", named)
+	}
+	if denied := calls[1]; !denied.IsError || !strings.Contains(denied.Error, "denied") || denied.URLAfter != "scope:"+mcpSessionScopeKey("abc") {
+		t.Fatalf("policy denial = %+v", denied)
+	}
+	if refused := calls[2]; !refused.IsError || !strings.Contains(refused.Error, "capability") {
+		t.Fatalf("capability refusal = %+v", refused)
+	}
+	// Typed text and credentials never reach the log.
+	if typed := calls[3]; typed.Arguments["text"] != netlogRedacted {
+		t.Fatalf("typed text recorded: %+v", typed.Arguments)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", ":pw@", "access_token=abc", "Bearer abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("audit log contains %q:\n%s", secret, data)
+		}
+	}
+	if masked := calls[4].Arguments; !strings.Contains(masked["url"].(string), "q=1") || masked["headers"].(map[string]interface{})["Accept"] != "*/*" {
+		t.Fatalf("harmless arguments masked: %+v", masked)
+	}
+}
+
+func TestReplayMCPCallsReportsDivergence(t *testing.T) {
+	swapMCPAudit(t)
+	mcpAuditPageURLFunc = func(context.Context) string { return "" }
+
+	var seen []map[string]interface{}
+	failing := true
+	aitools.RegisterHandler("test_audit_echo", func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		seen = append(seen, args)
+		return aitools.Result{Text: "echo"}, nil
+	})
+	aitools.RegisterHandler("test_audit_flaky", func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		if failing {
+			return aitools.Result{}, errors.New("element not found")
+		}
+		return aitools.Result{Text: "found"}, nil
+	})
+	calls := []mcpaudit.Record{
+		{Kind: mcpaudit.KindCall, Seq: 1, Tool: "test_audit_echo", Arguments: map[string]interface{}{"query": "h1", mcpScopeArg: "shared-login"}},
+		{Kind: mcpaudit.KindCall, Seq: 2, Tool: "test_audit_flaky", IsError: true, Error: "element not found"},
+		{Kind: mcpaudit.KindCall, Seq: 3, Tool: "network_watch"},
+	}
+
+	var out strings.Builder
+	if diverged := replayMCPCalls(context.Background(), &out, calls, time.Second); diverged != 0 {
+		t.Fatalf("replay diverged %d times:\n%s", diverged, out.String())
+	}
+	if len(seen) != 1 || seen[0]["query"] != "h1" || seen[0][mcpScopeArg] != nil {
+		t.Fatalf("replayed arguments = %v", seen)
+	}
+	if !strings.Contains(out.String(), "#3 network_watch skipped") {
+		t.Fatalf("MCP-only tool not skipped:\n%s", out.String())
+	}
+
+	failing = false
+	out.Reset()
+	if diverged := replayMCPCalls(context.Background(), &out, calls, time.Second); diverged != 1 || !strings.Contains(out.String(), "#2 test_audit_flaky ok") || !strings.Contains(out.String(), "DIVERGED") {
+		t.Fatalf("replay of a fixed call:\n%s", out.String())
+	}
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"roderik/internal/ai/policy"
+	aitools "roderik/internal/ai/tools"
+	"roderik/internal/appdirs"
+)
+
+// Built-in capability profiles selectable with --tools.
+const (
+	mcpProfileReadOnly   = "read-only"
+	mcpProfileNavigation = "navigation"
+	mcpProfileForensics  = "network-forensics"
+	mcpProfileFull       = "full"
+)
+
+// toolProfile selects the tools an MCP server offers: every tool of its
+// capabilities plus Allow, minus Deny.
+type toolProfile struct {
+	Capabilities []string `json:"capabilities"`
+	Allow        []string `json:"allow,omitempty"`
+	Deny         []string `json:"deny,omitempty"`
+}
+
+var builtinToolProfiles = map[string]toolProfile{
+	mcpProfileReadOnly:   {Capabilities: []string{aitools.CapInspect}},
+	mcpProfileNavigation: {Capabilities: []string{aitools.CapInspect, aitools.CapNavigate, aitools.CapInteract, aitools.CapWeb}},
+	mcpProfileForensics:  {Capabilities: []string{aitools.CapInspect, aitools.CapNavigate, aitools.CapNetwork}},
+	mcpProfileFull:       {Capabilities: aitools.Capabilities},
+}
+
+// mcpToolsConfig is the on-disk tool configuration (mcp-tools.json). Profile
+// is used when --tools is not given; Profiles adds or overrides named
+// profiles; Allow and Deny apply on top of whichever profile is selected.
+type mcpToolsConfig struct {
+	Profile  string                 `json:"profile"`
+	Allow    []string               `json:"allow"`
+	Deny     []string               `json:"deny"`
+	Profiles map[string]toolProfile `json:"profiles"`
+}
+
+var (
+	mcpToolsProfile    string
+	mcpToolsConfigPath string
+	mcpAllowTools      []string
+	mcpDenyTools       []string
+)
+
+// mcpToolsetState is the tool selection of the running server. The admin
+// tool changes caps at runtime.
+type mcpToolsetState struct {
+	profile string
+	caps    map[string]bool
+	allow   map[string]bool
+	deny    map[string]bool
+}
+
+var mcpToolset = struct {
+	sync.RWMutex
+	mcpToolsetState
+}{mcpToolsetState: mustToolset(mcpProfileFull, builtinToolProfiles[mcpProfileFull])}
+
+func mustToolset(name string, p toolProfile) mcpToolsetState {
+	ts, err := newToolset(name, p)
+	if err != nil {
+		panic(err)
+	}
+	return ts
+}
+
+func newToolset(name string, p toolProfile) (mcpToolsetState, error) {
+	ts := mcpToolsetState{
+		profile: name,
+		caps:    make(map[string]bool),
+		allow:   make(map[string]bool),
+		deny:    make(map[string]bool),
+	}
+	for _, c := range p.Capabilities {
+		if !knownCapability(c) {
+			return ts, fmt.Errorf("profile %s: unknown capability %q (want one of %s)", name, c, strings.Join(aitools.Capabilities, ", "))
+		}
+		ts.caps[c] = true
+	}
+	for _, list := range []struct {
+		names []string
+		into  map[string]bool
+	}{{p.Allow, ts.allow}, {p.Deny, ts.deny}} {
+		for _, tool := range list.names {
+			tool = strings.TrimSpace(tool)
+			if tool == "" {
+				continue
+			}
+			if _, ok := aitools.Lookup(tool); !ok {
+				return ts, fmt.Errorf("profile %s: unknown tool %q", name, tool)
+			}
+			list.into[tool] = true
+		}
+	}
+	return ts, nil
+}
+
+func knownCapability(c string) bool {
+	for _, known := range aitools.Capabilities {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// exposes reports whether def is offered; deny wins over allow.
+func (ts mcpToolsetState) exposes(def aitools.Definition) bool {
+	if ts.deny[def.Name] {
+		return false
+	}
+	return ts.allow[def.Name] || ts.caps[def.Capability]
+}
+
+func mcpToolExposed(def aitools.Definition) bool {
+	mcpToolset.RLock()
+	defer mcpToolset.RUnlock()
+	return mcpToolset.exposes(def)
+}
+
+func mcpCapabilityEnabled(c string) bool {
+	mcpToolset.RLock()
+	defer mcpToolset.RUnlock()
+	return mcpToolset.caps[c]
+}
+
+// defaultMCPToolsConfigPath returns the location of mcp-tools.json.
+func defaultMCPToolsConfigPath() string {
+	base, err := appdirs.BaseDir()
+	if err != nil || strings.TrimSpace(base) == "" {
+		return ""
+	}
+	return filepath.Join(base, "mcp-tools.json")
+}
+
+// loadMCPToolsConfig reads path; a missing file is an empty config.
+func loadMCPToolsConfig(path string) (mcpToolsConfig, error) {
+	var cfg mcpToolsConfig
+	if strings.TrimSpace(path) == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("read tools config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("decode tools config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// resolveMCPToolset picks the profile named by flag, else the config's, else
+// full, and layers the config and flag allow/deny lists on top.
+func resolveMCPToolset(flag string, allow, deny []string, cfg mcpToolsConfig) (mcpToolsetState, error) {
+	name := strings.TrimSpace(flag)
+	if name == "" {
+		name = strings.TrimSpace(cfg.Profile)
+	}
+	if name == "" {
+		name = mcpProfileFull
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		if p, ok = builtinToolProfiles[name]; !ok {
+			return mcpToolsetState{}, fmt.Errorf("unknown tool profile %q (want %s or one defined in the tools config)", name, strings.Join(mcpToolProfileNames(cfg), ", "))
+		}
+	}
+	p.Allow = append(append(append([]string(nil), p.Allow...), cfg.Allow...), allow...)
+	p.Deny = append(append(append([]string(nil), p.Deny...), cfg.Deny...), deny...)
+	return newToolset(name, p)
+}
+
+func mcpToolProfileNames(cfg mcpToolsConfig) []string {
+	var names []string
+	for name := range builtinToolProfiles {
+		names = append(names, name)
+	}
+	for name := range cfg.Profiles {
+		if _, ok := builtinToolProfiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configureMCPToolset applies --tools, --allow-tools, --deny-tools and the
+// tools config before the server registers its tools.
+func configureMCPToolset() error {
+	cfg, err := loadMCPToolsConfig(mcpToolsConfigPath)
+	if err != nil {
+		return err
+	}
+	ts, err := resolveMCPToolset(mcpToolsProfile, mcpAllowTools, mcpDenyTools, cfg)
+	if err != nil {
+		return err
+	}
+	mcpToolset.Lock()
+	mcpToolset.mcpToolsetState = ts
+	mcpToolset.Unlock()
+	return nil
+}
+
+// setMCPCapability switches c on or off and reports whether it changed.
+func setMCPCapability(c string, enabled bool) (bool, error) {
+	if !knownCapability(c) {
+		return false, fmt.Errorf("unknown capability %q (want one of %s)", c, strings.Join(aitools.Capabilities, ", "))
+	}
+	mcpToolset.Lock()
+	defer mcpToolset.Unlock()
+	if mcpToolset.caps[c] == enabled {
+		return false, nil
+	}
+	caps := make(map[string]bool, len(mcpToolset.caps)+1)
+	for k, v := range mcpToolset.caps {
+		caps[k] = v
+	}
+	caps[c] = enabled
+	mcpToolset.caps = caps
+	return true, nil
+}
+
+// mcpCapabilityMiddleware rejects calls that need a capability the server
+// does not offer: a tool switched off since the client listed it, or a url
+// argument that would make an inspect tool navigate without navigate.
+func mcpCapabilityMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		def, ok := aitools.Lookup(req.Params.Name)
+		if !ok {
+			return next(ctx, req)
+		}
+		var denied *policy.DeniedError
+		if !mcpToolExposed(def) {
+			denied = &policy.DeniedError{Tool: def.Name, Reason: fmt.Sprintf("capability %s is switched off", def.Capability)}
+		} else if url, _ := req.Params.Arguments["url"].(string); def.Capability == aitools.CapInspect && strings.TrimSpace(url) != "" && !mcpCapabilityEnabled(aitools.CapNavigate) {
+			denied = &policy.DeniedError{Tool: def.Name, Reason: "loading a url requires the navigate capability"}
+		}
+		if denied == nil {
+			return next(ctx, req)
+		}
+		log.Printf("[MCP] TOOL %s DENIED: %v", def.Name, denied)
+		payload, _ := toolDenialPayload(denied)
+		encoded, _ := json.Marshal(payload)
+		return mcp.NewToolResultError(string(encoded)), nil
+	}
+}
+
+// mcpCapabilitiesData is the Data of tool_capabilities.
+type mcpCapabilitiesData struct {
+	Profile      string          `json:"profile"`
+	Capabilities map[string]bool `json:"capabilities"`
+	Tools        []string        `json:"tools"`
+}
+
+// mcpToolCapabilitiesHandler lists the capabilities and toggles one. A
+// change re-registers the tool list and re-syncs the resources, which
+// notifies every client with tools/list_changed and resources/list_changed.
+func mcpToolCapabilitiesHandler(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.Params.Arguments
+		capability := strings.TrimSpace(mcp.ExtractString(args, "capability"))
+		var header string
+		if capability != "" {
+			enabled, ok := args["enabled"].(bool)
+			if !ok {
+				return mcp.NewToolResultError("tool_capabilities: enabled is required with capability"), nil
+			}
+			changed, err := setMCPCapability(capability, enabled)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			state := "off"
+			if enabled {
+				state = "on"
+			}
+			if changed {
+				log.Printf("[MCP] capability %s switched %s", capability, state)
+				if s != nil {
+					s.SetTools(mcpServerTools(s)...)
+					syncMCPResources(s)
+				}
+				header = fmt.Sprintf("capability %s switched %s", capability, state)
+			} else {
+				header = fmt.Sprintf("capability %s was already %s", capability, state)
+			}
+		}
+		return resultToMCP(mcpCapabilitiesResult(header))
+	}
+}
+
+func mcpCapabilitiesResult(header string) aitools.Result {
+	mcpToolset.RLock()
+	data := mcpCapabilitiesData{Profile: mcpToolset.profile, Capabilities: make(map[string]bool, len(aitools.Capabilities))}
+	for _, c := range aitools.Capabilities {
+		data.Capabilities[c] = mcpToolset.caps[c]
+	}
+	mcpToolset.RUnlock()
+	for _, def := range aitools.Available() {
+		if mcpToolExposed(def) {
+			data.Tools = append(data.Tools, def.Name)
+		}
+	}
+
+	var b strings.Builder
+	if header != "" {
+		b.WriteString(header + "\n")
+	}
+	fmt.Fprintf(&b, "profile %s\n", data.Profile)
+	for _, c := range aitools.Capabilities {
+		state := "off"
+		if data.Capabilities[c] {
+			state = "on"
+		}
+		fmt.Fprintf(&b, "  %-8s %s\n", c, state)
+	}
+	fmt.Fprintf(&b, "%d tools offered: %s", len(data.Tools), strings.Join(data.Tools, ", "))
+	return aitools.Result{Text: b.String(), Data: data}
+}
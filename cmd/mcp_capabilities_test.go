@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	aitools "roderik/internal/ai/tools"
+)
+
+func swapMCPToolset(t *testing.T, ts mcpToolsetState) {
+	t.Helper()
+	mcpToolset.Lock()
+	prev := mcpToolset.mcpToolsetState
+	mcpToolset.mcpToolsetState = ts
+	mcpToolset.Unlock()
+	t.Cleanup(func() {
+		mcpToolset.Lock()
+		mcpToolset.mcpToolsetState = prev
+		mcpToolset.Unlock()
+	})
+}
+
+func exposedToolNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, tool := range mcpServerTools(nil) {
+		names[tool.Tool.Name] = true
+	}
+	return names
+}
+
+func TestResolveMCPToolsetProfiles(t *testing.T) {
+	ts, err := resolveMCPToolset(mcpProfileReadOnly, []string{"load_url"}, []string{"text"}, mcpToolsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapMCPToolset(t, ts)
+	names := exposedToolNames()
+	if !names["to_markdown"] || !names["load_url"] || names["text"] || names["click"] || names["network_list"] || names["shutdown"] {
+		t.Fatalf("read-only tools = %v", names)
+	}
+
+	cfg := mcpToolsConfig{
+		Profile:  "audit",
+		Deny:     []string{"network_save"},
+		Profiles: map[string]toolProfile{"audit": {Capabilities: []string{aitools.CapNetwork}}},
+	}
+	ts, err = resolveMCPToolset("", nil, nil, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapMCPToolset(t, ts)
+	names = exposedToolNames()
+	if ts.profile != "audit" || !names["network_list"] || names["network_save"] || names["to_markdown"] {
+		t.Fatalf("config profile tools = %v", names)
+	}
+
+	if _, err := resolveMCPToolset("nope", nil, nil, mcpToolsConfig{}); err == nil {
+		t.Fatal("unknown profile accepted")
+	}
+	if _, err := resolveMCPToolset(mcpProfileFull, []string{"no_such_tool"}, nil, mcpToolsConfig{}); err == nil {
+		t.Fatal("unknown tool accepted")
+	}
+}
+
+func TestMCPCapabilityMiddlewareDeniesURLWithoutNavigate(t *testing.T) {
+	swapMCPToolset(t, mustToolset(mcpProfileReadOnly, builtinToolProfiles[mcpProfileReadOnly]))
+
+	called := false
+	handler := mcpCapabilityMiddleware(func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call := func(name string, args map[string]interface{}) *mcp.CallToolResult {
+		var req mcp.CallToolRequest
+		req.Params.Name = name
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if res := call("get_html", map[string]interface{}{"url": "https://example.com"}); !res.IsError || called {
+		t.Fatalf("get_html with url = %#v", res)
+	}
+	if res := call("click", nil); !res.IsError || called {
+		t.Fatalf("switched-off click = %#v", res)
+	}
+	if res := call("get_html", nil); res.IsError || !called {
+		t.Fatalf("get_html on the current page = %#v", res)
+	}
+}
+
+func TestToolCapabilitiesNotifiesListChanged(t *testing.T) {
+	registerHandlers()
+	swapMCPToolset(t, mustToolset(mcpProfileFull, builtinToolProfiles[mcpProfileFull]))
+
+	s := server.NewMCPServer("roderik", "test", server.WithToolCapabilities(true))
+	registerMCPTools(s)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = listenMCPStdio(ctx, s, inR, outW) }()
+	t.Cleanup(func() {
+		cancel()
+		inW.Close()
+		outR.Close()
+	})
+
+	c := &stdioTestClient{t: t, in: inW, out: bufio.NewScanner(outR)}
+	c.out.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	c.next()
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"tool_capabilities","arguments":{"capability":"network","enabled":false}}}`)
+	var sawNotification, sawResult bool
+	for i := 0; i < 2; i++ {
+		msg := c.next()
+		switch {
+		case msg["method"] == string(mcp.MethodNotificationToolsListChanged):
+			sawNotification = true
+		case msg["id"] == float64(2):
+			sawResult = true
+			if text := strings.Join(resultTexts(msg), "\n"); !strings.Contains(text, "capability network switched off") {
+				t.Fatalf("result = %s", text)
+			}
+		default:
+			t.Fatalf("unexpected message %v", msg)
+		}
+	}
+	if !sawNotification || !sawResult {
+		t.Fatalf("notification=%v result=%v", sawNotification, sawResult)
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	list := c.next()
+	tools, _ := list["result"].(map[string]any)["tools"].([]any)
+	for _, tool := range tools {
+		if name, _ := tool.(map[string]any)["name"].(string); strings.HasPrefix(name, "network_") {
+			t.Fatalf("network tool %s still listed", name)
+		}
+	}
+}
+
+func resultTexts(msg map[string]any) []string {
+	result, _ := msg["result"].(map[string]any)
+	contents, _ := result["content"].([]any)
+	var out []string
+	for _, c := range contents {
+		if text, ok := c.(map[string]any)["text"].(string); ok {
+			out = append(out, text)
+		}
+	}
+	return out
+}
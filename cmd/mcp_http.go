@@ -0,0 +1,466 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// MCP transports selectable with --transport.
+const (
+	mcpTransportStdio = "stdio"
+	mcpTransportHTTP  = "http"
+	mcpTransportSSE   = "sse"
+)
+
+const (
+	// mcpHTTPPath is the single endpoint of the streamable HTTP transport.
+	mcpHTTPPath = "/mcp"
+	// mcpSessionHeader carries the session ID assigned on initialize.
+	mcpSessionHeader = "Mcp-Session-Id"
+	// mcpTokenEnv supplies the bearer token when --token is not given.
+	mcpTokenEnv = "RODERIK_MCP_TOKEN"
+
+	mcpDefaultListen       = "127.0.0.1:8765"
+	mcpMaxRequestBytes     = 4 << 20
+	mcpSessionNotifyBuffer = 100
+	mcpStreamKeepAlive     = 30 * time.Second
+
+	mcpDefaultSessionIdle = 30 * time.Minute
+)
+
+var (
+	mcpTransport   string
+	mcpListen      string
+	mcpToken       string
+	mcpSessionIdle = mcpDefaultSessionIdle
+)
+
+// serveMCP runs the server on the transport selected by --transport.
+func serveMCP(s *server.MCPServer) error {
+	switch mcpTransport {
+	case "", mcpTransportStdio:
+		return serveMCPStdio(s, os.Stdin, os.Stdout)
+	case mcpTransportHTTP, mcpTransportSSE:
+		return serveMCPNetwork(s, mcpTransport, mcpListen, mcpBearerToken())
+	default:
+		return fmt.Errorf("unknown transport %q (want stdio, http or sse)", mcpTransport)
+	}
+}
+
+// mcpBearerToken returns the token from --token or RODERIK_MCP_TOKEN, or
+// generates one and prints it so the operator can hand it to clients.
+func mcpBearerToken() string {
+	if token := strings.TrimSpace(mcpToken); token != "" {
+		return token
+	}
+	if token := strings.TrimSpace(os.Getenv(mcpTokenEnv)); token != "" {
+		return token
+	}
+	token := newMCPSecret()
+	// Printed to stderr only so the token does not end up in the MCP log file.
+	fmt.Fprintf(os.Stderr, "MCP bearer token (set --token or %s to choose one): %s\n", mcpTokenEnv, token)
+	return token
+}
+
+func newMCPSecret() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("crypto/rand: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// serveMCPNetwork listens on addr until interrupted. Every client gets its own
+// MCP session; they share one browser, and tool calls touching the page are
+// serialised by withPage just as CLI actions are.
+func serveMCPNetwork(s *server.MCPServer, transport, addr, token string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mcpHTTPHandler(s, transport, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("[MCP] listening transport=%s endpoint=%s", transport, mcpEndpointURL(ln.Addr(), transport))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		log.Printf("[MCP] shutting down listener")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			// Event streams stay open until their clients leave; cut them off.
+			return srv.Close()
+		}
+		return nil
+	}
+}
+
+func mcpEndpointURL(addr net.Addr, transport string) string {
+	host := addr.String()
+	if tcp, ok := addr.(*net.TCPAddr); ok && tcp.IP.IsUnspecified() {
+		host = fmt.Sprintf("localhost:%d", tcp.Port)
+	}
+	if transport == mcpTransportSSE {
+		return "http://" + host + "/sse"
+	}
+	return "http://" + host + mcpHTTPPath
+}
+
+// mcpHTTPHandler routes the transport's endpoints behind bearer auth.
+func mcpHTTPHandler(s *server.MCPServer, transport, token string) http.Handler {
+	mux := http.NewServeMux()
+	if transport == mcpTransportSSE {
+		sse := server.NewSSEServer(s,
+			server.WithUseFullURLForMessageEndpoint(false),
+			server.WithKeepAlive(true),
+			server.WithSSEContextFunc(mcpTrackSSECall),
+		)
+		mux.Handle("/sse", sse)
+		mux.Handle("/message", sse)
+	} else {
+		h := newMCPStreamableHandler(s)
+		h.startReaper(mcpSessionIdle)
+		mux.Handle(mcpHTTPPath, h)
+	}
+	return mcpBearerAuth(token, mux)
+}
+
+// mcpBearerAuth rejects requests whose Authorization header does not carry
+// the expected bearer token.
+func mcpBearerAuth(token string, next http.Handler) http.Handler {
+	want := []byte(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, got, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), want) != 1 {
+			log.Printf("[MCP] unauthorized %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="roderik"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// mcpSession is a client of the streamable HTTP or stdio transport.
+// Notifications queue on the session until the transport delivers them; for
+// HTTP that is once the client opens its event stream with GET.
+type mcpSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	done          chan struct{}
+	initialized   atomic.Bool
+	streaming     atomic.Bool
+	// lastSeen is when the client last sent a request, in Unix nanoseconds.
+	lastSeen atomic.Int64
+}
+
+func newMCPSession(id string) *mcpSession {
+	sess := &mcpSession{
+		id:            id,
+		notifications: make(chan mcp.JSONRPCNotification, mcpSessionNotifyBuffer),
+		done:          make(chan struct{}),
+	}
+	sess.touch()
+	return sess
+}
+
+func (c *mcpSession) touch() { c.lastSeen.Store(time.Now().UnixNano()) }
+
+func (c *mcpSession) SessionID() string { return c.id }
+func (c *mcpSession) Initialize()       { c.initialized.Store(true) }
+func (c *mcpSession) Initialized() bool { return c.initialized.Load() }
+func (c *mcpSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return c.notifications
+}
+
+// mcpStreamableHandler implements the streamable HTTP transport: POST carries
+// JSON-RPC messages, GET opens the session's notification stream and DELETE
+// ends the session. Clients that go away without DELETE are expired once
+// idle.
+type mcpStreamableHandler struct {
+	server *server.MCPServer
+
+	mu       sync.Mutex
+	sessions map[string]*mcpSession
+}
+
+func newMCPStreamableHandler(s *server.MCPServer) *mcpStreamableHandler {
+	return &mcpStreamableHandler{server: s, sessions: make(map[string]*mcpSession)}
+}
+
+func (h *mcpStreamableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleStream(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// session resolves the Mcp-Session-Id header, writing the error response
+// itself when the header is missing or unknown.
+func (h *mcpStreamableHandler) session(w http.ResponseWriter, r *http.Request) (*mcpSession, bool) {
+	id := r.Header.Get(mcpSessionHeader)
+	if id == "" {
+		http.Error(w, "missing "+mcpSessionHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+	h.mu.Lock()
+	sess, ok := h.sessions[id]
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return nil, false
+	}
+	sess.touch()
+	return sess, true
+}
+
+func (h *mcpStreamableHandler) open(ctx context.Context) (*mcpSession, error) {
+	sess := newMCPSession(newMCPSecret())
+	if err := h.server.RegisterSession(ctx, sess); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	h.sessions[sess.id] = sess
+	h.mu.Unlock()
+	return sess, nil
+}
+
+func (h *mcpStreamableHandler) close(id string) bool {
+	h.mu.Lock()
+	sess, ok := h.sessions[id]
+	delete(h.sessions, id)
+	h.mu.Unlock()
+	if !ok {
+		return false
+	}
+	close(sess.done)
+	h.server.UnregisterSession(context.Background(), id)
+	stopMCPWatchesForSession(id)
+	return true
+}
+
+// reapIdle closes the sessions without a request for idle. A session with an
+// open event stream is still connected and never idle.
+func (h *mcpStreamableHandler) reapIdle(idle time.Duration) []string {
+	cutoff := time.Now().Add(-idle).UnixNano()
+	var expired []string
+	h.mu.Lock()
+	for id, sess := range h.sessions {
+		if !sess.streaming.Load() && sess.lastSeen.Load() < cutoff {
+			expired = append(expired, id)
+		}
+	}
+	h.mu.Unlock()
+	var closed []string
+	for _, id := range expired {
+		if h.close(id) {
+			closed = append(closed, id)
+		}
+	}
+	return closed
+}
+
+// startReaper expires idle sessions for the lifetime of the server; idle <= 0
+// keeps sessions until DELETE.
+func (h *mcpStreamableHandler) startReaper(idle time.Duration) {
+	if idle <= 0 {
+		return
+	}
+	interval := idle / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			for _, id := range h.reapIdle(idle) {
+				log.Printf("[MCP] session %s expired after %s without requests", id, idle)
+			}
+		}
+	}()
+}
+
+func (h *mcpStreamableHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, mcpMaxRequestBytes))
+	if err != nil {
+		http.Error(w, "reading request: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	batch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+	var messages []json.RawMessage
+	if batch {
+		err = json.Unmarshal(body, &messages)
+	} else {
+		messages = []json.RawMessage{body}
+		err = json.Unmarshal(body, new(json.RawMessage))
+	}
+	if err != nil || len(messages) == 0 {
+		http.Error(w, "invalid JSON-RPC payload", http.StatusBadRequest)
+		return
+	}
+
+	var sess *mcpSession
+	created := false
+	if mcpHasInitialize(messages) {
+		if sess, err = h.open(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		created = true
+		log.Printf("[MCP] session %s opened by %s", sess.id, r.RemoteAddr)
+	} else {
+		var ok bool
+		if sess, ok = h.session(w, r); !ok {
+			return
+		}
+	}
+
+	ctx := h.server.WithContext(r.Context(), sess)
+	var responses []mcp.JSONRPCMessage
+	for _, msg := range messages {
+		resp := mcpHandleMessage(ctx, h.server, sess.id, msg)
+		if resp == nil {
+			continue
+		}
+		if _, failed := resp.(mcp.JSONRPCError); failed && created && mcpIsInitialize(msg) {
+			h.close(sess.id)
+			created = false
+		}
+		responses = append(responses, resp)
+	}
+
+	if created {
+		w.Header().Set(mcpSessionHeader, sess.id)
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	var payload any = responses[0]
+	if batch {
+		payload = responses
+	}
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Printf("[MCP] session %s: writing response: %v", sess.id, err)
+	}
+}
+
+// handleStream delivers the session's notifications as server-sent events.
+// A session has at most one stream; notifications sent while none is open
+// wait in the session buffer.
+func (h *mcpStreamableHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if !sess.streaming.CompareAndSwap(false, true) {
+		http.Error(w, "session already has an open stream", http.StatusConflict)
+		return
+	}
+	defer sess.streaming.Store(false)
+	// The idle clock starts when the stream goes away.
+	defer sess.touch()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(mcpStreamKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case n := <-sess.notifications:
+			data, err := json.Marshal(n)
+			if err != nil {
+				log.Printf("[MCP] session %s: encoding notification: %v", sess.id, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-sess.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *mcpStreamableHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	h.close(sess.id)
+	log.Printf("[MCP] session %s closed by %s", sess.id, r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func mcpHasInitialize(messages []json.RawMessage) bool {
+	for _, msg := range messages {
+		if mcpIsInitialize(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func mcpIsInitialize(msg json.RawMessage) bool {
+	var head struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(msg, &head) == nil && head.Method == string(mcp.MethodInitialize)
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const testMCPToken = "s3cret"
+
+func newTestMCPHTTPServer(t *testing.T) (*server.MCPServer, *httptest.Server) {
+	t.Helper()
+	s := server.NewMCPServer("roderik", "test", server.WithToolCapabilities(true))
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		text, _ := req.Params.Arguments["text"].(string)
+		return mcp.NewToolResultText(text), nil
+	})
+	ts := httptest.NewServer(mcpHTTPHandler(s, mcpTransportHTTP, testMCPToken))
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func mcpPost(t *testing.T, ts *httptest.Server, session, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+mcpHTTPPath, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testMCPToken)
+	req.Header.Set("Content-Type", "application/json")
+	if session != "" {
+		req.Header.Set(mcpSessionHeader, session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func mcpInitialize(t *testing.T, ts *httptest.Server) string {
+	t.Helper()
+	resp := mcpPost(t, ts, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize status = %d", resp.StatusCode)
+	}
+	id := resp.Header.Get(mcpSessionHeader)
+	if id == "" {
+		t.Fatal("initialize did not assign a session ID")
+	}
+	mcpPost(t, ts, id, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return id
+}
+
+func TestMCPHTTPRequiresBearerToken(t *testing.T) {
+	_, ts := newTestMCPHTTPServer(t)
+	for _, auth := range []string{"", "Bearer wrong", "Basic " + testMCPToken} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+mcpHTTPPath, strings.NewReader(`{}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+			t.Fatalf("auth %q: status %d", auth, resp.StatusCode)
+		}
+	}
+}
+
+func TestMCPHTTPSessions(t *testing.T) {
+	_, ts := newTestMCPHTTPServer(t)
+	first := mcpInitialize(t, ts)
+	second := mcpInitialize(t, ts)
+	if first == second {
+		t.Fatal("clients share a session ID")
+	}
+
+	resp := mcpPost(t, ts, first, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+	var out struct {
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Result.Content) != 1 || out.Result.Content[0].Text != "hi" {
+		t.Fatalf("unexpected result: %+v", out.Result)
+	}
+
+	if resp := mcpPost(t, ts, "", `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("missing session: status %d", resp.StatusCode)
+	}
+	if resp := mcpPost(t, ts, "nope", `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown session: status %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+mcpHTTPPath, nil)
+	req.Header.Set("Authorization", "Bearer "+testMCPToken)
+	req.Header.Set(mcpSessionHeader, first)
+	del, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	del.Body.Close()
+	if del.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status %d", del.StatusCode)
+	}
+	if resp := mcpPost(t, ts, first, `{"jsonrpc":"2.0","id":4,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("closed session still accepted: status %d", resp.StatusCode)
+	}
+	if resp := mcpPost(t, ts, second, `{"jsonrpc":"2.0","id":4,"method":"tools/list"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("other session affected: status %d", resp.StatusCode)
+	}
+}
+
+func TestMCPHTTPStreamsSessionNotifications(t *testing.T) {
+	s, ts := newTestMCPHTTPServer(t)
+	id := mcpInitialize(t, ts)
+	other := mcpInitialize(t, ts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+mcpHTTPPath, nil)
+	req.Header.Set("Authorization", "Bearer "+testMCPToken)
+	req.Header.Set(mcpSessionHeader, id)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	if err := s.SendNotificationToSpecificClient(other, "notifications/message", map[string]any{"data": "not for you"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SendNotificationToSpecificClient(id, "notifications/message", map[string]any{"data": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		if strings.Contains(line, "not for you") {
+			t.Fatalf("received another session's notification: %s", line)
+		}
+		if strings.Contains(line, `"hello"`) {
+			return
+		}
+	}
+	t.Fatalf("notification not streamed: %v", sc.Err())
+}
+
+func TestMCPSSETransportAnnouncesEndpoint(t *testing.T) {
+	s := server.NewMCPServer("roderik", "test")
+	ts := httptest.NewServer(mcpHTTPHandler(s, mcpTransportSSE, testMCPToken))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	req.Header.Set("Authorization", "Bearer "+testMCPToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			if !strings.HasPrefix(data, "/message?sessionId=") {
+				t.Fatalf("unexpected endpoint %q", data)
+			}
+			return
+		}
+	}
+	t.Fatalf("no endpoint event: %v", sc.Err())
+}
+
+func TestMCPHTTPExpiresIdleSessions(t *testing.T) {
+	var unregistered []string
+	hooks := &server.Hooks{}
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		unregistered = append(unregistered, session.SessionID())
+	})
+	s := server.NewMCPServer("roderik", "test", server.WithHooks(hooks))
+	h := newMCPStreamableHandler(s)
+	ts := httptest.NewServer(mcpBearerAuth(testMCPToken, h))
+	t.Cleanup(ts.Close)
+
+	idle := mcpInitialize(t, ts)
+	active := mcpInitialize(t, ts)
+	streaming := mcpInitialize(t, ts)
+	h.mu.Lock()
+	past := time.Now().Add(-time.Hour).UnixNano()
+	h.sessions[idle].lastSeen.Store(past)
+	h.sessions[streaming].lastSeen.Store(past)
+	h.sessions[streaming].streaming.Store(true)
+	h.mu.Unlock()
+
+	if closed := h.reapIdle(time.Minute); len(closed) != 1 || closed[0] != idle {
+		t.Fatalf("reapIdle() = %v, want [%s]", closed, idle)
+	}
+	if len(unregistered) != 1 || unregistered[0] != idle {
+		t.Fatalf("unregistered sessions = %v", unregistered)
+	}
+	if resp := mcpPost(t, ts, idle, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expired session still accepted: status %d", resp.StatusCode)
+	}
+	for _, id := range []string{active, streaming} {
+		if resp := mcpPost(t, ts, id, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusOK {
+			t.Fatalf("session %s expired: status %d", id, resp.StatusCode)
+		}
+	}
+}
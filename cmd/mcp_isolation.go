@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Browser isolation modes selectable with --isolation.
+const (
+	mcpIsolationShared  = "shared"
+	mcpIsolationSession = "session"
+)
+
+const (
+	// mcpScopeArg names an isolated browser context explicitly. It is not
+	// called "session" because the network tools already use that for stored
+	// capture sessions.
+	mcpScopeArg = "browser_session"
+
+	mcpDefaultScopeIdle = 15 * time.Minute
+)
+
+var (
+	mcpIsolation = mcpIsolationShared
+	mcpScopeIdle = mcpDefaultScopeIdle
+)
+
+func validateMCPIsolation(mode string) error {
+	switch mode {
+	case mcpIsolationShared, mcpIsolationSession:
+		return nil
+	default:
+		return fmt.Errorf("unknown isolation %q (want shared or session)", mode)
+	}
+}
+
+// mcpScopeContext selects the browser scope of an MCP request in session
+// isolation: the context named by explicit, or else the client's own. In
+// shared mode every request uses the shared page.
+func mcpScopeContext(ctx context.Context, explicit string) context.Context {
+	if mcpIsolation != mcpIsolationSession {
+		return ctx
+	}
+	if name := strings.TrimSpace(explicit); name != "" {
+		return withBrowserScope(ctx, "named:"+name)
+	}
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		return withBrowserScope(ctx, mcpSessionScopeKey(cs.SessionID()))
+	}
+	return ctx
+}
+
+func mcpSessionScopeKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// mcpIsolationMiddleware runs each tool call in its client's browser scope
+// and strips the browser_session argument before the handler sees it.
+func mcpIsolationMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if mcpIsolation != mcpIsolationSession {
+			return next(ctx, req)
+		}
+		explicit, _ := req.Params.Arguments[mcpScopeArg].(string)
+		if _, ok := req.Params.Arguments[mcpScopeArg]; ok {
+			args := make(map[string]interface{}, len(req.Params.Arguments))
+			for k, v := range req.Params.Arguments {
+				if k != mcpScopeArg {
+					args[k] = v
+				}
+			}
+			req.Params.Arguments = args
+		}
+		return next(mcpScopeContext(ctx, explicit), req)
+	}
+}
+
+// addMCPScopeArg advertises browser_session on a tool.
+func addMCPScopeArg(tool *mcp.Tool) {
+	if tool.InputSchema.Properties == nil {
+		tool.InputSchema.Properties = make(map[string]interface{})
+	}
+	tool.InputSchema.Properties[mcpScopeArg] = map[string]interface{}{
+		"type":        "string",
+		"description": "Name of an isolated browser context to use instead of this client's own; calls naming the same context share cookies, page and focus.",
+	}
+}
+
+// mcpIsolationHooks closes a client's browser context when its MCP session
+// ends and keeps other clients' captures out of its resource list.
+func mcpIsolationHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterListResources(func(ctx context.Context, id any, message *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+		filterMCPCaptureResources(ctx, result)
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		key := mcpSessionScopeKey(session.SessionID())
+		// Closing waits for the page lock; do not hold up the transport.
+		go func() {
+			if closed := closeBrowserScopes(func(sc *browserScope) bool { return sc.key == key }); len(closed) > 0 {
+				log.Printf("[MCP] closed browser context of session %s", session.SessionID())
+			}
+		}()
+	})
+	return hooks
+}
+
+// reapIdleBrowserScopes closes the scopes no call entered for idle.
+func reapIdleBrowserScopes(idle time.Duration) []string {
+	cutoff := time.Now().Add(-idle)
+	return closeBrowserScopes(func(sc *browserScope) bool { return sc.lastUsed.Before(cutoff) })
+}
+
+// startBrowserScopeReaper closes idle browser contexts for the lifetime of
+// the server.
+func startBrowserScopeReaper(idle time.Duration) {
+	interval := idle / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if closed := reapIdleBrowserScopes(idle); len(closed) > 0 {
+				sort.Strings(closed)
+				log.Printf("[MCP] closed idle browser contexts: %s", strings.Join(closed, ", "))
+			}
+		}
+	}()
+}
@@ -9,6 +9,8 @@ import (
 
 var summarizeElementFunc = summarizeElement
 
+var elementDataFunc = readElementData
+
 var currentElementSelector = func(selector string) (*rod.Element, error) {
 	if CurrentElement == nil {
 		return nil, fmt.Errorf("no current element to scope selector %q", selector)
@@ -285,6 +287,101 @@ func summarizeElement(el *rod.Element) string {
 	return strings.Join(parts, " ")
 }
 
+// maxElementDataText caps the text of an element in structured results.
+const maxElementDataText = 200
+
+// elementData is the structured form of an element in navigation results.
+// Index is its position in the search results, or -1 when it is not part of
+// them (after child or parent).
+type elementData struct {
+	Index   int         `json:"index"`
+	Tag     string      `json:"tag,omitempty"`
+	ID      string      `json:"id,omitempty"`
+	Classes []string    `json:"classes,omitempty"`
+	Text    string      `json:"text,omitempty"`
+	XPath   string      `json:"xpath,omitempty"`
+	Box     *elementBox `json:"box,omitempty"`
+}
+
+// elementBox is an element's bounding box in CSS pixels, relative to the
+// top left of the document.
+type elementBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// navigationData is the Data of the navigation tools: the focused element
+// and, after a search, every match.
+type navigationData struct {
+	Count    int           `json:"count"`
+	Focus    int           `json:"focus"`
+	Focused  *elementData  `json:"focused,omitempty"`
+	Elements []elementData `json:"elements,omitempty"`
+}
+
+// currentNavigationData describes the focus and, when withList is set, the
+// whole element list. Callers hold the page lock.
+func currentNavigationData(withList bool) navigationData {
+	data := navigationData{Count: len(elementList), Focus: -1}
+	if currentIndex >= 0 && currentIndex < len(elementList) && elementList[currentIndex] == CurrentElement {
+		data.Focus = currentIndex
+	}
+	if withList {
+		data.Elements = make([]elementData, 0, len(elementList))
+		for i, el := range elementList {
+			data.Elements = append(data.Elements, elementDataFunc(el, i))
+		}
+	}
+	if CurrentElement != nil {
+		var focused elementData
+		if withList && data.Focus >= 0 {
+			focused = data.Elements[data.Focus]
+		} else {
+			focused = elementDataFunc(CurrentElement, data.Focus)
+		}
+		data.Focused = &focused
+	}
+	return data
+}
+
+// readElementData reads the structured description of el in one round trip
+// plus the XPath lookup. Fields that cannot be read are left empty.
+func readElementData(el *rod.Element, index int) elementData {
+	data := elementData{Index: index}
+	if el == nil {
+		return data
+	}
+	val, err := el.Eval(`() => {
+		const r = this.getBoundingClientRect ? this.getBoundingClientRect() : null;
+		return {
+			tag: (this.tagName || '').toLowerCase(),
+			id: this.id || '',
+			classes: Array.from(this.classList || []),
+			text: this.innerText || this.textContent || '',
+			box: r ? {x: r.left + window.scrollX, y: r.top + window.scrollY, width: r.width, height: r.height} : null,
+		};
+	}`)
+	if err == nil {
+		var raw struct {
+			Tag     string      `json:"tag"`
+			ID      string      `json:"id"`
+			Classes []string    `json:"classes"`
+			Text    string      `json:"text"`
+			Box     *elementBox `json:"box"`
+		}
+		if val.Value.Unmarshal(&raw) == nil {
+			data.Tag, data.ID, data.Classes, data.Box = raw.Tag, raw.ID, raw.Classes, raw.Box
+			data.Text = truncateContextText(normalizeWhitespace(raw.Text), maxElementDataText)
+		}
+	}
+	if xpath, err := getElementXPath(el); err == nil {
+		data.XPath = xpath
+	}
+	return data
+}
+
 func safeEvalAttr(el *rod.Element, body string) (string, error) {
 	val, err := el.Eval(fmt.Sprintf("() => { %s }", body))
 	if err != nil {
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/go-rod/rod"
+	"github.com/mark3labs/mcp-go/mcp"
+	aitools "roderik/internal/ai/tools"
 )
 
 func resetNavGlobals() {
@@ -442,6 +445,88 @@ func TestMCPNavParentErrorsWhenNoCurrentElement(t *testing.T) {
 	}
 }
 
+func TestNavigationDataAfterSearchAndChild(t *testing.T) {
+	resetNavGlobals()
+
+	fakeElements := []*rod.Element{&rod.Element{}, &rod.Element{}}
+	swapQueryElements(t, func(*rod.Page, string) ([]*rod.Element, error) {
+		return fakeElements, nil
+	})
+	swapSummarizeElement(t, func(*rod.Element) string { return "summary" })
+	child := &rod.Element{}
+	swapChildParent(t, func(*rod.Element) (*rod.Element, error) { return child, nil }, nil)
+	swapElementData(t, func(el *rod.Element, index int) elementData {
+		data := elementData{Index: index, Tag: "a", Classes: []string{"nav"}, XPath: fmt.Sprintf("/a[%d]", index+1)}
+		if el == child {
+			data.Tag = "span"
+		}
+		return data
+	})
+
+	if _, err := mcpSearch("a.nav"); err != nil {
+		t.Fatal(err)
+	}
+	data := currentNavigationData(true)
+	if data.Count != 2 || data.Focus != 0 || len(data.Elements) != 2 {
+		t.Fatalf("search data = %+v", data)
+	}
+	if data.Elements[1].Index != 1 || data.Elements[1].XPath != "/a[2]" || data.Focused == nil || data.Focused.Index != 0 {
+		t.Fatalf("search elements = %+v", data)
+	}
+
+	if _, err := mcpChild(); err != nil {
+		t.Fatal(err)
+	}
+	data = currentNavigationData(false)
+	if data.Focus != -1 || data.Elements != nil || data.Focused == nil || data.Focused.Tag != "span" || data.Focused.Index != -1 {
+		t.Fatalf("child data = %+v", data)
+	}
+}
+
+func TestResultToMCPAddsStructuredData(t *testing.T) {
+	res := aitools.Result{
+		Text: "found 1 elements",
+		Data: navigationData{Count: 1, Elements: []elementData{{Index: 0, Tag: "h1", Box: &elementBox{Width: 10, Height: 5}}}},
+	}
+	out, err := resultToMCP(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Content) != 2 {
+		t.Fatalf("contents = %#v", out.Content)
+	}
+	if text, ok := out.Content[0].(mcp.TextContent); !ok || text.Text != res.Text {
+		t.Fatalf("first content = %#v", out.Content[0])
+	}
+	embedded, ok := out.Content[1].(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("second content = %#v", out.Content[1])
+	}
+	resource, ok := embedded.Resource.(mcp.TextResourceContents)
+	if !ok || resource.MIMEType != "application/json" || resource.URI != mcpDataURI {
+		t.Fatalf("data resource = %#v", embedded.Resource)
+	}
+	var decoded navigationData
+	if err := json.Unmarshal([]byte(resource.Text), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Count != 1 || decoded.Elements[0].Tag != "h1" || decoded.Elements[0].Box.Width != 10 {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+
+	payload, ok := toolResultPayload(res).(map[string]interface{})
+	if !ok || payload["text"] != res.Text || payload["data"] == nil {
+		t.Fatalf("AI payload = %#v", toolResultPayload(res))
+	}
+}
+
+func swapElementData(t *testing.T, stub func(*rod.Element, int) elementData) {
+	t.Helper()
+	prev := elementDataFunc
+	elementDataFunc = stub
+	t.Cleanup(func() { elementDataFunc = prev })
+}
+
 func swapQueryElements(t *testing.T, stub func(*rod.Page, string) ([]*rod.Element, error)) {
 	t.Helper()
 	prev := queryElementsFunc
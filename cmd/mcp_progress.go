@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	aitools "roderik/internal/ai/tools"
+)
+
+const (
+	mcpProgressNotification  = "notifications/progress"
+	mcpCancelledNotification = "notifications/cancelled"
+)
+
+// mcpInflight holds the cancel funcs of running tool calls, keyed by
+// mcpInflightKey, so notifications/cancelled can stop them.
+var mcpInflight = struct {
+	sync.Mutex
+	calls map[string]context.CancelFunc
+}{calls: make(map[string]context.CancelFunc)}
+
+// mcpInflightKey identifies a request by session and JSON-RPC id. The id is
+// re-encoded so 7 and 7.0 from different decoders still match.
+func mcpInflightKey(session string, id any) string {
+	data, _ := json.Marshal(id)
+	return session + "\x00" + string(data)
+}
+
+// mcpTrackCall makes a tools/call message cancellable by the client. The
+// returned release func must run once the call has finished; it is a no-op
+// for every other message.
+func mcpTrackCall(ctx context.Context, session string, raw json.RawMessage) (context.Context, func()) {
+	var head struct {
+		ID     any    `json:"id"`
+		Method string `json:"method"`
+	}
+	if json.Unmarshal(raw, &head) != nil || head.ID == nil || head.Method != string(mcp.MethodToolsCall) {
+		return ctx, func() {}
+	}
+	key := mcpInflightKey(session, head.ID)
+	ctx, cancel := context.WithCancel(ctx)
+	mcpInflight.Lock()
+	mcpInflight.calls[key] = cancel
+	mcpInflight.Unlock()
+	return ctx, func() {
+		mcpInflight.Lock()
+		delete(mcpInflight.calls, key)
+		mcpInflight.Unlock()
+		cancel()
+	}
+}
+
+// mcpHandleMessage is server.HandleMessage with tools/call tracked for
+// cancellation. Transports that own their read loop call it.
+func mcpHandleMessage(ctx context.Context, s *server.MCPServer, session string, raw json.RawMessage) mcp.JSONRPCMessage {
+	ctx, release := mcpTrackCall(ctx, session, raw)
+	defer release()
+	return s.HandleMessage(ctx, raw)
+}
+
+// mcpTrackSSECall does the same for the SSE transport, whose message handler
+// belongs to mcp-go: it peeks at the body and releases the call when the
+// POST completes.
+func mcpTrackSSECall(ctx context.Context, r *http.Request) context.Context {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return ctx
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, mcpMaxRequestBytes))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ctx
+	}
+	ctx, release := mcpTrackCall(ctx, session.SessionID(), body)
+	go func() {
+		<-r.Context().Done()
+		release()
+	}()
+	return ctx
+}
+
+// cancelMCPCall cancels a running tool call; it reports whether one matched.
+func cancelMCPCall(session string, id any) bool {
+	mcpInflight.Lock()
+	cancel, ok := mcpInflight.calls[mcpInflightKey(session, id)]
+	mcpInflight.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// registerMCPCancellation handles notifications/cancelled. The cancelled
+// tool's context is done, so its lock wait, navigation or capture stops.
+func registerMCPCancellation(s *server.MCPServer) {
+	s.AddNotificationHandler(mcpCancelledNotification, func(ctx context.Context, n mcp.JSONRPCNotification) {
+		id := n.Params.AdditionalFields["requestId"]
+		if id == nil {
+			return
+		}
+		session := ""
+		if cs := server.ClientSessionFromContext(ctx); cs != nil {
+			session = cs.SessionID()
+		}
+		reason, _ := n.Params.AdditionalFields["reason"].(string)
+		if cancelMCPCall(session, id) {
+			log.Printf("[MCP] request %v cancelled by client: %s", id, reason)
+		}
+	})
+}
+
+// mcpProgressMiddleware forwards aitools.ReportProgress calls from the
+// handler as notifications/progress when the client sent a progress token.
+func mcpProgressMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s := server.ServerFromContext(ctx)
+		if s == nil || req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+			return next(ctx, req)
+		}
+		token := req.Params.Meta.ProgressToken
+		reporter := &mcpProgressReporter{last: -1}
+		ctx = aitools.WithProgress(ctx, func(progress, total float64, message string) {
+			params := reporter.params(progress, total, message)
+			params["progressToken"] = token
+			if err := s.SendNotificationToClient(ctx, mcpProgressNotification, params); err != nil {
+				log.Printf("[MCP] progress notification: %v", err)
+			}
+		})
+		return next(ctx, req)
+	}
+}
+
+// mcpProgressReporter keeps progress increasing across the stages of one
+// call. Handlers count each stage from zero (load_url 0..2, then stitching
+// 1..N), so a value that does not move forward starts a new stage on top of
+// the previous one.
+type mcpProgressReporter struct {
+	mu   sync.Mutex
+	base float64
+	last float64
+}
+
+func (r *mcpProgressReporter) params(progress, total float64, message string) map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	value := r.base + progress
+	if value <= r.last {
+		r.base = r.last + 1 - progress
+		value = r.last + 1
+	}
+	r.last = value
+	params := map[string]any{"progress": value}
+	if total > 0 {
+		params["total"] = r.base + total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	return params
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	aitools "roderik/internal/ai/tools"
+)
+
+type stdioTestClient struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out *bufio.Scanner
+}
+
+func (c *stdioTestClient) send(msg string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, msg+"\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *stdioTestClient) next() map[string]any {
+	c.t.Helper()
+	if !c.out.Scan() {
+		c.t.Fatalf("stdio closed: %v", c.out.Err())
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(c.out.Bytes(), &msg); err != nil {
+		c.t.Fatalf("decode %s: %v", c.out.Bytes(), err)
+	}
+	return msg
+}
+
+func TestMCPStdioProgressAndCancel(t *testing.T) {
+	started := make(chan struct{})
+	s := server.NewMCPServer("roderik", "test",
+		server.WithToolCapabilities(true),
+		server.WithToolHandlerMiddleware(mcpProgressMiddleware),
+	)
+	s.AddTool(mcp.NewTool("slow"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		aitools.ReportProgress(ctx, 0, 2, "navigating")
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	registerMCPCancellation(s)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- listenMCPStdio(ctx, s, inR, outW) }()
+	t.Cleanup(func() {
+		cancel()
+		inW.Close()
+		outR.Close()
+	})
+
+	c := &stdioTestClient{t: t, in: inW, out: bufio.NewScanner(outR)}
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	if msg := c.next(); msg["id"] != float64(1) {
+		t.Fatalf("initialize response = %v", msg)
+	}
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow","arguments":{},"_meta":{"progressToken":"tok"}}}`)
+	progress := c.next()
+	params, _ := progress["params"].(map[string]any)
+	if progress["method"] != mcpProgressNotification || params["progressToken"] != "tok" || params["message"] != "navigating" || params["total"] != float64(2) {
+		t.Fatalf("progress notification = %v", progress)
+	}
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tool call did not start")
+	}
+	c.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2,"reason":"user aborted"}}`)
+	resp := c.next()
+	if resp["id"] != float64(2) || resp["error"] == nil {
+		t.Fatalf("cancelled call response = %v", resp)
+	}
+	if msg, _ := resp["error"].(map[string]any)["message"].(string); !strings.Contains(msg, context.Canceled.Error()) {
+		t.Fatalf("error = %q, want context canceled", msg)
+	}
+
+	inW.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stdio server did not stop at EOF")
+	}
+}
+
+func TestMCPProgressReporterIncreases(t *testing.T) {
+	r := &mcpProgressReporter{last: -1}
+	var got []float64
+	for _, step := range [][2]float64{{0, 2}, {1, 2}, {2, 2}, {0, 0}, {1, 3}, {2, 3}, {3, 3}} {
+		params := r.params(step[0], step[1], "")
+		got = append(got, params["progress"].(float64))
+		if total, ok := params["total"].(float64); ok && total < params["progress"].(float64) {
+			t.Fatalf("total %v below progress %v", total, params["progress"])
+		}
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("progress not increasing: %v", got)
+		}
+	}
+}
+
+func TestLockPageCancelReleasesLock(t *testing.T) {
+	pageMu.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := lockPage(ctx, false, func() (struct{}, error) { return struct{}{}, nil })
+		errc <- err
+	}()
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("lockPage error = %v, want context.Canceled", err)
+	}
+	pageMu.Unlock()
+
+	// The abandoned Lock call must give the mutex back.
+	if _, err := lockPage(context.Background(), false, func() (struct{}, error) { return struct{}{}, nil }); err != nil {
+		t.Fatalf("lock after cancel: %v", err)
+	}
+}
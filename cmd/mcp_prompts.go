@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"roderik/internal/playbooks"
+)
+
+// registerMCPPrompts publishes each playbook as an MCP prompt. Arguments are
+// validated against their declared types when the prompt is fetched.
+func registerMCPPrompts(s *server.MCPServer) {
+	list, err := loadPlaybooks()
+	if err != nil {
+		log.Printf("[MCP] playbooks: %v", err)
+	}
+	for _, pb := range list {
+		s.AddPrompt(mcpPromptFromPlaybook(pb), mcpPlaybookHandler(pb))
+	}
+	log.Printf("[MCP] registered %d playbook prompts", len(list))
+}
+
+func mcpPromptFromPlaybook(pb playbooks.Playbook) mcp.Prompt {
+	opts := []mcp.PromptOption{mcp.WithPromptDescription(pb.Description)}
+	for _, arg := range pb.Arguments {
+		argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(arg.Summary())}
+		if arg.Required {
+			argOpts = append(argOpts, mcp.RequiredArgument())
+		}
+		opts = append(opts, mcp.WithArgument(arg.Name, argOpts...))
+	}
+	return mcp.NewPrompt(pb.Name, opts...)
+}
+
+func mcpPlaybookHandler(pb playbooks.Playbook) server.PromptHandlerFunc {
+	return func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		text, err := pb.Render(req.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewGetPromptResult(pb.Description, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestMCPPromptsFromPlaybooks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("RODERIK_HOME", home)
+	dir := filepath.Join(home, "playbooks")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "greet.md"), []byte("---\ndescription: Greet\nargument: who (string, required) Name\n---\nSay hello to {{.who}}."), 0o644)
+
+	s := server.NewMCPServer("roderik", "test", server.WithPromptCapabilities(false))
+	registerMCPPrompts(s)
+
+	list := string(mcpRPC(t, s, string(mcp.MethodPromptsList), map[string]any{}))
+	if !strings.Contains(list, `"greet"`) || !strings.Contains(list, `"summarise-page"`) || !strings.Contains(list, `"required":true`) {
+		t.Fatalf("unexpected prompt list: %s", list)
+	}
+	got := string(mcpRPC(t, s, string(mcp.MethodPromptsGet), map[string]any{"name": "greet", "arguments": map[string]string{"who": "Ada"}}))
+	if !strings.Contains(got, "Say hello to Ada.") {
+		t.Fatalf("unexpected prompt: %s", got)
+	}
+	if res := mcpRPC(t, s, string(mcp.MethodPromptsGet), map[string]any{"name": "greet"}); res != nil {
+		t.Fatalf("missing required argument accepted: %s", res)
+	}
+}
@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	aitools "roderik/internal/ai/tools"
+)
+
+// Resource URIs published by the MCP server.
+const (
+	mcpPageCurrentURI       = "roderik://page/current"
+	mcpPageMarkdownURI      = "roderik://page/markdown"
+	mcpNetworkURIPrefix     = "roderik://network/"
+	mcpCapturesURIPrefix    = "roderik://captures/"
+	mcpResourcesListChanged = "notifications/resources/list_changed"
+	mcpResourceUpdated      = "notifications/resources/updated"
+
+	// mcpCaptureSessionDirPrefix names the capture subdirectory of a client
+	// in session isolation.
+	mcpCaptureSessionDirPrefix = "session-"
+)
+
+// mcpCaptureExts are the files under the captures dir exposed as resources.
+var mcpCaptureExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".pdf": true}
+
+// mcpCapturesDir is where screenshot and PDF tools write files by default.
+var mcpCapturesDir = func() string {
+	dir := filepath.Join(".", defaultCaptureDir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// mcpActiveServer is the running MCP server; navigation and new captures are
+// announced to its clients.
+var mcpActiveServer atomic.Pointer[server.MCPServer]
+
+// mcpCaptureResources tracks the capture files currently listed as
+// resources and the clients owning a file of that name ("" when captures are
+// shared).
+var mcpCaptureResources = struct {
+	sync.Mutex
+	known map[string]map[string]bool
+}{known: make(map[string]map[string]bool)}
+
+// mcpPageState is the JSON body of roderik://page/current.
+type mcpPageState struct {
+	Loaded bool           `json:"loaded"`
+	URL    string         `json:"url,omitempty"`
+	Title  string         `json:"title,omitempty"`
+	Focus  *mcpFocusState `json:"focus,omitempty"`
+}
+
+type mcpFocusState struct {
+	Summary string `json:"summary"`
+	Index   int    `json:"index"`
+	Count   int    `json:"count"`
+}
+
+// registerMCPResources publishes the page resources, the network and capture
+// resources the capabilities allow, and makes s the target of change
+// notifications.
+func registerMCPResources(s *server.MCPServer) {
+	s.AddResource(
+		mcp.NewResource(mcpPageCurrentURI, "Current page",
+			mcp.WithResourceDescription("URL, title and focused element of the shared browser page."),
+			mcp.WithMIMEType("application/json"),
+		),
+		func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			state, err := currentPageState(mcpScopeContext(ctx, ""))
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.MarshalIndent(state, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "application/json", Text: string(data)}}, nil
+		},
+	)
+	s.AddResource(
+		mcp.NewResource(mcpPageMarkdownURI, "Current page as Markdown",
+			mcp.WithResourceDescription("The whole current page rendered to Markdown, as the to_markdown tool does."),
+			mcp.WithMIMEType("text/markdown"),
+		),
+		func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			md, err := currentPageMarkdown(mcpScopeContext(ctx, ""))
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "text/markdown", Text: md}}, nil
+		},
+	)
+	mcpActiveServer.Store(s)
+	syncMCPResources(s)
+}
+
+// syncMCPResources offers the network and capture resources according to
+// the capabilities currently switched on. mcp-go cannot remove a template
+// once added, so the read handlers check the capabilities again.
+func syncMCPResources(s *server.MCPServer) {
+	if mcpCapabilityEnabled(aitools.CapNetwork) {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate(mcpNetworkURIPrefix+"{request_id}", "Network request",
+				mcp.WithTemplateDescription("Headers and bodies of a request in the active network log, by the request_id shown by network_list."),
+				mcp.WithTemplateMIMEType("text/plain"),
+			),
+			readNetworkResource,
+		)
+	}
+	if mcpCapturesExposed() {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate(mcpCapturesURIPrefix+"{file}", "Capture",
+				mcp.WithTemplateDescription("A screenshot or PDF written under the captures directory."),
+			),
+			readCaptureResource,
+		)
+	}
+	syncMCPCaptureResources(s)
+}
+
+// mcpCapturesExposed reports whether a tool that writes captures is offered.
+func mcpCapturesExposed() bool {
+	for _, name := range []string{"capture_screenshot", "capture_pdf"} {
+		if def, ok := aitools.Lookup(name); ok && mcpToolExposed(def) {
+			return true
+		}
+	}
+	return false
+}
+
+func currentPageState(ctx context.Context) (mcpPageState, error) {
+	return inspectPageContext(ctx, func() (mcpPageState, error) {
+		if Page == nil {
+			return mcpPageState{}, nil
+		}
+		info, err := Page.Timeout(5 * time.Second).Info()
+		if err != nil {
+			return mcpPageState{}, fmt.Errorf("page info: %w", err)
+		}
+		state := mcpPageState{Loaded: true, URL: info.URL, Title: info.Title}
+		if CurrentElement != nil {
+			focus := &mcpFocusState{Summary: summarizeElementFunc(CurrentElement), Count: len(elementList)}
+			if len(elementList) > 0 {
+				focus.Index = currentIndex
+			}
+			state.Focus = focus
+		}
+		return state, nil
+	})
+}
+
+func currentPageMarkdown(ctx context.Context) (string, error) {
+	return inspectPageContext(ctx, func() (string, error) {
+		if Page == nil {
+			return "", fmt.Errorf("no page loaded – call load_url first")
+		}
+		body, err := Page.Timeout(5 * time.Second).Element("body")
+		if err != nil {
+			return "", fmt.Errorf("select <body>: %w", err)
+		}
+		return elementMarkdown(Page, body)
+	})
+}
+
+func readNetworkResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	if !mcpCapabilityEnabled(aitools.CapNetwork) {
+		return nil, fmt.Errorf("network resources require the %s capability", aitools.CapNetwork)
+	}
+	reqID, err := url.PathUnescape(strings.TrimPrefix(req.Params.URI, mcpNetworkURIPrefix))
+	if err != nil || reqID == "" {
+		return nil, fmt.Errorf("invalid network URI %q", req.Params.URI)
+	}
+	detail, err := lookupNetworkDetail(mcpScopeContext(ctx, ""), reqID, "", true)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "text/plain", Text: formatNetworkDetail(detail, 0)}}, nil
+}
+
+func readCaptureResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	if !mcpCapturesExposed() {
+		return nil, fmt.Errorf("capture resources require the capture tools")
+	}
+	name, err := url.PathUnescape(strings.TrimPrefix(req.Params.URI, mcpCapturesURIPrefix))
+	if err != nil || name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid capture URI %q", req.Params.URI)
+	}
+	if !mcpCaptureExts[strings.ToLower(filepath.Ext(name))] {
+		return nil, fmt.Errorf("%s is not a screenshot or PDF", name)
+	}
+	data, err := os.ReadFile(filepath.Join(mcpOwnerCapturesDir(mcpCaptureOwner(ctx)), name))
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.BlobResourceContents{
+		URI:      req.Params.URI,
+		MIMEType: captureMIMEType(name),
+		Blob:     base64.StdEncoding.EncodeToString(data),
+	}}, nil
+}
+
+func captureMIMEType(name string) string {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func mcpCaptureURI(name string) string {
+	return mcpCapturesURIPrefix + url.PathEscape(name)
+}
+
+// mcpCaptureOwner returns the client whose captures ctx may see: in session
+// isolation each client only sees its own, otherwise all are shared.
+func mcpCaptureOwner(ctx context.Context) string {
+	if mcpIsolation != mcpIsolationSession {
+		return ""
+	}
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		return cs.SessionID()
+	}
+	return ""
+}
+
+// mcpOwnerCapturesDir is the directory holding owner's captures.
+func mcpOwnerCapturesDir(owner string) string {
+	if owner == "" {
+		return mcpCapturesDir()
+	}
+	return filepath.Join(mcpCapturesDir(), mcpCaptureSessionDirPrefix+sanitizeFileName(owner))
+}
+
+// mcpCaptureOutputDir is where capture tools called with ctx save files by
+// default; "" leaves the choice to resolveOutputPath.
+func mcpCaptureOutputDir(ctx context.Context) string {
+	if owner := mcpCaptureOwner(ctx); owner != "" {
+		return mcpOwnerCapturesDir(owner)
+	}
+	return ""
+}
+
+// listCaptureFiles returns the screenshot and PDF names in dir.
+func listCaptureFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && !strings.HasPrefix(name, ".") && mcpCaptureExts[strings.ToLower(filepath.Ext(name))] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listCaptureOwners returns the owners with a captures directory: the shared
+// one, or in session isolation every client's.
+func listCaptureOwners() []string {
+	if mcpIsolation != mcpIsolationSession {
+		return []string{""}
+	}
+	entries, err := os.ReadDir(mcpCapturesDir())
+	if err != nil {
+		return nil
+	}
+	var owners []string
+	for _, e := range entries {
+		if owner, ok := strings.CutPrefix(e.Name(), mcpCaptureSessionDirPrefix); ok && e.IsDir() && owner != "" {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// syncMCPCaptureResources lists every capture file as a resource and drops
+// the ones that disappeared, or all of them when no capture tool is offered.
+// Adding or removing a resource makes the server send
+// notifications/resources/list_changed.
+func syncMCPCaptureResources(s *server.MCPServer) {
+	mcpCaptureResources.Lock()
+	defer mcpCaptureResources.Unlock()
+	present := make(map[string]map[string]bool)
+	if mcpCapturesExposed() {
+		for _, owner := range listCaptureOwners() {
+			for _, name := range listCaptureFiles(mcpOwnerCapturesDir(owner)) {
+				if present[name] == nil {
+					present[name] = make(map[string]bool)
+				}
+				present[name][owner] = true
+			}
+		}
+	}
+	for name, owners := range present {
+		if mcpCaptureResources.known[name] == nil {
+			s.AddResource(
+				mcp.NewResource(mcpCaptureURI(name), name,
+					mcp.WithResourceDescription("Screenshot or PDF in the captures directory"),
+					mcp.WithMIMEType(captureMIMEType(name)),
+				),
+				readCaptureResource,
+			)
+		}
+		mcpCaptureResources.known[name] = owners
+	}
+	for name := range mcpCaptureResources.known {
+		if present[name] == nil {
+			s.RemoveResource(mcpCaptureURI(name))
+			delete(mcpCaptureResources.known, name)
+		}
+	}
+}
+
+// filterMCPCaptureResources drops the captures of other clients from a
+// resources/list result.
+func filterMCPCaptureResources(ctx context.Context, result *mcp.ListResourcesResult) {
+	owner := mcpCaptureOwner(ctx)
+	mcpCaptureResources.Lock()
+	defer mcpCaptureResources.Unlock()
+	kept := result.Resources[:0]
+	for _, res := range result.Resources {
+		if name, ok := strings.CutPrefix(res.URI, mcpCapturesURIPrefix); ok {
+			if name, err := url.PathUnescape(name); err != nil || !mcpCaptureResources.known[name][owner] {
+				continue
+			}
+		}
+		kept = append(kept, res)
+	}
+	result.Resources = kept
+}
+
+// announceCaptureWritten refreshes the capture resources after a tool called
+// with ctx saved a file.
+func announceCaptureWritten(ctx context.Context, path string) {
+	s := mcpActiveServer.Load()
+	if s == nil {
+		return
+	}
+	if filepath.Dir(absPath(path)) != absPath(mcpOwnerCapturesDir(mcpCaptureOwner(ctx))) {
+		return
+	}
+	syncMCPCaptureResources(s)
+}
+
+// announcePageNavigated tells the clients using p that the page resources
+// changed: every client for the shared page, else the client whose browser
+// context p is. Named contexts have no page resources.
+func announcePageNavigated(p *rod.Page) {
+	s := mcpActiveServer.Load()
+	if s == nil {
+		return
+	}
+	key := pageScopeKey(p)
+	if key == "" {
+		if mcpIsolation != mcpIsolationSession {
+			s.SendNotificationToAllClients(mcpResourcesListChanged, nil)
+		}
+		return
+	}
+	if id, ok := strings.CutPrefix(key, mcpSessionScopeKey("")); ok {
+		_ = s.SendNotificationToSpecificClient(id, mcpResourcesListChanged, nil)
+	}
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	aitools "roderik/internal/ai/tools"
+)
+
+func newTestResourceServer(t *testing.T, opts ...server.ServerOption) (*server.MCPServer, string) {
+	t.Helper()
+	dir := t.TempDir()
+	prevDir, prevServer := mcpCapturesDir, mcpActiveServer.Load()
+	mcpCapturesDir = func() string { return dir }
+	t.Cleanup(func() {
+		mcpCapturesDir = prevDir
+		mcpActiveServer.Store(prevServer)
+		mcpCaptureResources.Lock()
+		mcpCaptureResources.known = make(map[string]map[string]bool)
+		mcpCaptureResources.Unlock()
+	})
+	s := server.NewMCPServer("roderik", "test", append([]server.ServerOption{server.WithResourceCapabilities(false, true)}, opts...)...)
+	registerMCPResources(s)
+	return s, dir
+}
+
+func mcpRPC(t *testing.T, s *server.MCPServer, method string, params any) json.RawMessage {
+	t.Helper()
+	return mcpSessionRPC(t, context.Background(), s, method, params)
+}
+
+func mcpSessionRPC(t *testing.T, ctx context.Context, s *server.MCPServer, method string, params any) json.RawMessage {
+	t.Helper()
+	msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	resp := s.HandleMessage(ctx, msg)
+	data, _ := json.Marshal(resp)
+	var out struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Error != nil {
+		return nil
+	}
+	return out.Result
+}
+
+func readMCPResource(t *testing.T, s *server.MCPServer, uri string) (text, blob, mimeType string, ok bool) {
+	t.Helper()
+	res := mcpRPC(t, s, string(mcp.MethodResourcesRead), map[string]any{"uri": uri})
+	if res == nil {
+		return "", "", "", false
+	}
+	var out struct {
+		Contents []struct {
+			Text     string `json:"text"`
+			Blob     string `json:"blob"`
+			MIMEType string `json:"mimeType"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(res, &out); err != nil || len(out.Contents) != 1 {
+		t.Fatalf("unexpected read result %s: %v", res, err)
+	}
+	c := out.Contents[0]
+	return c.Text, c.Blob, c.MIMEType, true
+}
+
+func TestMCPCaptureResources(t *testing.T) {
+	s, dir := newTestResourceServer(t)
+	path := filepath.Join(dir, "shot one.png")
+	if err := os.WriteFile(path, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+	announceCaptureWritten(context.Background(), path)
+
+	list := string(mcpRPC(t, s, string(mcp.MethodResourcesList), map[string]any{}))
+	uri := mcpCaptureURI("shot one.png")
+	if !strings.Contains(list, uri) || strings.Contains(list, "notes.txt") || !strings.Contains(list, mcpPageCurrentURI) {
+		t.Fatalf("unexpected resource list: %s", list)
+	}
+	if _, blob, mimeType, ok := readMCPResource(t, s, uri); !ok || blob != "cG5n" || mimeType != "image/png" {
+		t.Fatalf("read capture = %q %q %v", blob, mimeType, ok)
+	}
+	for _, bad := range []string{mcpCapturesURIPrefix + "..%2Fsecret.png", mcpCapturesURIPrefix + "notes.txt"} {
+		if _, _, _, ok := readMCPResource(t, s, bad); ok {
+			t.Fatalf("read of %s should fail", bad)
+		}
+	}
+
+	os.Remove(path)
+	announceCaptureWritten(context.Background(), path)
+	if list := string(mcpRPC(t, s, string(mcp.MethodResourcesList), map[string]any{})); strings.Contains(list, "shot") {
+		t.Fatalf("removed capture still listed: %s", list)
+	}
+}
+
+func TestMCPPageAndNetworkResources(t *testing.T) {
+	s, _ := newTestResourceServer(t)
+	prevPage := Page
+	Page = nil
+	t.Cleanup(func() { Page = prevPage })
+
+	text, _, _, ok := readMCPResource(t, s, mcpPageCurrentURI)
+	if !ok || !strings.Contains(text, `"loaded": false`) {
+		t.Fatalf("page/current without a page = %q", text)
+	}
+	if _, _, _, ok := readMCPResource(t, s, mcpPageMarkdownURI); ok {
+		t.Fatal("page/markdown without a page should fail")
+	}
+
+	prev := getActiveEventLog()
+	t.Cleanup(func() { setActiveEventLog(prev) })
+	log := newNetworkEventLog()
+	log.RecordRequest(&proto.NetworkRequestWillBeSent{RequestID: "9.1", Request: &proto.NetworkRequest{URL: "https://example.com/api", Method: "GET"}})
+	log.RecordResponse(&proto.NetworkResponseReceived{RequestID: "9.1", Response: &proto.NetworkResponse{Status: 200, MIMEType: "application/json"}})
+	log.StoreBody("9.1", []byte(`{"ok":true}`), false, 11)
+	setActiveEventLog(log)
+
+	text, _, _, ok = readMCPResource(t, s, mcpNetworkURIPrefix+"9.1")
+	if !ok || !strings.Contains(text, "https://example.com/api") || !strings.Contains(text, `"ok": true`) {
+		t.Fatalf("network resource = %q", text)
+	}
+	if _, _, _, ok := readMCPResource(t, s, mcpNetworkURIPrefix+"missing"); ok {
+		t.Fatal("unknown request should fail")
+	}
+}
+
+func TestMCPResourcesFollowCapabilities(t *testing.T) {
+	swapMCPToolset(t, mustToolset("no-captures", toolProfile{Capabilities: []string{aitools.CapInspect}, Deny: []string{"capture_screenshot", "capture_pdf"}}))
+	s, dir := newTestResourceServer(t)
+	path := filepath.Join(dir, "shot.png")
+	if err := os.WriteFile(path, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	announceCaptureWritten(context.Background(), path)
+
+	templates := string(mcpRPC(t, s, string(mcp.MethodResourcesTemplatesList), map[string]any{}))
+	if strings.Contains(templates, mcpNetworkURIPrefix) || strings.Contains(templates, mcpCapturesURIPrefix) {
+		t.Fatalf("templates offered without their capability: %s", templates)
+	}
+	if list := string(mcpRPC(t, s, string(mcp.MethodResourcesList), map[string]any{})); strings.Contains(list, "shot.png") {
+		t.Fatalf("capture listed without capture tools: %s", list)
+	}
+
+	if _, err := setMCPCapability(aitools.CapNetwork, true); err != nil {
+		t.Fatal(err)
+	}
+	syncMCPResources(s)
+	if templates := string(mcpRPC(t, s, string(mcp.MethodResourcesTemplatesList), map[string]any{})); !strings.Contains(templates, mcpNetworkURIPrefix) {
+		t.Fatalf("network template missing after switching network on: %s", templates)
+	}
+
+	// The template stays registered, but reads are refused once the
+	// capability is switched off again.
+	if _, err := setMCPCapability(aitools.CapNetwork, false); err != nil {
+		t.Fatal(err)
+	}
+	syncMCPResources(s)
+	var req mcp.ReadResourceRequest
+	req.Params.URI = mcpNetworkURIPrefix + "1"
+	if _, err := readNetworkResource(context.Background(), req); err == nil || !strings.Contains(err.Error(), "network capability") {
+		t.Fatalf("read without network = %v", err)
+	}
+}
+
+func TestMCPIsolatedSessionsOwnCapturesAndNavigation(t *testing.T) {
+	prevIsolation := mcpIsolation
+	mcpIsolation = mcpIsolationSession
+	t.Cleanup(func() { mcpIsolation = prevIsolation })
+	s, _ := newTestResourceServer(t, server.WithHooks(mcpIsolationHooks()))
+	ctxs := make(map[string]context.Context)
+	sessions := make(map[string]*mcpSession)
+	for _, id := range []string{"a", "b"} {
+		sess := newMCPSession(id)
+		sess.Initialize()
+		if err := s.RegisterSession(context.Background(), sess); err != nil {
+			t.Fatal(err)
+		}
+		sessions[id], ctxs[id] = sess, s.WithContext(context.Background(), sess)
+	}
+	drain := func(id string) []string {
+		var methods []string
+		for {
+			select {
+			case n := <-sessions[id].notifications:
+				methods = append(methods, n.Method)
+			default:
+				return methods
+			}
+		}
+	}
+
+	path, err := resolveOutputPath("", mcpCaptureOutputDir(ctxs["a"]), "shot", "screenshot", "png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	announceCaptureWritten(ctxs["a"], path)
+	uri := mcpCaptureURI("shot.png")
+	if list := string(mcpSessionRPC(t, ctxs["a"], s, string(mcp.MethodResourcesList), map[string]any{})); !strings.Contains(list, uri) {
+		t.Fatalf("owner does not see its capture: %s", list)
+	}
+	if list := string(mcpSessionRPC(t, ctxs["b"], s, string(mcp.MethodResourcesList), map[string]any{})); strings.Contains(list, uri) || !strings.Contains(list, mcpPageCurrentURI) {
+		t.Fatalf("other session's resource list = %s", list)
+	}
+	if res := mcpSessionRPC(t, ctxs["b"], s, string(mcp.MethodResourcesRead), map[string]any{"uri": uri}); res != nil {
+		t.Fatalf("other session read the capture: %s", res)
+	}
+	if res := mcpSessionRPC(t, ctxs["a"], s, string(mcp.MethodResourcesRead), map[string]any{"uri": uri}); res == nil {
+		t.Fatal("owner cannot read its capture")
+	}
+
+	page := &rod.Page{TargetID: "tab-a"}
+	browserScopes.Lock()
+	browserScopes.byKey[mcpSessionScopeKey("a")] = &browserScope{key: mcpSessionScopeKey("a"), page: page}
+	browserScopes.Unlock()
+	t.Cleanup(func() {
+		browserScopes.Lock()
+		delete(browserScopes.byKey, mcpSessionScopeKey("a"))
+		browserScopes.Unlock()
+	})
+	drain("a")
+	drain("b")
+	announcePageNavigated(page)
+	if got := drain("a"); len(got) != 1 || got[0] != mcpResourcesListChanged {
+		t.Fatalf("navigating session notified with %v", got)
+	}
+	if got := drain("b"); len(got) != 0 {
+		t.Fatalf("other session notified with %v", got)
+	}
+}
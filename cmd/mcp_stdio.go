@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mcpStdioSessionID names the single client of the stdio transport.
+const mcpStdioSessionID = "stdio"
+
+// serveMCPStdio serves one client over newline-delimited JSON-RPC. Unlike
+// server.ServeStdio it runs tools/call in the background, so the client can
+// cancel a long call and receives its progress notifications meanwhile.
+// Every other message is handled in order as it arrives.
+func serveMCPStdio(s *server.MCPServer, in io.Reader, out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return listenMCPStdio(ctx, s, in, out)
+}
+
+func listenMCPStdio(ctx context.Context, s *server.MCPServer, in io.Reader, out io.Writer) error {
+	sess := newMCPSession(mcpStdioSessionID)
+	if err := s.RegisterSession(ctx, sess); err != nil {
+		return fmt.Errorf("register session: %w", err)
+	}
+	defer func() {
+		s.UnregisterSession(context.Background(), sess.id)
+		stopMCPWatchesForSession(sess.id)
+	}()
+	ctx = s.WithContext(ctx, sess)
+
+	var writeMu sync.Mutex
+	enc := json.NewEncoder(out)
+	write := func(msg any) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if err := enc.Encode(msg); err != nil {
+			log.Printf("[MCP] stdio write: %v", err)
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case n := <-sess.notifications:
+				write(n)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadString('\n')
+			if strings.TrimSpace(line) != "" {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var calls sync.WaitGroup
+	defer calls.Wait()
+	for {
+		select {
+		case line := <-lines:
+			raw := json.RawMessage(line)
+			if !json.Valid(raw) {
+				write(mcp.NewJSONRPCError(nil, mcp.PARSE_ERROR, "Parse error", nil))
+				continue
+			}
+			handle := func() {
+				if resp := mcpHandleMessage(ctx, s, sess.id, raw); resp != nil {
+					write(resp)
+				}
+			}
+			if !mcpIsToolCall(raw) {
+				handle()
+				continue
+			}
+			calls.Add(1)
+			go func() {
+				defer calls.Done()
+				handle()
+			}()
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func mcpIsToolCall(msg json.RawMessage) bool {
+	var head struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(msg, &head) == nil && head.Method == string(mcp.MethodToolsCall)
+}
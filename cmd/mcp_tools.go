@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	aitools "roderik/internal/ai/tools"
+)
+
+func init() {
+	aitools.RegisterGate(aitools.GateNavigation, navigationToolsEnabled)
+}
+
+// mcpToolFromDefinition builds the MCP tool advertised for a registry
+// definition; the input schema is the one the AI agent sees.
+func mcpToolFromDefinition(def aitools.Definition) mcp.Tool {
+	tool := mcp.NewTool(def.Name, mcp.WithDescription(def.Description))
+	props, required := def.InputSchema()
+	tool.InputSchema.Properties = props
+	tool.InputSchema.Required = required
+	return tool
+}
+
+// mcpToolHandler dispatches an MCP call to the shared tool handler.
+func mcpToolHandler(name string) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		log.Printf("[MCP] TOOL %s CALLED args=%#v", name, req.Params.Arguments)
+		res, err := aitools.Call(ctx, name, req.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		out, err := resultToMCP(res)
+		if err != nil {
+			return nil, err
+		}
+		if res.Binary != nil {
+			log.Printf("[MCP] TOOL %s RESULT binary length=%d", name, len(res.Binary))
+		} else {
+			log.Printf("[MCP] TOOL %s RESULT length=%d", name, len(res.Text))
+		}
+		return out, nil
+	}
+}
+
+// mcpHandlerOverrides returns handlers for tools that need the MCP server
+// itself rather than the shared tool handlers.
+func mcpHandlerOverrides(s *server.MCPServer) map[string]server.ToolHandlerFunc {
+	return map[string]server.ToolHandlerFunc{
+		"shutdown":          mcpShutdownHandler(),
+		"tool_capabilities": mcpToolCapabilitiesHandler(s),
+		"network_watch":     mcpNetworkWatchHandler(s),
+		"network_unwatch":   mcpNetworkUnwatchHandler,
+	}
+}
+
+// mcpServerTools generates the MCP tools from the registry, skipping tools
+// whose gate is closed or whose capability the server does not offer.
+func mcpServerTools(s *server.MCPServer) []server.ServerTool {
+	overrides := mcpHandlerOverrides(s)
+	defs := aitools.Available()
+	out := make([]server.ServerTool, 0, len(defs))
+	for _, def := range defs {
+		if !mcpToolExposed(def) {
+			continue
+		}
+		handler, ok := overrides[def.Name]
+		if !ok {
+			handler = mcpToolHandler(def.Name)
+		}
+		tool := mcpToolFromDefinition(def)
+		if mcpIsolation == mcpIsolationSession {
+			addMCPScopeArg(&tool)
+		}
+		out = append(out, server.ServerTool{Tool: tool, Handler: handler})
+	}
+	return out
+}
+
+func registerMCPTools(s *server.MCPServer) {
+	s.AddTools(mcpServerTools(s)...)
+}
+
+func mcpShutdownHandler() server.ToolHandlerFunc {
+	var shutdownOnce sync.Once
+	return func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		shutdownOnce.Do(func() {
+			go func() {
+				time.Sleep(200 * time.Millisecond) // allow stdio response to flush
+				log.Printf("[MCP] shutdown requested – exiting")
+				os.Exit(0)
+			}()
+		})
+		return mcp.NewToolResultText("shutting down"), nil
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	aitools "roderik/internal/ai/tools"
+	"roderik/internal/subtitles"
+)
+
+func TestMCPToolsMatchRegistry(t *testing.T) {
+	registerHandlers()
+	defs := aitools.Available()
+	tools := mcpServerTools(nil)
+	if len(tools) != len(defs) {
+		t.Fatalf("got %d MCP tools for %d definitions", len(tools), len(defs))
+	}
+	overrides := mcpHandlerOverrides(nil)
+	for i, def := range defs {
+		tool := tools[i].Tool
+		if tool.Name != def.Name || tool.Description != def.Description {
+			t.Fatalf("tool %d = %q, want %q", i, tool.Name, def.Name)
+		}
+		props, required := def.InputSchema()
+		if !reflect.DeepEqual(tool.InputSchema.Properties, props) || !reflect.DeepEqual(tool.InputSchema.Required, required) {
+			t.Fatalf("%s: MCP schema differs from registry", def.Name)
+		}
+		if _, ok := overrides[def.Name]; !ok && !aitools.HasHandler(def.Name) {
+			t.Fatalf("%s: no handler registered", def.Name)
+		}
+	}
+	for name := range overrides {
+		def, ok := aitools.Lookup(name)
+		if !ok || !def.MCPOnly {
+			t.Fatalf("override %q should be an MCP-only definition", name)
+		}
+	}
+}
+
+func TestAIToolsMatchRegistry(t *testing.T) {
+	llmTools, mapping := aitools.LLMTools("roderik")
+	want := 0
+	for _, def := range aitools.Available() {
+		if !def.MCPOnly {
+			want++
+		}
+	}
+	if len(llmTools) != want {
+		t.Fatalf("got %d AI tools, want %d", len(llmTools), want)
+	}
+	for _, tool := range llmTools {
+		def := mapping[tool.Name]
+		if def.MCPOnly {
+			t.Fatalf("MCP-only tool %q offered to the AI", def.Name)
+		}
+		props, required := def.InputSchema()
+		if tool.Description != def.Description || !reflect.DeepEqual(tool.InputSchema.Properties, props) || !reflect.DeepEqual(tool.InputSchema.Required, required) {
+			t.Fatalf("%s: AI schema differs from registry", def.Name)
+		}
+	}
+}
+
+func TestToolHelpListsRegistry(t *testing.T) {
+	help := toolsHelpText(aitools.List())
+	for _, def := range aitools.List() {
+		if !strings.Contains(help, "\n"+def.Name) && !strings.HasPrefix(help, def.Name) {
+			t.Fatalf("help is missing %q", def.Name)
+		}
+	}
+	if !strings.Contains(help, "quality integer, range 0..100") {
+		t.Fatalf("help is missing parameter bounds:\n%s", help)
+	}
+}
+
+func TestNavigationGateHidesTools(t *testing.T) {
+	t.Setenv("RODERIK_ENABLE_LOAD_URL", "false")
+	for _, tool := range mcpServerTools(nil) {
+		if def, _ := aitools.Lookup(tool.Tool.Name); def.Gate == aitools.GateNavigation {
+			t.Fatalf("gated tool %q still exposed", tool.Tool.Name)
+		}
+	}
+	llmTools, _ := aitools.LLMTools("roderik")
+	for _, tool := range llmTools {
+		if strings.HasSuffix(tool.Name, "load_url") {
+			t.Fatalf("load_url offered to the AI while disabled")
+		}
+	}
+}
+
+func TestRegistryEnumsMatchImplementations(t *testing.T) {
+	sorted := func(in []string) []string {
+		out := append([]string(nil), in...)
+		sort.Strings(out)
+		return out
+	}
+	if got, want := sorted(aitools.SearchEngines), sorted(searchEngineNames()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SearchEngines = %v, want %v", got, want)
+	}
+	if got, want := sorted(aitools.TranscriptFormats), sorted(subtitles.Formats); !reflect.DeepEqual(got, want) {
+		t.Fatalf("TranscriptFormats = %v, want %v", got, want)
+	}
+	def, _ := aitools.Lookup("yttrans")
+	for _, p := range def.Parameters {
+		if p.Name == "source" {
+			want := []string{transcriptSourceAuto, transcriptSourceYTDLP, transcriptSourceBrowser}
+			if !reflect.DeepEqual(p.Enum, want) {
+				t.Fatalf("yttrans source enum = %v, want %v", p.Enum, want)
+			}
+		}
+	}
+}
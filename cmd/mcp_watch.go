@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mcpWatchURIPrefix prefixes the resource URI of each network watch; reading
+// netlog://watch/<id> drains the entries buffered since the last read.
+const mcpWatchURIPrefix = "netlog://watch/"
+
+// mcpWatchPending bounds the entries kept for a watch between reads.
+const mcpWatchPending = 1000
+
+type mcpNetworkWatch struct {
+	watch   *networkWatch
+	session string
+	notify  bool
+
+	mu      sync.Mutex
+	pending []networkFollowRecord
+	dropped int
+}
+
+// mcpNetworkWatches tracks the watches created over MCP by ID.
+var mcpNetworkWatches = struct {
+	sync.Mutex
+	byID map[int]*mcpNetworkWatch
+}{byID: make(map[int]*mcpNetworkWatch)}
+
+func mcpWatchURI(id int) string {
+	return mcpWatchURIPrefix + strconv.Itoa(id)
+}
+
+// mcpNetworkWatchHandler starts a watch. Each completed entry matching it is
+// buffered for the netlog://watch/{id} resource and announced to the client
+// that created the watch with notifications/resources/updated and a
+// notifications/message carrying the entry, so traffic can be observed while
+// other tools run.
+func mcpNetworkWatchHandler(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		log.Printf("[MCP] TOOL network_watch CALLED args=%#v", req.Params.Arguments)
+		filter, err := networkFilterFromArgs(req.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		notify := true
+		if v, ok := toBool(req.Params.Arguments["notify"]); ok {
+			notify = v
+		}
+		session := ""
+		if cs := server.ClientSessionFromContext(ctx); cs != nil {
+			session = cs.SessionID()
+		}
+		mw := startMCPNetworkWatch(s, filter, session, browserScopeFromContext(ctx), notify)
+		uri := mcpWatchURI(mw.watch.ID)
+		log.Printf("[MCP] TOOL network_watch RESULT id=%d", mw.watch.ID)
+		return mcp.NewToolResultText(fmt.Sprintf("watching network traffic as watch %d; read %s for buffered entries and call network_unwatch with watch_id %d to stop", mw.watch.ID, uri, mw.watch.ID)), nil
+	}
+}
+
+func mcpNetworkUnwatchHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	log.Printf("[MCP] TOOL network_unwatch CALLED args=%#v", req.Params.Arguments)
+	id, ok := toInt(req.Params.Arguments["watch_id"])
+	if !ok {
+		return nil, fmt.Errorf("network_unwatch: watch_id is required")
+	}
+	if !stopMCPNetworkWatch(id) {
+		return nil, fmt.Errorf("network_unwatch: watch %d not found", id)
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("stopped watch %d", id)), nil
+}
+
+// registerNetworkWatchMCP adds the netlog://watch/{id} resource read by
+// network_watch clients.
+func registerNetworkWatchMCP(s *server.MCPServer) {
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			mcpWatchURIPrefix+"{id}",
+			"Network watch",
+			mcp.WithTemplateDescription("Entries completed since the last read for a watch created with network_watch, as NDJSON."),
+			mcp.WithTemplateMIMEType("application/x-ndjson"),
+		),
+		func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			id, err := strconv.Atoi(strings.TrimPrefix(req.Params.URI, mcpWatchURIPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid watch URI %q", req.Params.URI)
+			}
+			data, err := drainMCPNetworkWatch(id)
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "application/x-ndjson", Text: data}}, nil
+		},
+	)
+}
+
+func startMCPNetworkWatch(s *server.MCPServer, filter NetworkLogFilter, session, scope string, notify bool) *mcpNetworkWatch {
+	mw := &mcpNetworkWatch{watch: networkWatchers.watch(filter, scope), session: session, notify: notify}
+	mcpNetworkWatches.Lock()
+	mcpNetworkWatches.byID[mw.watch.ID] = mw
+	mcpNetworkWatches.Unlock()
+	go func() {
+		uri := mcpWatchURI(mw.watch.ID)
+		for entry := range mw.watch.C {
+			rec := newNetworkFollowRecord(entry)
+			mw.add(rec)
+			if s == nil {
+				continue
+			}
+			mw.send(s, mcpResourceUpdated, map[string]any{"uri": uri})
+			if mw.notify {
+				mw.send(s, "notifications/message", map[string]any{
+					"level":  "info",
+					"logger": "roderik/netlog",
+					"data":   map[string]any{"watch_id": mw.watch.ID, "entry": rec},
+				})
+			}
+		}
+	}()
+	return mw
+}
+
+func (mw *mcpNetworkWatch) add(rec networkFollowRecord) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	if len(mw.pending) >= mcpWatchPending {
+		mw.pending = mw.pending[1:]
+		mw.dropped++
+	}
+	mw.pending = append(mw.pending, rec)
+}
+
+func (mw *mcpNetworkWatch) send(s *server.MCPServer, method string, params map[string]any) {
+	if mw.session == "" {
+		s.SendNotificationToAllClients(method, params)
+		return
+	}
+	err := s.SendNotificationToSpecificClient(mw.session, method, params)
+	if errors.Is(err, server.ErrSessionNotFound) {
+		// The client went away without calling network_unwatch.
+		stopMCPNetworkWatch(mw.watch.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("[MCP] watch %d notification %s: %v", mw.watch.ID, method, err)
+	}
+}
+
+func stopMCPNetworkWatch(id int) bool {
+	mcpNetworkWatches.Lock()
+	_, ok := mcpNetworkWatches.byID[id]
+	delete(mcpNetworkWatches.byID, id)
+	mcpNetworkWatches.Unlock()
+	if !ok {
+		return false
+	}
+	networkWatchers.cancel(id)
+	return true
+}
+
+// stopMCPWatchesForSession stops the watches created by a client session.
+func stopMCPWatchesForSession(session string) {
+	mcpNetworkWatches.Lock()
+	var ids []int
+	for id, mw := range mcpNetworkWatches.byID {
+		if mw.session == session {
+			ids = append(ids, id)
+		}
+	}
+	mcpNetworkWatches.Unlock()
+	for _, id := range ids {
+		stopMCPNetworkWatch(id)
+	}
+}
+
+// drainMCPNetworkWatch returns the buffered entries of a watch as NDJSON and
+// clears the buffer.
+func drainMCPNetworkWatch(id int) (string, error) {
+	mcpNetworkWatches.Lock()
+	mw, ok := mcpNetworkWatches.byID[id]
+	mcpNetworkWatches.Unlock()
+	if !ok {
+		return "", fmt.Errorf("watch %d not found", id)
+	}
+	mw.mu.Lock()
+	pending := mw.pending
+	mw.pending = nil
+	mw.mu.Unlock()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range pending {
+		if err := enc.Encode(rec); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
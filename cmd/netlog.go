@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -10,10 +11,12 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	survey "github.com/AlecAivazis/survey/v2"
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/spf13/cobra"
+	"roderik/internal/netstore"
 )
 
 var (
@@ -33,6 +36,12 @@ var (
 	netlogFilenameSuffix          string
 	netlogFilenameUseTimestamp    bool
 	netlogFilenameTimestampFormat string
+	netlogSession                 string
+	netlogSince                   string
+	netlogGraphQLOps              []string
+	netlogShowRequest             string
+	netlogFollow                  bool
+	netlogNDJSON                  bool
 )
 
 var netlogEnableCmd = &cobra.Command{
@@ -65,9 +74,43 @@ var netlogDisableCmd = &cobra.Command{
 
 var netlogStatusCmd = &cobra.Command{
 	Use:   "status",
-	Short: "Show whether network activity logging is currently enabled",
+	Short: "Show whether network activity logging and body capture are enabled",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stdout, "network activity logging enabled: %t\n", isNetworkActivityEnabled())
+		policy, err := activeCapturePolicy()
+		if err != nil {
+			return err
+		}
+		bodies, used, evicted := bodyMemory.stats()
+		limit := "unlimited"
+		if l := bodyMemoryLimit(); l > 0 {
+			limit = formatByteSize(l)
+		}
+		fmt.Fprintf(os.Stdout, "body capture enabled: %t\n", policy.Enabled)
+		fmt.Fprintf(os.Stdout, "bodies in memory: %d (%s of %s, %d evicted)\n", bodies, formatByteSize(used), limit, evicted)
+		return nil
+	},
+}
+
+var netlogSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List network log sessions persisted on disk",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := netstore.DefaultDir()
+		sessions, err := netstore.Sessions(dir)
+		if err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			fmt.Fprintf(os.Stderr, "no persisted network sessions in %s\n", dir)
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 4, 2, 2, ' ', 0)
+		defer tw.Flush()
+		fmt.Fprintln(tw, "SESSION\tSTARTED\tUPDATED\tSEGMENTS\tSIZE")
+		for _, s := range sessions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", s.ID, s.Started.Local().Format("2006-01-02 15:04:05"), s.Updated.Format("2006-01-02 15:04:05"), s.Segments, formatByteSize(s.Bytes))
+		}
 		return nil
 	},
 }
@@ -75,9 +118,36 @@ var netlogStatusCmd = &cobra.Command{
 var netlogCmd = &cobra.Command{
 	Use:   "netlog",
 	Short: "Inspect and save captured network activity",
+	Long: `netlog lists the network activity captured for the current page.
+
+With --netlog-persist, entries are also kept under <base>/netlog (see
+--netlog-persist-bodies, --netlog-max-age and --netlog-max-size), one session
+per run and one navigation per loaded URL. Use --session (an ID from "netlog sessions",
+"current" or "latest") and/or --since to query that history instead.`,
+	Example: `  # Everything captured in the last two hours, across runs
+  roderik netlog --since 2h
+
+  # JSON responses from the most recent persisted session
+  roderik netlog --session latest --mime json
+
+  # Stream XHR/Fetch requests as they complete, as NDJSON
+  roderik netlog --follow --type XHR,Fetch --ndjson`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if id := strings.TrimSpace(netlogShowRequest); id != "" {
+			detail, err := lookupNetworkDetail(context.Background(), id, strings.TrimSpace(netlogSession), true)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stdout, formatNetworkDetail(detail, 0))
+			return nil
+		}
+
+		stored := strings.TrimSpace(netlogSession) != "" || strings.TrimSpace(netlogSince) != ""
+		if netlogFollow && (stored || netlogSave) {
+			return fmt.Errorf("--follow cannot be combined with --session, --since or --save")
+		}
 		log := getActiveEventLog()
-		if log == nil {
+		if log == nil && !stored && !netlogFollow {
 			return fmt.Errorf("no active network log; load a page first")
 		}
 
@@ -88,6 +158,7 @@ var netlogCmd = &cobra.Command{
 			TextContains:   normalizeStrings(netlogContains),
 			Methods:        normalizeStrings(netlogMethods),
 			Domains:        normalizeStrings(netlogDomains),
+			GraphQLOps:     normalizeStrings(netlogGraphQLOps),
 		}
 
 		if len(netlogTypes) > 0 {
@@ -98,7 +169,24 @@ var netlogCmd = &cobra.Command{
 			filter.ResourceTypes = rts
 		}
 
-		entries := log.FilterEntries(filter)
+		if netlogFollow {
+			return runNetlogFollow(filter, netlogNDJSON)
+		}
+
+		var entries []*NetworkLogEntry
+		if stored {
+			since, err := parseNetlogSince(netlogSince, time.Now())
+			if err != nil {
+				return err
+			}
+			all, err := storedNetworkEntries(netlogSession, since)
+			if err != nil {
+				return err
+			}
+			entries = filterNetworkEntries(all, filter)
+		} else {
+			entries = log.FilterEntries(filter)
+		}
 		if len(entries) == 0 {
 			fmt.Fprintln(os.Stderr, "no network entries matched the specified filters")
 			return nil
@@ -113,7 +201,11 @@ var netlogCmd = &cobra.Command{
 			return ti.Before(tj)
 		})
 
-		printNetlogEntries(entries)
+		if stored {
+			printStoredNetlogEntries(entries)
+		} else {
+			printNetlogEntries(entries)
+		}
 
 		if !netlogSave {
 			return nil
@@ -128,7 +220,17 @@ var netlogCmd = &cobra.Command{
 			return nil
 		}
 
-		results, err := saveNetworkEntriesToDisk(selected, netlogOutputDir)
+		var results []networkSaveResult
+		if stored {
+			results, err = writeNetworkEntries(selected, netlogOutputDir, func(entry *NetworkLogEntry) ([]byte, error) {
+				if entry.BodyBlob == "" {
+					return nil, fmt.Errorf("body was not persisted for request %s", entry.RequestID)
+				}
+				return storedNetworkBody(entry)
+			})
+		} else {
+			results, err = saveNetworkEntriesToDisk(selected, netlogOutputDir)
+		}
 		if err != nil {
 			return err
 		}
@@ -148,6 +250,7 @@ func init() {
 	netlogCmd.AddCommand(netlogEnableCmd)
 	netlogCmd.AddCommand(netlogDisableCmd)
 	netlogCmd.AddCommand(netlogStatusCmd)
+	netlogCmd.AddCommand(netlogSessionsCmd)
 
 	netlogCmd.Flags().StringSliceVar(&netlogMIMEs, "mime", nil, "Filter by MIME substring (repeatable)")
 	netlogCmd.Flags().StringSliceVar(&netlogSuffixes, "suffix", nil, "Filter by URL suffix (e.g. .mp3)")
@@ -165,6 +268,12 @@ func init() {
 	netlogCmd.Flags().StringVar(&netlogFilenameSuffix, "filename-suffix", "", "Optional filename suffix when saving")
 	netlogCmd.Flags().BoolVar(&netlogFilenameUseTimestamp, "filename-timestamp", false, "Include a timestamp in saved filenames")
 	netlogCmd.Flags().StringVar(&netlogFilenameTimestampFormat, "filename-timestamp-format", "2006-01-02_150405", "Go time format for timestamps when --filename-timestamp is set")
+	netlogCmd.Flags().StringVar(&netlogSession, "session", "", "Query the persisted log of this session (ID, current or latest) instead of the current page")
+	netlogCmd.Flags().StringSliceVar(&netlogGraphQLOps, "graphql-op", nil, "Filter by GraphQL operation name substring")
+	netlogCmd.Flags().StringVar(&netlogShowRequest, "show-request", "", "Show headers, request body and response body of one request ID (with --session for persisted requests)")
+	netlogCmd.Flags().BoolVarP(&netlogFollow, "follow", "f", false, "Stream filtered entries as they complete (status, size, timing, type) until interrupted")
+	netlogCmd.Flags().BoolVar(&netlogNDJSON, "ndjson", false, "With --follow, print one JSON object per entry")
+	netlogCmd.Flags().StringVar(&netlogSince, "since", "", "Query persisted entries captured since a duration ago (2h) or a time (2006-01-02 15:04)")
 }
 
 func printNetlogEntries(entries []*NetworkLogEntry) {
@@ -182,8 +291,51 @@ func printNetlogEntries(entries []*NetworkLogEntry) {
 		if typ == "" {
 			typ = "(unknown)"
 		}
-		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", idx, status, entry.Method, typ, mime, entry.URL)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", idx, status, entry.Method, typ, mime, netlogURLColumn(entry))
+	}
+}
+
+// printStoredNetlogEntries lists persisted entries with the session and
+// navigation they were captured in.
+func printStoredNetlogEntries(entries []*NetworkLogEntry) {
+	tw := tabwriter.NewWriter(os.Stdout, 4, 2, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintln(tw, "INDEX\tTIME\tSESSION\tNAV\tREQUEST\tSTATUS\tMETHOD\tTYPE\tMIME\tURL")
+	for idx, entry := range entries {
+		status := "-"
+		mime := ""
+		if entry.Response != nil {
+			status = fmt.Sprintf("%d", entry.Response.Status)
+			mime = entry.Response.MIMEType
+		}
+		if entry.Failure != nil {
+			status = "failed"
+		}
+		typ := string(entry.ResourceType)
+		if typ == "" {
+			typ = "(unknown)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", idx, entry.RequestTimestamp.Local().Format("2006-01-02 15:04:05"),
+			entry.SessionID, entry.NavigationID, entry.RequestID, status, entry.Method, typ, mime, netlogURLColumn(entry))
+	}
+}
+
+// netlogURLColumn is the URL plus the GraphQL operation, when there is one.
+func netlogURLColumn(entry *NetworkLogEntry) string {
+	if entry.GraphQLOperation == "" {
+		return entry.URL
 	}
+	return fmt.Sprintf("%s [graphql: %s]", entry.URL, entry.GraphQLOperation)
+}
+
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
 }
 
 func selectEntriesForSave(entries []*NetworkLogEntry) ([]*NetworkLogEntry, error) {
@@ -251,43 +403,43 @@ func saveNetworkEntriesToDisk(entries []*NetworkLogEntry, dir string) ([]network
 	if len(entries) == 0 {
 		return nil, nil
 	}
-	var results []networkSaveResult
-	res, err := withPage(func() ([]networkSaveResult, error) {
+	return withPage(func() ([]networkSaveResult, error) {
 		if Page == nil {
 			return nil, fmt.Errorf("no page loaded – cannot retrieve response bodies")
 		}
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return nil, fmt.Errorf("create output directory: %w", err)
-		}
-		used := make(map[string]int)
-		out := make([]networkSaveResult, 0, len(entries))
-		nameOpts := FileNamingOptions{
-			Prefix:           netlogFilenamePrefix,
-			Suffix:           netlogFilenameSuffix,
-			IncludeTimestamp: netlogFilenameUseTimestamp,
-			TimestampFormat:  netlogFilenameTimestampFormat,
+		return writeNetworkEntries(entries, dir, func(entry *NetworkLogEntry) ([]byte, error) {
+			return retrieveNetworkBody(Page, entry)
+		})
+	})
+}
+
+func writeNetworkEntries(entries []*NetworkLogEntry, dir string, fetch bodyFetcher) ([]networkSaveResult, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+	used := make(map[string]int)
+	out := make([]networkSaveResult, 0, len(entries))
+	nameOpts := FileNamingOptions{
+		Prefix:           netlogFilenamePrefix,
+		Suffix:           netlogFilenameSuffix,
+		IncludeTimestamp: netlogFilenameUseTimestamp,
+		TimestampFormat:  netlogFilenameTimestampFormat,
+	}
+	for idx, entry := range entries {
+		data, err := fetch(entry)
+		if err != nil {
+			out = append(out, networkSaveResult{Entry: entry, Err: err})
+			continue
 		}
-		for idx, entry := range entries {
-			data, err := retrieveNetworkBody(Page, entry)
-			if err != nil {
-				out = append(out, networkSaveResult{Entry: entry, Err: err})
-				continue
-			}
-			name := ensureUniqueFilename(dir, buildFilenameForEntry(entry, idx, nameOpts), used)
-			fullPath := filepath.Join(dir, name)
-			if writeErr := os.WriteFile(fullPath, data, 0o644); writeErr != nil {
-				out = append(out, networkSaveResult{Entry: entry, Err: writeErr})
-				continue
-			}
-			out = append(out, networkSaveResult{Entry: entry, Path: fullPath, Bytes: len(data)})
+		name := ensureUniqueFilename(dir, buildFilenameForEntry(entry, idx, nameOpts), used)
+		fullPath := filepath.Join(dir, name)
+		if writeErr := os.WriteFile(fullPath, data, 0o644); writeErr != nil {
+			out = append(out, networkSaveResult{Entry: entry, Err: writeErr})
+			continue
 		}
-		return out, nil
-	})
-	if err != nil {
-		return nil, err
+		out = append(out, networkSaveResult{Entry: entry, Path: fullPath, Bytes: len(data)})
 	}
-	results = append(results, res...)
-	return results, nil
+	return out, nil
 }
 
 func suggestFilename(entry *NetworkLogEntry, index int) string {
@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Body capture defaults: API responses, documents, caption tracks and
+// streaming manifests are fetched as soon as they finish loading. Audio and
+// video are left out; add them with --capture-mime/--capture-type.
+var (
+	defaultCaptureMIMEs = []string{"json", "graphql", "text/vtt", "mpegurl", "dash+xml"}
+	defaultCaptureTypes = []string{"Document", "XHR", "Fetch", "TextTrack"}
+)
+
+const (
+	defaultCaptureMaxSizeMB = 25
+	defaultCaptureMemoryMB  = 256
+
+	// bodyCaptureWorkers fetch selected bodies off the event goroutines;
+	// at most bodyCaptureQueueSize requests wait for them.
+	bodyCaptureWorkers   = 4
+	bodyCaptureQueueSize = 256
+)
+
+var (
+	captureBodies    bool
+	captureMIMEs     []string
+	captureTypes     []string
+	captureDomains   []string
+	captureMaxSizeMB int64
+	captureMemoryMB  int64
+)
+
+func init() {
+	flags := RootCmd.PersistentFlags()
+	flags.BoolVar(&captureBodies, "capture-bodies", true, "Fetch response bodies matching the capture policy as soon as each request finishes")
+	flags.StringSliceVar(&captureMIMEs, "capture-mime", defaultCaptureMIMEs, "Capture bodies whose MIME type contains one of these substrings")
+	flags.StringSliceVar(&captureTypes, "capture-type", defaultCaptureTypes, "Capture bodies of these resource types (Document, XHR, Fetch, Media, ...)")
+	flags.StringSliceVar(&captureDomains, "capture-domain", nil, "Only capture bodies from hosts containing one of these substrings")
+	flags.Int64Var(&captureMaxSizeMB, "capture-max-size", defaultCaptureMaxSizeMB, "Skip eager capture of bodies larger than this many MiB (0 = no limit)")
+	flags.Int64Var(&captureMemoryMB, "capture-memory", defaultCaptureMemoryMB, "Keep at most this many MiB of response bodies in memory, evicting the oldest first (0 = no limit)")
+}
+
+// NetworkCapturePolicy decides which response bodies are fetched eagerly on
+// NetworkLoadingFinished, before Chrome evicts them or the page navigates
+// away. An entry qualifies when its host matches Domains (if any) and its
+// MIME type or resource type matches (when neither list is set, everything
+// qualifies).
+type NetworkCapturePolicy struct {
+	Enabled        bool
+	MIMESubstrings []string
+	ResourceTypes  []proto.NetworkResourceType
+	Domains        []string
+	// MaxBodyBytes skips larger bodies; 0 means no limit.
+	MaxBodyBytes int64
+}
+
+// activeCapturePolicy builds the policy from the --capture-* flags, which the
+// set command can change at runtime.
+func activeCapturePolicy() (NetworkCapturePolicy, error) {
+	policy := NetworkCapturePolicy{
+		Enabled:        captureBodies,
+		MIMESubstrings: normalizeStrings(captureMIMEs),
+		Domains:        normalizeStrings(captureDomains),
+	}
+	if captureMaxSizeMB > 0 {
+		policy.MaxBodyBytes = captureMaxSizeMB << 20
+	}
+	types, err := parseResourceTypes(captureTypes)
+	if err != nil {
+		return policy, fmt.Errorf("--capture-type: %w", err)
+	}
+	policy.ResourceTypes = types
+	return policy, nil
+}
+
+func (p NetworkCapturePolicy) allows(entry *NetworkLogEntry) bool {
+	if !p.Enabled || entry == nil || entry.Response == nil || entry.Failure != nil || entry.Body != nil {
+		return false
+	}
+	switch status := entry.Response.Status; {
+	case status == 204, status >= 300 && status < 400:
+		return false
+	}
+	if strings.EqualFold(entry.Method, "OPTIONS") {
+		return false
+	}
+	if p.MaxBodyBytes > 0 && expectedBodySize(entry) > p.MaxBodyBytes {
+		return false
+	}
+	if len(p.Domains) > 0 {
+		u, err := url.Parse(entry.URL)
+		if err != nil {
+			return false
+		}
+		host := strings.ToLower(u.Hostname())
+		matched := false
+		for _, d := range p.Domains {
+			if strings.Contains(host, d) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(p.MIMESubstrings) == 0 && len(p.ResourceTypes) == 0 {
+		return true
+	}
+	mime := strings.ToLower(entry.Response.MIMEType)
+	for _, m := range p.MIMESubstrings {
+		if strings.Contains(mime, m) {
+			return true
+		}
+	}
+	for _, rt := range p.ResourceTypes {
+		if entry.ResourceType == rt {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedBodySize estimates a body's size before fetching it from the
+// Content-Length header or the bytes received on the wire.
+func expectedBodySize(entry *NetworkLogEntry) int64 {
+	for k, v := range entry.Response.Headers {
+		if strings.EqualFold(k, "Content-Length") {
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return n
+			}
+		}
+	}
+	if entry.Finished != nil {
+		return int64(entry.Finished.EncodedDataLength)
+	}
+	return 0
+}
+
+// captureResponseBody queues the body of a finished request for the capture
+// workers when the capture policy selects it. It runs on the page's event
+// goroutine, so it never waits for Chrome; when the queue is full the body is
+// skipped and can still be fetched on demand.
+func captureResponseBody(p *rod.Page, log *NetworkEventLog, reqID proto.NetworkRequestID) {
+	if p == nil || log == nil {
+		return
+	}
+	policy, err := activeCapturePolicy()
+	if err != nil {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "warning: body capture disabled: %v\n", err)
+		}
+		return
+	}
+	entry, ok := log.EntryByID(string(reqID))
+	if !ok || !policy.allows(entry) {
+		return
+	}
+	bodyCaptureOnce.Do(startBodyCaptureWorkers)
+	select {
+	case bodyCaptureJobs <- bodyCaptureJob{page: p, log: log, reqID: reqID, url: entry.URL, maxBytes: policy.MaxBodyBytes}:
+	default:
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "warning: body capture queue full, skipping %s\n", entry.URL)
+		}
+	}
+}
+
+type bodyCaptureJob struct {
+	page     *rod.Page
+	log      *NetworkEventLog
+	reqID    proto.NetworkRequestID
+	url      string
+	maxBytes int64
+}
+
+var (
+	bodyCaptureOnce sync.Once
+	bodyCaptureJobs chan bodyCaptureJob
+)
+
+func startBodyCaptureWorkers() {
+	bodyCaptureJobs = make(chan bodyCaptureJob, bodyCaptureQueueSize)
+	for i := 0; i < bodyCaptureWorkers; i++ {
+		go func() {
+			for job := range bodyCaptureJobs {
+				job.run()
+			}
+		}()
+	}
+}
+
+// run fetches the body and stores it on the entry.
+func (job bodyCaptureJob) run() {
+	body, err := fetchResponseBody(job.page, job.reqID)
+	if err != nil {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "warning: capture body for %s: %v\n", job.url, err)
+		}
+		return
+	}
+	if job.maxBytes > 0 && int64(len(body)) > job.maxBytes {
+		return
+	}
+	job.log.StoreBody(job.reqID, body, false, len(body))
+}
+
+// fetchResponseBody asks Chrome for a response body and decodes it.
+func fetchResponseBody(page *rod.Page, reqID proto.NetworkRequestID) ([]byte, error) {
+	res, err := proto.NetworkGetResponseBody{RequestID: reqID}.Call(page)
+	if err != nil {
+		return nil, fmt.Errorf("get response body: %w", err)
+	}
+	if !res.Base64Encoded {
+		return []byte(res.Body), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64 body: %w", err)
+	}
+	return decoded, nil
+}
+
+// bodyMemory caps the response bodies held in memory across every network
+// log. Evicted bodies stay retrievable from the persisted store when one is
+// attached.
+var bodyMemory = &bodyBudget{}
+
+type bodyRef struct {
+	log  *NetworkEventLog
+	id   string
+	body *NetworkBody
+}
+
+type bodyBudget struct {
+	mu      sync.Mutex
+	refs    []bodyRef
+	used    int64
+	evicted int
+}
+
+// bodyMemoryLimit reads --capture-memory; 0 disables the cap.
+func bodyMemoryLimit() int64 {
+	if captureMemoryMB <= 0 {
+		return 0
+	}
+	return captureMemoryMB << 20
+}
+
+// add accounts for a newly stored body and evicts the oldest bodies until the
+// total fits the limit again. The newest body is never evicted.
+func (b *bodyBudget) add(log *NetworkEventLog, id string, body *NetworkBody) {
+	b.mu.Lock()
+	b.refs = append(b.refs, bodyRef{log: log, id: id, body: body})
+	b.used += int64(len(body.Data))
+	limit := bodyMemoryLimit()
+	var victims []bodyRef
+	for limit > 0 && b.used > limit && len(b.refs) > 1 {
+		victim := b.refs[0]
+		b.refs = b.refs[1:]
+		b.used -= int64(len(victim.body.Data))
+		b.evicted++
+		victims = append(victims, victim)
+	}
+	b.mu.Unlock()
+	for _, v := range victims {
+		v.log.dropBody(v.id, v.body)
+	}
+}
+
+// forget stops accounting for the bodies of a log that is no longer active.
+func (b *bodyBudget) forget(log *NetworkEventLog) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	kept := b.refs[:0]
+	for _, ref := range b.refs {
+		if ref.log == log {
+			b.used -= int64(len(ref.body.Data))
+			continue
+		}
+		kept = append(kept, ref)
+	}
+	for i := len(kept); i < len(b.refs); i++ {
+		b.refs[i] = bodyRef{}
+	}
+	b.refs = kept
+}
+
+// stats reports how many bodies and bytes are held and how many were evicted.
+func (b *bodyBudget) stats() (bodies int, bytes int64, evicted int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.refs), b.used, b.evicted
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestNetworkCapturePolicyAllows(t *testing.T) {
+	policy := NetworkCapturePolicy{
+		Enabled:        true,
+		MIMESubstrings: []string{"json"},
+		ResourceTypes:  []proto.NetworkResourceType{proto.NetworkResourceTypeMedia},
+		Domains:        []string{"example.com"},
+		MaxBodyBytes:   1000,
+	}
+	entry := func(rawURL, mime string, rt proto.NetworkResourceType, status int, headers map[string]string) *NetworkLogEntry {
+		return &NetworkLogEntry{
+			URL:          rawURL,
+			Method:       "GET",
+			ResourceType: rt,
+			Response:     &NetworkResponseInfo{Status: status, MIMEType: mime, Headers: headers},
+			Finished:     &NetworkFinishedInfo{EncodedDataLength: 10},
+		}
+	}
+	cases := []struct {
+		name  string
+		entry *NetworkLogEntry
+		want  bool
+	}{
+		{"json api", entry("https://api.example.com/v1", "application/json", proto.NetworkResourceTypeFetch, 200, nil), true},
+		{"media by type", entry("https://cdn.example.com/a.bin", "application/octet-stream", proto.NetworkResourceTypeMedia, 206, nil), true},
+		{"other domain", entry("https://tracker.test/v1", "application/json", proto.NetworkResourceTypeFetch, 200, nil), false},
+		{"unmatched type", entry("https://example.com/app.js", "text/javascript", proto.NetworkResourceTypeScript, 200, nil), false},
+		{"redirect", entry("https://example.com/r", "application/json", proto.NetworkResourceTypeFetch, 302, nil), false},
+		{"too large", entry("https://example.com/big", "application/json", proto.NetworkResourceTypeFetch, 200, map[string]string{"content-length": "5000"}), false},
+	}
+	for _, tc := range cases {
+		if got := policy.allows(tc.entry); got != tc.want {
+			t.Fatalf("%s: allows() = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+	policy.Enabled = false
+	if policy.allows(cases[0].entry) {
+		t.Fatal("disabled policy must not capture")
+	}
+
+	// The defaults leave audio and video alone.
+	defaults, err := parseResourceTypes(defaultCaptureTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy = NetworkCapturePolicy{Enabled: true, MIMESubstrings: defaultCaptureMIMEs, ResourceTypes: defaults}
+	if policy.allows(entry("https://cdn.example.com/clip.mp4", "video/mp4", proto.NetworkResourceTypeMedia, 200, nil)) {
+		t.Fatal("default policy must not capture media")
+	}
+	if !policy.allows(cases[0].entry) {
+		t.Fatal("default policy must capture JSON")
+	}
+}
+
+func TestBodyMemoryEvictsOldestAndFallsBackToStore(t *testing.T) {
+	t.Setenv("RODERIK_HOME", t.TempDir())
+	netStoreMu.Lock()
+	prevStore, prevOpened, prevPersist, prevBodies := netStore, netStoreOpened, netlogPersist, netlogPersistBodies
+	netStore, netStoreOpened, netlogPersist, netlogPersistBodies = nil, false, true, true
+	netStoreMu.Unlock()
+	prevBudget, prevMemory := bodyMemory, captureMemoryMB
+	bodyMemory, captureMemoryMB = &bodyBudget{}, 1
+	t.Cleanup(func() {
+		netStoreMu.Lock()
+		if netStore != nil {
+			netStore.Close()
+		}
+		netStore, netStoreOpened, netlogPersist, netlogPersistBodies = prevStore, prevOpened, prevPersist, prevBodies
+		netStoreMu.Unlock()
+		bodyMemory, captureMemoryMB = prevBudget, prevMemory
+	})
+
+	log := newNetworkEventLog()
+	attachNetStore(log)
+	big := strings.Repeat("a", 600<<10)
+	for _, id := range []string{"1", "2", "3"} {
+		log.RecordRequest(&proto.NetworkRequestWillBeSent{RequestID: proto.NetworkRequestID(id), Request: &proto.NetworkRequest{URL: "https://example.com/" + id, Method: "GET"}})
+		log.RecordFinished(&proto.NetworkLoadingFinished{RequestID: proto.NetworkRequestID(id)})
+		log.StoreBody(proto.NetworkRequestID(id), []byte(id+big), false, len(big)+1)
+	}
+
+	bodies, used, evicted := bodyMemory.stats()
+	if bodies != 1 || evicted != 2 || used > 1<<20 {
+		t.Fatalf("stats = %d bodies, %d bytes, %d evicted", bodies, used, evicted)
+	}
+	first, _ := log.EntryByID("1")
+	if first.Body != nil || first.BodyBlob == "" {
+		t.Fatalf("expected the oldest body evicted but still persisted: %+v", first)
+	}
+	data, err := retrieveNetworkBody(nil, first)
+	if err != nil || !strings.HasPrefix(string(data), "1aaa") {
+		t.Fatalf("retrieveNetworkBody() after eviction = %d bytes, %v", len(data), err)
+	}
+	if last, _ := log.EntryByID("3"); last.Body == nil {
+		t.Fatal("newest body must stay in memory")
+	}
+
+	setActiveEventLog(log)
+	setActiveEventLog(newNetworkEventLog())
+	if bodies, used, _ := bodyMemory.stats(); bodies != 0 || used != 0 {
+		t.Fatalf("replaced log still accounted: %d bodies, %d bytes", bodies, used)
+	}
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// networkWatchBuffer is how many completed entries a watcher may fall behind
+// before further entries are dropped for it.
+const networkWatchBuffer = 256
+
+// networkWatchers fans completed entries out to live subscribers (netlog
+// --follow and MCP network_watch). Entries are published once they finish or
+// fail, independent of which page's log recorded them.
+var networkWatchers = &networkWatchHub{watches: make(map[int]*networkWatch)}
+
+type networkWatchHub struct {
+	mu      sync.Mutex
+	next    int
+	watches map[int]*networkWatch
+}
+
+// networkWatch is one subscription. Entries arrive on C until the watch is
+// cancelled, which closes C.
+type networkWatch struct {
+	ID     int
+	Filter NetworkLogFilter
+	// Scope is the browser scope whose traffic the watch sees; empty for
+	// the shared page.
+	Scope string
+	C     <-chan *NetworkLogEntry
+
+	ch      chan *NetworkLogEntry
+	dropped int
+}
+
+// watch subscribes to completed entries of scope matching filter, with the
+// same semantics as netlog/network_list filtering.
+func (h *networkWatchHub) watch(filter NetworkLogFilter, scope string) *networkWatch {
+	ch := make(chan *NetworkLogEntry, networkWatchBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.next++
+	w := &networkWatch{ID: h.next, Filter: filter, Scope: scope, C: ch, ch: ch}
+	h.watches[w.ID] = w
+	return w
+}
+
+// cancel ends a watch and closes its channel. It reports whether the watch
+// was active.
+func (h *networkWatchHub) cancel(id int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w, ok := h.watches[id]
+	if !ok {
+		return false
+	}
+	delete(h.watches, id)
+	close(w.ch)
+	return true
+}
+
+func (h *networkWatchHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.watches) > 0
+}
+
+// dropped reports how many entries a watch missed because it fell behind.
+func (h *networkWatchHub) dropped(id int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if w, ok := h.watches[id]; ok {
+		return w.dropped
+	}
+	return 0
+}
+
+// publish delivers entry, recorded in scope, to every matching watch without
+// blocking.
+func (h *networkWatchHub) publish(entry *NetworkLogEntry, scope string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, w := range h.watches {
+		if w.Scope != scope || len(filterNetworkEntries([]*NetworkLogEntry{entry}, w.Filter)) == 0 {
+			continue
+		}
+		select {
+		case w.ch <- entry:
+		default:
+			w.dropped++
+		}
+	}
+}
+
+// publishNetworkEntry hands a just-completed entry of log to the watchers.
+func publishNetworkEntry(log *NetworkEventLog, reqID string) {
+	if log == nil || !networkWatchers.active() {
+		return
+	}
+	if entry, ok := log.EntryByID(reqID); ok {
+		networkWatchers.publish(entry, eventLogScope(log))
+	}
+}
+
+// networkFollowRecord is the NDJSON form of a completed entry, shared by
+// netlog --follow --ndjson and MCP watch notifications.
+type networkFollowRecord struct {
+	Time         time.Time `json:"time"`
+	RequestID    string    `json:"request_id"`
+	Method       string    `json:"method"`
+	URL          string    `json:"url"`
+	Status       int       `json:"status,omitempty"`
+	Size         int64     `json:"size,omitempty"`
+	DurationMS   int64     `json:"duration_ms,omitempty"`
+	ResourceType string    `json:"resource_type,omitempty"`
+	MIMEType     string    `json:"mime_type,omitempty"`
+	Failure      string    `json:"failure,omitempty"`
+	GraphQLOp    string    `json:"graphql_op,omitempty"`
+}
+
+func newNetworkFollowRecord(entry *NetworkLogEntry) networkFollowRecord {
+	rec := networkFollowRecord{
+		Time:         entry.RequestTimestamp,
+		RequestID:    entry.RequestID,
+		Method:       entry.Method,
+		URL:          entry.URL,
+		ResourceType: string(entry.ResourceType),
+		GraphQLOp:    entry.GraphQLOperation,
+	}
+	if r := entry.Response; r != nil {
+		rec.Status = r.Status
+		rec.MIMEType = r.MIMEType
+	}
+	var done time.Time
+	if f := entry.Finished; f != nil {
+		rec.Size = int64(f.EncodedDataLength)
+		done = f.FinishedTimestamp
+	}
+	if f := entry.Failure; f != nil {
+		rec.Failure = f.ErrorText
+		done = f.FailureTimestamp
+	}
+	if !done.IsZero() && !entry.RequestTimestamp.IsZero() {
+		rec.DurationMS = done.Sub(entry.RequestTimestamp).Milliseconds()
+	}
+	return rec
+}
+
+// formatFollowLine renders one completed entry as fixed-width columns:
+// time, status, size, duration, type, method and URL.
+func formatFollowLine(entry *NetworkLogEntry) string {
+	rec := newNetworkFollowRecord(entry)
+	status := "-"
+	switch {
+	case rec.Failure != "":
+		status = "ERR"
+	case rec.Status != 0:
+		status = fmt.Sprintf("%d", rec.Status)
+	}
+	size := "-"
+	if rec.Size > 0 {
+		size = formatByteSize(rec.Size)
+	}
+	duration := "-"
+	if rec.DurationMS > 0 {
+		duration = fmt.Sprintf("%dms", rec.DurationMS)
+	}
+	typ := rec.ResourceType
+	if typ == "" {
+		typ = "-"
+	}
+	stamp := rec.Time
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	line := fmt.Sprintf("%s  %-4s %10s %8s  %-10s %-6s %s", stamp.Local().Format("15:04:05.000"), status, size, duration, typ, rec.Method, netlogURLColumn(entry))
+	if rec.Failure != "" {
+		line += "  (" + rec.Failure + ")"
+	}
+	return line
+}
+
+// followNetwork prints entries matching filter as they complete until ctx
+// is done.
+func followNetwork(ctx context.Context, w io.Writer, filter NetworkLogFilter, ndjson bool) error {
+	watch := networkWatchers.watch(filter, "")
+	defer networkWatchers.cancel(watch.ID)
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry, ok := <-watch.C:
+			if !ok {
+				return nil
+			}
+			if ndjson {
+				if err := enc.Encode(newNetworkFollowRecord(entry)); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := fmt.Fprintln(w, formatFollowLine(entry)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// runNetlogFollow streams to stdout until interrupted.
+func runNetlogFollow(filter NetworkLogFilter, ndjson bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if !ndjson {
+		fmt.Fprintln(os.Stderr, "following network activity; press Ctrl-C to stop")
+	}
+	return followNetwork(ctx, os.Stdout, filter, ndjson)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// syncBuffer guards a bytes.Buffer written by followNetwork's goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func completeTestRequest(log *NetworkEventLog, id, rawURL string, rt proto.NetworkResourceType, status int) {
+	log.RecordRequest(&proto.NetworkRequestWillBeSent{RequestID: proto.NetworkRequestID(id), Type: rt, Request: &proto.NetworkRequest{URL: rawURL, Method: "GET"}})
+	log.RecordResponse(&proto.NetworkResponseReceived{RequestID: proto.NetworkRequestID(id), Type: rt, Response: &proto.NetworkResponse{URL: rawURL, Status: status, MIMEType: "application/json"}})
+	recordNetworkFinishedFor(log, &proto.NetworkLoadingFinished{RequestID: proto.NetworkRequestID(id), EncodedDataLength: 2048})
+}
+
+// recordNetworkFinishedFor mirrors recordNetworkFinished for a specific log.
+func recordNetworkFinishedFor(log *NetworkEventLog, e *proto.NetworkLoadingFinished) {
+	log.RecordFinished(e)
+	publishNetworkEntry(log, string(e.RequestID))
+}
+
+func waitForOutput(t *testing.T, out *syncBuffer, want string) string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s := out.String(); strings.Contains(s, want) {
+			return s
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %q in:\n%s", want, out.String())
+	return ""
+}
+
+func TestFollowNetworkStreamsMatchingEntries(t *testing.T) {
+	log := newNetworkEventLog()
+	filter := NetworkLogFilter{ResourceTypes: []proto.NetworkResourceType{proto.NetworkResourceTypeXHR}}
+
+	for _, ndjson := range []bool{false, true} {
+		out := &syncBuffer{}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- followNetwork(ctx, out, filter, ndjson) }()
+		for !networkWatchers.active() {
+			time.Sleep(time.Millisecond)
+		}
+
+		completeTestRequest(log, "img", "https://example.com/a.png", proto.NetworkResourceTypeImage, 200)
+		completeTestRequest(log, "api", "https://example.com/api", proto.NetworkResourceTypeXHR, 201)
+		got := waitForOutput(t, out, "https://example.com/api")
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("followNetwork() error = %v", err)
+		}
+		if strings.Contains(got, "a.png") {
+			t.Fatalf("filtered entry streamed:\n%s", got)
+		}
+		if ndjson {
+			var rec networkFollowRecord
+			if err := json.Unmarshal([]byte(strings.TrimSpace(got)), &rec); err != nil {
+				t.Fatalf("invalid NDJSON %q: %v", got, err)
+			}
+			if rec.RequestID != "api" || rec.Status != 201 || rec.Size != 2048 || rec.ResourceType != "XHR" {
+				t.Fatalf("unexpected record: %+v", rec)
+			}
+		} else if !strings.Contains(got, "201") || !strings.Contains(got, "2.0 KiB") || !strings.Contains(got, "XHR") {
+			t.Fatalf("unexpected follow line: %q", got)
+		}
+	}
+	if networkWatchers.active() {
+		t.Fatal("watch not cancelled after followNetwork returned")
+	}
+}
+
+func TestMCPNetworkWatchBuffersEntries(t *testing.T) {
+	log := newNetworkEventLog()
+	mw := startMCPNetworkWatch(nil, NetworkLogFilter{TextContains: []string{"/api"}}, "", "", false)
+	t.Cleanup(func() { stopMCPNetworkWatch(mw.watch.ID) })
+
+	completeTestRequest(log, "doc", "https://example.com/", proto.NetworkResourceTypeDocument, 200)
+	completeTestRequest(log, "api", "https://example.com/api/items", proto.NetworkResourceTypeFetch, 200)
+
+	var data string
+	deadline := time.Now().Add(2 * time.Second)
+	for data == "" && time.Now().Before(deadline) {
+		var err error
+		if data, err = drainMCPNetworkWatch(mw.watch.ID); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(data, `"request_id":"api"`) || strings.Contains(data, `"request_id":"doc"`) {
+		t.Fatalf("unexpected watch contents: %q", data)
+	}
+	if again, _ := drainMCPNetworkWatch(mw.watch.ID); again != "" {
+		t.Fatalf("drain did not clear the buffer: %q", again)
+	}
+	if !stopMCPNetworkWatch(mw.watch.ID) || stopMCPNetworkWatch(mw.watch.ID) {
+		t.Fatal("stopMCPNetworkWatch should succeed exactly once")
+	}
+	if _, err := drainMCPNetworkWatch(mw.watch.ID); err == nil {
+		t.Fatal("reading a stopped watch should fail")
+	}
+}
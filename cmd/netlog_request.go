@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// inlinePostData returns the request body Chrome sent with the request event,
+// preferring the byte-exact postDataEntries over the deprecated postData.
+func inlinePostData(req *proto.NetworkRequest) []byte {
+	if req == nil {
+		return nil
+	}
+	if len(req.PostDataEntries) > 0 {
+		var buf bytes.Buffer
+		for _, part := range req.PostDataEntries {
+			if part != nil {
+				buf.Write(part.Bytes)
+			}
+		}
+		return buf.Bytes()
+	}
+	if req.PostData != "" {
+		return []byte(req.PostData)
+	}
+	return nil
+}
+
+// capturePostData fetches a request body that was too large to be inlined in
+// Network.requestWillBeSent.
+func capturePostData(p *rod.Page, log *NetworkEventLog, reqID proto.NetworkRequestID) {
+	if p == nil || log == nil {
+		return
+	}
+	res, err := proto.NetworkGetRequestPostData{RequestID: reqID}.Call(p)
+	if err != nil {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "warning: get post data for %s: %v\n", reqID, err)
+		}
+		return
+	}
+	log.StorePostData(reqID, []byte(res.PostData))
+}
+
+var graphQLQueryNameRe = regexp.MustCompile(`\b(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// graphQLOperationName extracts the operation name from a GraphQL request:
+// a JSON body (single or batched) with operationName/query, a form body
+// (including Facebook's fb_api_req_friendly_name) or GET query parameters.
+// Batched operations are joined with commas; "" means not GraphQL.
+func graphQLOperationName(rawURL string, body []byte) string {
+	var names []string
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case len(trimmed) == 0:
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		var payloads []map[string]interface{}
+		if trimmed[0] == '[' {
+			_ = json.Unmarshal(trimmed, &payloads)
+		} else {
+			var one map[string]interface{}
+			if json.Unmarshal(trimmed, &one) == nil {
+				payloads = append(payloads, one)
+			}
+		}
+		for _, p := range payloads {
+			name, _ := p["operationName"].(string)
+			query, _ := p["query"].(string)
+			if n := graphQLNameFromFields(name, query); n != "" {
+				names = append(names, n)
+			}
+		}
+	default:
+		if values, err := url.ParseQuery(string(trimmed)); err == nil {
+			n := strings.TrimSpace(values.Get("fb_api_req_friendly_name"))
+			if n == "" {
+				n = graphQLNameFromFields(values.Get("operationName"), values.Get("query"))
+			}
+			if n != "" {
+				names = append(names, n)
+			}
+		}
+	}
+	if len(names) == 0 {
+		if u, err := url.Parse(rawURL); err == nil {
+			q := u.Query()
+			if n := graphQLNameFromFields(q.Get("operationName"), q.Get("query")); n != "" {
+				names = append(names, n)
+			}
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func graphQLNameFromFields(operationName, query string) string {
+	if name := strings.TrimSpace(operationName); name != "" {
+		return name
+	}
+	if m := graphQLQueryNameRe.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// formatBodyForDisplay renders a body for reading: JSON is indented, form
+// bodies are listed one field per line (with JSON values indented), other
+// text is returned as is and binary data is summarised.
+func formatBodyForDisplay(data []byte, contentType string) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		var out bytes.Buffer
+		if json.Indent(&out, trimmed, "", "  ") == nil {
+			return out.String()
+		}
+	}
+	if strings.Contains(strings.ToLower(contentType), "x-www-form-urlencoded") {
+		if formatted, ok := formatFormBody(string(trimmed)); ok {
+			return formatted
+		}
+	}
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("(%d bytes of binary data)", len(data))
+	}
+	return string(data)
+}
+
+func formatFormBody(body string) (string, bool) {
+	var b strings.Builder
+	for i, pair := range strings.Split(body, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		k, err := url.QueryUnescape(key)
+		if err != nil {
+			return "", false
+		}
+		v, err := url.QueryUnescape(value)
+		if err != nil {
+			return "", false
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		trimmed := strings.TrimSpace(v)
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+			var out bytes.Buffer
+			if json.Indent(&out, []byte(trimmed), "  ", "  ") == nil {
+				v = out.String()
+			}
+		}
+		fmt.Fprintf(&b, "%s = %s", k, v)
+	}
+	return b.String(), true
+}
+
+// headerValue looks up a header case-insensitively.
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// networkDetail is one entry plus the outcome of looking up its response
+// body.
+type networkDetail struct {
+	Entry   *NetworkLogEntry
+	Body    []byte
+	BodyErr error
+}
+
+// formatNetworkDetail describes an entry with its headers and bodies. Bodies
+// are cut to maxChars characters when maxChars > 0.
+func formatNetworkDetail(d networkDetail, maxChars int) string {
+	entry := d.Entry
+	var b strings.Builder
+	fmt.Fprintf(&b, "request %s", entry.RequestID)
+	if entry.SessionID != "" {
+		fmt.Fprintf(&b, " (session %s, navigation %d)", entry.SessionID, entry.NavigationID)
+	}
+	fmt.Fprintf(&b, "\n%s %s\n", entry.Method, entry.URL)
+	if entry.ResourceType != "" {
+		fmt.Fprintf(&b, "type: %s\n", entry.ResourceType)
+	}
+	if entry.GraphQLOperation != "" {
+		fmt.Fprintf(&b, "graphql operation: %s\n", entry.GraphQLOperation)
+	}
+	if !entry.RequestTimestamp.IsZero() {
+		fmt.Fprintf(&b, "sent: %s\n", entry.RequestTimestamp.Local().Format("2006-01-02 15:04:05.000"))
+	}
+	if r := entry.Response; r != nil {
+		fmt.Fprintf(&b, "status: %s\n", strings.TrimSpace(fmt.Sprintf("%d %s", r.Status, r.StatusText)))
+		if r.MIMEType != "" {
+			fmt.Fprintf(&b, "mime: %s\n", r.MIMEType)
+		}
+	}
+	if f := entry.Failure; f != nil {
+		fmt.Fprintf(&b, "failed: %s\n", f.ErrorText)
+	}
+
+	writeHeaders(&b, "request headers", entry.RequestHeaders)
+	switch {
+	case len(entry.PostData) > 0:
+		fmt.Fprintf(&b, "\nrequest body (%d bytes):\n", len(entry.PostData))
+		b.WriteString(truncateDetailBody(formatBodyForDisplay(entry.PostData, headerValue(entry.RequestHeaders, "Content-Type")), maxChars))
+		b.WriteString("\n")
+	case entry.HasPostData:
+		b.WriteString("\nrequest body: (not captured)\n")
+	}
+
+	if entry.Response != nil {
+		writeHeaders(&b, "response headers", entry.Response.Headers)
+		switch {
+		case d.BodyErr != nil:
+			fmt.Fprintf(&b, "\nresponse body: unavailable (%v)\n", d.BodyErr)
+		case d.Body != nil:
+			fmt.Fprintf(&b, "\nresponse body (%d bytes):\n", len(d.Body))
+			b.WriteString(truncateDetailBody(formatBodyForDisplay(d.Body, entry.Response.MIMEType), maxChars))
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func writeHeaders(b *strings.Builder, title string, headers map[string]string) {
+	if len(headers) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return strings.ToLower(keys[i]) < strings.ToLower(keys[j]) })
+	fmt.Fprintf(b, "\n%s:\n", title)
+	for _, k := range keys {
+		fmt.Fprintf(b, "  %s: %s\n", k, headers[k])
+	}
+}
+
+func truncateDetailBody(s string, maxChars int) string {
+	if maxChars <= 0 {
+		return s
+	}
+	return truncateContextText(s, maxChars)
+}
+
+// lookupNetworkDetail finds a request in the active log, or in the persisted
+// log when session is set, and (with withBody) its response body.
+func lookupNetworkDetail(ctx context.Context, reqID, session string, withBody bool) (networkDetail, error) {
+	var d networkDetail
+	if session != "" {
+		stored, err := storedNetworkEntries(session, time.Time{})
+		if err != nil {
+			return d, err
+		}
+		for _, candidate := range stored {
+			if candidate.RequestID == reqID {
+				d.Entry = candidate
+			}
+		}
+		if d.Entry == nil {
+			return d, fmt.Errorf("request %s not found in session %s", reqID, session)
+		}
+		if withBody && d.Entry.Response != nil {
+			if d.Entry.BodyBlob == "" {
+				d.BodyErr = fmt.Errorf("body was not persisted")
+			} else {
+				d.Body, d.BodyErr = storedNetworkBody(d.Entry)
+			}
+		}
+		return d, nil
+	}
+
+	log := scopedEventLog(ctx)
+	if log == nil {
+		return d, fmt.Errorf("no active network log; load a page first")
+	}
+	entry, ok := log.EntryByID(reqID)
+	if !ok {
+		return d, fmt.Errorf("request %s not found", reqID)
+	}
+	d.Entry = entry
+	if withBody && entry.Response != nil && entry.Failure == nil {
+		d.Body, d.BodyErr = fetchPageBody(ctx, entry)
+	}
+	return d, nil
+}
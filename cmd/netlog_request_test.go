@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+func TestGraphQLOperationName(t *testing.T) {
+	cases := []struct {
+		name, url, body, want string
+	}{
+		{"json operationName", "https://api.test/graphql", `{"operationName":"GetThread","variables":{}}`, "GetThread"},
+		{"json query only", "https://api.test/graphql", `{"query":"# comment\nquery  ListMessages($id: ID!) { x }"}`, "ListMessages"},
+		{"batched", "https://api.test/graphql", `[{"operationName":"A"},{"query":"mutation B { y }"}]`, "A,B"},
+		{"facebook form", "https://www.facebook.com/api/graphql/", "av=1&fb_api_req_friendly_name=MWChatVoiceQuery&variables=%7B%7D&doc_id=123", "MWChatVoiceQuery"},
+		{"get params", "https://api.test/graphql?operationName=Viewer&variables=%7B%7D", "", "Viewer"},
+		{"not graphql", "https://example.com/form", "name=alice&age=3", ""},
+	}
+	for _, tc := range cases {
+		if got := graphQLOperationName(tc.url, []byte(tc.body)); got != tc.want {
+			t.Fatalf("%s: graphQLOperationName() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFormatBodyForDisplay(t *testing.T) {
+	if got := formatBodyForDisplay([]byte(`{"a":[1,2]}`), "application/json"); got != "{\n  \"a\": [\n    1,\n    2\n  ]\n}" {
+		t.Fatalf("json body = %q", got)
+	}
+	got := formatBodyForDisplay([]byte("doc_id=1&variables=%7B%22id%22%3A7%7D"), "application/x-www-form-urlencoded")
+	if got != "doc_id = 1\nvariables = {\n    \"id\": 7\n  }" {
+		t.Fatalf("form body = %q", got)
+	}
+	if got := formatBodyForDisplay([]byte{0xff, 0xfe, 0x00}, ""); got != "(3 bytes of binary data)" {
+		t.Fatalf("binary body = %q", got)
+	}
+}
+
+func TestNetworkGetAndGraphQLFilter(t *testing.T) {
+	prev := getActiveEventLog()
+	t.Cleanup(func() { setActiveEventLog(prev) })
+
+	log := newNetworkEventLog()
+	log.RecordRequest(&proto.NetworkRequestWillBeSent{
+		RequestID: "7.1",
+		Type:      proto.NetworkResourceTypeXHR,
+		Request: &proto.NetworkRequest{
+			URL:             "https://www.facebook.com/api/graphql/",
+			Method:          "POST",
+			Headers:         proto.NetworkHeaders{"Content-Type": gson.New("application/x-www-form-urlencoded")},
+			HasPostData:     true,
+			PostDataEntries: []*proto.NetworkPostDataEntry{{Bytes: []byte("fb_api_req_friendly_name=")}, {Bytes: []byte("ThreadQuery&doc_id=9")}},
+		},
+	})
+	log.RecordRequest(&proto.NetworkRequestWillBeSent{RequestID: "7.2", Request: &proto.NetworkRequest{URL: "https://www.facebook.com/favicon.ico", Method: "GET"}})
+	log.RecordResponse(&proto.NetworkResponseReceived{RequestID: "7.1", Type: proto.NetworkResourceTypeXHR, Response: &proto.NetworkResponse{Status: 200, MIMEType: "application/json"}})
+	log.StoreBody("7.1", []byte(`{"data":{"ok":true}}`), false, 20)
+	setActiveEventLog(log)
+
+	matched := log.FilterEntries(NetworkLogFilter{GraphQLOps: []string{"thread"}})
+	if len(matched) != 1 || matched[0].RequestID != "7.1" || matched[0].GraphQLOperation != "ThreadQuery" {
+		t.Fatalf("graphql filter matched %+v", matched)
+	}
+
+	res, err := networkGetHandler(context.Background(), map[string]interface{}{"request_id": "7.1"})
+	if err != nil {
+		t.Fatalf("networkGetHandler() error = %v", err)
+	}
+	for _, want := range []string{
+		"POST https://www.facebook.com/api/graphql/",
+		"graphql operation: ThreadQuery",
+		"request body (45 bytes):\nfb_api_req_friendly_name = ThreadQuery\ndoc_id = 9",
+		"response body (20 bytes):\n{\n  \"data\": {\n    \"ok\": true\n  }\n}",
+	} {
+		if !strings.Contains(res.Text, want) {
+			t.Fatalf("network_get output missing %q:\n%s", want, res.Text)
+		}
+	}
+	if _, err := networkGetHandler(context.Background(), map[string]interface{}{"request_id": "nope"}); err == nil {
+		t.Fatal("expected an error for an unknown request")
+	}
+}
@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"roderik/internal/netstore"
+)
+
+// netlogSessionCurrent and netlogSessionLatest are accepted wherever a
+// persisted session ID is expected.
+const (
+	netlogSessionCurrent = "current"
+	netlogSessionLatest  = "latest"
+)
+
+// netlogRedacted replaces credentials in persisted headers.
+const netlogRedacted = "[redacted]"
+
+// netlogCredentialHeaders are never written to disk; the live log keeps them.
+var netlogCredentialHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+	"x-auth-token":        true,
+	"x-csrf-token":        true,
+	"x-xsrf-token":        true,
+}
+
+var (
+	netlogPersist       bool
+	netlogPersistBodies bool
+	netlogMaxAge        time.Duration
+	netlogMaxSizeMB     int64
+
+	netStoreMu     sync.Mutex
+	netStoreOpened bool
+	netStore       *netstore.Store
+)
+
+func init() {
+	RootCmd.PersistentFlags().BoolVar(&netlogPersist, "netlog-persist", false, "Keep captured network activity on disk under <base>/netlog across navigations and runs (credential headers are redacted)")
+	RootCmd.PersistentFlags().BoolVar(&netlogPersistBodies, "netlog-persist-bodies", false, "With --netlog-persist, also keep captured bodies on disk; they may contain credentials")
+	RootCmd.PersistentFlags().DurationVar(&netlogMaxAge, "netlog-max-age", netstore.DefaultMaxAge, "Drop persisted network log segments older than this (negative disables)")
+	RootCmd.PersistentFlags().Int64Var(&netlogMaxSizeMB, "netlog-max-size", netstore.DefaultMaxBytes>>20, "Cap the persisted network log, bodies included, at this many MiB (negative disables)")
+}
+
+// activeNetStore opens the on-disk network log on first use. It returns nil
+// when persistence is disabled or the store cannot be opened; the failure is
+// reported once.
+func activeNetStore() *netstore.Store {
+	netStoreMu.Lock()
+	defer netStoreMu.Unlock()
+	if netStoreOpened {
+		return netStore
+	}
+	netStoreOpened = true
+	if !netlogPersist {
+		return nil
+	}
+	maxBytes := netlogMaxSizeMB
+	if maxBytes > 0 {
+		maxBytes <<= 20
+	}
+	store, err := netstore.Open(netstore.DefaultDir(), netstore.Options{MaxAge: netlogMaxAge, MaxBytes: maxBytes})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: network log will not be persisted: %v\n", err)
+		return nil
+	}
+	netStore = store
+	return netStore
+}
+
+// attachNetStore makes log persist its entries as a new navigation of the
+// current session.
+func attachNetStore(log *NetworkEventLog) {
+	store := activeNetStore()
+	if log == nil || store == nil {
+		return
+	}
+	nav := store.NextNavigation()
+	log.mu.Lock()
+	log.store, log.navigation = store, nav
+	log.mu.Unlock()
+}
+
+// persistEntry snapshots entry for the store and returns the write, which
+// callers run after releasing l.mu so events and readers never wait on the
+// disk. Callers hold l.mu.
+func (l *NetworkEventLog) persistEntry(entry *NetworkLogEntry) func() {
+	store := l.store
+	if store == nil {
+		return func() {}
+	}
+	rec := networkRecordFromEntry(entry, l.navigation)
+	return func() {
+		if err := store.Append(rec); err != nil && Verbose {
+			fmt.Fprintf(os.Stderr, "warning: persist network entry %s: %v\n", rec.RequestID, err)
+		}
+	}
+}
+
+// persistBody returns the write that stores a retrieved body for entry and
+// remembers the blob so the body stays available after it is evicted from
+// memory. Bodies are only persisted with --netlog-persist-bodies. Callers
+// hold l.mu and run the write after releasing it.
+func (l *NetworkEventLog) persistBody(entry *NetworkLogEntry, data []byte) func() {
+	store := l.store
+	if store == nil || !netlogPersistBodies {
+		return func() {}
+	}
+	rec := netstore.Record{Navigation: l.navigation, RequestID: entry.RequestID}
+	return func() {
+		name, err := store.AppendBody(rec, data)
+		if err != nil {
+			if Verbose {
+				fmt.Fprintf(os.Stderr, "warning: persist network body %s: %v\n", rec.RequestID, err)
+			}
+			return
+		}
+		l.mu.Lock()
+		entry.BodyBlob = name
+		l.mu.Unlock()
+	}
+}
+
+func networkRecordFromEntry(entry *NetworkLogEntry, navigation int) netstore.Record {
+	rec := netstore.Record{
+		Navigation:       navigation,
+		RequestID:        entry.RequestID,
+		Time:             entry.RequestTimestamp,
+		URL:              entry.URL,
+		Method:           entry.Method,
+		ResourceType:     string(entry.ResourceType),
+		DocumentURL:      entry.DocumentURL,
+		FrameID:          string(entry.FrameID),
+		LoaderID:         string(entry.LoaderID),
+		InitiatorType:    entry.InitiatorType,
+		RequestHeaders:   redactCredentialHeaders(entry.RequestHeaders),
+		HasPostData:      entry.HasPostData,
+		GraphQLOperation: entry.GraphQLOperation,
+	}
+	if netlogPersistBodies {
+		rec.PostData = redactPostData(entry.PostData, headerValue(entry.RequestHeaders, "Content-Type"))
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if r := entry.Response; r != nil {
+		rec.Status = r.Status
+		rec.StatusText = r.StatusText
+		rec.MIMEType = r.MIMEType
+		rec.ResponseHeaders = redactCredentialHeaders(r.Headers)
+		rec.EncodedDataLength = r.EncodedDataLength
+		rec.FromCache = r.FromDiskCache || r.FromPrefetchCache
+	}
+	if f := entry.Finished; f != nil {
+		rec.Done = f.FinishedTimestamp
+		if f.EncodedDataLength > 0 {
+			rec.EncodedDataLength = f.EncodedDataLength
+		}
+	}
+	if f := entry.Failure; f != nil {
+		rec.Failure = f.ErrorText
+		rec.Canceled = f.Canceled
+		rec.Done = f.FailureTimestamp
+	}
+	return rec
+}
+
+// redactCredentialHeaders returns headers with the values of credential
+// headers replaced, leaving the map of the live entry untouched.
+func redactCredentialHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if netlogCredentialHeaders[strings.ToLower(k)] {
+			v = netlogRedacted
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// netlogSensitiveField matches form and JSON field names whose values are
+// masked in persisted request bodies.
+var netlogSensitiveField = regexp.MustCompile(`(?i)pass(word|wd|code)?$|^pwd$|secret|token|api[_-]?key|credential|session|cookie|^auth|otp$|^pin$|cvc|cvv`)
+
+// redactPostData returns a copy of a request body with the values of
+// sensitive fields masked. Form and JSON bodies are rewritten, GraphQL
+// variables included; other bodies are kept as sent.
+func redactPostData(data []byte, contentType string) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	mime := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(mime, "x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return data
+		}
+		changed := false
+		for k, vs := range values {
+			if netlogSensitiveField.MatchString(k) {
+				for i := range vs {
+					vs[i] = netlogRedacted
+				}
+				changed = true
+			} else if masked, ok := redactJSONFields([]byte(vs[0])); len(vs) == 1 && ok {
+				// Form bodies often carry JSON, e.g. GraphQL variables.
+				vs[0] = string(masked)
+				changed = true
+			}
+		}
+		if !changed {
+			return data
+		}
+		return []byte(values.Encode())
+	case strings.Contains(mime, "json") || json.Valid(data):
+		if masked, ok := redactJSONFields(data); ok {
+			return masked
+		}
+	}
+	return data
+}
+
+// redactJSONFields masks sensitive object fields at any depth of a JSON
+// document. ok is false when data is not JSON or nothing was masked.
+func redactJSONFields(data []byte) ([]byte, bool) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false
+	}
+	var walk func(v interface{}) bool
+	walk = func(v interface{}) bool {
+		changed := false
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, child := range t {
+				if netlogSensitiveField.MatchString(k) {
+					t[k] = netlogRedacted
+					changed = true
+				} else if walk(child) {
+					changed = true
+				}
+			}
+		case []interface{}:
+			for _, child := range t {
+				if walk(child) {
+					changed = true
+				}
+			}
+		}
+		return changed
+	}
+	if !walk(doc) {
+		return nil, false
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func networkEntryFromRecord(rec netstore.Record) *NetworkLogEntry {
+	entry := &NetworkLogEntry{
+		RequestID:        rec.RequestID,
+		ProtoRequestID:   proto.NetworkRequestID(rec.RequestID),
+		URL:              rec.URL,
+		Method:           rec.Method,
+		ResourceType:     proto.NetworkResourceType(rec.ResourceType),
+		DocumentURL:      rec.DocumentURL,
+		FrameID:          proto.PageFrameID(rec.FrameID),
+		LoaderID:         proto.NetworkLoaderID(rec.LoaderID),
+		InitiatorType:    rec.InitiatorType,
+		RequestHeaders:   rec.RequestHeaders,
+		RequestTimestamp: rec.Time,
+		HasPostData:      rec.HasPostData,
+		PostData:         rec.PostData,
+		GraphQLOperation: rec.GraphQLOperation,
+		SessionID:        rec.Session,
+		NavigationID:     rec.Navigation,
+		BodyBlob:         rec.Body,
+	}
+	if rec.Status != 0 || rec.MIMEType != "" {
+		entry.Response = &NetworkResponseInfo{
+			Status:            rec.Status,
+			StatusText:        rec.StatusText,
+			MIMEType:          rec.MIMEType,
+			Headers:           rec.ResponseHeaders,
+			EncodedDataLength: rec.EncodedDataLength,
+			FromDiskCache:     rec.FromCache,
+		}
+	}
+	if rec.Failure != "" || rec.Canceled {
+		entry.Failure = &NetworkFailureInfo{
+			ErrorText:        rec.Failure,
+			Canceled:         rec.Canceled,
+			ResourceType:     entry.ResourceType,
+			FailureTimestamp: rec.Done,
+		}
+	} else if !rec.Done.IsZero() {
+		entry.Finished = &NetworkFinishedInfo{EncodedDataLength: rec.EncodedDataLength, FinishedTimestamp: rec.Done}
+	}
+	return entry
+}
+
+// storedNetworkEntries loads persisted entries for session ("current",
+// "latest" or an ID; empty means every session) captured at or after since.
+func storedNetworkEntries(session string, since time.Time) ([]*NetworkLogEntry, error) {
+	dir := netstore.DefaultDir()
+	id, err := resolveNetlogSession(dir, session)
+	if err != nil {
+		return nil, err
+	}
+	records, err := netstore.Load(dir, netstore.Query{Session: id, Since: since})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*NetworkLogEntry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, networkEntryFromRecord(rec))
+	}
+	return entries, nil
+}
+
+func resolveNetlogSession(dir, session string) (string, error) {
+	session = strings.TrimSpace(session)
+	switch strings.ToLower(session) {
+	case "":
+		return "", nil
+	case netlogSessionCurrent:
+		store := activeNetStore()
+		if store == nil {
+			return "", fmt.Errorf("network log persistence is disabled")
+		}
+		return store.Session(), nil
+	case netlogSessionLatest:
+		sessions, err := netstore.Sessions(dir)
+		if err != nil {
+			return "", err
+		}
+		if len(sessions) == 0 {
+			return "", fmt.Errorf("no persisted network sessions in %s", dir)
+		}
+		return sessions[len(sessions)-1].ID, nil
+	}
+	return session, nil
+}
+
+// parseNetlogSince accepts a duration ("90m", "2h"), an RFC 3339 timestamp
+// or a local date/time ("2006-01-02", "2006-01-02 15:04").
+func parseNetlogSince(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 2h or a time like 2006-01-02 15:04", raw)
+}
+
+// storedNetworkBody reads the persisted body of an entry loaded from disk.
+func storedNetworkBody(entry *NetworkLogEntry) ([]byte, error) {
+	data, err := netstore.ReadBlob(netstore.DefaultDir(), entry.BodyBlob)
+	if err != nil {
+		return nil, err
+	}
+	entry.Body = &NetworkBody{Data: data, RetrievedAt: time.Now(), OriginalSize: len(data)}
+	return append([]byte(nil), data...), nil
+}
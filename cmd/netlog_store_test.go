@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestPersistedNetworkLogSurvivesNavigation(t *testing.T) {
+	t.Setenv("RODERIK_HOME", t.TempDir())
+	netStoreMu.Lock()
+	prevStore, prevOpened, prevPersist, prevBodies := netStore, netStoreOpened, netlogPersist, netlogPersistBodies
+	netStore, netStoreOpened, netlogPersist, netlogPersistBodies = nil, false, true, true
+	netStoreMu.Unlock()
+	prevLog := getActiveEventLog()
+	t.Cleanup(func() {
+		netStoreMu.Lock()
+		if netStore != nil {
+			netStore.Close()
+		}
+		netStore, netStoreOpened, netlogPersist, netlogPersistBodies = prevStore, prevOpened, prevPersist, prevBodies
+		netStoreMu.Unlock()
+		setActiveEventLog(prevLog)
+	})
+
+	navigate := func(id, rawURL string) *NetworkEventLog {
+		log := newNetworkEventLog()
+		attachNetStore(log)
+		setActiveEventLog(log)
+		reqID := proto.NetworkRequestID(id)
+		log.RecordRequest(&proto.NetworkRequestWillBeSent{RequestID: reqID, Request: &proto.NetworkRequest{URL: rawURL, Method: "GET"}, Type: proto.NetworkResourceTypeDocument})
+		log.RecordResponse(&proto.NetworkResponseReceived{RequestID: reqID, Type: proto.NetworkResourceTypeDocument, Response: &proto.NetworkResponse{URL: rawURL, Status: 200, MIMEType: "text/html"}})
+		log.RecordFinished(&proto.NetworkLoadingFinished{RequestID: reqID, EncodedDataLength: 42})
+		return log
+	}
+	first := navigate("1.1", "https://example.com/first")
+	first.StoreBody("1.1", []byte("<p>first</p>"), false, 12)
+	navigate("2.1", "https://example.com/second")
+
+	res, err := networkListHandler(context.Background(), map[string]interface{}{"session": "current"})
+	if err != nil {
+		t.Fatalf("networkListHandler() error = %v", err)
+	}
+	var payload networkListResponse
+	if err := json.Unmarshal([]byte(res.Text), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Total != 2 || payload.Entries[0].URL != "https://example.com/first" || payload.Entries[1].Navigation != 2 {
+		t.Fatalf("unexpected persisted listing %+v", payload)
+	}
+	if !payload.Entries[0].Retrieved || payload.Entries[0].Session == "" {
+		t.Fatalf("expected first entry to carry its session and stored body: %+v", payload.Entries[0])
+	}
+
+	// the in-memory log only knows about the current navigation
+	res, err = networkListHandler(context.Background(), nil)
+	if err != nil || json.Unmarshal([]byte(res.Text), &payload) != nil || payload.Total != 1 {
+		t.Fatalf("expected one live entry, got %q err=%v", res.Text, err)
+	}
+
+	res, err = networkSaveHandler(context.Background(), map[string]interface{}{"request_id": "1.1", "session": "current", "return": "binary"})
+	if err != nil {
+		t.Fatalf("networkSaveHandler() error = %v", err)
+	}
+	if string(res.Binary) != "<p>first</p>" || res.ContentType != "text/html" {
+		t.Fatalf("unexpected stored body %q (%s)", res.Binary, res.ContentType)
+	}
+
+	entries, err := storedNetworkEntries(netlogSessionLatest, time.Now().Add(-time.Minute))
+	if err != nil || len(entries) != 2 || entries[1].Finished == nil || entries[1].Response.Status != 200 {
+		t.Fatalf("storedNetworkEntries(latest) = %+v, %v", entries, err)
+	}
+}
+
+func TestParseNetlogSince(t *testing.T) {
+	now := time.Date(2024, 5, 6, 12, 0, 0, 0, time.Local)
+	cases := map[string]time.Time{
+		"":                     {},
+		"90m":                  now.Add(-90 * time.Minute),
+		"2024-05-01":           time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+		"2024-05-01 08:30":     time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local),
+		"2024-05-01T08:30:00Z": time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+	}
+	for raw, want := range cases {
+		got, err := parseNetlogSince(raw, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("parseNetlogSince(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	if _, err := parseNetlogSince("yesterday", now); err == nil {
+		t.Fatal("expected an error for an unparseable value")
+	}
+}
+
+func TestPersistedRecordRedactsCredentialHeaders(t *testing.T) {
+	entry := &NetworkLogEntry{
+		RequestID:      "1",
+		URL:            "https://example.com/api",
+		RequestHeaders: map[string]string{"Cookie": "sid=secret", "Authorization": "Bearer abc", "Accept": "application/json"},
+		Response:       &NetworkResponseInfo{Status: 200, Headers: map[string]string{"set-cookie": "sid=new", "Content-Type": "application/json"}},
+	}
+	rec := networkRecordFromEntry(entry, 1)
+	if rec.RequestHeaders["Cookie"] != netlogRedacted || rec.RequestHeaders["Authorization"] != netlogRedacted || rec.RequestHeaders["Accept"] != "application/json" {
+		t.Fatalf("request headers = %v", rec.RequestHeaders)
+	}
+	if rec.ResponseHeaders["set-cookie"] != netlogRedacted || rec.ResponseHeaders["Content-Type"] != "application/json" {
+		t.Fatalf("response headers = %v", rec.ResponseHeaders)
+	}
+	if entry.RequestHeaders["Cookie"] != "sid=secret" {
+		t.Fatal("redaction changed the live entry")
+	}
+}
+
+func TestPersistedRecordMasksRequestBodies(t *testing.T) {
+	prevBodies := netlogPersistBodies
+	t.Cleanup(func() { netlogPersistBodies = prevBodies })
+	form := &NetworkLogEntry{
+		RequestID:      "1",
+		RequestHeaders: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		HasPostData:    true,
+		PostData:       []byte(`user=ann&password=hunter2&variables=%7B%22id%22%3A1%2C%22access_token%22%3A%22t%22%7D`),
+	}
+	gql := &NetworkLogEntry{
+		RequestID:      "2",
+		RequestHeaders: map[string]string{"content-type": "application/json"},
+		HasPostData:    true,
+		PostData:       []byte(`{"operationName":"Login","variables":{"input":{"email":"a@b.c","password":"x"}}}`),
+	}
+
+	netlogPersistBodies = false
+	if rec := networkRecordFromEntry(form, 1); rec.PostData != nil || !rec.HasPostData {
+		t.Fatalf("body persisted without --netlog-persist-bodies: %q", rec.PostData)
+	}
+
+	netlogPersistBodies = true
+	values, err := url.ParseQuery(string(networkRecordFromEntry(form, 1).PostData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.Get("user") != "ann" || values.Get("password") != netlogRedacted || values.Get("variables") != `{"access_token":"[redacted]","id":1}` {
+		t.Fatalf("form body = %v", values)
+	}
+	if got := string(networkRecordFromEntry(gql, 1).PostData); got != `{"operationName":"Login","variables":{"input":{"email":"a@b.c","password":"[redacted]"}}}` {
+		t.Fatalf("JSON body = %s", got)
+	}
+	if !strings.Contains(string(form.PostData), "hunter2") {
+		t.Fatal("masking changed the live entry")
+	}
+}
@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/spf13/cobra"
+)
+
+// Frame directions and connection kinds recorded for WebSocket and
+// EventSource traffic.
+const (
+	FrameSent     = "sent"
+	FrameReceived = "received"
+	FrameEvent    = "event"
+
+	ConnWebSocket   = "websocket"
+	ConnEventSource = "eventsource"
+)
+
+// maxConnectionFrames bounds the frames kept per connection; the oldest are
+// dropped first.
+const maxConnectionFrames = 5000
+
+// NetworkFrame is one WebSocket frame or EventSource message.
+type NetworkFrame struct {
+	Direction string
+	// Opcode is the WebSocket opcode (1 text, 2 binary); 0 for EventSource.
+	Opcode int
+	// Data is the payload; binary frames are base64 encoded by Chrome.
+	Data      string
+	EventName string
+	EventID   string
+	Timestamp time.Time
+}
+
+// Binary reports whether the payload is base64-encoded binary data.
+func (f NetworkFrame) Binary() bool {
+	return f.Opcode == 2
+}
+
+// NetworkConnection groups the frames of one WebSocket or EventSource
+// connection, keyed by the request ID that opened it.
+type NetworkConnection struct {
+	RequestID string
+	URL       string
+	Kind      string
+	Created   time.Time
+	Closed    time.Time
+	Error     string
+	Frames    []NetworkFrame
+	// Dropped counts frames discarded once maxConnectionFrames was reached.
+	Dropped int
+}
+
+// Counts returns the number of frames sent and received (EventSource
+// messages count as received).
+func (c *NetworkConnection) Counts() (sent, received int) {
+	for _, f := range c.Frames {
+		if f.Direction == FrameSent {
+			sent++
+		} else {
+			received++
+		}
+	}
+	return sent, received
+}
+
+func (c *NetworkConnection) clone() *NetworkConnection {
+	cp := *c
+	cp.Frames = append([]NetworkFrame(nil), c.Frames...)
+	return &cp
+}
+
+// connection returns the connection for id, creating it when needed. Callers
+// hold l.mu.
+func (l *NetworkEventLog) connection(id, kind string) *NetworkConnection {
+	if l.conns == nil {
+		l.conns = make(map[string]*NetworkConnection)
+	}
+	conn, ok := l.conns[id]
+	if !ok {
+		conn = &NetworkConnection{RequestID: id, Kind: kind, Created: time.Now()}
+		if entry, ok := l.entries[id]; ok {
+			conn.URL = entry.URL
+		}
+		l.conns[id] = conn
+		l.connOrder = append(l.connOrder, id)
+	}
+	return conn
+}
+
+func (c *NetworkConnection) appendFrame(f NetworkFrame) {
+	if len(c.Frames) >= maxConnectionFrames {
+		drop := len(c.Frames) - maxConnectionFrames + 1
+		c.Frames = append(c.Frames[:0], c.Frames[drop:]...)
+		c.Dropped += drop
+	}
+	c.Frames = append(c.Frames, f)
+}
+
+func (l *NetworkEventLog) RecordWebSocketCreated(e *proto.NetworkWebSocketCreated) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conn := l.connection(string(e.RequestID), ConnWebSocket)
+	conn.URL = e.URL
+}
+
+func (l *NetworkEventLog) RecordWebSocketFrame(reqID proto.NetworkRequestID, direction string, frame *proto.NetworkWebSocketFrame) {
+	if frame == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.connection(string(reqID), ConnWebSocket).appendFrame(NetworkFrame{
+		Direction: direction,
+		Opcode:    int(frame.Opcode),
+		Data:      frame.PayloadData,
+		Timestamp: time.Now(),
+	})
+}
+
+func (l *NetworkEventLog) RecordWebSocketClosed(e *proto.NetworkWebSocketClosed) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.connection(string(e.RequestID), ConnWebSocket).Closed = time.Now()
+}
+
+func (l *NetworkEventLog) RecordWebSocketError(e *proto.NetworkWebSocketFrameError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.connection(string(e.RequestID), ConnWebSocket).Error = e.ErrorMessage
+}
+
+func (l *NetworkEventLog) RecordEventSourceMessage(e *proto.NetworkEventSourceMessageReceived) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.connection(string(e.RequestID), ConnEventSource).appendFrame(NetworkFrame{
+		Direction: FrameEvent,
+		Data:      e.Data,
+		EventName: e.EventName,
+		EventID:   e.EventID,
+		Timestamp: time.Now(),
+	})
+}
+
+// Connections returns copies of the recorded connections in creation order.
+func (l *NetworkEventLog) Connections() []*NetworkConnection {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]*NetworkConnection, 0, len(l.connOrder))
+	for _, id := range l.connOrder {
+		out = append(out, l.conns[id].clone())
+	}
+	return out
+}
+
+// ConnectionByID returns a copy of one connection.
+func (l *NetworkEventLog) ConnectionByID(id string) (*NetworkConnection, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conn, ok := l.conns[id]
+	if !ok {
+		return nil, false
+	}
+	return conn.clone(), true
+}
+
+// filterFrames keeps frames whose payload or event name contains every
+// substring (case-insensitive).
+func filterFrames(frames []NetworkFrame, contains []string) []NetworkFrame {
+	if len(contains) == 0 {
+		return frames
+	}
+	out := make([]NetworkFrame, 0, len(frames))
+	for _, f := range frames {
+		text := strings.ToLower(f.Data + " " + f.EventName)
+		matched := true
+		for _, c := range contains {
+			if !strings.Contains(text, c) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// decodeFrameJSON parses a text payload as JSON. Socket.IO style packets,
+// where the JSON follows a numeric type prefix such as 42["event",{...}],
+// are decoded too.
+func decodeFrameJSON(data string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(data)
+	if i := strings.IndexAny(trimmed, "[{"); i > 0 && strings.Trim(trimmed[:i], "0123456789") == "" {
+		trimmed = trimmed[i:]
+	}
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// frameDisplayData renders a payload on one line, or indented when pretty
+// is set and the payload is JSON.
+func frameDisplayData(f NetworkFrame, decode, pretty bool) string {
+	if f.Binary() {
+		return fmt.Sprintf("(binary, %d bytes base64)", len(f.Data))
+	}
+	if decode {
+		if v, ok := decodeFrameJSON(f.Data); ok {
+			var out []byte
+			var err error
+			if pretty {
+				out, err = json.MarshalIndent(v, "", "  ")
+			} else {
+				out, err = json.Marshal(v)
+			}
+			if err == nil {
+				return string(out)
+			}
+		}
+	}
+	return strings.ReplaceAll(f.Data, "\n", `\n`)
+}
+
+func frameDirectionLabel(f NetworkFrame) string {
+	switch f.Direction {
+	case FrameSent:
+		return "send"
+	case FrameEvent:
+		if f.EventName != "" {
+			return "event:" + f.EventName
+		}
+		return "event"
+	}
+	return "recv"
+}
+
+func connectionState(c *NetworkConnection) string {
+	switch {
+	case c.Error != "":
+		return "error"
+	case !c.Closed.IsZero():
+		return "closed"
+	}
+	return "open"
+}
+
+// frameExport is one NDJSON line written by exportFrames.
+type frameExport struct {
+	Connection string      `json:"connection"`
+	URL        string      `json:"url,omitempty"`
+	Direction  string      `json:"direction"`
+	Opcode     int         `json:"opcode,omitempty"`
+	EventName  string      `json:"event,omitempty"`
+	EventID    string      `json:"event_id,omitempty"`
+	Time       time.Time   `json:"time"`
+	Data       string      `json:"data"`
+	JSON       interface{} `json:"json,omitempty"`
+}
+
+// exportFrames writes frames as newline-delimited JSON, adding the decoded
+// payload under "json" when it parses.
+func exportFrames(w io.Writer, conn *NetworkConnection, frames []NetworkFrame) error {
+	enc := json.NewEncoder(w)
+	for _, f := range frames {
+		line := frameExport{
+			Connection: conn.RequestID,
+			URL:        conn.URL,
+			Direction:  f.Direction,
+			Opcode:     f.Opcode,
+			EventName:  f.EventName,
+			EventID:    f.EventID,
+			Time:       f.Timestamp,
+			Data:       f.Data,
+		}
+		if !f.Binary() {
+			if v, ok := decodeFrameJSON(f.Data); ok {
+				line.JSON = v
+			}
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportFramesToFile writes frames to path, or to a generated file under dir
+// when path is empty, and returns the file written.
+func exportFramesToFile(conn *NetworkConnection, frames []NetworkFrame, path, dir string) (string, error) {
+	if path == "" {
+		if dir == "" {
+			dir = defaultDownloadsDir()
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%s-%s.ndjson", conn.Kind, sanitizeComponent(conn.RequestID), time.Now().Format("20060102-150405")))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := exportFrames(&buf, conn, frames); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func printConnections(w io.Writer, conns []*NetworkConnection) {
+	tw := tabwriter.NewWriter(w, 4, 2, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintln(tw, "CONN\tKIND\tSTATE\tSENT\tRECV\tURL")
+	for _, c := range conns {
+		sent, recv := c.Counts()
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", c.RequestID, c.Kind, connectionState(c), sent, recv, c.URL)
+	}
+}
+
+func printFrames(w io.Writer, frames []NetworkFrame, pretty bool) {
+	for _, f := range frames {
+		fmt.Fprintf(w, "%s  %-6s %6d  %s\n", f.Timestamp.Local().Format("15:04:05.000"), frameDirectionLabel(f), len(f.Data), frameDisplayData(f, true, pretty))
+	}
+}
+
+var (
+	netlogWSConn     string
+	netlogWSContains []string
+	netlogWSPretty   bool
+	netlogWSExport   string
+)
+
+var netlogWSCmd = &cobra.Command{
+	Use:   "ws",
+	Short: "Inspect WebSocket and EventSource frames captured for the current page",
+	Long: `ws lists the WebSocket and EventSource connections opened by the current
+page. With --conn it prints the frames of one connection, decoding JSON
+payloads (including Socket.IO style packets such as 42["event",{...}]).`,
+	Example: `  # Connections with frame counts
+  roderik netlog ws
+
+  # Frames of one connection mentioning "price", pretty-printed
+  roderik netlog ws --conn 1234.56 --contains price --pretty
+
+  # Export every frame of a connection as NDJSON
+  roderik netlog ws --conn 1234.56 --export frames.ndjson`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log := getActiveEventLog()
+		if log == nil {
+			return fmt.Errorf("no active network log; load a page first")
+		}
+		id := strings.TrimSpace(netlogWSConn)
+		if id == "" {
+			conns := log.Connections()
+			if len(conns) == 0 {
+				fmt.Fprintln(os.Stderr, "no WebSocket or EventSource connections captured")
+				return nil
+			}
+			printConnections(os.Stdout, conns)
+			return nil
+		}
+		conn, ok := log.ConnectionByID(id)
+		if !ok {
+			return fmt.Errorf("connection %s not found", id)
+		}
+		frames := filterFrames(conn.Frames, normalizeStrings(netlogWSContains))
+		if netlogWSExport != "" {
+			path, err := exportFramesToFile(conn, frames, netlogWSExport, "")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "exported %d frames to %s\n", len(frames), path)
+			return nil
+		}
+		if len(frames) == 0 {
+			fmt.Fprintln(os.Stderr, "no frames matched")
+			return nil
+		}
+		if conn.Dropped > 0 {
+			fmt.Fprintf(os.Stderr, "%d older frames were dropped\n", conn.Dropped)
+		}
+		printFrames(os.Stdout, frames, netlogWSPretty)
+		return nil
+	},
+}
+
+func init() {
+	netlogCmd.AddCommand(netlogWSCmd)
+	netlogWSCmd.Flags().StringVar(&netlogWSConn, "conn", "", "Show the frames of this connection (request ID)")
+	netlogWSCmd.Flags().StringSliceVar(&netlogWSContains, "contains", nil, "Only frames whose payload contains this substring (repeatable)")
+	netlogWSCmd.Flags().BoolVar(&netlogWSPretty, "pretty", false, "Indent JSON payloads")
+	netlogWSCmd.Flags().StringVar(&netlogWSExport, "export", "", "Write the selected frames to this file as NDJSON instead of printing them")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestNetworkEventLogRecordsConnections(t *testing.T) {
+	log := newNetworkEventLog()
+	log.RecordWebSocketCreated(&proto.NetworkWebSocketCreated{RequestID: "ws1", URL: "wss://example.com/socket"})
+	log.RecordWebSocketFrame("ws1", FrameSent, &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: `{"op":"subscribe","channel":"prices"}`})
+	log.RecordWebSocketFrame("ws1", FrameReceived, &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: `42["price",{"symbol":"ABC","value":12.5}]`})
+	log.RecordWebSocketFrame("ws1", FrameReceived, &proto.NetworkWebSocketFrame{Opcode: 2, PayloadData: "AAEC"})
+	log.RecordWebSocketClosed(&proto.NetworkWebSocketClosed{RequestID: "ws1"})
+
+	log.RecordRequest(&proto.NetworkRequestWillBeSent{RequestID: "es1", Request: &proto.NetworkRequest{URL: "https://example.com/events", Method: "GET"}})
+	log.RecordEventSourceMessage(&proto.NetworkEventSourceMessageReceived{RequestID: "es1", EventName: "tick", EventID: "7", Data: `{"n":1}`})
+
+	conns := log.Connections()
+	if len(conns) != 2 {
+		t.Fatalf("expected 2 connections, got %d", len(conns))
+	}
+	ws := conns[0]
+	if ws.Kind != ConnWebSocket || ws.URL != "wss://example.com/socket" || connectionState(ws) != "closed" {
+		t.Fatalf("unexpected websocket connection: %+v", ws)
+	}
+	if sent, recv := ws.Counts(); sent != 1 || recv != 2 {
+		t.Fatalf("Counts() = %d sent, %d received", sent, recv)
+	}
+	if es := conns[1]; es.Kind != ConnEventSource || es.URL != "https://example.com/events" || es.Frames[0].EventName != "tick" {
+		t.Fatalf("unexpected eventsource connection: %+v", es)
+	}
+
+	if got := filterFrames(ws.Frames, []string{"abc"}); len(got) != 1 || got[0].Direction != FrameReceived {
+		t.Fatalf("filterFrames() = %+v", got)
+	}
+	if got := frameDisplayData(ws.Frames[1], true, false); got != `["price",{"symbol":"ABC","value":12.5}]` {
+		t.Fatalf("frameDisplayData() = %q", got)
+	}
+	if got := frameDisplayData(ws.Frames[2], true, false); !strings.Contains(got, "binary") {
+		t.Fatalf("binary frame rendered as %q", got)
+	}
+}
+
+func TestConnectionFramesAreCapped(t *testing.T) {
+	log := newNetworkEventLog()
+	for i := 0; i < maxConnectionFrames+10; i++ {
+		log.RecordWebSocketFrame("ws1", FrameReceived, &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: "x"})
+	}
+	conn, ok := log.ConnectionByID("ws1")
+	if !ok || len(conn.Frames) != maxConnectionFrames || conn.Dropped != 10 {
+		t.Fatalf("expected %d frames and 10 dropped, got %d and %d", maxConnectionFrames, len(conn.Frames), conn.Dropped)
+	}
+}
+
+func TestNetworkWSFramesHandlerExports(t *testing.T) {
+	log := newNetworkEventLog()
+	log.RecordWebSocketCreated(&proto.NetworkWebSocketCreated{RequestID: "ws1", URL: "wss://example.com/socket"})
+	for _, payload := range []string{`{"seq":1}`, `{"seq":2,"note":"keep"}`, `plain keep`} {
+		log.RecordWebSocketFrame("ws1", FrameReceived, &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: payload})
+	}
+	prev := getActiveEventLog()
+	setActiveEventLog(log)
+	t.Cleanup(func() { setActiveEventLog(prev) })
+
+	listing, err := networkWSFramesHandler(context.Background(), map[string]interface{}{})
+	if err != nil || !strings.Contains(listing.Text, "wss://example.com/socket") {
+		t.Fatalf("listing = %q, %v", listing.Text, err)
+	}
+
+	dir := t.TempDir()
+	res, err := networkWSFramesHandler(context.Background(), map[string]interface{}{
+		"conn":     "ws1",
+		"contains": "keep",
+		"limit":    1,
+		"export":   true,
+		"save_dir": dir,
+	})
+	if err != nil {
+		t.Fatalf("networkWSFramesHandler() error = %v", err)
+	}
+	if !strings.Contains(res.Text, "2 matching frames") || !strings.Contains(res.Text, "plain keep") || strings.Contains(res.Text, `"seq":2`) {
+		t.Fatalf("unexpected result:\n%s", res.Text)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+	if len(files) != 1 {
+		t.Fatalf("expected one export, got %v", files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []frameExport
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line frameExport
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0].JSON == nil || lines[1].JSON != nil || lines[0].Connection != "ws1" {
+		t.Fatalf("unexpected export: %+v", lines)
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"strings"
@@ -11,7 +12,8 @@ import (
 	"golang.org/x/text/transform"
 )
 
-// probeURL downloads up to maxBytes from the given URL and returns:
+// probeURL downloads up to maxBytes from the given URL, giving up when ctx
+// ends, and returns:
 //   - the partial body that was read
 //   - the Content‐Type header
 //   - whether the content looks like HTML
@@ -20,8 +22,8 @@ import (
 // The heuristic for “looks like HTML” is:
 //  1. Content‐Type header contains the word “html”, OR
 //  2. The first chunk of the body contains the string “<html”.
-func probeURL(u string, maxBytes int) ([]byte, string, bool, error) {
-	resp, err := http.Get(u)
+func probeURL(ctx context.Context, u string, maxBytes int) ([]byte, string, bool, error) {
+	resp, err := fetchURL(ctx, u)
 	if err != nil {
 		return nil, "", false, err
 	}
@@ -43,6 +45,15 @@ func probeURL(u string, maxBytes int) ([]byte, string, bool, error) {
 	return body, ct, looksHTML, nil
 }
 
+// fetchURL issues a GET for u that is cancelled with ctx.
+func fetchURL(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
 // decodeToUTF8 best-effort decodes an HTTP response body into UTF-8 so that
 // MCP tool responses always marshal cleanly even when the upstream server uses
 // a legacy charset.
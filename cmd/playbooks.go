@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"roderik/internal/playbooks"
+)
+
+var (
+	playbooksDir  string
+	playbooksArgs []string
+)
+
+var playbooksCmd = &cobra.Command{
+	Use:   "playbooks",
+	Short: "List and show the browsing playbooks served as MCP prompts",
+	Long: `Playbooks are vetted multi-step recipes ("summarise page", "download all
+media", ...) that the MCP server publishes as prompts. Roderik ships a few
+built-in playbooks; Markdown files in <base>/playbooks/ add new ones or replace
+a built-in playbook of the same name. See internal/playbooks for the file
+format.`,
+}
+
+var playbooksListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List available playbooks",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, err := loadPlaybooks()
+		printPlaybooks(os.Stdout, list)
+		return err
+	},
+}
+
+var playbooksShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a playbook, or render it with --arg",
+	Example: `  roderik playbooks show summarise-page
+  roderik playbooks show summarise-page --arg url=https://example.com --arg words=80`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, err := loadPlaybooks()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "warning:", err)
+		}
+		pb, ok := playbooks.Find(list, args[0])
+		if !ok {
+			return fmt.Errorf("playbook %q not found", args[0])
+		}
+		if len(playbooksArgs) == 0 {
+			printPlaybook(os.Stdout, pb)
+			return nil
+		}
+		values, err := parsePlaybookArgs(playbooksArgs)
+		if err != nil {
+			return err
+		}
+		text, err := pb.Render(values)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, text)
+		return nil
+	},
+}
+
+func init() {
+	playbooksCmd.PersistentFlags().StringVar(&playbooksDir, "dir", "", "playbook directory (default <base>/playbooks)")
+	playbooksShowCmd.Flags().StringArrayVar(&playbooksArgs, "arg", nil, "render with argument name=value (repeatable)")
+	playbooksCmd.AddCommand(playbooksListCmd)
+	playbooksCmd.AddCommand(playbooksShowCmd)
+	RootCmd.AddCommand(playbooksCmd)
+}
+
+func loadPlaybooks() ([]playbooks.Playbook, error) {
+	dir := strings.TrimSpace(playbooksDir)
+	if dir == "" {
+		dir = playbooks.DefaultDir()
+	}
+	return playbooks.Load(dir)
+}
+
+func parsePlaybookArgs(pairs []string) (map[string]string, error) {
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("--arg %q: expected name=value", pair)
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values, nil
+}
+
+func printPlaybooks(w io.Writer, list []playbooks.Playbook) {
+	tw := tabwriter.NewWriter(w, 4, 2, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintln(tw, "NAME\tARGUMENTS\tSOURCE\tDESCRIPTION")
+	for _, pb := range list {
+		names := make([]string, 0, len(pb.Arguments))
+		for _, arg := range pb.Arguments {
+			name := arg.Name
+			if arg.Required {
+				name += "*"
+			}
+			names = append(names, name)
+		}
+		args := strings.Join(names, ",")
+		if args == "" {
+			args = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", pb.Name, args, pb.Source, pb.Description)
+	}
+}
+
+func printPlaybook(w io.Writer, pb playbooks.Playbook) {
+	fmt.Fprintf(w, "%s: %s\nsource: %s\n", pb.Name, pb.Description, pb.Source)
+	if len(pb.Arguments) > 0 {
+		fmt.Fprintln(w, "arguments:")
+		for _, arg := range pb.Arguments {
+			required := ""
+			if arg.Required {
+				required = " (required)"
+			}
+			fmt.Fprintf(w, "  %s%s %s\n", arg.Name, required, arg.Summary())
+		}
+	}
+	fmt.Fprintf(w, "\n%s\n", pb.Body)
+}
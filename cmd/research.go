@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/spf13/cobra"
+	client "roderik/duckduck"
+)
+
+const (
+	defaultResearchPages    = 5
+	maxResearchPages        = 10
+	defaultResearchMaxChars = 4000
+	researchPageTimeout     = 30 * time.Second
+)
+
+var (
+	researchPages    int
+	researchMaxChars int
+	researchEngine   string
+
+	researchCmd = &cobra.Command{
+		Use:   "research [flags] <search terms>",
+		Short: "Search the web and return the top results as one Markdown bundle",
+		Long: `Research runs a web search (same engines as duck), opens the top N results
+in parallel tabs of the browser, converts each page to Markdown and prints a
+single source-attributed bundle. Results pointing at the same canonical URL
+are fetched once, and each page is truncated to --max-chars.`,
+		Example: `  # Read the top 5 results for a query
+  roderik research golang generics tutorial
+
+  # Three pages, 2000 characters each, via SearXNG
+  roderik research -n 3 --max-chars 2000 --engine searxng rod cdp`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bundle, err := runResearch(cmd.Context(), researchOptions{
+				Query:    strings.Join(args, " "),
+				Pages:    researchPages,
+				MaxChars: researchMaxChars,
+				Engine:   researchEngine,
+			}, researchFetch)
+			if err != nil {
+				return err
+			}
+			cmd.Println(bundle.Markdown())
+			return nil
+		},
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(researchCmd)
+	flags := researchCmd.Flags()
+	flags.IntVarP(&researchPages, "num", "n", defaultResearchPages, fmt.Sprintf("number of result pages to open (max %d)", maxResearchPages))
+	flags.IntVar(&researchMaxChars, "max-chars", defaultResearchMaxChars, "truncate each page's Markdown to this many characters (0 = no limit)")
+	flags.StringVar(&researchEngine, "engine", engineAuto, "search engine: "+strings.Join(searchEngineNames(), ", "))
+}
+
+type researchOptions struct {
+	Query    string
+	Pages    int
+	MaxChars int
+	Engine   string
+	// Quick trims search retries/delays, as for tool calls.
+	Quick bool
+}
+
+// researchFetcher loads rawURL and returns its final URL, title and Markdown.
+type researchFetcher func(ctx context.Context, rawURL string) (researchPage, error)
+
+type researchPage struct {
+	URL      string
+	Title    string
+	Markdown string
+}
+
+type researchSource struct {
+	Index     int
+	URL       string
+	Title     string
+	Snippet   string
+	Markdown  string
+	Truncated bool
+	Err       error
+}
+
+type researchBundle struct {
+	Query   string
+	Engine  string
+	Sources []researchSource
+	// Skipped counts search results dropped as duplicates.
+	Skipped int
+}
+
+// runResearch searches for opts.Query, fetches the top distinct results in
+// parallel with fetch, and returns them in search order.
+func runResearch(ctx context.Context, opts researchOptions, fetch researchFetcher) (researchBundle, error) {
+	query := strings.TrimSpace(opts.Query)
+	if query == "" {
+		return researchBundle{}, fmt.Errorf("research: query is required")
+	}
+	pages := opts.Pages
+	if pages <= 0 {
+		pages = defaultResearchPages
+	}
+	if pages > maxResearchPages {
+		pages = maxResearchPages
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Ask for extra results so duplicates don't leave us short.
+	results, engine, err := webSearch(query, pages*2, opts.Engine, client.SearchOptions{}, opts.Quick)
+	if err != nil {
+		return researchBundle{}, fmt.Errorf("research search failed: %w", err)
+	}
+
+	bundle := researchBundle{Query: query, Engine: engine}
+	seen := make(map[string]bool)
+	for _, r := range results {
+		raw := strings.TrimSpace(r.FormattedUrl)
+		key := canonicalURL(raw)
+		if key == "" {
+			continue
+		}
+		if seen[key] {
+			bundle.Skipped++
+			continue
+		}
+		if len(bundle.Sources) >= pages {
+			break
+		}
+		seen[key] = true
+		bundle.Sources = append(bundle.Sources, researchSource{
+			URL:     absoluteResultURL(raw),
+			Title:   strings.TrimSpace(r.Title),
+			Snippet: strings.TrimSpace(r.Snippet),
+		})
+	}
+
+	var wg sync.WaitGroup
+	for i := range bundle.Sources {
+		wg.Add(1)
+		go func(src *researchSource) {
+			defer wg.Done()
+			page, err := fetch(ctx, src.URL)
+			if err != nil {
+				src.Err = err
+				return
+			}
+			if page.URL != "" {
+				src.URL = page.URL
+			}
+			if page.Title != "" {
+				src.Title = page.Title
+			}
+			src.Markdown, src.Truncated = truncateResearchText(strings.TrimSpace(page.Markdown), opts.MaxChars)
+		}(&bundle.Sources[i])
+	}
+	wg.Wait()
+
+	// Redirects can land two results on the same page; keep the first.
+	finals := make(map[string]bool)
+	kept := bundle.Sources[:0]
+	for _, src := range bundle.Sources {
+		key := canonicalURL(src.URL)
+		if src.Err == nil && finals[key] {
+			bundle.Skipped++
+			continue
+		}
+		finals[key] = true
+		kept = append(kept, src)
+	}
+	bundle.Sources = kept
+	for i := range bundle.Sources {
+		bundle.Sources[i].Index = i + 1
+	}
+	return bundle, nil
+}
+
+// Markdown renders the bundle with a numbered, attributed section per source.
+func (b researchBundle) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Research: %s\n\n", b.Query)
+	if len(b.Sources) == 0 {
+		sb.WriteString("No results found.\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%d sources", len(b.Sources))
+	if b.Engine != "" {
+		fmt.Fprintf(&sb, " via %s", b.Engine)
+	}
+	if b.Skipped > 0 {
+		fmt.Fprintf(&sb, " (%d duplicate results skipped)", b.Skipped)
+	}
+	sb.WriteString(".\n")
+	for _, src := range b.Sources {
+		title := src.Title
+		if title == "" {
+			title = src.URL
+		}
+		fmt.Fprintf(&sb, "\n---\n\n## [%d] %s\nsource: %s\n\n", src.Index, title, src.URL)
+		switch {
+		case src.Err != nil:
+			fmt.Fprintf(&sb, "_fetch failed: %v_\n", src.Err)
+			if src.Snippet != "" {
+				fmt.Fprintf(&sb, "\nsnippet: %s\n", src.Snippet)
+			}
+		case src.Markdown == "":
+			sb.WriteString("_no readable content_\n")
+		default:
+			sb.WriteString(src.Markdown)
+			sb.WriteString("\n")
+			if src.Truncated {
+				fmt.Fprintf(&sb, "\n_[truncated; use to_markdown with url %s for the full page]_\n", src.URL)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// truncateResearchText cuts s to at most limit runes, preferring a line break.
+func truncateResearchText(s string, limit int) (string, bool) {
+	if limit <= 0 {
+		return s, false
+	}
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s, false
+	}
+	cut := string(runes[:limit])
+	if idx := strings.LastIndex(cut, "\n"); idx > len(cut)/2 {
+		cut = cut[:idx]
+	}
+	return strings.TrimRight(cut, " \n") + " …", true
+}
+
+// absoluteResultURL adds a scheme to display URLs such as "www.example.com/a"
+// that the DuckDuckGo HTML endpoint returns.
+func absoluteResultURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.Contains(raw, "://") {
+		return raw
+	}
+	return "https://" + strings.TrimPrefix(raw, "//")
+}
+
+// trackingParams are query parameters that never change page content.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "msclkid": true, "ref_src": true,
+}
+
+// canonicalURL normalises raw for duplicate detection: scheme and "www." are
+// ignored, host is lower-cased, fragments, default ports, tracking parameters
+// and trailing slashes are dropped, and the query is sorted.
+func canonicalURL(raw string) string {
+	u, err := url.Parse(absoluteResultURL(raw))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "utm_") || trackingParams[lk] {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := q[k]
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+
+	key := host + strings.TrimRight(u.EscapedPath(), "/")
+	if len(parts) > 0 {
+		key += "?" + strings.Join(parts, "&")
+	}
+	return key
+}
+
+// researchFetch is the fetcher used by the research tool; tests replace it.
+var researchFetch researchFetcher = browserResearchFetcher
+
+// browserResearchFetcher opens rawURL in its own tab of the shared Browser so
+// several results load in parallel without touching the current page.
+func browserResearchFetcher(ctx context.Context, rawURL string) (researchPage, error) {
+	b, err := withPageContext(ctx, func() (*rod.Browser, error) {
+		if Browser == nil {
+			return nil, errors.New("no browser session")
+		}
+		return Browser, nil
+	})
+	if err != nil {
+		return researchPage{}, err
+	}
+
+	tab, err := newPageForBrowser(b)
+	if err != nil {
+		return researchPage{}, fmt.Errorf("open tab: %w", err)
+	}
+	defer tab.Close()
+
+	nav := tab.Context(ctx).Timeout(researchPageTimeout)
+	if err := nav.Navigate(rawURL); err != nil && !isNavigationAborted(err) {
+		return researchPage{}, fmt.Errorf("navigate: %w", err)
+	}
+	if err := nav.WaitLoad(); err != nil && !isNavigationAborted(err) {
+		if !errors.Is(err, context.DeadlineExceeded) || !pageReady(tab) {
+			return researchPage{}, fmt.Errorf("wait for load: %w", err)
+		}
+	}
+
+	page := researchPage{URL: rawURL}
+	if info, err := tab.Info(); err == nil {
+		page.URL = info.URL
+		page.Title = info.Title
+	}
+	body, err := nav.Element("body")
+	if err != nil {
+		return researchPage{}, fmt.Errorf("select <body>: %w", err)
+	}
+	md, err := elementMarkdown(nav, body)
+	if err != nil {
+		return researchPage{}, err
+	}
+	page.Markdown = md
+	return page, nil
+}
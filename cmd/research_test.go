@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	same := [][2]string{
+		{"https://www.Example.com/docs/", "http://example.com/docs"},
+		{"example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"https://example.com/a?utm_source=x&id=3#top", "https://example.com/a?id=3"},
+		{"https://example.com:443/", "https://example.com"},
+	}
+	for _, tc := range same {
+		if a, b := canonicalURL(tc[0]), canonicalURL(tc[1]); a != b {
+			t.Fatalf("canonicalURL(%q)=%q, canonicalURL(%q)=%q; want equal", tc[0], a, tc[1], b)
+		}
+	}
+	if canonicalURL("https://example.com/a?id=1") == canonicalURL("https://example.com/a?id=2") {
+		t.Fatalf("different queries must not collapse")
+	}
+}
+
+func TestTruncateResearchText(t *testing.T) {
+	text := strings.Repeat("line of text\n", 10)
+	out, truncated := truncateResearchText(text, 40)
+	if !truncated || len([]rune(out)) > 42 || !strings.HasSuffix(out, "…") {
+		t.Fatalf("unexpected truncation %q (truncated=%v)", out, truncated)
+	}
+	if out, truncated := truncateResearchText("short", 40); truncated || out != "short" {
+		t.Fatalf("short text should be untouched, got %q", out)
+	}
+}
+
+func TestResearchHandlerBundlesDistinctResults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results":[
+			{"url":"https://www.example.com/guide/","title":"Guide","content":"first"},
+			{"url":"https://example.com/guide?utm_source=feed","title":"Guide again","content":"dup"},
+			{"url":"https://example.org/broken","title":"Broken","content":"will fail"},
+			{"url":"https://example.net/old","title":"Old","content":"redirects to guide"},
+			{"url":"https://example.dev/long","title":"Long","content":"long page"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	t.Setenv("RODERIK_HOME", home)
+	config := fmt.Sprintf(`{"failover":["searxng"],"engines":{"searxng":{"base_url":%q}}}`, srv.URL)
+	if err := os.WriteFile(filepath.Join(home, "search-engines.json"), []byte(config), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	var mu sync.Mutex
+	var fetched []string
+	orig := researchFetch
+	researchFetch = func(ctx context.Context, rawURL string) (researchPage, error) {
+		mu.Lock()
+		fetched = append(fetched, rawURL)
+		mu.Unlock()
+		switch {
+		case strings.Contains(rawURL, "example.org"):
+			return researchPage{}, errors.New("net::ERR_NAME_NOT_RESOLVED")
+		case strings.Contains(rawURL, "example.net"):
+			return researchPage{URL: "https://example.com/guide", Markdown: "# Guide"}, nil
+		case strings.Contains(rawURL, "example.dev"):
+			return researchPage{URL: rawURL, Title: "Long page", Markdown: strings.Repeat("word ", 100)}, nil
+		default:
+			return researchPage{URL: rawURL, Title: "The Guide", Markdown: "# Guide\n\nHello."}, nil
+		}
+	}
+	defer func() { researchFetch = orig }()
+
+	res, err := researchHandler(context.Background(), map[string]interface{}{"query": "guide", "num": float64(4), "max_chars": float64(50)})
+	if err != nil {
+		t.Fatalf("researchHandler() error = %v", err)
+	}
+	if len(fetched) != 4 {
+		t.Fatalf("expected 4 distinct fetches, got %v", fetched)
+	}
+	out := res.Text
+	for _, want := range []string{
+		"# Research: guide",
+		"3 sources via searxng (2 duplicate results skipped)",
+		"## [1] The Guide\nsource: https://www.example.com/guide/",
+		"## [2] Broken\nsource: https://example.org/broken",
+		"_fetch failed: net::ERR_NAME_NOT_RESOLVED_",
+		"## [3] Long page",
+		"_[truncated;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("bundle missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Guide again") {
+		t.Fatalf("duplicate result should be skipped:\n%s", out)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,6 +28,8 @@ import (
 	"github.com/go-rod/stealth"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
+	aitools "roderik/internal/ai/tools"
+	"roderik/internal/netstore"
 )
 
 func GetUserInput(prompt string) string {
@@ -40,7 +41,10 @@ func GetUserInput(prompt string) string {
 }
 
 func AskForConfirmation(prompt string) bool {
-	response := GetUserInput(prompt)
+	response := strings.TrimSpace(GetUserInput(prompt))
+	if response == "" {
+		return false
+	}
 	firstChar := strings.ToLower(string(response[0]))
 	if firstChar == "y" {
 		return true
@@ -231,10 +235,22 @@ type NetworkLogEntry struct {
 	InitiatorType    string
 	RequestHeaders   map[string]string
 	RequestTimestamp time.Time
+	// HasPostData is set when Chrome reported a request body; PostData stays
+	// empty until it is fetched when the body was too large to inline.
+	HasPostData bool
+	PostData    []byte
+	// GraphQLOperation is the operation name(s) found in a GraphQL request.
+	GraphQLOperation string
 	Response         *NetworkResponseInfo
 	Finished         *NetworkFinishedInfo
 	Failure          *NetworkFailureInfo
 	Body             *NetworkBody
+	// SessionID and NavigationID identify the run and LoadURL call the entry
+	// was captured in when the log is persisted (see netlog_store.go).
+	SessionID    string
+	NavigationID int
+	// BodyBlob names the persisted body of an entry loaded from disk.
+	BodyBlob string
 }
 
 type NetworkEventLog struct {
@@ -242,6 +258,15 @@ type NetworkEventLog struct {
 	messages []string
 	entries  map[string]*NetworkLogEntry
 	order    []string
+
+	// WebSocket and EventSource connections, keyed by request ID.
+	conns     map[string]*NetworkConnection
+	connOrder []string
+
+	// store, when set, receives every finished or failed entry and every
+	// retrieved body.
+	store      *netstore.Store
+	navigation int
 }
 
 type NetworkLogFilter struct {
@@ -252,11 +277,13 @@ type NetworkLogFilter struct {
 	Methods        []string
 	Domains        []string
 	ResourceTypes  []proto.NetworkResourceType
+	GraphQLOps     []string
 }
 
 func newNetworkEventLog() *NetworkEventLog {
 	return &NetworkEventLog{
 		entries: make(map[string]*NetworkLogEntry),
+		conns:   make(map[string]*NetworkConnection),
 	}
 }
 
@@ -324,6 +351,9 @@ func (e *NetworkLogEntry) clone() *NetworkLogEntry {
 	}
 	cpy := *e
 	cpy.RequestHeaders = cloneStringMap(e.RequestHeaders)
+	if e.PostData != nil {
+		cpy.PostData = append([]byte(nil), e.PostData...)
+	}
 	cpy.Response = e.Response.clone()
 	cpy.Finished = e.Finished.clone()
 	cpy.Failure = e.Failure.clone()
@@ -342,6 +372,9 @@ func (l *NetworkEventLog) recordEntry(reqID proto.NetworkRequestID) *NetworkLogE
 	entry, ok := l.entries[key]
 	if !ok {
 		entry = &NetworkLogEntry{RequestID: key, ProtoRequestID: reqID}
+		if l.store != nil {
+			entry.SessionID, entry.NavigationID = l.store.Session(), l.navigation
+		}
 		l.entries[key] = entry
 		l.order = append(l.order, key)
 	}
@@ -366,6 +399,19 @@ func (l *NetworkEventLog) RecordRequest(e *proto.NetworkRequestWillBeSent) {
 	}
 	entry.RequestHeaders = cloneHeaders(e.Request.Headers)
 	entry.RequestTimestamp = time.Now()
+	entry.HasPostData = e.Request.HasPostData || e.Request.PostData != "" || len(e.Request.PostDataEntries) > 0
+	entry.PostData = inlinePostData(e.Request)
+	entry.GraphQLOperation = graphQLOperationName(entry.URL, entry.PostData)
+}
+
+// StorePostData records a request body fetched with Network.getRequestPostData.
+func (l *NetworkEventLog) StorePostData(reqID proto.NetworkRequestID, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := l.recordEntry(reqID)
+	entry.HasPostData = true
+	entry.PostData = append([]byte(nil), data...)
+	entry.GraphQLOperation = graphQLOperationName(entry.URL, entry.PostData)
 }
 
 func (l *NetworkEventLog) RecordResponse(e *proto.NetworkResponseReceived) {
@@ -387,17 +433,18 @@ func (l *NetworkEventLog) RecordResponse(e *proto.NetworkResponseReceived) {
 
 func (l *NetworkEventLog) RecordFinished(e *proto.NetworkLoadingFinished) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
 	entry := l.recordEntry(e.RequestID)
 	entry.Finished = &NetworkFinishedInfo{
 		EncodedDataLength: e.EncodedDataLength,
 		FinishedTimestamp: time.Now(),
 	}
+	persist := l.persistEntry(entry)
+	l.mu.Unlock()
+	persist()
 }
 
 func (l *NetworkEventLog) RecordFailure(e *proto.NetworkLoadingFailed) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
 	entry := l.recordEntry(e.RequestID)
 	entry.ResourceType = e.Type
 	entry.Failure = &NetworkFailureInfo{
@@ -407,19 +454,37 @@ func (l *NetworkEventLog) RecordFailure(e *proto.NetworkLoadingFailed) {
 		BlockedReason:    e.BlockedReason,
 		FailureTimestamp: time.Now(),
 	}
+	persist := l.persistEntry(entry)
+	l.mu.Unlock()
+	persist()
 }
 
 func (l *NetworkEventLog) StoreBody(reqID proto.NetworkRequestID, data []byte, fromStream bool, originalSize int) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
 	entry := l.recordEntry(reqID)
 	bodyCopy := append([]byte(nil), data...)
-	entry.Body = &NetworkBody{
+	body := &NetworkBody{
 		Data:         bodyCopy,
 		RetrievedAt:  time.Now(),
 		FromStream:   fromStream,
 		OriginalSize: originalSize,
 	}
+	entry.Body = body
+	persist := l.persistBody(entry, bodyCopy)
+	id := entry.RequestID
+	l.mu.Unlock()
+	persist()
+	// accounted outside l.mu: making room may evict bodies from this log
+	bodyMemory.add(l, id, body)
+}
+
+// dropBody releases an in-memory body unless it has been replaced since.
+func (l *NetworkEventLog) dropBody(id string, body *NetworkBody) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry, ok := l.entries[id]; ok && entry.Body == body {
+		entry.Body = nil
+	}
 }
 
 func (l *NetworkEventLog) EntryByID(id string) (*NetworkLogEntry, bool) {
@@ -453,7 +518,10 @@ func (l *NetworkEventLog) Messages() []string {
 }
 
 func (l *NetworkEventLog) FilterEntries(filter NetworkLogFilter) []*NetworkLogEntry {
-	entries := l.Entries()
+	return filterNetworkEntries(l.Entries(), filter)
+}
+
+func filterNetworkEntries(entries []*NetworkLogEntry, filter NetworkLogFilter) []*NetworkLogEntry {
 	if isEmptyFilter(filter) {
 		return entries
 	}
@@ -471,7 +539,8 @@ func (l *NetworkEventLog) FilterEntries(filter NetworkLogFilter) []*NetworkLogEn
 
 func isEmptyFilter(f NetworkLogFilter) bool {
 	return len(f.MIMESubstrings) == 0 && len(f.Suffixes) == 0 && len(f.StatusCodes) == 0 &&
-		len(f.TextContains) == 0 && len(f.Methods) == 0 && len(f.Domains) == 0 && len(f.ResourceTypes) == 0
+		len(f.TextContains) == 0 && len(f.Methods) == 0 && len(f.Domains) == 0 && len(f.ResourceTypes) == 0 &&
+		len(f.GraphQLOps) == 0
 }
 
 func normalizeStrings(values []string) []string {
@@ -595,6 +664,23 @@ func (f NetworkLogFilter) matches(entry *NetworkLogEntry) bool {
 		}
 	}
 
+	if len(f.GraphQLOps) > 0 {
+		op := strings.ToLower(entry.GraphQLOperation)
+		if op == "" {
+			return false
+		}
+		match := false
+		for _, want := range f.GraphQLOps {
+			if strings.Contains(op, strings.ToLower(want)) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -633,6 +719,18 @@ var (
 )
 
 func setActiveEventLog(log *NetworkEventLog) {
+	eventLogMu.Lock()
+	prev := activeEventLog
+	activeEventLog = log
+	eventLogMu.Unlock()
+	if prev != nil && prev != log {
+		bodyMemory.forget(prev)
+	}
+}
+
+// swapActiveEventLog activates log without dropping the bodies remembered
+// for the previous one, which stays in use by a background browser scope.
+func swapActiveEventLog(log *NetworkEventLog) {
 	eventLogMu.Lock()
 	activeEventLog = log
 	eventLogMu.Unlock()
@@ -664,27 +762,29 @@ func isNetworkActivityEnabled() bool {
 	return netActivityEnabled.Load()
 }
 
-func recordNetworkRequest(e *proto.NetworkRequestWillBeSent) {
-	if log := getActiveEventLog(); log != nil {
+func recordNetworkRequest(log *NetworkEventLog, e *proto.NetworkRequestWillBeSent) {
+	if log != nil {
 		log.RecordRequest(e)
 	}
 }
 
-func recordNetworkResponse(e *proto.NetworkResponseReceived) {
-	if log := getActiveEventLog(); log != nil {
+func recordNetworkResponse(log *NetworkEventLog, e *proto.NetworkResponseReceived) {
+	if log != nil {
 		log.RecordResponse(e)
 	}
 }
 
-func recordNetworkFinished(e *proto.NetworkLoadingFinished) {
-	if log := getActiveEventLog(); log != nil {
+func recordNetworkFinished(log *NetworkEventLog, e *proto.NetworkLoadingFinished) {
+	if log != nil {
 		log.RecordFinished(e)
+		publishNetworkEntry(log, string(e.RequestID))
 	}
 }
 
-func recordNetworkFailed(e *proto.NetworkLoadingFailed) {
-	if log := getActiveEventLog(); log != nil {
+func recordNetworkFailed(log *NetworkEventLog, e *proto.NetworkLoadingFailed) {
+	if log != nil {
 		log.RecordFailure(e)
+		publishNetworkEntry(log, string(e.RequestID))
 	}
 }
 
@@ -695,23 +795,18 @@ func retrieveNetworkBody(page *rod.Page, entry *NetworkLogEntry) ([]byte, error)
 	if entry.Body != nil {
 		return append([]byte(nil), entry.Body.Data...), nil
 	}
+	if entry.BodyBlob != "" {
+		return storedNetworkBody(entry)
+	}
 	if page == nil {
 		return nil, fmt.Errorf("no page loaded to retrieve body")
 	}
 	if entry.ProtoRequestID == "" {
 		return nil, fmt.Errorf("entry missing request id")
 	}
-	res, err := proto.NetworkGetResponseBody{RequestID: entry.ProtoRequestID}.Call(page)
+	body, err := fetchResponseBody(page, entry.ProtoRequestID)
 	if err != nil {
-		return nil, fmt.Errorf("get response body: %w", err)
-	}
-	body := []byte(res.Body)
-	if res.Base64Encoded {
-		decoded, err := base64.StdEncoding.DecodeString(res.Body)
-		if err != nil {
-			return nil, fmt.Errorf("decode base64 body: %w", err)
-		}
-		body = decoded
+		return nil, err
 	}
 	if log := getActiveEventLog(); log != nil {
 		log.StoreBody(entry.ProtoRequestID, body, false, len(body))
@@ -754,32 +849,74 @@ var registerPageEvents = func(p *rod.Page) {
 		if isNetworkActivityEnabled() {
 			fmt.Fprintln(os.Stderr, msg)
 		}
-		recordNetworkRequest(e)
-		appendEventLog(msg)
+		log := pageEventLog(p)
+		recordNetworkRequest(log, e)
+		if log != nil {
+			log.AddMessage(msg)
+		}
+		if e.Request.HasPostData && e.Request.PostData == "" && len(e.Request.PostDataEntries) == 0 {
+			go capturePostData(p, log, e.RequestID)
+		}
 	})()
 	go p.EachEvent(func(e *proto.NetworkResponseReceived) {
 		msg := fmt.Sprintf("Response received: %s Status: %d", e.Response.URL, e.Response.Status)
 		if isNetworkActivityEnabled() {
 			fmt.Fprintln(os.Stderr, msg)
 		}
-		recordNetworkResponse(e)
-		appendEventLog(msg)
+		log := pageEventLog(p)
+		recordNetworkResponse(log, e)
+		if log != nil {
+			log.AddMessage(msg)
+		}
 	})()
 	go p.EachEvent(func(e *proto.NetworkLoadingFinished) {
-		recordNetworkFinished(e)
+		log := pageEventLog(p)
+		recordNetworkFinished(log, e)
+		captureResponseBody(p, log, e.RequestID)
 	})()
 	go p.EachEvent(func(e *proto.NetworkLoadingFailed) {
-		recordNetworkFailed(e)
+		recordNetworkFailed(pageEventLog(p), e)
+	})()
+	go p.EachEvent(func(e *proto.NetworkWebSocketCreated) {
+		if log := pageEventLog(p); log != nil {
+			log.RecordWebSocketCreated(e)
+		}
+	})()
+	go p.EachEvent(func(e *proto.NetworkWebSocketFrameSent) {
+		if log := pageEventLog(p); log != nil {
+			log.RecordWebSocketFrame(e.RequestID, FrameSent, e.Response)
+		}
+	})()
+	go p.EachEvent(func(e *proto.NetworkWebSocketFrameReceived) {
+		if log := pageEventLog(p); log != nil {
+			log.RecordWebSocketFrame(e.RequestID, FrameReceived, e.Response)
+		}
+	})()
+	go p.EachEvent(func(e *proto.NetworkWebSocketClosed) {
+		if log := pageEventLog(p); log != nil {
+			log.RecordWebSocketClosed(e)
+		}
+	})()
+	go p.EachEvent(func(e *proto.NetworkWebSocketFrameError) {
+		if log := pageEventLog(p); log != nil {
+			log.RecordWebSocketError(e)
+		}
+	})()
+	go p.EachEvent(func(e *proto.NetworkEventSourceMessageReceived) {
+		if log := pageEventLog(p); log != nil {
+			log.RecordEventSourceMessage(e)
+		}
 	})()
 	go p.EachEvent(func(e *proto.PageFrameNavigated) {
 		fmt.Fprintln(os.Stderr, "Navigated to:", e.Frame.URL)
 		if el, err := p.Timeout(5 * time.Second).Element("body"); err == nil {
-			CurrentElement = el
-			elementList = nil
-			currentIndex = 0
+			resetPageFocus(p, el)
 		} else if Verbose {
 			fmt.Fprintf(os.Stderr, "warning: failed to reset body after navigation: %v\n", err)
 		}
+		if e.Frame.ParentID == "" {
+			announcePageNavigated(p)
+		}
 	})()
 	go p.EachEvent(func(e *proto.PageJavascriptDialogOpening) {
 		fmt.Println("Dialog type: ", e.Type, "Dialog message: ", e.Message)
@@ -827,6 +964,11 @@ func ensurePageEventHandlers(p *rod.Page) {
 		return
 	}
 
+	if isScopedPage(p) {
+		// Isolated browser scopes register their tab's events when they open it.
+		return
+	}
+
 	id := pageIdentity{target: p.TargetID, session: p.SessionID}
 
 	pageEventMu.Lock()
@@ -1156,15 +1298,21 @@ func isValidURL(str string) bool {
 }
 
 func LoadURL(targetURL string) (*rod.Page, error) {
+	return LoadURLContext(context.Background(), targetURL)
+}
+
+// LoadURLContext is LoadURL that aborts navigation when ctx is cancelled and
+// reports its stages through aitools.ReportProgress.
+func LoadURLContext(ctx context.Context, targetURL string) (*rod.Page, error) {
 	// setup network aktivity logging
 	eventLog := newNetworkEventLog()
+	attachNetStore(eventLog)
 	setActiveEventLog(eventLog)
 	ensurePageEventHandlers(Page)
 
-	navCtx := Page.Timeout(defaultNavigationTimeout)
-	defer func() {
-		Page = navCtx.CancelTimeout()
-	}()
+	aitools.ReportProgress(ctx, 0, 2, "navigating to "+targetURL)
+	navCtx := pageContext(Page, ctx).Timeout(defaultNavigationTimeout)
+	defer navCtx.CancelTimeout()
 
 	if err := navCtx.Navigate(targetURL); err != nil {
 		if !isNavigationAborted(err) {
@@ -1175,8 +1323,11 @@ func LoadURL(targetURL string) (*rod.Page, error) {
 		}
 	}
 
+	aitools.ReportProgress(ctx, 1, 2, "waiting for the page to load")
 	if err := navCtx.WaitLoad(); err != nil {
 		switch {
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
 		case isNavigationAborted(err):
 			// ignore transient aborted errors from redirects
 		case errors.Is(err, context.DeadlineExceeded):
@@ -1187,6 +1338,7 @@ func LoadURL(targetURL string) (*rod.Page, error) {
 			return nil, err
 		}
 	}
+	aitools.ReportProgress(ctx, 2, 2, "loaded")
 
 	return Page, nil
 }
@@ -1300,6 +1452,7 @@ var ExitCmd = &cobra.Command{
 	Long:    `This command will exit the application.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Goodbye!")
+		closeChatSession()
 		if Browser != nil {
 			if err := Browser.Close(); err != nil && Verbose {
 				fmt.Fprintf(os.Stderr, "warning: failed to close browser: %v\n", err)
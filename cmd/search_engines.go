@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/mark3labs/mcp-go/mcp"
+	client "roderik/duckduck"
+	aitools "roderik/internal/ai/tools"
+	"roderik/internal/appdirs"
+)
+
+// engineAuto selects the failover chain from the search config.
+const engineAuto = "auto"
+
+// engineDuckBrowser runs DuckDuckGo's HTML search inside the live browser so
+// its cookies (and any manually solved challenge) apply.
+const engineDuckBrowser = "ddg-browser"
+
+const browserSearchTimeout = 30 * time.Second
+
+func init() {
+	client.RegisterEngine(engineDuckBrowser, func(cfg client.EngineConfig) (client.SearchClient, error) {
+		base := strings.TrimSpace(cfg.BaseURL)
+		if base == "" {
+			base = "https://html.duckduckgo.com/html/"
+		}
+		return browserDuckClient{baseURL: base}, nil
+	})
+	// last resort after a challenge; only this program can run it
+	client.DefaultFailover = append(client.DefaultFailover, engineDuckBrowser)
+}
+
+// searchConfigPath returns the location of the search engine config file.
+func searchConfigPath() string {
+	base, err := appdirs.BaseDir()
+	if err != nil || strings.TrimSpace(base) == "" {
+		return ""
+	}
+	return filepath.Join(base, "search-engines.json")
+}
+
+// searchEngineNames lists the values accepted by --engine.
+func searchEngineNames() []string {
+	return append([]string{engineAuto}, client.EngineNames()...)
+}
+
+// webSearch runs query on the requested engine ("auto" or empty uses the
+// configured failover order) and reports which engine answered.
+// quick trims DuckDuckGo's politeness delay for interactive tool calls.
+func webSearch(query string, limit int, engine string, opts client.SearchOptions, quick bool) ([]client.Result, string, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, err := client.LoadConfig(searchConfigPath())
+	if err != nil {
+		return nil, "", err
+	}
+	if quick {
+		ddg := cfg.Engines[client.EngineDuckDuckGo]
+		if ddg.InitialDelayMS == nil {
+			zero := 0
+			ddg.InitialDelayMS = &zero
+		}
+		if ddg.MaxRetries == nil {
+			retries := 2
+			ddg.MaxRetries = &retries
+		}
+		cfg.Engines[client.EngineDuckDuckGo] = ddg
+	}
+
+	engine = strings.ToLower(strings.TrimSpace(engine))
+	if engine != "" && engine != engineAuto {
+		c, err := client.NewEngine(engine, cfg.Engines[engine])
+		if err != nil {
+			return nil, "", err
+		}
+		results, err := client.SearchWith(c, query, limit, opts)
+		return results, engine, err
+	}
+
+	failover := client.NewFailoverClient(cfg)
+	failover.OnFailover = func(name string, err error) {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "search engine %s failed, trying next: %v\n", name, err)
+		}
+		aitools.ReportProgress(opts.Context, 0, 0, fmt.Sprintf("%s failed, trying the next engine", name))
+	}
+	return failover.SearchWithEngineOptions(query, limit, opts)
+}
+
+// searchOptionsFromArgs reads the duck tool's filter arguments.
+func searchOptionsFromArgs(args map[string]interface{}) client.SearchOptions {
+	opts := client.SearchOptions{
+		Region:     mcp.ExtractString(args, "region"),
+		SafeSearch: mcp.ExtractString(args, "safe_search"),
+		TimeRange:  mcp.ExtractString(args, "time_range"),
+	}
+	if v, ok := toBool(args["include_ads"]); ok {
+		opts.IncludeAds = v
+	}
+	return opts
+}
+
+type browserDuckClient struct {
+	baseURL string
+}
+
+// ChallengeFallback keeps the "auto" chain from opening a tab for ordinary
+// HTTP failures.
+func (browserDuckClient) ChallengeFallback() {}
+
+func (c browserDuckClient) Search(query string) ([]client.Result, error) {
+	return c.SearchLimited(query, 0)
+}
+
+// SearchLimited opens the results page in a new tab of the live browser, so
+// the user's current page is left untouched.
+func (c browserDuckClient) SearchLimited(query string, limit int) ([]client.Result, error) {
+	return c.SearchWithOptions(query, limit, client.SearchOptions{})
+}
+
+// SearchWithOptions applies the region/safe-search/time filters to the query
+// URL. Only the first results page is read. The engine is unavailable while
+// no browser runs; it never launches one.
+func (c browserDuckClient) SearchWithOptions(query string, limit int, opts client.SearchOptions) ([]client.Result, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	opts.ApplyDuckDuckGoParams(params)
+	target := c.baseURL + "?" + params.Encode()
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	running, _ := inspectPageContext(ctx, func() (bool, error) { return Browser != nil, nil })
+	if !running {
+		return nil, fmt.Errorf("%s: no browser session: %w", engineDuckBrowser, client.ErrEngineUnavailable)
+	}
+	return withPageContext(ctx, func() ([]client.Result, error) {
+		tab, err := Browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+		if err != nil {
+			return nil, fmt.Errorf("%s: open tab: %w", engineDuckBrowser, err)
+		}
+		defer tab.Close()
+		tab = tab.Context(ctx).Timeout(browserSearchTimeout)
+		if err := tab.Navigate(target); err != nil {
+			return nil, fmt.Errorf("%s: navigate: %w", engineDuckBrowser, err)
+		}
+		if err := tab.WaitLoad(); err != nil {
+			return nil, fmt.Errorf("%s: wait for results: %w", engineDuckBrowser, err)
+		}
+		html, err := tab.HTML()
+		if err != nil {
+			return nil, fmt.Errorf("%s: read results: %w", engineDuckBrowser, err)
+		}
+		if !opts.IncludeAds {
+			return client.ParseHTMLResults(strings.NewReader(html), limit)
+		}
+		page, err := client.ParseHTMLPage(strings.NewReader(html), limit)
+		return page.Results, err
+	})
+}
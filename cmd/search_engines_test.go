@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	client "roderik/duckduck"
+)
+
+func TestDuckHandlerUsesConfiguredFailover(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results":[{"url":"https://example.org/","title":"Example","content":"from searxng"}]}`)
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	t.Setenv("RODERIK_HOME", home)
+	config := fmt.Sprintf(`{"failover":["brave","searxng"],"engines":{"searxng":{"base_url":%q}}}`, srv.URL)
+	if err := os.WriteFile(filepath.Join(home, "search-engines.json"), []byte(config), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("BRAVE_API_KEY", "")
+
+	res, err := duckHandler(context.Background(), map[string]interface{}{"query": "example", "num": float64(3)})
+	if err != nil {
+		t.Fatalf("duckHandler() error = %v", err)
+	}
+	if !strings.HasPrefix(res.Text, "Results via searxng:") || !strings.Contains(res.Text, "https://example.org/") {
+		t.Fatalf("unexpected duck output %q", res.Text)
+	}
+
+	if _, _, err := webSearch("example", 3, "brave", client.SearchOptions{}, true); err == nil {
+		t.Fatalf("expected explicit unconfigured engine to fail")
+	}
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/go-rod/rod"
 )
 
 // pageMu guards Browser, Page and CurrentElement.
@@ -14,6 +17,37 @@ var pageMu sync.Mutex
 // It waits up to 30 s for the lock; afterwards it returns an error so the
 // caller can report “page busy”.
 func withPage[R any](fn func() (R, error)) (R, error) {
+	return lockPage(context.Background(), true, fn)
+}
+
+// withPageContext is withPage that also stops waiting for the lock when ctx
+// is cancelled, and that loads the browser scope selected by ctx (see
+// withBrowserScope). fn should bind its CDP calls to ctx with pageContext.
+func withPageContext[R any](ctx context.Context, fn func() (R, error)) (R, error) {
+	return lockPage(ctx, true, fn)
+}
+
+// pageContext binds p's CDP calls to ctx, so they abort when the caller
+// cancels. A context that can never be cancelled leaves p as it is.
+func pageContext(p *rod.Page, ctx context.Context) *rod.Page {
+	if ctx.Done() == nil {
+		return p
+	}
+	return p.Context(ctx)
+}
+
+// inspectPage is withPage for read-only views: it never launches or attaches
+// a browser, so fn must cope with Page being nil.
+func inspectPage[R any](fn func() (R, error)) (R, error) {
+	return lockPage(context.Background(), false, fn)
+}
+
+// inspectPageContext is inspectPage for the browser scope selected by ctx.
+func inspectPageContext[R any](ctx context.Context, fn func() (R, error)) (R, error) {
+	return lockPage(ctx, false, fn)
+}
+
+func lockPage[R any](ctx context.Context, ready bool, fn func() (R, error)) (R, error) {
 	const timeout = 30 * time.Second
 	var zero R
 
@@ -23,14 +57,66 @@ func withPage[R any](fn func() (R, error)) (R, error) {
 		close(locked)
 	}()
 
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 	select {
 	case <-locked:
 		defer pageMu.Unlock()
-		if err := ensurePageReady(); err != nil {
+		if err := enterBrowserScope(browserScopeFromContext(ctx), ready); err != nil {
 			return zero, err
 		}
+		if ready {
+			if err := ensurePageReady(); err != nil {
+				return zero, err
+			}
+		}
+		defer bindPageState(ctx)()
 		return fn()
-	case <-time.After(timeout):
+	case <-timer.C:
+		releaseAbandonedLock(locked)
 		return zero, fmt.Errorf("page busy: could not acquire lock within %s", timeout)
+	case <-ctx.Done():
+		releaseAbandonedLock(locked)
+		return zero, ctx.Err()
 	}
 }
+
+// bindPageState points Page and the focus at ctx while fn runs, so handlers
+// that use them directly still stop at the caller's deadline. Elements
+// inherit the context of the page they were found on, so the returned func
+// detaches whatever fn leaves in the globals again. Callers hold pageMu.
+func bindPageState(ctx context.Context) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	rebindPageState(ctx)
+	return func() { rebindPageState(context.Background()) }
+}
+
+func rebindPageState(ctx context.Context) {
+	if Page != nil {
+		Page = Page.Context(ctx)
+	}
+	if CurrentElement != nil {
+		CurrentElement = CurrentElement.Context(ctx)
+	}
+	if len(elementList) > 0 {
+		list := make([]*rod.Element, len(elementList))
+		for i, el := range elementList {
+			if el != nil {
+				el = el.Context(ctx)
+			}
+			list[i] = el
+		}
+		elementList = list
+	}
+}
+
+// releaseAbandonedLock unlocks pageMu once a Lock call nobody waits for
+// anymore succeeds, so a caller that gave up does not hold it forever.
+func releaseAbandonedLock(locked <-chan struct{}) {
+	go func() {
+		<-locked
+		pageMu.Unlock()
+	}()
+}
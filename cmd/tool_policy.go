@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"roderik/internal/ai/policy"
+)
+
+// path to the tool approval policy, override with --tool-policy
+var toolPolicyPath string
+
+var (
+	toolPolicyMu     sync.Mutex
+	toolPolicyLoaded bool
+	toolPolicy       *policy.Policy
+	toolPolicyErr    error
+)
+
+// toolApprover asks an operator to confirm a tool call flagged as "ask". It
+// returns the (possibly edited) arguments and whether the call may proceed.
+type toolApprover func(name string, args map[string]interface{}, reason string) (map[string]interface{}, bool)
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&toolPolicyPath, "tool-policy", policy.DefaultPath(), "Path to the tool approval policy applied to AI and MCP tool calls")
+}
+
+func activeToolPolicy() (*policy.Policy, error) {
+	toolPolicyMu.Lock()
+	defer toolPolicyMu.Unlock()
+	if !toolPolicyLoaded {
+		toolPolicy, toolPolicyErr = policy.Load(toolPolicyPath)
+		toolPolicyLoaded = true
+	}
+	return toolPolicy, toolPolicyErr
+}
+
+// setToolPolicyForTest swaps the active policy (test helper).
+func setToolPolicyForTest(p *policy.Policy) func() {
+	toolPolicyMu.Lock()
+	prevPolicy, prevLoaded, prevErr := toolPolicy, toolPolicyLoaded, toolPolicyErr
+	toolPolicy, toolPolicyLoaded, toolPolicyErr = p, true, nil
+	toolPolicyMu.Unlock()
+	return func() {
+		toolPolicyMu.Lock()
+		toolPolicy, toolPolicyLoaded, toolPolicyErr = prevPolicy, prevLoaded, prevErr
+		toolPolicyMu.Unlock()
+	}
+}
+
+// enforceToolPolicy evaluates a pending tool call. Calls marked "ask" are sent
+// to approve; a nil approver means no operator is available and the call is
+// denied. Focus rules judge the focused element of ctx's browser scope. The
+// returned arguments reflect any operator edits.
+func enforceToolPolicy(ctx context.Context, name string, args map[string]interface{}, approve toolApprover) (map[string]interface{}, error) {
+	p, err := activeToolPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	req := policy.Request{Tool: name, Args: args}
+	if p.NeedsFocus() {
+		req.Focus = policyFocusDescriptor(ctx)
+	}
+	decision := p.Evaluate(req)
+
+	switch decision.Action {
+	case policy.ActionDeny:
+		return nil, &policy.DeniedError{Tool: name, Reason: decision.Reason}
+	case policy.ActionAsk:
+		if approve == nil {
+			return nil, &policy.DeniedError{Tool: name, Reason: "requires operator approval, but no interactive operator is available"}
+		}
+		edited, ok := approve(name, args, decision.Reason)
+		if !ok {
+			return nil, &policy.DeniedError{Tool: name, Reason: "operator declined"}
+		}
+		if edited == nil {
+			edited = args
+		}
+		// re-check edited arguments so an operator typo cannot bypass a deny rule
+		if recheck := p.Evaluate(policy.Request{Tool: name, Args: edited, Focus: req.Focus}); recheck.Action == policy.ActionDeny {
+			return nil, &policy.DeniedError{Tool: name, Reason: recheck.Reason}
+		}
+		return edited, nil
+	default:
+		return args, nil
+	}
+}
+
+// interactiveToolApprover returns the REPL approver when stdin is a terminal.
+func interactiveToolApprover() toolApprover {
+	if !StdinIsTerminal() {
+		return nil
+	}
+	return promptToolApproval
+}
+
+func promptToolApproval(name string, args map[string]interface{}, reason string) (map[string]interface{}, bool) {
+	argsLabel := formatToolArgs(args)
+	if reason != "" {
+		fmt.Fprintf(os.Stderr, "AI ▶ approval needed for %s (%s)\n", name, reason)
+	}
+	for {
+		answer := strings.ToLower(strings.TrimSpace(GetUserInput(fmt.Sprintf("AI ▶ run %s %s? [y]es/[n]o/[e]dit: ", name, argsLabel))))
+		switch {
+		case strings.HasPrefix(answer, "y"):
+			return args, true
+		case answer == "" || strings.HasPrefix(answer, "n"):
+			return nil, false
+		case strings.HasPrefix(answer, "e"):
+			edited, err := promptEditedArgs(args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "AI ▶ %v\n", err)
+				continue
+			}
+			if AskForConfirmation(fmt.Sprintf("AI ▶ run %s %s? (y/n) ", name, formatToolArgs(edited))) {
+				return edited, true
+			}
+			return nil, false
+		}
+	}
+}
+
+func promptEditedArgs(args map[string]interface{}) (map[string]interface{}, error) {
+	current, _ := json.Marshal(args)
+	fmt.Fprintf(os.Stderr, "AI ▶ current arguments: %s\n", current)
+	raw := strings.TrimSpace(GetUserInput("AI ▶ new arguments as JSON (empty keeps current): "))
+	if raw == "" {
+		return args, nil
+	}
+	var edited map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &edited); err != nil {
+		return nil, fmt.Errorf("invalid JSON arguments: %w", err)
+	}
+	return edited, nil
+}
+
+// describeFocusFunc describes the focused element for policy focus rules.
+var describeFocusFunc = func(el *rod.Element) (*proto.DOMNode, error) {
+	return el.Timeout(5*time.Second).Describe(0, false)
+}
+
+// policyFocusDescriptor renders the focused element of ctx's browser scope
+// for policy focus rules, e.g. `input type="password" name="pw"`.
+func policyFocusDescriptor(ctx context.Context) string {
+	props, _ := inspectPageContext(ctx, func() (*proto.DOMNode, error) {
+		if CurrentElement == nil {
+			return nil, nil
+		}
+		return describeFocusFunc(CurrentElement)
+	})
+	if props == nil {
+		return ""
+	}
+	parts := []string{strings.ToLower(props.NodeName)}
+	for i := 0; i+1 < len(props.Attributes); i += 2 {
+		key := strings.ToLower(strings.TrimSpace(props.Attributes[i]))
+		switch key {
+		case "type", "name", "id", "autocomplete", "role", "aria-label":
+			parts = append(parts, fmt.Sprintf(`%s="%s"`, key, props.Attributes[i+1]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// toolDenialPayload converts a policy denial into the structured error the
+// model receives instead of a tool result.
+func toolDenialPayload(err error) (map[string]interface{}, bool) {
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"error":  denied.Error(),
+		"denied": true,
+		"tool":   denied.Tool,
+		"reason": denied.Reason,
+	}, true
+}
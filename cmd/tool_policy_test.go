@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/mark3labs/mcp-go/mcp"
+	"roderik/internal/ai/llm"
+	"roderik/internal/ai/policy"
+	aitools "roderik/internal/ai/tools"
+)
+
+func installTestPolicy(t *testing.T, cfg policy.Config) {
+	t.Helper()
+	p, err := policy.New(cfg)
+	if err != nil {
+		t.Fatalf("policy.New() error = %v", err)
+	}
+	t.Cleanup(setToolPolicyForTest(p))
+}
+
+func TestEnforceToolPolicyAskWithoutOperatorDenies(t *testing.T) {
+	installTestPolicy(t, policy.Config{Tools: map[string]policy.Action{"run_js": policy.ActionAsk}})
+
+	_, err := enforceToolPolicy(context.Background(), "run_js", map[string]interface{}{"script": "1"}, nil)
+	if !policy.IsDenied(err) {
+		t.Fatalf("expected denial without an approver, got %v", err)
+	}
+}
+
+func TestEnforceToolPolicyUsesEditedArgs(t *testing.T) {
+	installTestPolicy(t, policy.Config{
+		Default:        policy.ActionAsk,
+		AllowedDomains: []string{"example.com"},
+	})
+
+	approve := func(name string, args map[string]interface{}, reason string) (map[string]interface{}, bool) {
+		return map[string]interface{}{"url": "https://example.com/edited"}, true
+	}
+	args, err := enforceToolPolicy(context.Background(), "load_url", map[string]interface{}{"url": "https://example.com/"}, approve)
+	if err != nil {
+		t.Fatalf("enforceToolPolicy() error = %v", err)
+	}
+	if args["url"] != "https://example.com/edited" {
+		t.Fatalf("expected edited url, got %#v", args["url"])
+	}
+
+	escape := func(name string, args map[string]interface{}, reason string) (map[string]interface{}, bool) {
+		return map[string]interface{}{"url": "https://evil.test/"}, true
+	}
+	if _, err := enforceToolPolicy(context.Background(), "load_url", map[string]interface{}{"url": "https://example.com/"}, escape); !policy.IsDenied(err) {
+		t.Fatalf("expected edited url outside the allowlist to be denied, got %v", err)
+	}
+}
+
+func TestExecuteToolCallsReportsDenialWithoutRunning(t *testing.T) {
+	installTestPolicy(t, policy.Config{Tools: map[string]policy.Action{"test_denied_tool": policy.ActionDeny}})
+
+	ran := false
+	aitools.RegisterHandler("test_denied_tool", func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		ran = true
+		return aitools.Result{Text: "ran"}, nil
+	})
+
+	session := &ChatSession{
+		toolRegistry: map[string]aitools.Definition{
+			"roderik__test_denied_tool": {Name: "test_denied_tool"},
+		},
+	}
+	outcomes := session.executeToolCalls(context.Background(), []llm.ToolCall{
+		inlineToolCall{id: "1", name: "roderik__test_denied_tool"},
+	})
+	if ran {
+		t.Fatalf("denied tool should not run")
+	}
+	payload, ok := toolDenialPayload(outcomes[0].err)
+	if !ok {
+		t.Fatalf("expected denial error, got %v", outcomes[0].err)
+	}
+	if payload["denied"] != true || payload["tool"] != "test_denied_tool" {
+		t.Fatalf("unexpected denial payload: %#v", payload)
+	}
+}
+
+func TestExecuteToolCallsChecksFocusAfterEarlierCalls(t *testing.T) {
+	installTestPolicy(t, policy.Config{Rules: []policy.Rule{{Tool: "test_policy_type", Focus: `type="password"`, Action: policy.ActionDeny}}})
+
+	prevDescribe := describeFocusFunc
+	describeFocusFunc = func(*rod.Element) (*proto.DOMNode, error) {
+		return &proto.DOMNode{NodeName: "INPUT", Attributes: []string{"type", "password", "name", "pw"}}, nil
+	}
+	t.Cleanup(func() {
+		describeFocusFunc = prevDescribe
+		CurrentElement = nil
+	})
+
+	// test_policy_focus moves the focus to the password input, as focus does.
+	aitools.RegisterHandler("test_policy_focus", func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		return inspectPageContext(ctx, func() (aitools.Result, error) {
+			CurrentElement = &rod.Element{}
+			return aitools.Result{Text: "focused"}, nil
+		})
+	})
+	typed := false
+	aitools.RegisterHandler("test_policy_type", func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+		typed = true
+		return aitools.Result{Text: "typed"}, nil
+	})
+
+	session := &ChatSession{
+		toolRegistry: map[string]aitools.Definition{
+			"roderik__test_policy_focus": {Name: "test_policy_focus"},
+			"roderik__test_policy_type":  {Name: "test_policy_type"},
+		},
+	}
+	outcomes := session.executeToolCalls(context.Background(), []llm.ToolCall{
+		inlineToolCall{id: "1", name: "roderik__test_policy_focus"},
+		inlineToolCall{id: "2", name: "roderik__test_policy_type", args: map[string]interface{}{"text": "hunter2"}},
+	})
+	if outcomes[0].err != nil {
+		t.Fatalf("focus call error = %v", outcomes[0].err)
+	}
+	if typed || !policy.IsDenied(outcomes[1].err) {
+		t.Fatalf("typing into the newly focused password field was not denied: typed=%t err=%v", typed, outcomes[1].err)
+	}
+}
+
+func TestMCPToolPolicyMiddlewareReturnsToolError(t *testing.T) {
+	installTestPolicy(t, policy.Config{Tools: map[string]policy.Action{"run_js": policy.ActionAsk}})
+
+	called := false
+	handler := mcpToolPolicyMiddleware(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	var req mcp.CallToolRequest
+	req.Params.Name = "run_js"
+	req.Params.Arguments = map[string]interface{}{"script": "1"}
+	res, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("middleware error = %v", err)
+	}
+	if called {
+		t.Fatalf("expected handler to be skipped for an ask tool over MCP")
+	}
+	if !res.IsError || len(res.Content) == 0 {
+		t.Fatalf("expected tool error result, got %#v", res)
+	}
+	text, ok := res.Content[0].(mcp.TextContent)
+	if !ok || !strings.Contains(text.Text, `"denied":true`) {
+		t.Fatalf("expected denial payload, got %#v", res.Content[0])
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	aitools "roderik/internal/ai/tools"
+)
+
+// toolsHelpCmd is a help topic ("roderik help tools") generated from the tool
+// registry, so it lists exactly what the AI assistant and MCP clients see.
+var toolsHelpCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Tools available to the AI assistant and MCP clients",
+}
+
+func init() {
+	toolsHelpCmd.Long = "Tools available to the AI assistant (ai) and to MCP clients (mcp).\n\n" + toolsHelpText(aitools.List())
+	RootCmd.AddCommand(toolsHelpCmd)
+}
+
+// toolsHelpText renders each definition with its parameters, types,
+// defaults, enums and bounds.
+func toolsHelpText(defs []aitools.Definition) string {
+	var b strings.Builder
+	for i, def := range defs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(def.Name)
+		var tags []string
+		if def.Capability != "" {
+			tags = append(tags, "capability "+def.Capability)
+		}
+		if def.MCPOnly {
+			tags = append(tags, "MCP only")
+		}
+		if def.Gate == aitools.GateNavigation {
+			tags = append(tags, "off when RODERIK_ENABLE_LOAD_URL=false")
+		}
+		if len(tags) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(tags, ", "))
+		}
+		summary, _, _ := strings.Cut(strings.TrimSpace(def.Description), "\n")
+		fmt.Fprintf(&b, "\n  %s\n", summary)
+		for _, p := range def.Parameters {
+			fmt.Fprintf(&b, "    %s %s", p.Name, parameterTypeLabel(p))
+			if p.Required {
+				b.WriteString(", required")
+			}
+			if p.Default != nil {
+				fmt.Fprintf(&b, ", default %v", p.Default)
+			}
+			if len(p.Enum) > 0 {
+				fmt.Fprintf(&b, ", one of %s", strings.Join(p.Enum, "|"))
+			}
+			if p.Minimum != nil || p.Maximum != nil {
+				fmt.Fprintf(&b, ", range %s..%s", formatBound(p.Minimum), formatBound(p.Maximum))
+			}
+			if p.Description != "" {
+				fmt.Fprintf(&b, ": %s", p.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func parameterTypeLabel(p aitools.Parameter) string {
+	if p.Type != aitools.ParamArray {
+		return p.Type.JSONType()
+	}
+	items := p.Items
+	if items == "" {
+		items = aitools.ParamString
+	}
+	return "[]" + items.JSONType()
+}
+
+func formatBound(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/spf13/cobra"
+	"roderik/internal/appdirs"
+	"roderik/internal/subtitles"
+)
+
+// transcriptsMaxProbe caps how many unhinted JSON/XML responses are fetched
+// just to check whether their body is a caption document.
+const transcriptsMaxProbe = 50
+
+var (
+	transcriptsFormat          string
+	transcriptsOutputDir       string
+	transcriptsNoSave          bool
+	transcriptsPrint           bool
+	transcriptsRequestIDs      []string
+	transcriptsPrefix          string
+	transcriptsSuffix          string
+	transcriptsUseTimestamp    bool
+	transcriptsTimestampFormat string
+
+	transcriptsCmd = &cobra.Command{
+		Use:   "transcripts",
+		Short: "Extract captions/subtitles from the captured network traffic",
+		Long: `transcripts scans the active network log for caption-like responses
+(text/vtt, .srt/.vtt files, YouTube-style timedtext JSON/XML, or any JSON/XML
+body shaped like a caption track), converts each to a unified transcript with
+timings, and saves it next to other network captures.
+
+Load the page and start playback (with captions enabled) first so the player
+requests its caption tracks.`,
+		Example: `  # Save every caption track seen so far as timestamped markdown
+  roderik transcripts
+
+  # Print plain text for one request without saving
+  roderik transcripts --request-id 1234.56 --format text --no-save --print`,
+		RunE: runTranscripts,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(transcriptsCmd)
+	flags := transcriptsCmd.Flags()
+	flags.StringVarP(&transcriptsFormat, "format", "f", subtitles.FormatMarkdown, "output format: "+strings.Join(subtitles.Formats, ", "))
+	flags.StringVar(&transcriptsOutputDir, "output", defaultDownloadsDir(), "Directory to write transcripts")
+	flags.BoolVar(&transcriptsNoSave, "no-save", false, "Only list (and with --print, show) transcripts without writing files")
+	flags.BoolVar(&transcriptsPrint, "print", false, "Print each transcript to stdout")
+	flags.StringSliceVar(&transcriptsRequestIDs, "request-id", nil, "Only consider these request IDs")
+	flags.StringVar(&transcriptsPrefix, "filename-prefix", "", "Optional filename prefix when saving")
+	flags.StringVar(&transcriptsSuffix, "filename-suffix", "", "Optional filename suffix when saving")
+	flags.BoolVar(&transcriptsUseTimestamp, "filename-timestamp", false, "Include a timestamp in saved filenames")
+	flags.StringVar(&transcriptsTimestampFormat, "filename-timestamp-format", "2006-01-02_150405", "Go time format for timestamps when --filename-timestamp is set")
+}
+
+// transcriptOptions controls extractTranscripts.
+type transcriptOptions struct {
+	Format     string
+	RequestIDs []string
+	// SaveDir is where transcripts are written; empty disables saving.
+	SaveDir string
+	Naming  FileNamingOptions
+}
+
+// capturedTranscript is one caption response converted to a transcript.
+type capturedTranscript struct {
+	Entry    *NetworkLogEntry
+	Kind     string
+	Cues     int
+	Duration time.Duration
+	Text     string
+	Path     string
+	Err      error
+}
+
+// bodyFetcher returns the response body for a network entry.
+type bodyFetcher func(entry *NetworkLogEntry) ([]byte, error)
+
+func runTranscripts(cmd *cobra.Command, args []string) error {
+	log := getActiveEventLog()
+	if log == nil {
+		return fmt.Errorf("no active network log; load a page first")
+	}
+	opts := transcriptOptions{
+		Format:     transcriptsFormat,
+		RequestIDs: transcriptsRequestIDs,
+		Naming: FileNamingOptions{
+			Prefix:           transcriptsPrefix,
+			Suffix:           transcriptsSuffix,
+			IncludeTimestamp: transcriptsUseTimestamp,
+			TimestampFormat:  transcriptsTimestampFormat,
+		},
+	}
+	if !transcriptsNoSave {
+		opts.SaveDir = transcriptsOutputDir
+	}
+	found, err := extractTranscripts(log.Entries(), opts, pageBodyFetcher)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		fmt.Fprintln(os.Stderr, "no caption responses found in the network log")
+		return nil
+	}
+	for _, t := range found {
+		if t.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", t.Entry.RequestID, t.Entry.URL, t.Err)
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "%s\t%s\t%d cues\t%s\t%s\n", t.Entry.RequestID, t.Kind, t.Cues, subtitles.Timestamp(t.Duration), t.Entry.URL)
+		if t.Path != "" {
+			fmt.Fprintf(os.Stdout, "  saved to %s\n", t.Path)
+		}
+		if transcriptsPrint {
+			fmt.Fprintf(os.Stdout, "\n%s\n\n", t.Text)
+		}
+	}
+	return nil
+}
+
+// pageBodyFetcher returns stored bodies directly and asks the browser for
+// the rest.
+func pageBodyFetcher(entry *NetworkLogEntry) ([]byte, error) {
+	return fetchPageBody(context.Background(), entry)
+}
+
+// fetchPageBody is pageBodyFetcher for the browser scope selected by ctx.
+func fetchPageBody(ctx context.Context, entry *NetworkLogEntry) ([]byte, error) {
+	if entry.Body != nil {
+		return append([]byte(nil), entry.Body.Data...), nil
+	}
+	return withPageContext(ctx, func() ([]byte, error) {
+		return retrieveNetworkBody(Page, entry)
+	})
+}
+
+// extractTranscripts converts every caption-like response in entries. Entries
+// whose body turns out not to be captions are skipped silently; failures on
+// strongly hinted entries (caption MIME, suffix or URL) are reported.
+func extractTranscripts(entries []*NetworkLogEntry, opts transcriptOptions, fetch bodyFetcher) ([]capturedTranscript, error) {
+	if _, err := subtitles.Render(nil, opts.Format); err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(opts.RequestIDs))
+	for _, id := range opts.RequestIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
+		}
+	}
+	if opts.SaveDir != "" {
+		if err := appdirs.EnsureDir(opts.SaveDir); err != nil {
+			return nil, fmt.Errorf("transcripts: create directory: %w", err)
+		}
+	}
+
+	var out []capturedTranscript
+	used := make(map[string]int)
+	probed := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry == nil || (len(wanted) > 0 && !wanted[entry.RequestID]) {
+			continue
+		}
+		strong, weak := captionHint(entry)
+		if !strong && !weak && len(wanted) == 0 {
+			continue
+		}
+		if !strong && len(wanted) == 0 {
+			if probed >= transcriptsMaxProbe {
+				continue
+			}
+			probed++
+		}
+
+		t := capturedTranscript{Entry: entry}
+		body, err := fetch(entry)
+		if err != nil {
+			if strong || len(wanted) > 0 {
+				t.Err = err
+				out = append(out, t)
+			}
+			continue
+		}
+		t.Kind = subtitles.Sniff(body)
+		if t.Kind == "" {
+			if strong || len(wanted) > 0 {
+				t.Err = subtitles.ErrUnknownFormat
+				out = append(out, t)
+			}
+			continue
+		}
+		cues, err := subtitles.Parse(body)
+		if err == nil && len(cues) == 0 {
+			err = fmt.Errorf("caption track is empty")
+		}
+		if err != nil {
+			t.Err = err
+			out = append(out, t)
+			continue
+		}
+		cues = subtitles.Dedupe(cues)
+		t.Cues = len(cues)
+		t.Duration = cues[len(cues)-1].End
+		t.Text, _ = subtitles.Render(cues, opts.Format)
+
+		if opts.SaveDir != "" {
+			name := buildFilenameForEntry(entry, len(out), opts.Naming)
+			name = strings.TrimSuffix(name, filepath.Ext(name)) + subtitles.Extension(opts.Format)
+			name = ensureUniqueFilename(opts.SaveDir, name, used)
+			t.Path = filepath.Join(opts.SaveDir, name)
+			if err := os.WriteFile(t.Path, []byte(t.Text), 0o644); err != nil {
+				t.Err = fmt.Errorf("write %s: %w", t.Path, err)
+				t.Path = ""
+			}
+		}
+		out = append(out, t)
+	}
+	// report in capture order
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// captionMIMEs are response types that are caption documents by definition.
+var captionMIMEs = []string{"text/vtt", "application/x-subrip", "text/srt", "application/ttml+xml", "text/x-ssa"}
+
+// captionHint classifies an entry: strong when its MIME, file suffix or URL
+// says captions; weak when it is a finished JSON/XML XHR/fetch response that
+// is worth sniffing.
+func captionHint(entry *NetworkLogEntry) (strong, weak bool) {
+	if entry.Response == nil || entry.Response.Status < 200 || entry.Response.Status >= 300 || entry.Failure != nil {
+		return false, false
+	}
+	mime := strings.ToLower(entry.Response.MIMEType)
+	for _, m := range captionMIMEs {
+		if strings.Contains(mime, m) {
+			return true, false
+		}
+	}
+	u, err := url.Parse(entry.URL)
+	if err == nil {
+		lowerPath := strings.ToLower(u.Path)
+		switch path.Ext(lowerPath) {
+		case ".vtt", ".webvtt", ".srt":
+			return true, false
+		}
+		if strings.Contains(lowerPath, "timedtext") || strings.Contains(lowerPath, "caption") || strings.Contains(lowerPath, "subtitle") {
+			return true, false
+		}
+	}
+	if entry.Finished == nil {
+		return false, false
+	}
+	switch entry.ResourceType {
+	case proto.NetworkResourceTypeXHR, proto.NetworkResourceTypeFetch:
+		return false, strings.Contains(mime, "json") || strings.Contains(mime, "xml")
+	}
+	return false, false
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestTranscriptsHandlerExtractsCaptionResponses(t *testing.T) {
+	prev := getActiveEventLog()
+	t.Cleanup(func() { setActiveEventLog(prev) })
+
+	log := newNetworkEventLog()
+	add := func(id, rawURL, mime string, rt proto.NetworkResourceType, body string) {
+		entry := &NetworkLogEntry{
+			RequestID:    id,
+			URL:          rawURL,
+			Method:       "GET",
+			ResourceType: rt,
+			Response:     &NetworkResponseInfo{Status: 200, MIMEType: mime},
+			Finished:     &NetworkFinishedInfo{},
+		}
+		if body != "" {
+			entry.Body = &NetworkBody{Data: []byte(body)}
+		}
+		log.entries[id] = entry
+		log.order = append(log.order, id)
+	}
+	add("1", "https://cdn.example.com/video/en.vtt", "text/vtt", proto.NetworkResourceTypeXHR,
+		"WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nhello\n\n00:00:02.000 --> 00:00:04.000\nhello\nworld\n")
+	add("2", "https://api.example.com/player/track", "application/json", proto.NetworkResourceTypeFetch,
+		`{"events":[{"tStartMs":65000,"dDurationMs":1000,"segs":[{"utf8":"json captions"}]}]}`)
+	add("3", "https://api.example.com/config", "application/json", proto.NetworkResourceTypeFetch, `{"ok":true}`)
+	add("4", "https://example.com/app.js", "application/javascript", proto.NetworkResourceTypeScript, "")
+	setActiveEventLog(log)
+
+	dir := t.TempDir()
+	res, err := transcriptsHandler(context.Background(), map[string]interface{}{
+		"save_dir":        dir,
+		"filename_prefix": "talk",
+	})
+	if err != nil {
+		t.Fatalf("transcriptsHandler() error = %v", err)
+	}
+	for _, want := range []string{"request 1", "format: vtt, cues: 2", "[00:01] hello world", "request 2", "[01:05] json captions"} {
+		if !strings.Contains(res.Text, want) {
+			t.Fatalf("result missing %q:\n%s", want, res.Text)
+		}
+	}
+	if strings.Contains(res.Text, "config") || strings.Contains(res.Text, "app.js") {
+		t.Fatalf("non-caption responses should be skipped:\n%s", res.Text)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "talk_*.md"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 saved markdown transcripts, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil || !strings.HasPrefix(string(data), "[") {
+		t.Fatalf("unexpected saved transcript %q (err=%v)", data, err)
+	}
+}
+
+func TestExtractTranscriptsReportsExplicitNonCaption(t *testing.T) {
+	entry := &NetworkLogEntry{
+		RequestID: "9",
+		URL:       "https://example.com/data.json",
+		Response:  &NetworkResponseInfo{Status: 200, MIMEType: "application/json"},
+		Body:      &NetworkBody{Data: []byte(`{"hello":"world"}`)},
+	}
+	found, err := extractTranscripts([]*NetworkLogEntry{entry}, transcriptOptions{Format: "text", RequestIDs: []string{"9"}}, pageBodyFetcher)
+	if err != nil {
+		t.Fatalf("extractTranscripts() error = %v", err)
+	}
+	if len(found) != 1 || found[0].Err == nil {
+		t.Fatalf("expected an error for an explicitly requested non-caption body, got %#v", found)
+	}
+	if _, err := extractTranscripts(nil, transcriptOptions{Format: "pdf"}, pageBodyFetcher); err == nil {
+		t.Fatalf("expected unknown format to be rejected")
+	}
+}
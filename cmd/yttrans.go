@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -10,11 +11,14 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"roderik/internal/subtitles"
 )
 
 var (
 	ytLanguage     string
 	ytOutputFolder string
+	ytFormat       string
+	ytSource       string
 
 	// YTTransCmd downloads YouTube transcripts via yt-dlp and prints cleaned text.
 	YTTransCmd = &cobra.Command{
@@ -25,7 +29,15 @@ var (
 		Long: `yttrans uses yt-dlp to fetch the auto-generated or uploaded transcript for a
 YouTube video, converts it to plain text, and stores both the original VTT and
 cleaned TXT inside a local cache directory. The resulting text is also written to
-stdout so other commands can capture it.`,
+stdout so other commands can capture it.
+
+When yt-dlp is not installed (or --source browser is given) the video is opened
+in the browser and the caption track is read from the page's network log,
+asking the player to load captions if it has not requested them yet.
+
+Rolling auto-caption lines are merged so each line appears once. --format picks
+the output: text (paragraphs), markdown ("[mm:ss]" paragraphs) or json (timed
+segments).`,
 	}
 )
 
@@ -34,44 +46,65 @@ func init() {
 	flags := YTTransCmd.Flags()
 	flags.StringVarP(&ytLanguage, "language", "l", "en", "language code to request (e.g. en, de)")
 	flags.StringVarP(&ytOutputFolder, "output-folder", "o", "./yttrans-cache", "folder for cached transcripts")
+	flags.StringVarP(&ytFormat, "format", "f", subtitles.FormatText, "output format: "+strings.Join(subtitles.Formats, ", "))
+	flags.StringVar(&ytSource, "source", transcriptSourceAuto, "where to fetch captions: auto, yt-dlp or browser")
 }
 
+// Transcript sources; auto prefers yt-dlp and falls back to the browser.
+const (
+	transcriptSourceAuto    = "auto"
+	transcriptSourceYTDLP   = "yt-dlp"
+	transcriptSourceBrowser = "browser"
+)
+
 // TranscriptOptions controls how a transcript download is performed.
 type TranscriptOptions struct {
 	Language     string
 	URL          string
 	OutputFolder string
+	// Format is a subtitles output format (text, markdown or json).
+	Format string
+	// Source is auto, yt-dlp or browser.
+	Source string
+	// Context selects the browser scope the browser source reads and cancels
+	// its wait for captions; nil means context.Background().
+	Context context.Context
 }
 
 // TranscriptResult provides metadata about downloaded transcripts.
 type TranscriptResult struct {
 	VideoID  string
 	Language string
+	// VTTPath is the original caption file: the VTT written by yt-dlp or the
+	// raw track captured by the browser (VTT, json3 or XML).
 	VTTPath  string
 	TextPath string
 	Text     string
+	Format   string
+	Source   string
 }
 
 func runYTTrans(cmd *cobra.Command, args []string) error {
-	opts := TranscriptOptions{Language: ytLanguage, URL: args[0], OutputFolder: ytOutputFolder}
+	opts := TranscriptOptions{Language: ytLanguage, URL: args[0], OutputFolder: ytOutputFolder, Format: ytFormat, Source: ytSource}
 	res, err := DownloadAndProcessTranscript(opts)
 	if err != nil {
 		return err
 	}
-	cmd.Printf("Transcript saved to %s (language=%s)\n\n%s\n", res.TextPath, res.Language, res.Text)
+	cmd.Printf("Transcript saved to %s (language=%s, source=%s)\n\n%s\n", res.TextPath, res.Language, res.Source, res.Text)
 	return nil
 }
 
-// DownloadAndProcessTranscript downloads a YouTube transcript via yt-dlp, converts it to text, and saves it alongside the VTT file.
+// DownloadAndProcessTranscript downloads a YouTube transcript (via yt-dlp, or
+// the browser's network log when yt-dlp is unavailable), converts it to the
+// requested format, and saves it alongside the original caption file.
 func DownloadAndProcessTranscript(opts TranscriptOptions) (TranscriptResult, error) {
-	result := TranscriptResult{Language: opts.Language}
+	result := TranscriptResult{Language: opts.Language, Format: opts.Format}
+	if result.Format == "" {
+		result.Format = subtitles.FormatText
+	}
 	if strings.TrimSpace(opts.URL) == "" {
 		return result, errors.New("yttrans: URL is required")
 	}
-	ytBinary, err := exec.LookPath("yt-dlp")
-	if err != nil {
-		return result, fmt.Errorf("yttrans: yt-dlp not found in PATH: %w", err)
-	}
 	videoID := extractVideoID(opts.URL)
 	if videoID == "" {
 		return result, fmt.Errorf("yttrans: could not extract video id from %q", opts.URL)
@@ -81,6 +114,57 @@ func DownloadAndProcessTranscript(opts TranscriptOptions) (TranscriptResult, err
 		return result, fmt.Errorf("yttrans: create output dir: %w", err)
 	}
 
+	source := strings.ToLower(strings.TrimSpace(opts.Source))
+	switch source {
+	case "", transcriptSourceAuto:
+		if _, err := exec.LookPath("yt-dlp"); err != nil {
+			source = transcriptSourceBrowser
+		} else {
+			source = transcriptSourceYTDLP
+		}
+	case transcriptSourceYTDLP, transcriptSourceBrowser:
+	default:
+		return result, fmt.Errorf("yttrans: unknown source %q (expected auto, yt-dlp or browser)", opts.Source)
+	}
+	result.Source = source
+
+	var err error
+	if source == transcriptSourceBrowser {
+		result.VTTPath, err = fetchTranscriptViaBrowser(opts, videoID)
+	} else {
+		result.VTTPath, err = fetchTranscriptViaYTDLP(opts, videoID)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	raw, err := os.ReadFile(result.VTTPath)
+	if err != nil {
+		return result, fmt.Errorf("yttrans: read %s: %w", result.VTTPath, err)
+	}
+	cues, err := subtitles.Parse(raw)
+	if err != nil {
+		return result, fmt.Errorf("yttrans: parse %s: %w", result.VTTPath, err)
+	}
+	text, err := subtitles.Render(subtitles.Dedupe(cues), result.Format)
+	if err != nil {
+		return result, fmt.Errorf("yttrans: %w", err)
+	}
+	result.TextPath = strings.TrimSuffix(result.VTTPath, filepath.Ext(result.VTTPath)) + subtitles.Extension(result.Format)
+	if err := os.WriteFile(result.TextPath, []byte(text), 0o644); err != nil {
+		return result, fmt.Errorf("yttrans: write %s: %w", result.TextPath, err)
+	}
+	result.Text = text
+	return result, nil
+}
+
+// fetchTranscriptViaYTDLP runs yt-dlp and returns the path of the VTT it wrote.
+func fetchTranscriptViaYTDLP(opts TranscriptOptions, videoID string) (string, error) {
+	ytBinary, err := exec.LookPath("yt-dlp")
+	if err != nil {
+		return "", fmt.Errorf("yttrans: yt-dlp not found in PATH: %w", err)
+	}
+
 	// Use yt-dlp to download subtitles without media; template ensures unique names per video title+id.
 	// We convert subtitles to VTT so downstream parsing remains stable.
 	cmd := exec.Command(
@@ -94,83 +178,18 @@ func DownloadAndProcessTranscript(opts TranscriptOptions) (TranscriptResult, err
 		opts.URL,
 	)
 	if err := cmd.Run(); err != nil {
-		return result, fmt.Errorf("yttrans: yt-dlp failed: %w", err)
+		return "", fmt.Errorf("yttrans: yt-dlp failed: %w", err)
 	}
 
 	vttPattern := filepath.Join(opts.OutputFolder, fmt.Sprintf("*%s*.vtt", videoID))
 	matches, err := filepath.Glob(vttPattern)
 	if err != nil {
-		return result, fmt.Errorf("yttrans: glob vtt: %w", err)
+		return "", fmt.Errorf("yttrans: glob vtt: %w", err)
 	}
 	if len(matches) == 0 {
-		return result, fmt.Errorf("yttrans: no VTT transcript found for %s", videoID)
-	}
-	result.VTTPath = matches[0]
-	raw, err := os.ReadFile(result.VTTPath)
-	if err != nil {
-		return result, fmt.Errorf("yttrans: read %s: %w", result.VTTPath, err)
-	}
-
-	text := vttToText(string(raw))
-	result.TextPath = strings.TrimSuffix(result.VTTPath, filepath.Ext(result.VTTPath)) + ".txt"
-	if err := os.WriteFile(result.TextPath, []byte(text), 0o644); err != nil {
-		return result, fmt.Errorf("yttrans: write %s: %w", result.TextPath, err)
-	}
-	result.Text = text
-	return result, nil
-}
-
-var (
-	timestampRe    = regexp.MustCompile(`(?m)^(\d{2}:\d{2})(:\d{2}\.\d{3})?\s+-->.*$`)
-	markupRe       = regexp.MustCompile(`</c>|<c(\.color\w+)?>|<\d{2}:\d{2}:\d{2}\.\d{3}>`)
-	multiSpaceRe   = regexp.MustCompile(` +`)
-	shortLineLimit = 80
-)
-
-func vttToText(vtt string) string {
-	cleaned := markupRe.ReplaceAllString(vtt, "")
-	cleaned = timestampRe.ReplaceAllString(cleaned, "$1")
-	lines := strings.Split(cleaned, "\n")
-
-	var out []string
-	var lastTimestamp, buffer string
-
-	flush := func() {
-		if strings.TrimSpace(buffer) == "" {
-			buffer = ""
-			return
-		}
-		out = append(out, multiSpaceRe.ReplaceAllString(strings.TrimSpace(buffer), " "))
-		buffer = ""
+		return "", fmt.Errorf("yttrans: no VTT transcript found for %s", videoID)
 	}
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		if len(line) == 5 && line[2] == ':' { // timestamp HH:MM
-			if line != lastTimestamp {
-				flush()
-				out = append(out, "\n"+line)
-				lastTimestamp = line
-			}
-			continue
-		}
-		if len(buffer)+len(line)+1 <= shortLineLimit {
-			if buffer == "" {
-				buffer = line
-			} else {
-				buffer += " " + line
-			}
-		} else {
-			flush()
-			buffer = line
-		}
-	}
-	flush()
-
-	return strings.Join(out, "\n")
+	return matches[0], nil
 }
 
 var videoIDPattern = regexp.MustCompile(`(?:v=|/)([0-9A-Za-z_-]{11})`)
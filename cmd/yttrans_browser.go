@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"roderik/internal/subtitles"
+)
+
+// captionWaitTimeout bounds how long we wait for the player to request a
+// caption track after being asked to show captions.
+const captionWaitTimeout = 10 * time.Second
+
+// enableCaptionsJS asks the YouTube player to load the captions module and
+// select a track, which makes it request /api/timedtext.
+const enableCaptionsJS = `(lang) => {
+	const p = document.getElementById('movie_player');
+	if (!p || typeof p.setOption !== 'function') return false;
+	try {
+		if (typeof p.loadModule === 'function') p.loadModule('captions');
+		p.setOption('captions', 'track', {languageCode: lang});
+		return true;
+	} catch (e) {
+		return false;
+	}
+}`
+
+// fetchCaptionTrackJS fetches the best matching caption track listed in the
+// player response from inside the page, so the page's cookies apply.
+const fetchCaptionTrackJS = `async (lang) => {
+	const p = document.getElementById('movie_player');
+	const resp = (p && typeof p.getPlayerResponse === 'function' && p.getPlayerResponse()) || window.ytInitialPlayerResponse;
+	const tracks = (((resp || {}).captions || {}).playerCaptionsTracklistRenderer || {}).captionTracks || [];
+	if (!tracks.length) return '';
+	const pick = tracks.find(t => t.languageCode === lang && t.kind !== 'asr')
+		|| tracks.find(t => t.languageCode === lang)
+		|| tracks.find(t => (t.languageCode || '').split('-')[0] === lang.split('-')[0])
+		|| tracks[0];
+	const res = await fetch(pick.baseUrl + '&fmt=json3', {credentials: 'include'});
+	return res.ok ? await res.text() : '';
+}`
+
+// fetchTranscriptViaBrowser opens the video (unless it is already the current
+// page), reads its caption track from the network log and stores the raw
+// payload in opts.OutputFolder.
+func fetchTranscriptViaBrowser(opts TranscriptOptions, videoID string) (string, error) {
+	lang := strings.TrimSpace(opts.Language)
+	if lang == "" {
+		lang = "en"
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	raw, err := withPageContext(ctx, func() ([]byte, error) {
+		if info, err := Page.Info(); err != nil || extractVideoID(info.URL) != videoID {
+			if _, err := LoadURLContext(ctx, opts.URL); err != nil {
+				return nil, fmt.Errorf("yttrans: load %s: %w", opts.URL, err)
+			}
+			if body, err := Page.Element("body"); err == nil {
+				CurrentElement = body
+			}
+		}
+
+		if body := capturedCaptionBody(videoID, lang); body != nil {
+			return body, nil
+		}
+
+		if res, err := Page.Context(ctx).Eval(enableCaptionsJS, lang); err == nil && res.Value.Bool() {
+			deadline := time.Now().Add(captionWaitTimeout)
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				time.Sleep(250 * time.Millisecond)
+				if body := capturedCaptionBody(videoID, lang); body != nil {
+					return body, nil
+				}
+			}
+		}
+
+		res, err := Page.Context(ctx).Timeout(captionWaitTimeout).Eval(fetchCaptionTrackJS, lang)
+		if err != nil {
+			return nil, fmt.Errorf("yttrans: read caption tracks: %w", err)
+		}
+		if text := res.Value.Str(); strings.TrimSpace(text) != "" {
+			return []byte(text), nil
+		}
+		return nil, errors.New("yttrans: no caption track found in the page (the video may have no captions in this language)")
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ext := ".vtt"
+	switch subtitles.Sniff(raw) {
+	case subtitles.KindJSON3:
+		ext = ".json3"
+	case subtitles.KindTimedTextXML:
+		ext = ".xml"
+	case subtitles.KindSRT:
+		ext = ".srt"
+	}
+	path := filepath.Join(opts.OutputFolder, fmt.Sprintf("%s.%s%s", videoID, lang, ext))
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return "", fmt.Errorf("yttrans: write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// capturedCaptionBody returns the body of the newest finished timedtext
+// response for videoID/lang in the active network log. Callers hold pageMu.
+func capturedCaptionBody(videoID, lang string) []byte {
+	log := getActiveEventLog()
+	if log == nil {
+		return nil
+	}
+	entry := findCaptionEntry(log.Entries(), videoID, lang)
+	if entry == nil {
+		return nil
+	}
+	body, err := retrieveNetworkBody(Page, entry)
+	if err != nil || subtitles.Sniff(body) == "" {
+		return nil
+	}
+	return body
+}
+
+// findCaptionEntry picks the newest finished /api/timedtext request for the
+// video in the requested language (or a translation into it).
+func findCaptionEntry(entries []*NetworkLogEntry, videoID, lang string) *NetworkLogEntry {
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry == nil || entry.Finished == nil || entry.Response == nil || entry.Response.Status != 200 {
+			continue
+		}
+		u, err := url.Parse(entry.URL)
+		if err != nil || !strings.HasSuffix(u.Path, "/api/timedtext") {
+			continue
+		}
+		q := u.Query()
+		if v := q.Get("v"); v != "" && v != videoID {
+			continue
+		}
+		if matchesLanguage(q.Get("tlang"), lang) || (q.Get("tlang") == "" && matchesLanguage(q.Get("lang"), lang)) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func matchesLanguage(got, want string) bool {
+	if got == "" || want == "" {
+		return false
+	}
+	got, want = strings.ToLower(got), strings.ToLower(want)
+	return got == want || strings.SplitN(got, "-", 2)[0] == strings.SplitN(want, "-", 2)[0]
+}
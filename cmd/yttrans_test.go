@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFindCaptionEntryPrefersRequestedLanguage(t *testing.T) {
+	finished := func(id, rawURL string) *NetworkLogEntry {
+		return &NetworkLogEntry{
+			RequestID: id,
+			URL:       rawURL,
+			Response:  &NetworkResponseInfo{Status: 200},
+			Finished:  &NetworkFinishedInfo{},
+		}
+	}
+	entries := []*NetworkLogEntry{
+		finished("1", "https://www.youtube.com/api/timedtext?v=dQw4w9WgXcQ&lang=en&fmt=json3"),
+		finished("2", "https://www.youtube.com/api/timedtext?v=dQw4w9WgXcQ&lang=de&fmt=json3"),
+		finished("3", "https://www.youtube.com/api/timedtext?v=otherVideo1&lang=en"),
+		{RequestID: "4", URL: "https://www.youtube.com/api/timedtext?v=dQw4w9WgXcQ&lang=en"},
+		finished("5", "https://www.youtube.com/youtubei/v1/player"),
+	}
+
+	if got := findCaptionEntry(entries, "dQw4w9WgXcQ", "en-US"); got == nil || got.RequestID != "1" {
+		t.Fatalf("expected entry 1, got %#v", got)
+	}
+	if got := findCaptionEntry(entries, "dQw4w9WgXcQ", "de"); got == nil || got.RequestID != "2" {
+		t.Fatalf("expected entry 2, got %#v", got)
+	}
+	if got := findCaptionEntry(entries, "dQw4w9WgXcQ", "fr"); got != nil {
+		t.Fatalf("expected no match for fr, got %#v", got)
+	}
+}
+
+func TestDownloadAndProcessTranscriptRejectsUnknownSource(t *testing.T) {
+	_, err := DownloadAndProcessTranscript(TranscriptOptions{
+		URL:          "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		OutputFolder: t.TempDir(),
+		Source:       "vhs",
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown source") {
+		t.Fatalf("expected unknown source error, got %v", err)
+	}
+}
+
+func TestDownloadAndProcessTranscriptViaYTDLPStub(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("yt-dlp stub is a shell script")
+	}
+	dir := t.TempDir()
+	bin := t.TempDir()
+	script := "#!/bin/sh\ncat > \"" + filepath.Join(dir, "Video-dQw4w9WgXcQ.en.vtt") + "\" <<'VTT'\nWEBVTT\n\n00:00:01.000 --> 00:00:02.000\nhello\n\n00:00:02.000 --> 00:00:03.000\nhello\nworld\nVTT\n"
+	if err := os.WriteFile(filepath.Join(bin, "yt-dlp"), []byte(script), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	res, err := DownloadAndProcessTranscript(TranscriptOptions{
+		Language:     "en",
+		URL:          "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		OutputFolder: dir,
+		Format:       "markdown",
+	})
+	if err != nil {
+		t.Fatalf("DownloadAndProcessTranscript() error = %v", err)
+	}
+	if res.Source != transcriptSourceYTDLP || filepath.Ext(res.TextPath) != ".md" {
+		t.Fatalf("unexpected result %#v", res)
+	}
+	if res.Text != "[00:01] hello world" {
+		t.Fatalf("unexpected transcript %q", res.Text)
+	}
+}
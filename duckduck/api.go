@@ -1,8 +1,10 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -25,6 +27,10 @@ type DuckDuckGoSearchClient struct {
 	Backoff      time.Duration
 	client       *http.Client
 	UserAgent    string
+	// MaxPages caps how many result pages are followed when the limit is not
+	// met by the first one; PageDelay is slept between page requests.
+	MaxPages  int
+	PageDelay time.Duration
 }
 
 var ErrBotChallenge = errors.New("duckduckgo bot challenge detected")
@@ -64,6 +70,8 @@ func NewDuckDuckGoSearchClient() *DuckDuckGoSearchClient {
 		MaxRetries:   3,
 		InitialDelay: 5 * time.Second,
 		Backoff:      4 * time.Second,
+		MaxPages:     5,
+		PageDelay:    time.Second,
 		client:       httpClient,
 		// a realistic Chrome user‐agent to reduce rate‐limiting
 		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) " +
@@ -76,57 +84,215 @@ func (c *DuckDuckGoSearchClient) Search(query string) ([]Result, error) {
 }
 
 func (c *DuckDuckGoSearchClient) SearchLimited(query string, limit int) ([]Result, error) {
-	queryUrl := c.baseUrl + "?q=" + url.QueryEscape(query)
+	return c.SearchWithOptions(query, limit, SearchOptions{})
+}
+
+// SearchWithOptions applies region, safe-search and time filters and, when
+// limit exceeds one page, follows the "Next" form for up to MaxPages pages.
+func (c *DuckDuckGoSearchClient) SearchWithOptions(query string, limit int, opts SearchOptions) ([]Result, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	opts.ApplyDuckDuckGoParams(params)
 
+	ctx := opts.ctx()
 	if c.InitialDelay > 0 {
-		time.Sleep(c.InitialDelay)
+		if err := sleepContext(ctx, c.InitialDelay); err != nil {
+			return nil, err
+		}
 	}
 
-	var resp *http.Response
-	var err error
+	maxPages := c.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+	var results []Result
+	method := http.MethodGet
+	target := c.baseUrl
+	for page := 0; page < maxPages; page++ {
+		if page > 0 && c.PageDelay > 0 {
+			if err := sleepContext(ctx, c.PageDelay); err != nil {
+				return nil, err
+			}
+		}
+		body, err := c.fetch(ctx, method, target, params)
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			// the endpoint kept answering without content
+			break
+		}
+		parsed, err := ParseHTMLPage(strings.NewReader(string(body)), 0)
+		if err != nil {
+			if page > 0 && IsChallengeError(err) && len(results) > 0 {
+				break
+			}
+			return nil, err
+		}
+		results = append(results, dropAds(parsed.Results, opts)...)
+		if limit <= 0 || len(results) >= limit || parsed.Next == nil {
+			break
+		}
+		method, target, params = http.MethodPost, c.resolve(parsed.NextAction), parsed.Next
+		opts.ApplyDuckDuckGoParams(params)
+	}
+	if results == nil {
+		results = []Result{}
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// fetch performs one request with the 2xx-retry policy. A nil body with a nil
+// error means the endpoint never returned 200.
+func (c *DuckDuckGoSearchClient) fetch(ctx context.Context, method, target string, params url.Values) ([]byte, error) {
 	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
-		req, _ := http.NewRequest("GET", queryUrl, nil)
+		var req *http.Request
+		if method == http.MethodPost {
+			req, _ = http.NewRequestWithContext(ctx, method, target, strings.NewReader(params.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			req, _ = http.NewRequestWithContext(ctx, method, target+"?"+params.Encode(), nil)
+		}
 		req.Header.Set("User-Agent", c.UserAgent)
-		resp, err = c.client.Do(req)
+		resp, err := c.client.Do(req)
 		if err != nil {
 			return nil, err
 		}
 		if resp.StatusCode == http.StatusOK {
-			break
+			defer resp.Body.Close()
+			return io.ReadAll(resp.Body)
 		}
 		resp.Body.Close()
 		if resp.StatusCode >= http.StatusOK && resp.StatusCode < 300 {
 			if attempt == c.MaxRetries {
-				return []Result{}, nil
+				return nil, nil
+			}
+			if err := sleepContext(ctx, c.Backoff*(1<<attempt)); err != nil {
+				return nil, err
 			}
-			time.Sleep(c.Backoff * (1 << attempt))
 			continue
 		}
 		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
-	defer resp.Body.Close()
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	return nil, nil
+}
+
+// resolve turns a form action into an absolute URL against baseUrl.
+func (c *DuckDuckGoSearchClient) resolve(action string) string {
+	base, err := url.Parse(c.baseUrl)
+	if err != nil || strings.TrimSpace(action) == "" {
+		return c.baseUrl
+	}
+	ref, err := url.Parse(action)
+	if err != nil {
+		return c.baseUrl
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// ApplyDuckDuckGoParams sets kl (region), kp (safe search) and df (time
+// range) on a DuckDuckGo HTML query. opts must already be normalized.
+func (o SearchOptions) ApplyDuckDuckGoParams(params url.Values) {
+	if o.Region != "" {
+		params.Set("kl", o.Region)
+	}
+	switch o.SafeSearch {
+	case SafeSearchStrict:
+		params.Set("kp", "1")
+	case SafeSearchModerate:
+		params.Set("kp", "-1")
+	case SafeSearchOff:
+		params.Set("kp", "-2")
+	}
+	if o.TimeRange != "" {
+		params.Set("df", o.TimeRange)
+	}
+}
+
+// HTMLPage is one parsed DuckDuckGo HTML results page.
+type HTMLPage struct {
+	Results []Result
+	// Next holds the hidden fields of the "Next" form (nil on the last page)
+	// and NextAction its action URL.
+	Next       url.Values
+	NextAction string
+}
+
+// ParseHTMLResults extracts results from a DuckDuckGo HTML results page,
+// returning a ChallengeError when the page is a bot challenge instead.
+// Sponsored results are left out.
+func ParseHTMLResults(r io.Reader, limit int) ([]Result, error) {
+	page, err := ParseHTMLPage(r, 0)
 	if err != nil {
 		return nil, err
 	}
+	results := dropAds(page.Results, SearchOptions{})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// ParseHTMLPage extracts every result (ads included, tagged by Type) and the
+// next-page form from a DuckDuckGo HTML results page.
+func ParseHTMLPage(r io.Reader, limit int) (HTMLPage, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return HTMLPage{}, err
+	}
 	if isChallengePage(doc) {
-		return nil, NewChallengeError()
+		return HTMLPage{}, NewChallengeError()
 	}
-	results := make([]Result, 0)
-	doc.Find(".results .web-result").Each(func(i int, s *goquery.Selection) {
-		if i > limit-1 && limit > 0 {
+	page := HTMLPage{Results: make([]Result, 0)}
+	doc.Find(".results .result").Each(func(i int, s *goquery.Selection) {
+		if limit > 0 && len(page.Results) >= limit {
+			return
+		}
+		if s.HasClass("result--no-result") || s.Find(".result__a").Length() == 0 {
 			return
 		}
-		results = append(results, c.collectResult(s))
+		page.Results = append(page.Results, collectResult(s))
 	})
-	if limit > 0 && len(results) == 0 {
+	if len(page.Results) == 0 && doc.Find(".result__a, .result__url").Length() > 0 {
 		// fallback for simple HTML structures without wrapper elements
-		results = append(results, c.collectResult(doc.Selection))
+		page.Results = append(page.Results, collectResult(doc.Selection))
 	}
-	return results, nil
+	page.Next, page.NextAction = nextPageForm(doc)
+	return page, nil
 }
 
-func (c *DuckDuckGoSearchClient) collectResult(s *goquery.Selection) Result {
+// nextPageForm finds the form behind the "Next" button in the nav links.
+func nextPageForm(doc *goquery.Document) (url.Values, string) {
+	var values url.Values
+	var action string
+	doc.Find(".nav-link form").EachWithBreak(func(_ int, form *goquery.Selection) bool {
+		submit, _ := form.Find("input[type=submit]").Attr("value")
+		if !strings.EqualFold(strings.TrimSpace(submit), "next") {
+			return true
+		}
+		values = url.Values{}
+		form.Find("input[type=hidden]").Each(func(_ int, in *goquery.Selection) {
+			name, ok := in.Attr("name")
+			if !ok || name == "" {
+				return
+			}
+			v, _ := in.Attr("value")
+			values.Add(name, v)
+		})
+		action, _ = form.Attr("action")
+		return false
+	})
+	return values, action
+}
+
+func collectResult(s *goquery.Selection) Result {
 	resUrlHtml := html(s.Find(".result__url").Html())
 	resUrl := clean(s.Find(".result__url").Text())
 	titleHtml := html(s.Find(".result__a").Html())
@@ -149,7 +315,46 @@ func (c *DuckDuckGoSearchClient) collectResult(s *goquery.Selection) Result {
 			Width:  toInt(width),
 			Height: toInt(height),
 		},
+		Date: resultDate(s),
+		Type: resultType(s),
+	}
+}
+
+// resultType classifies a result block by its CSS classes.
+func resultType(s *goquery.Selection) string {
+	switch {
+	case s.HasClass("result--ad") || s.Find(".badge--ad").Length() > 0:
+		return ResultTypeAd
+	case s.HasClass("result--news"):
+		return ResultTypeNews
+	case s.HasClass("result"):
+		return ResultTypeWeb
+	}
+	return ""
+}
+
+// resultDate returns the date DuckDuckGo prints after the result URL, e.g.
+// "2024-01-15T00:00:00.0000000", trimmed to the calendar date.
+func resultDate(s *goquery.Selection) string {
+	raw := s.Find(".result__timestamp").First().Text()
+	if strings.TrimSpace(raw) == "" {
+		s.Find(".result__extras__url > span").Each(func(_ int, span *goquery.Selection) {
+			if span.HasClass("result__icon") {
+				return
+			}
+			raw = span.Text()
+		})
+	}
+	raw = strings.TrimSpace(strings.ReplaceAll(raw, "\u00a0", " "))
+	if raw == "" || raw[0] < '0' || raw[0] > '9' {
+		return ""
+	}
+	if len(raw) >= 10 && raw[4] == '-' && raw[7] == '-' {
+		if _, err := time.Parse("2006-01-02", raw[:10]); err == nil {
+			return raw[:10]
+		}
 	}
+	return raw
 }
 
 func html(html string, err error) string {
@@ -82,3 +82,91 @@ func TestSearchLimited_DetectsBotChallenge(t *testing.T) {
 		t.Fatal("expected a descriptive error message, got empty string")
 	}
 }
+
+func TestSearchWithOptions_FollowsNextPageAndAppliesFilters(t *testing.T) {
+	page1 := `<html><body><div class="results">
+		<div class="result results_links result--ad"><a class="result__a">Sponsored</a><a class="result__url">ads.example</a></div>
+		<div class="result results_links web-result"><a class="result__a">One</a><a class="result__url">one.example</a>
+			<div class="result__extras__url"><span class="result__icon"></span><a class="result__url">one.example</a><span>&nbsp; &nbsp; 2024-01-15T00:00:00.0000000</span></div></div>
+		<div class="result results_links result--news"><a class="result__a">Two</a><a class="result__url">two.example</a></div>
+	</div>
+	<div class="nav-link"><form action="/html/" method="post">
+		<input type="submit" class="btn btn--alt" value="Next">
+		<input type="hidden" name="q" value="golang"><input type="hidden" name="s" value="30"><input type="hidden" name="vqd" value="abc">
+	</form></div></body></html>`
+	page2 := `<html><body><div class="results">
+		<div class="result results_links web-result"><a class="result__a">Three</a><a class="result__url">three.example</a></div>
+		<div class="result results_links web-result"><a class="result__a">Four</a><a class="result__url">four.example</a></div>
+	</div></body></html>`
+
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r)
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, page2)
+			return
+		}
+		fmt.Fprint(w, page1)
+	}))
+	defer srv.Close()
+
+	client := NewDuckDuckGoSearchClient()
+	client.baseUrl = srv.URL + "/html/"
+	client.InitialDelay = 0
+	client.PageDelay = 0
+
+	results, err := client.SearchWithOptions("golang", 3, SearchOptions{Region: "de-DE", SafeSearch: "off", TimeRange: "week"})
+	if err != nil {
+		t.Fatalf("SearchWithOptions() error = %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests (first page + next), got %d", len(requests))
+	}
+	first, next := requests[0], requests[1]
+	if first.Form.Get("kl") != "de-de" || first.Form.Get("kp") != "-2" || first.Form.Get("df") != "w" {
+		t.Fatalf("unexpected first page params %v", first.Form)
+	}
+	if next.URL.Path != "/html/" || next.Form.Get("s") != "30" || next.Form.Get("vqd") != "abc" || next.Form.Get("df") != "w" {
+		t.Fatalf("unexpected next page request %s %v", next.URL.Path, next.Form)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results (ad dropped, limit applied), got %#v", results)
+	}
+	if results[0].Title != "One" || results[0].Date != "2024-01-15" || results[0].Type != ResultTypeWeb {
+		t.Fatalf("unexpected first result %#v", results[0])
+	}
+	if results[1].Type != ResultTypeNews || results[2].Title != "Three" {
+		t.Fatalf("unexpected results %#v", results)
+	}
+}
+
+func TestParseHTMLPageKeepsAdsTagged(t *testing.T) {
+	body := `<div class="results"><div class="result result--ad"><a class="result__a">Buy</a></div></div>`
+	page, err := ParseHTMLPage(strings.NewReader(body), 0)
+	if err != nil {
+		t.Fatalf("ParseHTMLPage() error = %v", err)
+	}
+	if len(page.Results) != 1 || page.Results[0].Type != ResultTypeAd || page.Next != nil {
+		t.Fatalf("unexpected page %#v", page)
+	}
+	if results, _ := ParseHTMLResults(strings.NewReader(body), 0); len(results) != 0 {
+		t.Fatalf("ParseHTMLResults should drop ads, got %#v", results)
+	}
+}
+
+func TestSearchOptionsNormalize(t *testing.T) {
+	opts, err := SearchOptions{Region: " US-EN ", SafeSearch: "Strict", TimeRange: "month"}.Normalize()
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if opts.Region != "us-en" || opts.SafeSearch != SafeSearchStrict || opts.TimeRange != "m" {
+		t.Fatalf("unexpected normalized options %#v", opts)
+	}
+	for _, bad := range []SearchOptions{{Region: "germany"}, {SafeSearch: "maybe"}, {TimeRange: "decade"}} {
+		if _, err := bad.Normalize(); err == nil {
+			t.Fatalf("expected %#v to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	htmlstd "html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	braveDefaultURL = "https://api.search.brave.com/res/v1/web/search"
+	braveMaxCount   = 20
+)
+
+// BraveSearchClient queries the Brave Search web API.
+type BraveSearchClient struct {
+	baseUrl string
+	APIKey  string
+	client  *http.Client
+}
+
+func NewBraveSearchClient(apiKey string) *BraveSearchClient {
+	return &BraveSearchClient{
+		baseUrl: braveDefaultURL,
+		APIKey:  apiKey,
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+func newBraveEngine(cfg EngineConfig) (SearchClient, error) {
+	key := cfg.ResolveAPIKey("BRAVE_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("brave: set engines.brave.api_key_env or BRAVE_API_KEY: %w", ErrEngineUnavailable)
+	}
+	c := NewBraveSearchClient(key)
+	if strings.TrimSpace(cfg.BaseURL) != "" {
+		c.baseUrl = cfg.BaseURL
+	}
+	return c, nil
+}
+
+func (c *BraveSearchClient) Search(query string) ([]Result, error) {
+	return c.SearchLimited(query, 0)
+}
+
+func (c *BraveSearchClient) SearchLimited(query string, limit int) ([]Result, error) {
+	return c.SearchWithOptions(query, limit, SearchOptions{})
+}
+
+// SearchWithOptions maps region to Brave's country/search_lang, time range
+// to freshness, and passes safe search through.
+func (c *BraveSearchClient) SearchWithOptions(query string, limit int, opts SearchOptions) ([]Result, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	if country, lang := opts.regionParts(); country != "" {
+		params.Set("country", strings.ToUpper(country))
+		params.Set("search_lang", lang)
+	}
+	if opts.SafeSearch != "" {
+		params.Set("safesearch", opts.SafeSearch)
+	}
+	if opts.TimeRange != "" {
+		params.Set("freshness", "p"+opts.TimeRange)
+	}
+	count := limit
+	if count <= 0 || count > braveMaxCount {
+		count = braveMaxCount
+	}
+	params.Set("count", strconv.Itoa(count))
+
+	req, err := http.NewRequestWithContext(opts.ctx(), http.MethodGet, c.baseUrl+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", c.APIKey)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("brave: unexpected status code %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Web struct {
+			Results []struct {
+				URL         string `json:"url"`
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				Age         string `json:"age"`
+				PageAge     string `json:"page_age"`
+				Profile     struct {
+					Img string `json:"img"`
+				} `json:"profile"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("brave: decode response: %w", err)
+	}
+
+	results := make([]Result, 0, len(payload.Web.Results))
+	for _, r := range payload.Web.Results {
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+		results = append(results, Result{
+			HtmlTitle:    clean(r.Title),
+			HtmlSnippet:  clean(r.Description),
+			FormattedUrl: clean(r.URL),
+			Title:        clean(htmlstd.UnescapeString(stripTags(r.Title))),
+			Snippet:      clean(htmlstd.UnescapeString(stripTags(r.Description))),
+			Icon:         Icon{Src: r.Profile.Img},
+			Date:         braveDate(r.PageAge, r.Age),
+			Type:         ResultTypeWeb,
+		})
+	}
+	return results, nil
+}
+
+// braveDate prefers the machine-readable page_age over the display age.
+func braveDate(pageAge, age string) string {
+	if d := publishedDay(pageAge); d != "" {
+		return d
+	}
+	return strings.TrimSpace(age)
+}
+
+// stripTags removes the <strong> highlighting Brave adds to titles/snippets.
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
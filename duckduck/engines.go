@@ -0,0 +1,157 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEngineUnavailable is returned by engine factories when the engine is
+// missing required settings (API key, instance URL, live browser, ...).
+// Failover skips such engines instead of reporting them as failures.
+var ErrEngineUnavailable = errors.New("search engine unavailable")
+
+// EngineConfig carries per-engine settings from the search config file.
+type EngineConfig struct {
+	// BaseURL points at the engine endpoint (required for SearXNG instances).
+	BaseURL string `json:"base_url,omitempty"`
+	// APIKey is used as-is; APIKeyEnv names an environment variable holding it.
+	APIKey    string `json:"api_key,omitempty"`
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// InitialDelayMS, MaxRetries and MaxPages tune the DuckDuckGo HTML client.
+	InitialDelayMS *int `json:"initial_delay_ms,omitempty"`
+	MaxRetries     *int `json:"max_retries,omitempty"`
+	MaxPages       *int `json:"max_pages,omitempty"`
+}
+
+// ResolveAPIKey returns the inline key or the value of APIKeyEnv (falling
+// back to fallbackEnv when neither is configured).
+func (c EngineConfig) ResolveAPIKey(fallbackEnv string) string {
+	if key := strings.TrimSpace(c.APIKey); key != "" {
+		return key
+	}
+	envName := strings.TrimSpace(c.APIKeyEnv)
+	if envName == "" {
+		envName = fallbackEnv
+	}
+	if envName == "" {
+		return ""
+	}
+	return strings.TrimSpace(os.Getenv(envName))
+}
+
+// ChallengeFallback marks engines that only exist to get past a bot
+// challenge, such as a search in the live browser. Failover tries them only
+// after an earlier engine hit a challenge.
+type ChallengeFallback interface {
+	ChallengeFallback()
+}
+
+// EngineFactory builds a SearchClient from its configuration.
+type EngineFactory func(cfg EngineConfig) (SearchClient, error)
+
+var (
+	enginesMu sync.RWMutex
+	engines   = map[string]EngineFactory{}
+)
+
+// Names of the built-in engines.
+const (
+	EngineDuckDuckGo = "ddg"
+	EngineSearXNG    = "searxng"
+	EngineBrave      = "brave"
+)
+
+func init() {
+	RegisterEngine(EngineDuckDuckGo, newDuckDuckGoEngine)
+	RegisterEngine(EngineSearXNG, newSearXNGEngine)
+	RegisterEngine(EngineBrave, newBraveEngine)
+}
+
+// RegisterEngine makes an engine selectable by name. Registering an existing
+// name replaces its factory.
+func RegisterEngine(name string, factory EngineFactory) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	engines[strings.ToLower(strings.TrimSpace(name))] = factory
+}
+
+// EngineNames lists the registered engines in alphabetical order.
+func EngineNames() []string {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEngine builds the named engine.
+func NewEngine(name string, cfg EngineConfig) (SearchClient, error) {
+	enginesMu.RLock()
+	factory, ok := engines[strings.ToLower(strings.TrimSpace(name))]
+	enginesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown search engine %q (available: %s)", name, strings.Join(EngineNames(), ", "))
+	}
+	return factory(cfg)
+}
+
+func newDuckDuckGoEngine(cfg EngineConfig) (SearchClient, error) {
+	c := NewDuckDuckGoSearchClient()
+	if strings.TrimSpace(cfg.BaseURL) != "" {
+		c.baseUrl = cfg.BaseURL
+	}
+	if cfg.InitialDelayMS != nil {
+		c.InitialDelay = time.Duration(*cfg.InitialDelayMS) * time.Millisecond
+	}
+	if cfg.MaxRetries != nil {
+		c.MaxRetries = *cfg.MaxRetries
+	}
+	if cfg.MaxPages != nil {
+		c.MaxPages = *cfg.MaxPages
+	}
+	return c, nil
+}
+
+// DefaultFailover is the engine order used when no config file sets one.
+// Programs that register more engines may append them.
+var DefaultFailover = []string{EngineDuckDuckGo, EngineSearXNG, EngineBrave}
+
+// Config is the on-disk search configuration.
+type Config struct {
+	// Failover lists engines tried in order by the "auto" engine.
+	Failover []string                `json:"failover"`
+	Engines  map[string]EngineConfig `json:"engines"`
+}
+
+// LoadConfig reads the search config at path. A missing file yields the
+// default failover order with no engine settings.
+func LoadConfig(path string) (Config, error) {
+	cfg := Config{}
+	if strings.TrimSpace(path) != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return Config{}, fmt.Errorf("decode search config %s: %w", path, err)
+			}
+		case !os.IsNotExist(err):
+			return Config{}, fmt.Errorf("read search config: %w", err)
+		}
+	}
+	if len(cfg.Failover) == 0 {
+		cfg.Failover = append([]string(nil), DefaultFailover...)
+	}
+	if cfg.Engines == nil {
+		cfg.Engines = map[string]EngineConfig{}
+	}
+	return cfg, nil
+}
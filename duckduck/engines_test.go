@@ -0,0 +1,218 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearXNGSearchLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" || r.URL.Query().Get("q") != "golang" {
+			t.Errorf("unexpected request %s", r.URL.String())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"results":[
+			{"url":"https://go.dev/","title":"The Go Programming Language","content":"Go is an open source language."},
+			{"url":"https://pkg.go.dev/","title":"Go Packages","content":"Discover packages."}
+		]}`)
+	}))
+	defer srv.Close()
+
+	client, err := NewEngine(EngineSearXNG, EngineConfig{BaseURL: srv.URL + "/"})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	results, err := client.SearchLimited("golang", 1)
+	if err != nil {
+		t.Fatalf("SearchLimited() error = %v", err)
+	}
+	if len(results) != 1 || results[0].FormattedUrl != "https://go.dev/" || results[0].Snippet != "Go is an open source language." {
+		t.Fatalf("unexpected results %#v", results)
+	}
+}
+
+func TestSearXNGAppliesSearchOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("language") != "en-US" || q.Get("safesearch") != "2" || q.Get("time_range") != "day" {
+			t.Errorf("unexpected params %v", q)
+		}
+		fmt.Fprint(w, `{"results":[{"url":"https://news.example/","title":"Story","content":"today","category":"news","publishedDate":"2024-03-02T10:00:00"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := NewEngine(EngineSearXNG, EngineConfig{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	results, err := SearchWith(client, "story", 5, SearchOptions{Region: "us-en", SafeSearch: SafeSearchStrict, TimeRange: "d"})
+	if err != nil {
+		t.Fatalf("SearchWith() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Type != ResultTypeNews || results[0].Date != "2024-03-02" {
+		t.Fatalf("unexpected results %#v", results)
+	}
+}
+
+func TestBraveSearchLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Subscription-Token"); got != "secret" {
+			t.Errorf("expected subscription token header, got %q", got)
+		}
+		if got := r.URL.Query().Get("count"); got != "5" {
+			t.Errorf("expected count=5, got %q", got)
+		}
+		fmt.Fprint(w, `{"web":{"results":[
+			{"url":"https://example.com/","title":"<strong>Example</strong> Domain","description":"It&#x27;s for <strong>examples</strong>."}
+		]}}`)
+	}))
+	defer srv.Close()
+
+	client, err := NewEngine(EngineBrave, EngineConfig{BaseURL: srv.URL, APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	results, err := client.SearchLimited("example", 5)
+	if err != nil {
+		t.Fatalf("SearchLimited() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Example Domain" || results[0].Snippet != "It's for examples." {
+		t.Fatalf("unexpected results %#v", results)
+	}
+}
+
+func TestEnginesWithoutSettingsAreUnavailable(t *testing.T) {
+	t.Setenv("RODERIK_SEARXNG_URL", "")
+	t.Setenv("BRAVE_API_KEY", "")
+	for _, name := range []string{EngineSearXNG, EngineBrave} {
+		if _, err := NewEngine(name, EngineConfig{}); !errors.Is(err, ErrEngineUnavailable) {
+			t.Fatalf("%s: expected ErrEngineUnavailable, got %v", name, err)
+		}
+	}
+	if _, err := NewEngine("altavista", EngineConfig{}); err == nil {
+		t.Fatalf("expected unknown engine to fail")
+	}
+}
+
+func TestFailoverMovesPastChallenge(t *testing.T) {
+	ddg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><form id="challenge-form"></form></body></html>`)
+	}))
+	defer ddg.Close()
+	searx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results":[{"url":"https://example.org/","title":"Fallback","content":"from searxng"}]}`)
+	}))
+	defer searx.Close()
+
+	t.Setenv("BRAVE_API_KEY", "")
+	zero := 0
+	var failed []string
+	f := &FailoverClient{
+		Order: []string{EngineDuckDuckGo, EngineBrave, EngineSearXNG},
+		Configs: map[string]EngineConfig{
+			EngineDuckDuckGo: {BaseURL: ddg.URL, InitialDelayMS: &zero, MaxRetries: &zero},
+			EngineSearXNG:    {BaseURL: searx.URL},
+		},
+		OnFailover: func(engine string, err error) { failed = append(failed, engine) },
+	}
+
+	results, engine, err := f.SearchWithEngine("anything", 3)
+	if err != nil {
+		t.Fatalf("SearchWithEngine() error = %v", err)
+	}
+	if engine != EngineSearXNG || len(results) != 1 || results[0].Title != "Fallback" {
+		t.Fatalf("expected searxng fallback, got engine=%s results=%#v", engine, results)
+	}
+	if len(failed) != 1 || failed[0] != EngineDuckDuckGo {
+		t.Fatalf("expected only ddg to be reported as failed (brave is unconfigured), got %v", failed)
+	}
+}
+
+func TestFailoverKeepsChallengeErrorDetectable(t *testing.T) {
+	ddg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div class="anomaly-modal__modal"></div></body></html>`)
+	}))
+	defer ddg.Close()
+
+	zero := 0
+	f := &FailoverClient{
+		Order:   []string{EngineDuckDuckGo},
+		Configs: map[string]EngineConfig{EngineDuckDuckGo: {BaseURL: ddg.URL, InitialDelayMS: &zero}},
+	}
+	if _, err := f.SearchLimited("anything", 1); !IsChallengeError(err) {
+		t.Fatalf("expected challenge error to survive failover, got %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Failover) != len(DefaultFailover) {
+		t.Fatalf("expected default failover, got %v", cfg.Failover)
+	}
+	// The default order only names engines this package registers.
+	for _, name := range cfg.Failover {
+		if _, err := NewEngine(name, EngineConfig{}); err != nil && !errors.Is(err, ErrEngineUnavailable) {
+			t.Fatalf("default engine %q: %v", name, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "search-engines.json")
+	if err := os.WriteFile(path, []byte(`{"failover":["searxng","ddg"],"engines":{"searxng":{"base_url":"http://localhost:8888"}}}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg, err = LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Failover[0] != EngineSearXNG || cfg.Engines[EngineSearXNG].BaseURL != "http://localhost:8888" {
+		t.Fatalf("unexpected config %#v", cfg)
+	}
+}
+
+type fallbackEngine struct{ calls *int }
+
+func (fallbackEngine) ChallengeFallback() {}
+
+func (e fallbackEngine) Search(query string) ([]Result, error) { return e.SearchLimited(query, 0) }
+
+func (e fallbackEngine) SearchLimited(query string, limit int) ([]Result, error) {
+	*e.calls++
+	return []Result{{Title: "From the browser"}}, nil
+}
+
+func TestFailoverTriesChallengeFallbackOnlyAfterChallenge(t *testing.T) {
+	calls := 0
+	RegisterEngine("test-fallback", func(EngineConfig) (SearchClient, error) { return fallbackEngine{calls: &calls}, nil })
+	challenge := true
+	ddg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if challenge {
+			fmt.Fprint(w, `<html><body><form id="challenge-form"></form></body></html>`)
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ddg.Close()
+
+	zero := 0
+	f := &FailoverClient{
+		Order:   []string{EngineDuckDuckGo, "test-fallback"},
+		Configs: map[string]EngineConfig{EngineDuckDuckGo: {BaseURL: ddg.URL, InitialDelayMS: &zero, MaxRetries: &zero}},
+	}
+	results, engine, err := f.SearchWithEngine("anything", 3)
+	if err != nil || engine != "test-fallback" || len(results) != 1 || calls != 1 {
+		t.Fatalf("after a challenge: engine=%s results=%v err=%v calls=%d", engine, results, err, calls)
+	}
+
+	challenge = false
+	if _, engine, err := f.SearchWithEngine("anything", 3); err == nil || calls != 1 {
+		t.Fatalf("fallback tried after a plain failure: engine=%s err=%v calls=%d", engine, err, calls)
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FailoverClient tries a list of engines in order and returns the first
+// successful result set. Engines that are not configured are skipped, and so
+// are challenge fallbacks unless an earlier engine was challenged.
+type FailoverClient struct {
+	Order   []string
+	Configs map[string]EngineConfig
+	// OnFailover is called whenever an engine fails and the next one is tried.
+	OnFailover func(engine string, err error)
+}
+
+// NewFailoverClient builds a client from a loaded Config.
+func NewFailoverClient(cfg Config) *FailoverClient {
+	return &FailoverClient{Order: cfg.Failover, Configs: cfg.Engines}
+}
+
+func (f *FailoverClient) Search(query string) ([]Result, error) {
+	return f.SearchLimited(query, 0)
+}
+
+func (f *FailoverClient) SearchLimited(query string, limit int) ([]Result, error) {
+	results, _, err := f.SearchWithEngine(query, limit)
+	return results, err
+}
+
+func (f *FailoverClient) SearchWithOptions(query string, limit int, opts SearchOptions) ([]Result, error) {
+	results, _, err := f.SearchWithEngineOptions(query, limit, opts)
+	return results, err
+}
+
+// SearchWithEngine runs the failover chain and also reports which engine
+// produced the results.
+func (f *FailoverClient) SearchWithEngine(query string, limit int) ([]Result, string, error) {
+	return f.SearchWithEngineOptions(query, limit, SearchOptions{})
+}
+
+// SearchWithEngineOptions is SearchWithEngine with search options applied by
+// every engine that supports them.
+func (f *FailoverClient) SearchWithEngineOptions(query string, limit int, opts SearchOptions) ([]Result, string, error) {
+	if _, err := opts.Normalize(); err != nil {
+		return nil, "", err
+	}
+	var failures []string
+	var errs []error
+	challenged := false
+	for _, name := range f.Order {
+		engine, err := NewEngine(name, f.Configs[name])
+		if err != nil {
+			if errors.Is(err, ErrEngineUnavailable) {
+				continue
+			}
+			return nil, "", err
+		}
+		if _, ok := engine.(ChallengeFallback); ok && !challenged {
+			continue
+		}
+		results, err := SearchWith(engine, query, limit, opts)
+		if err == nil {
+			return results, name, nil
+		}
+		if ctxErr := opts.ctx().Err(); ctxErr != nil {
+			// cancelled by the caller; trying the next engine would be pointless
+			return nil, "", ctxErr
+		}
+		challenged = challenged || IsChallengeError(err)
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		errs = append(errs, err)
+		if f.OnFailover != nil {
+			f.OnFailover(name, err)
+		}
+	}
+	if len(failures) == 0 {
+		return nil, "", fmt.Errorf("no search engine available (tried %s): %w", strings.Join(f.Order, ", "), ErrEngineUnavailable)
+	}
+	// keep every cause reachable so IsChallengeError still works on the result
+	return nil, "", fmt.Errorf("all search engines failed (%s): %w", strings.Join(failures, "; "), errors.Join(errs...))
+}
@@ -14,6 +14,12 @@ type Result struct {
 	Snippet string `json:"snippet,omitempty"`
 
 	Icon Icon `json:"icon,omitempty"`
+
+	// Date is the publication date shown next to the result, as displayed.
+	Date string `json:"date,omitempty"`
+
+	// Type is ResultTypeWeb, ResultTypeNews or ResultTypeAd when known.
+	Type string `json:"type,omitempty"`
 }
 
 type Icon struct {
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Result types reported in Result.Type.
+const (
+	ResultTypeWeb  = "web"
+	ResultTypeNews = "news"
+	ResultTypeAd   = "ad"
+)
+
+// Safe-search levels accepted by SearchOptions.SafeSearch.
+const (
+	SafeSearchStrict   = "strict"
+	SafeSearchModerate = "moderate"
+	SafeSearchOff      = "off"
+)
+
+// SearchOptions narrows a search. The zero value means engine defaults.
+type SearchOptions struct {
+	// Region is a DuckDuckGo region code such as "us-en", "de-de" or "wt-wt"
+	// (no region). Other engines derive their country/language from it.
+	Region string
+	// SafeSearch is one of SafeSearchStrict, SafeSearchModerate or SafeSearchOff.
+	SafeSearch string
+	// TimeRange limits results by age: "d" (day), "w" (week), "m" (month) or
+	// "y" (year). The long forms "day", "week", ... are accepted too.
+	TimeRange string
+	// IncludeAds keeps sponsored results, which are dropped by default.
+	IncludeAds bool
+	// Context, when set, cancels requests and the delays between retries
+	// and result pages.
+	Context context.Context
+}
+
+// OptionsSearcher is implemented by clients that understand SearchOptions.
+type OptionsSearcher interface {
+	SearchWithOptions(query string, limit int, opts SearchOptions) ([]Result, error)
+}
+
+// IsZero reports whether opts leaves every setting at the engine default.
+func (o SearchOptions) IsZero() bool {
+	o.Context = nil
+	return o == SearchOptions{}
+}
+
+// ctx returns opts.Context, or context.Background when unset.
+func (o SearchOptions) ctx() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Normalize validates the options and returns them in canonical form.
+func (o SearchOptions) Normalize() (SearchOptions, error) {
+	o.Region = strings.ToLower(strings.TrimSpace(o.Region))
+	if o.Region != "" {
+		if parts := strings.Split(o.Region, "-"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return o, fmt.Errorf("invalid region %q (expected a code like us-en or wt-wt)", o.Region)
+		}
+	}
+
+	switch safe := strings.ToLower(strings.TrimSpace(o.SafeSearch)); safe {
+	case "", SafeSearchStrict, SafeSearchModerate, SafeSearchOff:
+		o.SafeSearch = safe
+	default:
+		return o, fmt.Errorf("invalid safe search %q (expected strict, moderate or off)", o.SafeSearch)
+	}
+
+	switch tr := strings.ToLower(strings.TrimSpace(o.TimeRange)); tr {
+	case "", "any", "all":
+		o.TimeRange = ""
+	case "d", "day", "w", "week", "m", "month", "y", "year":
+		o.TimeRange = tr[:1]
+	default:
+		return o, fmt.Errorf("invalid time range %q (expected day, week, month or year)", o.TimeRange)
+	}
+	return o, nil
+}
+
+// regionParts splits a DuckDuckGo region ("us-en") into country and
+// language; "wt-wt" (worldwide) yields empty strings.
+func (o SearchOptions) regionParts() (country, language string) {
+	parts := strings.SplitN(o.Region, "-", 2)
+	if len(parts) != 2 || parts[0] == "wt" {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// timeRangeName expands a canonical time range ("d") to "day", "week", ...
+func timeRangeName(tr string) string {
+	switch tr {
+	case "d":
+		return "day"
+	case "w":
+		return "week"
+	case "m":
+		return "month"
+	case "y":
+		return "year"
+	}
+	return ""
+}
+
+// SearchWith runs query on c, passing opts when the client supports them.
+// Clients without option support ignore them.
+func SearchWith(c SearchClient, query string, limit int, opts SearchOptions) ([]Result, error) {
+	if oc, ok := c.(OptionsSearcher); ok {
+		return oc.SearchWithOptions(query, limit, opts)
+	}
+	return c.SearchLimited(query, limit)
+}
+
+// dropAds removes sponsored results unless opts asks to keep them.
+func dropAds(results []Result, opts SearchOptions) []Result {
+	if opts.IncludeAds {
+		return results
+	}
+	kept := results[:0]
+	for _, r := range results {
+		if r.Type != ResultTypeAd {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SearXNGSearchClient queries a SearXNG instance through its JSON API. The
+// instance must have the "json" format enabled in its settings.
+type SearXNGSearchClient struct {
+	BaseURL string
+	APIKey  string
+	client  *http.Client
+}
+
+func NewSearXNGSearchClient(baseURL string) *SearXNGSearchClient {
+	return &SearXNGSearchClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+func newSearXNGEngine(cfg EngineConfig) (SearchClient, error) {
+	base := strings.TrimSpace(cfg.BaseURL)
+	if base == "" {
+		base = strings.TrimSpace(os.Getenv("RODERIK_SEARXNG_URL"))
+	}
+	if base == "" {
+		return nil, fmt.Errorf("searxng: set engines.searxng.base_url or RODERIK_SEARXNG_URL: %w", ErrEngineUnavailable)
+	}
+	c := NewSearXNGSearchClient(base)
+	c.APIKey = cfg.ResolveAPIKey("")
+	return c, nil
+}
+
+func (c *SearXNGSearchClient) Search(query string) ([]Result, error) {
+	return c.SearchLimited(query, 0)
+}
+
+func (c *SearXNGSearchClient) SearchLimited(query string, limit int) ([]Result, error) {
+	return c.SearchWithOptions(query, limit, SearchOptions{})
+}
+
+// SearchWithOptions maps region to SearXNG's language, and passes safe
+// search and time range through.
+func (c *SearXNGSearchClient) SearchWithOptions(query string, limit int, opts SearchOptions) ([]Result, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+	if country, lang := opts.regionParts(); lang != "" {
+		params.Set("language", lang+"-"+strings.ToUpper(country))
+	}
+	switch opts.SafeSearch {
+	case SafeSearchOff:
+		params.Set("safesearch", "0")
+	case SafeSearchModerate:
+		params.Set("safesearch", "1")
+	case SafeSearchStrict:
+		params.Set("safesearch", "2")
+	}
+	if tr := timeRangeName(opts.TimeRange); tr != "" {
+		params.Set("time_range", tr)
+	}
+	req, err := http.NewRequestWithContext(opts.ctx(), http.MethodGet, c.BaseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("searxng: unexpected status code %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Results []struct {
+			URL           string `json:"url"`
+			Title         string `json:"title"`
+			Content       string `json:"content"`
+			Category      string `json:"category"`
+			PublishedDate string `json:"publishedDate"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("searxng: decode response: %w", err)
+	}
+
+	results := make([]Result, 0, len(payload.Results))
+	for _, r := range payload.Results {
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+		results = append(results, Result{
+			FormattedUrl: clean(r.URL),
+			Title:        clean(r.Title),
+			Snippet:      clean(r.Content),
+			Date:         publishedDay(r.PublishedDate),
+			Type:         searxngResultType(r.Category),
+		})
+	}
+	return results, nil
+}
+
+func searxngResultType(category string) string {
+	if strings.EqualFold(category, "news") {
+		return ResultTypeNews
+	}
+	return ResultTypeWeb
+}
+
+// publishedDay trims RFC 3339 style timestamps to their date.
+func publishedDay(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 10 && raw[4] == '-' && raw[7] == '-' {
+		return raw[:10]
+	}
+	return raw
+}
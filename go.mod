@@ -12,6 +12,7 @@ require (
 	github.com/go-rod/stealth v0.4.9
 	github.com/mark3labs/mcp-go v0.24.1
 	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
 	github.com/ysmood/gson v0.7.3
 	golang.org/x/net v0.0.0-20220722155237-a158d28d115b
 	golang.org/x/term v0.26.0
@@ -29,7 +30,6 @@ require (
 	github.com/mattn/go-isatty v0.0.8 // indirect
 	github.com/mgutz/ansi v0.0.0-20170206155736-9520e82c474b // indirect
 	github.com/spf13/cast v1.7.1 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	github.com/ysmood/fetchup v0.2.4 // indirect
 	github.com/ysmood/goob v0.4.0 // indirect
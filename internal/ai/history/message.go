@@ -64,6 +64,19 @@ func (m *HistoryMessage) GetUsage() (int, int) {
 	return 0, 0 // History doesn't track usage
 }
 
+func (m *HistoryMessage) GetImages() []llm.Image {
+	var images []llm.Image
+	for _, block := range m.Content {
+		if block.Type == "image" && block.Source != nil && len(block.Source.Data) > 0 {
+			images = append(images, llm.Image{
+				MIMEType: block.Source.MediaType,
+				Data:     block.Source.Data,
+			})
+		}
+	}
+	return images
+}
+
 const (
 	maxUserContentLen      = 800
 	maxAssistantContentLen = 900
@@ -84,6 +97,46 @@ func NewUserMessage(text string) *HistoryMessage {
 	}
 }
 
+// NewImageMessage creates a user history entry carrying images (e.g. tool
+// screenshots) together with a short caption.
+func NewImageMessage(caption string, images []llm.Image) *HistoryMessage {
+	msg := &HistoryMessage{Role: "user"}
+	if text := summarizeText(caption, maxUserContentLen); text != "" {
+		msg.Content = append(msg.Content, ContentBlock{Type: "text", Text: text})
+	}
+	for _, img := range images {
+		if len(img.Data) == 0 {
+			continue
+		}
+		msg.Content = append(msg.Content, ContentBlock{
+			Type: "image",
+			Source: &ImageSource{
+				Type:      "base64",
+				MediaType: img.MIMEType,
+				Data:      img.Data,
+			},
+		})
+	}
+	return msg
+}
+
+// WithoutImages returns a copy of msg whose image blocks are replaced by a
+// text placeholder, so older screenshots stop costing tokens on every turn.
+func WithoutImages(msg llm.Message) llm.Message {
+	h, ok := msg.(*HistoryMessage)
+	if !ok || len(h.GetImages()) == 0 {
+		return msg
+	}
+	clone := &HistoryMessage{Role: h.Role}
+	for _, block := range h.Content {
+		if block.Type == "image" {
+			block = ContentBlock{Type: "text", Text: "[earlier image omitted]"}
+		}
+		clone.Content = append(clone.Content, block)
+	}
+	return clone
+}
+
 // CloneAssistantMessage converts an assistant/provider message into a compact history entry.
 func CloneAssistantMessage(msg llm.Message) llm.Message {
 	if msg == nil {
@@ -197,4 +250,12 @@ type ContentBlock struct {
 	Name      string          `json:"name,omitempty"`
 	Input     json.RawMessage `json:"input,omitempty"`
 	Content   interface{}     `json:"content,omitempty"`
+	Source    *ImageSource    `json:"source,omitempty"`
+}
+
+// ImageSource holds the payload of an "image" content block
+type ImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      []byte `json:"data"`
 }
@@ -2,6 +2,7 @@ package openai
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -15,6 +16,7 @@ type Provider struct {
 	model        string
 	systemPrompt string
 	maxTokens    int
+	vision       bool
 	debugLogger  func(string)
 }
 
@@ -32,6 +34,12 @@ func (p *Provider) SetSystemPrompt(prompt string) {
 	p.systemPrompt = prompt
 }
 
+// SetVision enables sending message images as image_url content parts. When
+// disabled, images are replaced by a short note so text-only models still work.
+func (p *Provider) SetVision(enabled bool) {
+	p.vision = enabled
+}
+
 func (p *Provider) debug(msg string, keyvals ...interface{}) {
 	if p.debugLogger == nil {
 		return
@@ -123,6 +131,10 @@ func (p *Provider) CreateMessage(
 			param.Content = &content
 		}
 
+		if images := msg.GetImages(); len(images) > 0 {
+			p.applyImages(&param, images)
+		}
+
 		// Handle function/tool calls
 		toolCalls := msg.GetToolCalls()
 		if len(toolCalls) > 0 {
@@ -259,6 +271,36 @@ func (p *Provider) CreateMessage(
 	return &Message{Resp: resp, Choice: &resp.Choices[0]}, nil
 }
 
+func (p *Provider) applyImages(param *MessageParam, images []llm.Image) {
+	if !p.vision {
+		note := fmt.Sprintf("[%d image(s) omitted: the model profile does not enable vision]", len(images))
+		if param.Content != nil && *param.Content != "" {
+			note = *param.Content + "\n" + note
+		}
+		param.Content = &note
+		return
+	}
+
+	parts := make([]ContentPart, 0, len(images)+1)
+	if param.Content != nil && *param.Content != "" {
+		parts = append(parts, ContentPart{Type: "text", Text: *param.Content})
+	}
+	for _, img := range images {
+		mimeType := img.MIMEType
+		if mimeType == "" {
+			mimeType = "image/png"
+		}
+		parts = append(parts, ContentPart{
+			Type: "image_url",
+			ImageURL: &ImageURL{
+				URL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(img.Data),
+			},
+		})
+	}
+	p.debug("attaching images", "count", len(images))
+	param.Parts = parts
+}
+
 func (p *Provider) SupportsTools() bool {
 	return true
 }
@@ -400,6 +442,10 @@ func (m *Message) GetUsage() (int, int) {
 	return m.Resp.Usage.PromptTokens, m.Resp.Usage.CompletionTokens
 }
 
+func (m *Message) GetImages() []llm.Image {
+	return nil
+}
+
 // ToolCallWrapper implements llm.ToolCall
 type ToolCallWrapper struct {
 	Call ToolCall
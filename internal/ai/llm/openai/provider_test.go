@@ -0,0 +1,66 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"roderik/internal/ai/history"
+	"roderik/internal/ai/llm"
+)
+
+func captureRequest(t *testing.T, vision bool, messages []llm.Message) map[string]interface{} {
+	t.Helper()
+	var captured []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	p := NewProvider("key", srv.URL, "gpt-4o", "", 0)
+	p.SetVision(vision)
+	if _, err := p.CreateMessage(context.Background(), "", messages, nil); err != nil {
+		t.Fatalf("CreateMessage() error = %v", err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(captured, &body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	return body
+}
+
+func TestCreateMessageSendsImagePartsWhenVisionEnabled(t *testing.T) {
+	msg := history.NewImageMessage("Image output from capture_screenshot", []llm.Image{{MIMEType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}}})
+	body := captureRequest(t, true, []llm.Message{msg})
+
+	messages := body["messages"].([]interface{})
+	parts, ok := messages[0].(map[string]interface{})["content"].([]interface{})
+	if !ok || len(parts) != 2 {
+		t.Fatalf("expected text and image parts, got %#v", messages[0])
+	}
+	image := parts[1].(map[string]interface{})
+	if image["type"] != "image_url" {
+		t.Fatalf("expected image_url part, got %#v", image)
+	}
+	url := image["image_url"].(map[string]interface{})["url"].(string)
+	if !strings.HasPrefix(url, "data:image/png;base64,") {
+		t.Fatalf("expected data URL, got %q", url)
+	}
+}
+
+func TestCreateMessageOmitsImagesWithoutVision(t *testing.T) {
+	msg := history.NewImageMessage("Image output from capture_screenshot", []llm.Image{{MIMEType: "image/png", Data: []byte{1}}})
+	body := captureRequest(t, false, []llm.Message{msg})
+
+	messages := body["messages"].([]interface{})
+	content, ok := messages[0].(map[string]interface{})["content"].(string)
+	if !ok || !strings.Contains(content, "image(s) omitted") {
+		t.Fatalf("expected text placeholder, got %#v", messages[0])
+	}
+}
@@ -1,6 +1,10 @@
 package openai
 
-import "roderik/internal/ai/llm"
+import (
+	"encoding/json"
+
+	"roderik/internal/ai/llm"
+)
 
 type CreateRequest struct {
 	Model       string         `json:"model"`
@@ -18,6 +22,33 @@ type MessageParam struct {
 	ToolCalls        []ToolCall    `json:"tool_calls,omitempty"`
 	Name             string        `json:"name,omitempty"`
 	ToolCallID       string        `json:"tool_call_id,omitempty"`
+	// Parts replaces Content with multimodal content parts when set.
+	Parts []ContentPart `json:"-"`
+}
+
+// MarshalJSON emits Parts as the content array when present, since the API
+// accepts either a string or a list of typed parts in the same field.
+func (m MessageParam) MarshalJSON() ([]byte, error) {
+	type plain MessageParam
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain(m), m.Parts})
+}
+
+// ContentPart is one element of a multimodal message content array.
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
 }
 
 type ToolCall struct {
@@ -21,6 +21,15 @@ type Message interface {
 
 	// GetUsage returns token usage statistics if available
 	GetUsage() (input int, output int)
+
+	// GetImages returns image parts attached to the message, if any
+	GetImages() []Image
+}
+
+// Image is an image content part, e.g. a page screenshot for vision models
+type Image struct {
+	MIMEType string `json:"mime_type"`
+	Data     []byte `json:"data"`
 }
 
 // ToolCall represents a tool invocation
@@ -0,0 +1,118 @@
+// Package mcpclient connects the AI assistant to external MCP servers and
+// exposes their tools and resources next to roderik's own.
+package mcpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"roderik/internal/appdirs"
+)
+
+// Transports of servers reached over the network.
+const (
+	TransportHTTP = "http"
+	TransportSSE  = "sse"
+)
+
+// ServerConfig declares one external MCP server. Command launches a local
+// server speaking stdio; URL attaches to a running one instead.
+type ServerConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+
+	URL string `json:"url"`
+	// Transport is http (streamable HTTP, the default) or sse.
+	Transport string            `json:"transport"`
+	Headers   map[string]string `json:"headers"`
+
+	// Disabled keeps the entry in the file without connecting to it.
+	Disabled bool `json:"disabled"`
+	// Concurrent lets the assistant run the server's tools alongside other
+	// calls. Tools of unknown servers may write, so they run one at a time by
+	// default.
+	Concurrent bool `json:"concurrent"`
+}
+
+// Config is the on-disk list of external servers, keyed by the name their
+// tools are namespaced with.
+type Config struct {
+	Servers map[string]ServerConfig `json:"servers"`
+}
+
+// ReservedName is the namespace of roderik's own tools.
+const ReservedName = "roderik"
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// DefaultPath returns the standard location of the MCP server config.
+func DefaultPath() string {
+	base, err := appdirs.BaseDir()
+	if err != nil || strings.TrimSpace(base) == "" {
+		return ""
+	}
+	return filepath.Join(base, "mcp-servers.json")
+}
+
+// LoadConfig reads and validates the config at path. A missing file means no
+// external servers.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	if strings.TrimSpace(path) == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("read MCP server config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("decode MCP server config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks server names and that each server has exactly one of
+// command and url.
+func (c Config) Validate() error {
+	for _, name := range c.Names() {
+		sc := c.Servers[name]
+		switch {
+		case !validName.MatchString(name):
+			return fmt.Errorf("MCP server name %q may only contain letters, digits, _ and -", name)
+		case name == ReservedName:
+			return fmt.Errorf("MCP server name %q is reserved for roderik's own tools", name)
+		case strings.TrimSpace(sc.Command) == "" && strings.TrimSpace(sc.URL) == "":
+			return fmt.Errorf("MCP server %s needs a command or a url", name)
+		case sc.Command != "" && sc.URL != "":
+			return fmt.Errorf("MCP server %s has both a command and a url", name)
+		}
+		switch sc.Transport {
+		case "", TransportHTTP, TransportSSE:
+		default:
+			return fmt.Errorf("MCP server %s: unknown transport %q (want http or sse)", name, sc.Transport)
+		}
+	}
+	return nil
+}
+
+// Names returns the server names in a stable order.
+func (c Config) Names() []string {
+	names := make([]string, 0, len(c.Servers))
+	for name := range c.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
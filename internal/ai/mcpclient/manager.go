@@ -0,0 +1,342 @@
+package mcpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"roderik/internal/ai/llm"
+	aitools "roderik/internal/ai/tools"
+)
+
+// resourceTool is the per-server tool that lists and reads resources.
+const resourceTool = "read_resource"
+
+// maxListedResources caps the resources named in a read_resource description.
+const maxListedResources = 20
+
+// Server is a connected external MCP server.
+type Server struct {
+	Name      string
+	Tools     []mcp.Tool
+	Resources []mcp.Resource
+	// Concurrent marks the tools independent of other calls; see
+	// ServerConfig.Concurrent.
+	Concurrent bool
+	client     *client.Client
+}
+
+// Manager holds the connections to the external servers of one AI session.
+type Manager struct {
+	mu      sync.Mutex
+	servers []*Server
+}
+
+// Connect launches or attaches to every enabled server in cfg. A server that
+// fails to start is reported in the returned errors and left out; the others
+// stay usable.
+func Connect(ctx context.Context, cfg Config, version string) (*Manager, []error) {
+	m := &Manager{}
+	var errs []error
+	for _, name := range cfg.Names() {
+		sc := cfg.Servers[name]
+		if sc.Disabled {
+			continue
+		}
+		c, err := newClient(sc)
+		if err == nil {
+			var srv *Server
+			if srv, err = m.attach(ctx, name, c, version); err == nil {
+				srv.Concurrent = sc.Concurrent
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("MCP server %s: %w", name, err))
+		}
+	}
+	return m, errs
+}
+
+func newClient(sc ServerConfig) (*client.Client, error) {
+	if sc.Command != "" {
+		env := make([]string, 0, len(sc.Env))
+		for k, v := range sc.Env {
+			env = append(env, k+"="+v)
+		}
+		sort.Strings(env)
+		// The process must outlive the connect context, so it gets none.
+		c, err := client.NewStdioMCPClient(sc.Command, env, sc.Args...)
+		if err != nil {
+			return nil, err
+		}
+		if stderr, ok := client.GetStderr(c); ok {
+			// An undrained stderr pipe would eventually block the server.
+			go func() { _, _ = io.Copy(io.Discard, stderr) }()
+		}
+		return c, nil
+	}
+	var (
+		c   *client.Client
+		err error
+	)
+	if sc.Transport == TransportSSE {
+		c, err = client.NewSSEMCPClient(sc.URL, client.WithHeaders(sc.Headers))
+	} else {
+		c, err = client.NewStreamableHttpClient(sc.URL, transport.WithHTTPHeaders(sc.Headers))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Start(context.Background()); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Attach initializes a started client and records its tools and resources
+// under name. The manager closes c from then on, on failure too.
+func (m *Manager) Attach(ctx context.Context, name string, c *client.Client, version string) error {
+	_, err := m.attach(ctx, name, c, version)
+	return err
+}
+
+func (m *Manager) attach(ctx context.Context, name string, c *client.Client, version string) (*Server, error) {
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: ReservedName, Version: version}
+	info, err := c.Initialize(ctx, init)
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	srv := &Server{Name: name, client: c}
+	if info.Capabilities.Tools != nil {
+		tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("list tools: %w", err)
+		}
+		srv.Tools = tools.Tools
+	}
+	if info.Capabilities.Resources != nil {
+		// Resources are optional; a server that fails to list them still
+		// offers its tools.
+		if res, err := c.ListResources(ctx, mcp.ListResourcesRequest{}); err == nil {
+			srv.Resources = res.Resources
+		}
+	}
+	m.mu.Lock()
+	m.servers = append(m.servers, srv)
+	m.mu.Unlock()
+	return srv, nil
+}
+
+// Servers returns the connected servers.
+func (m *Manager) Servers() []*Server {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Server(nil), m.servers...)
+}
+
+// Close disconnects every server, stopping the ones it launched.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	servers := m.servers
+	m.servers = nil
+	m.mu.Unlock()
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close MCP server %s: %w", srv.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// QualifiedName is the name an external tool is dispatched and approved
+// under, e.g. "files/read_file".
+func QualifiedName(server, tool string) string {
+	return server + "/" + tool
+}
+
+// LLMTools returns the external tools namespaced as server__tool, with a
+// read_resource tool for servers that publish resources, and the mapping
+// back to their definitions. Call RegisterHandlers so the definitions'
+// names dispatch to the servers.
+func (m *Manager) LLMTools() ([]llm.Tool, map[string]aitools.Definition) {
+	var out []llm.Tool
+	mapping := make(map[string]aitools.Definition)
+	add := func(server, tool, description string, schema llm.Schema, independent bool) {
+		sanitized := aitools.SanitizeName(server, tool)
+		if _, dup := mapping[sanitized]; dup {
+			return
+		}
+		out = append(out, llm.Tool{Name: sanitized, Description: description, InputSchema: schema})
+		// External tools never touch the browser page, but may write
+		// elsewhere.
+		mapping[sanitized] = aitools.Definition{
+			Name:        QualifiedName(server, tool),
+			Description: description,
+			Independent: independent,
+		}
+	}
+	for _, srv := range m.Servers() {
+		for _, tool := range srv.Tools {
+			schema := llm.Schema{Type: "object", Properties: tool.InputSchema.Properties, Required: tool.InputSchema.Required}
+			if schema.Properties == nil {
+				schema.Properties = map[string]interface{}{}
+			}
+			add(srv.Name, tool.Name, tool.Description, schema, srv.Concurrent)
+		}
+		if len(srv.Resources) > 0 && !srv.hasTool(resourceTool) {
+			add(srv.Name, resourceTool, srv.resourceToolDescription(), llm.Schema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"uri": map[string]interface{}{"type": "string", "description": "URI of the resource to read; omit to list them all"},
+				},
+			}, true)
+		}
+	}
+	return out, mapping
+}
+
+// RegisterHandlers routes the qualified names from LLMTools to the servers.
+func (m *Manager) RegisterHandlers() {
+	for _, srv := range m.Servers() {
+		srv := srv
+		for _, tool := range srv.Tools {
+			name := tool.Name
+			aitools.RegisterHandler(QualifiedName(srv.Name, name), func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+				return srv.CallTool(ctx, name, args)
+			})
+		}
+		if len(srv.Resources) > 0 && !srv.hasTool(resourceTool) {
+			aitools.RegisterHandler(QualifiedName(srv.Name, resourceTool), func(ctx context.Context, args map[string]interface{}) (aitools.Result, error) {
+				uri, _ := args["uri"].(string)
+				if strings.TrimSpace(uri) == "" {
+					return aitools.Result{Text: srv.resourceList(0)}, nil
+				}
+				return srv.ReadResource(ctx, uri)
+			})
+		}
+	}
+}
+
+func (s *Server) hasTool(name string) bool {
+	for _, tool := range s.Tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) resourceToolDescription() string {
+	return fmt.Sprintf("Read a resource published by the %s MCP server. Available resources:\n%s", s.Name, s.resourceList(maxListedResources))
+}
+
+// resourceList names the server's resources, at most limit of them when
+// limit is positive.
+func (s *Server) resourceList(limit int) string {
+	var b strings.Builder
+	for i, res := range s.Resources {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(&b, "- … %d more (call without uri to list all)\n", len(s.Resources)-limit)
+			break
+		}
+		fmt.Fprintf(&b, "- %s", res.URI)
+		if res.Name != "" && res.Name != res.URI {
+			fmt.Fprintf(&b, " (%s)", res.Name)
+		}
+		if res.Description != "" {
+			fmt.Fprintf(&b, ": %s", res.Description)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// CallTool calls a tool of the server. A result flagged as an error is
+// returned as an error carrying the server's message.
+func (s *Server) CallTool(ctx context.Context, name string, args map[string]interface{}) (aitools.Result, error) {
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := s.client.CallTool(ctx, req)
+	if err != nil {
+		return aitools.Result{}, fmt.Errorf("%s: %w", QualifiedName(s.Name, name), err)
+	}
+	out := contentsResult(res.Content)
+	if res.IsError {
+		msg := strings.TrimSpace(out.Text)
+		if msg == "" {
+			msg = "tool reported an error"
+		}
+		return aitools.Result{}, fmt.Errorf("%s: %s", QualifiedName(s.Name, name), msg)
+	}
+	return out, nil
+}
+
+// ReadResource reads one resource of the server.
+func (s *Server) ReadResource(ctx context.Context, uri string) (aitools.Result, error) {
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+	res, err := s.client.ReadResource(ctx, req)
+	if err != nil {
+		return aitools.Result{}, fmt.Errorf("read %s from %s: %w", uri, s.Name, err)
+	}
+	contents := make([]mcp.Content, 0, len(res.Contents))
+	for _, rc := range res.Contents {
+		contents = append(contents, mcp.NewEmbeddedResource(rc))
+	}
+	return contentsResult(contents), nil
+}
+
+// contentsResult folds MCP contents into one tool result: text is joined,
+// the first image or blob becomes the binary payload and further ones are
+// only mentioned.
+func contentsResult(contents []mcp.Content) aitools.Result {
+	var out aitools.Result
+	var texts []string
+	attach := func(data, mimeType, what string) bool {
+		if len(out.Binary) > 0 {
+			texts = append(texts, fmt.Sprintf("(%s omitted: %s)", what, mimeType))
+			return false
+		}
+		raw, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			texts = append(texts, fmt.Sprintf("(%s could not be decoded: %v)", what, err))
+			return false
+		}
+		out.Binary, out.ContentType = raw, mimeType
+		return true
+	}
+	for _, c := range contents {
+		switch c := c.(type) {
+		case mcp.TextContent:
+			texts = append(texts, c.Text)
+		case mcp.ImageContent:
+			attach(c.Data, c.MIMEType, "image")
+		case mcp.EmbeddedResource:
+			switch rc := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				texts = append(texts, fmt.Sprintf("%s:\n%s", rc.URI, rc.Text))
+			case mcp.BlobResourceContents:
+				if attach(rc.Blob, rc.MIMEType, rc.URI) {
+					out.InlineURI = rc.URI
+				}
+			}
+		}
+	}
+	out.Text = strings.Join(texts, "\n\n")
+	return out
+}
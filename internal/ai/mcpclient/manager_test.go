@@ -0,0 +1,119 @@
+package mcpclient_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"roderik/internal/ai/mcpclient"
+	aitools "roderik/internal/ai/tools"
+)
+
+func newTestServer() *server.MCPServer {
+	s := server.NewMCPServer("files", "test", server.WithToolCapabilities(false), server.WithResourceCapabilities(false, false))
+	s.AddTool(mcp.NewTool("read_file",
+		mcp.WithDescription("Read a file"),
+		mcp.WithString("path", mcp.Required()),
+	), func(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, _ := req.Params.Arguments["path"].(string)
+		if path == "missing" {
+			return mcp.NewToolResultError("no such file"), nil
+		}
+		return mcp.NewToolResultText("contents of " + path), nil
+	})
+	s.AddResource(mcp.NewResource("file:///notes.txt", "notes"), func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: "file:///notes.txt", MIMEType: "text/plain", Text: "remember the milk"}}, nil
+	})
+	return s
+}
+
+func attachTestServer(t *testing.T) *mcpclient.Manager {
+	t.Helper()
+	c, err := client.NewInProcessClient(newTestServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mcpclient.Manager{}
+	if err := m.Attach(context.Background(), "files", c, "test"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestManagerNamespacesAndRoutesTools(t *testing.T) {
+	m := attachTestServer(t)
+	m.RegisterHandlers()
+
+	tools, mapping := m.LLMTools()
+	if len(tools) != 2 {
+		t.Fatalf("tools = %v", tools)
+	}
+	def, ok := mapping[aitools.SanitizeName("files", "read_file")]
+	if !ok || def.Name != "files/read_file" || def.Independent {
+		t.Fatalf("read_file mapping = %+v", def)
+	}
+	if !mapping[aitools.SanitizeName("files", "read_resource")].Independent {
+		t.Fatal("reading resources should run concurrently")
+	}
+	m.Servers()[0].Concurrent = true
+	if _, mapping := m.LLMTools(); !mapping[aitools.SanitizeName("files", "read_file")].Independent {
+		t.Fatal("tools of a concurrent server should be independent")
+	}
+	if got := tools[0].InputSchema.Required; len(got) != 1 || got[0] != "path" {
+		t.Fatalf("schema required = %v", got)
+	}
+
+	res, err := aitools.Call(context.Background(), def.Name, map[string]interface{}{"path": "a.txt"})
+	if err != nil || res.Text != "contents of a.txt" {
+		t.Fatalf("call = %q, %v", res.Text, err)
+	}
+	if _, err := aitools.Call(context.Background(), def.Name, map[string]interface{}{"path": "missing"}); err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Fatalf("error result = %v", err)
+	}
+}
+
+func TestManagerSurfacesResources(t *testing.T) {
+	m := attachTestServer(t)
+	m.RegisterHandlers()
+
+	_, mapping := m.LLMTools()
+	def, ok := mapping[aitools.SanitizeName("files", "read_resource")]
+	if !ok || !strings.Contains(def.Description, "file:///notes.txt (notes)") {
+		t.Fatalf("read_resource mapping = %+v", def)
+	}
+	res, err := aitools.Call(context.Background(), def.Name, map[string]interface{}{"uri": "file:///notes.txt"})
+	if err != nil || !strings.Contains(res.Text, "remember the milk") {
+		t.Fatalf("read = %q, %v", res.Text, err)
+	}
+	res, err = aitools.Call(context.Background(), def.Name, nil)
+	if err != nil || !strings.Contains(res.Text, "file:///notes.txt") {
+		t.Fatalf("list = %q, %v", res.Text, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cases := map[string]mcpclient.ServerConfig{
+		"roderik":  {Command: "x"},
+		"bad name": {Command: "x"},
+		"empty":    {},
+		"both":     {Command: "x", URL: "http://localhost"},
+		"proto":    {URL: "http://localhost", Transport: "ws"},
+	}
+	for name, sc := range cases {
+		cfg := mcpclient.Config{Servers: map[string]mcpclient.ServerConfig{name: sc}}
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("%s: config %+v accepted", name, sc)
+		}
+	}
+	ok := mcpclient.Config{Servers: map[string]mcpclient.ServerConfig{
+		"files":  {Command: "mcp-files", Args: []string{"/tmp"}},
+		"ticket": {URL: "https://tickets.example.com/mcp", Transport: mcpclient.TransportSSE},
+	}}
+	if err := ok.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,277 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"roderik/internal/appdirs"
+)
+
+// Action is the verdict a policy returns for a tool call.
+type Action string
+
+const (
+	ActionAllow Action = "allow"
+	ActionDeny  Action = "deny"
+	ActionAsk   Action = "ask"
+)
+
+// Rule matches a tool call by name, argument pattern and/or focused element and
+// assigns it an action. Rules are evaluated in order; the first match wins.
+type Rule struct {
+	// Tool restricts the rule to one tool; empty or "*" matches every tool.
+	Tool string `json:"tool"`
+	// Arg names the argument whose string value Pattern is matched against.
+	Arg string `json:"arg"`
+	// Pattern is a regular expression applied to the Arg value.
+	Pattern string `json:"pattern"`
+	// Focus is a regular expression applied to a description of the focused
+	// element (e.g. `input type="password"`).
+	Focus  string `json:"focus"`
+	Action Action `json:"action"`
+	Reason string `json:"reason"`
+
+	argRe   *regexp.Regexp
+	focusRe *regexp.Regexp
+}
+
+// Config is the on-disk representation of a tool policy.
+type Config struct {
+	// Default applies when no rule or per-tool action matches. Defaults to allow.
+	Default Action `json:"default"`
+	// Tools maps tool names to a fixed action.
+	Tools map[string]Action `json:"tools"`
+	// AllowedDomains restricts every `url` argument to the listed hosts. A
+	// leading "*." also matches subdomains. Empty means any domain.
+	AllowedDomains []string `json:"allowed_domains"`
+	Rules          []Rule   `json:"rules"`
+}
+
+// Policy evaluates tool calls against a compiled Config.
+type Policy struct {
+	cfg        Config
+	needsFocus bool
+}
+
+// Request describes a pending tool call.
+type Request struct {
+	Tool  string
+	Args  map[string]interface{}
+	Focus string
+}
+
+// Decision is the outcome of evaluating a Request.
+type Decision struct {
+	Action Action
+	Reason string
+}
+
+// ErrDenied is wrapped by every DeniedError so callers can use errors.Is.
+var ErrDenied = errors.New("tool call denied by policy")
+
+// DeniedError reports a tool call rejected by the policy or by the operator.
+type DeniedError struct {
+	Tool   string
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s denied by policy", e.Tool)
+	}
+	return fmt.Sprintf("%s denied by policy: %s", e.Tool, e.Reason)
+}
+
+func (e *DeniedError) Unwrap() error {
+	return ErrDenied
+}
+
+// IsDenied reports whether err originates from a policy denial.
+func IsDenied(err error) bool {
+	return errors.Is(err, ErrDenied)
+}
+
+// DefaultPath returns the standard location of the tool policy file.
+func DefaultPath() string {
+	base, err := appdirs.BaseDir()
+	if err != nil || strings.TrimSpace(base) == "" {
+		return ""
+	}
+	return filepath.Join(base, "tool-policy.json")
+}
+
+// Load reads and compiles the policy at path. A missing file yields a policy
+// that allows everything, matching the behaviour before policies existed.
+func Load(path string) (*Policy, error) {
+	if strings.TrimSpace(path) == "" {
+		return New(Config{})
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return New(Config{})
+		}
+		return nil, fmt.Errorf("read tool policy: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("decode tool policy %s: %w", path, err)
+	}
+	return New(cfg)
+}
+
+// New validates cfg and compiles its rule patterns.
+func New(cfg Config) (*Policy, error) {
+	if cfg.Default == "" {
+		cfg.Default = ActionAllow
+	}
+	if !validAction(cfg.Default) {
+		return nil, fmt.Errorf("tool policy: invalid default action %q", cfg.Default)
+	}
+	for name, action := range cfg.Tools {
+		if !validAction(action) {
+			return nil, fmt.Errorf("tool policy: invalid action %q for tool %s", action, name)
+		}
+	}
+	p := &Policy{cfg: cfg}
+	p.cfg.Rules = make([]Rule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		if !validAction(rule.Action) {
+			return nil, fmt.Errorf("tool policy: rule %d has invalid action %q", i+1, rule.Action)
+		}
+		if rule.Pattern != "" {
+			if strings.TrimSpace(rule.Arg) == "" {
+				return nil, fmt.Errorf("tool policy: rule %d has a pattern but no arg to match it against", i+1)
+			}
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("tool policy: rule %d pattern: %w", i+1, err)
+			}
+			rule.argRe = re
+		}
+		if rule.Focus != "" {
+			re, err := regexp.Compile(rule.Focus)
+			if err != nil {
+				return nil, fmt.Errorf("tool policy: rule %d focus: %w", i+1, err)
+			}
+			rule.focusRe = re
+			p.needsFocus = true
+		}
+		p.cfg.Rules[i] = rule
+	}
+	return p, nil
+}
+
+// NeedsFocus reports whether any rule inspects the focused element, letting
+// callers skip the CDP round-trip needed to describe it.
+func (p *Policy) NeedsFocus() bool {
+	return p != nil && p.needsFocus
+}
+
+// Evaluate returns the action for req.
+func (p *Policy) Evaluate(req Request) Decision {
+	if p == nil {
+		return Decision{Action: ActionAllow}
+	}
+
+	if len(p.cfg.AllowedDomains) > 0 {
+		if raw, ok := req.Args["url"].(string); ok && strings.TrimSpace(raw) != "" {
+			if !p.domainAllowed(raw) {
+				return Decision{Action: ActionDeny, Reason: fmt.Sprintf("domain of %q is not in the allowlist", raw)}
+			}
+		}
+	}
+
+	for _, rule := range p.cfg.Rules {
+		if rule.matches(req) {
+			return Decision{Action: rule.Action, Reason: rule.describe()}
+		}
+	}
+
+	if action, ok := p.cfg.Tools[req.Tool]; ok {
+		return Decision{Action: action, Reason: fmt.Sprintf("tool %s is set to %s", req.Tool, action)}
+	}
+	return Decision{Action: p.cfg.Default}
+}
+
+func (p *Policy) domainAllowed(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range p.cfg.AllowedDomains {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		switch {
+		case allowed == "":
+			continue
+		case strings.HasPrefix(allowed, "*."):
+			root := strings.TrimPrefix(allowed, "*.")
+			if host == root || strings.HasSuffix(host, "."+root) {
+				return true
+			}
+		case host == allowed:
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matches(req Request) bool {
+	if r.Tool != "" && r.Tool != "*" && r.Tool != req.Tool {
+		return false
+	}
+	if r.argRe != nil {
+		val, ok := req.Args[r.Arg]
+		if !ok {
+			return false
+		}
+		if !r.argRe.MatchString(fmt.Sprint(val)) {
+			return false
+		}
+	}
+	if r.focusRe != nil && !r.focusRe.MatchString(req.Focus) {
+		return false
+	}
+	return true
+}
+
+func (r Rule) describe() string {
+	if r.Reason != "" {
+		return r.Reason
+	}
+	var parts []string
+	if r.Arg != "" && r.Pattern != "" {
+		parts = append(parts, fmt.Sprintf("%s matches %q", r.Arg, r.Pattern))
+	}
+	if r.Focus != "" {
+		parts = append(parts, fmt.Sprintf("focus matches %q", r.Focus))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("rule for %s", firstNonEmpty(r.Tool, "*"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func validAction(a Action) bool {
+	switch a {
+	case ActionAllow, ActionDeny, ActionAsk:
+		return true
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluateDefaultsToAllow(t *testing.T) {
+	p, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := p.Evaluate(Request{Tool: "click"}); got.Action != ActionAllow {
+		t.Fatalf("expected allow, got %q", got.Action)
+	}
+}
+
+func TestEvaluatePerToolAction(t *testing.T) {
+	p, err := New(Config{
+		Default: ActionAllow,
+		Tools:   map[string]Action{"run_js": ActionAsk, "shutdown": ActionDeny},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := p.Evaluate(Request{Tool: "run_js"}); got.Action != ActionAsk {
+		t.Fatalf("expected ask for run_js, got %q", got.Action)
+	}
+	if got := p.Evaluate(Request{Tool: "shutdown"}); got.Action != ActionDeny {
+		t.Fatalf("expected deny for shutdown, got %q", got.Action)
+	}
+	if got := p.Evaluate(Request{Tool: "text"}); got.Action != ActionAllow {
+		t.Fatalf("expected allow for text, got %q", got.Action)
+	}
+}
+
+func TestEvaluateDomainAllowlist(t *testing.T) {
+	p, err := New(Config{AllowedDomains: []string{"example.com", "*.wikipedia.org"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cases := map[string]Action{
+		"https://example.com/path":        ActionAllow,
+		"https://en.wikipedia.org/wiki/X": ActionAllow,
+		"https://wikipedia.org":           ActionAllow,
+		"https://evil.example.net":        ActionDeny,
+		"https://notexample.com":          ActionDeny,
+	}
+	for raw, want := range cases {
+		got := p.Evaluate(Request{Tool: "load_url", Args: map[string]interface{}{"url": raw}})
+		if got.Action != want {
+			t.Fatalf("url %s: expected %q, got %q (%s)", raw, want, got.Action, got.Reason)
+		}
+	}
+}
+
+func TestEvaluateRulesFirstMatchWins(t *testing.T) {
+	p, err := New(Config{
+		Tools: map[string]Action{"type": ActionAllow},
+		Rules: []Rule{
+			{Tool: "type", Focus: `type="password"`, Action: ActionDeny, Reason: "no typing into password fields"},
+			{Tool: "run_js", Arg: "script", Pattern: `document\.cookie`, Action: ActionDeny},
+			{Tool: "run_js", Action: ActionAsk},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !p.NeedsFocus() {
+		t.Fatalf("expected NeedsFocus with focus rule present")
+	}
+
+	got := p.Evaluate(Request{Tool: "type", Args: map[string]interface{}{"text": "hunter2"}, Focus: `input type="password" name="pw"`})
+	if got.Action != ActionDeny || got.Reason != "no typing into password fields" {
+		t.Fatalf("expected password deny, got %#v", got)
+	}
+	got = p.Evaluate(Request{Tool: "type", Args: map[string]interface{}{"text": "hello"}, Focus: `input type="text"`})
+	if got.Action != ActionAllow {
+		t.Fatalf("expected allow for text input, got %#v", got)
+	}
+	got = p.Evaluate(Request{Tool: "run_js", Args: map[string]interface{}{"script": "document.cookie"}})
+	if got.Action != ActionDeny {
+		t.Fatalf("expected deny for cookie script, got %#v", got)
+	}
+	got = p.Evaluate(Request{Tool: "run_js", Args: map[string]interface{}{"script": "1+1"}})
+	if got.Action != ActionAsk {
+		t.Fatalf("expected ask for other scripts, got %#v", got)
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	if _, err := New(Config{Default: "maybe"}); err == nil {
+		t.Fatalf("expected invalid default to fail")
+	}
+	if _, err := New(Config{Rules: []Rule{{Action: ActionDeny, Arg: "script", Pattern: "("}}}); err == nil {
+		t.Fatalf("expected invalid pattern to fail")
+	}
+	if _, err := New(Config{Rules: []Rule{{Action: ActionDeny, Pattern: "cookie"}}}); err == nil {
+		t.Fatalf("expected pattern without arg to fail")
+	}
+}
+
+func TestLoadMissingFileAllowsEverything(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := p.Evaluate(Request{Tool: "click"}); got.Action != ActionAllow {
+		t.Fatalf("expected allow, got %q", got.Action)
+	}
+}
+
+func TestLoadReadsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool-policy.json")
+	if err := os.WriteFile(path, []byte(`{"default":"ask","tools":{"text":"allow"}}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := p.Evaluate(Request{Tool: "click"}); got.Action != ActionAsk {
+		t.Fatalf("expected default ask, got %q", got.Action)
+	}
+	if got := p.Evaluate(Request{Tool: "text"}); got.Action != ActionAllow {
+		t.Fatalf("expected text allow, got %q", got.Action)
+	}
+}
+
+func TestDeniedErrorIsDetectable(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &DeniedError{Tool: "click", Reason: "operator declined"})
+	if !IsDenied(err) {
+		t.Fatalf("expected IsDenied to detect wrapped denial")
+	}
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.Tool != "click" {
+		t.Fatalf("expected errors.As to recover DeniedError, got %#v", denied)
+	}
+}
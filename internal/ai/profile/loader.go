@@ -7,7 +7,9 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"roderik/internal/ai/usage"
 	"roderik/internal/appdirs"
 )
 
@@ -21,12 +23,55 @@ type ModelProfile struct {
 	APIKeyEnv    string `json:"api_key_env"`
 	MaxTokens    int    `json:"max_tokens"`
 	SystemPrompt string `json:"system_prompt"`
+
+	// MaxIterations caps how many model round-trips a single turn may take.
+	MaxIterations int `json:"max_iterations"`
+	// TurnTimeoutSeconds bounds the wall-clock time of a whole turn, tools included.
+	TurnTimeoutSeconds int `json:"turn_timeout_seconds"`
+	// ToolTimeoutSeconds bounds each individual tool call within a turn.
+	ToolTimeoutSeconds int `json:"tool_timeout_seconds"`
+
+	// Vision marks multimodal models that accept screenshots as image input.
+	Vision bool `json:"vision"`
+	// VisionMaxDimension caps the longest side (in pixels) of images sent to
+	// the model; larger screenshots are downscaled first.
+	VisionMaxDimension int `json:"vision_max_dimension"`
+
+	// Price overrides the config-level price table entry for this profile's model.
+	Price *usage.Price `json:"price,omitempty"`
+	// MaxSessionTokens stops the agent loop once a chat session used this many tokens.
+	MaxSessionTokens int64 `json:"max_session_tokens"`
+	// MaxSessionCost stops the agent loop once the estimated session cost reaches this amount.
+	MaxSessionCost float64 `json:"max_session_cost"`
+}
+
+// Budget returns the session caps configured for the profile.
+func (p ModelProfile) Budget() usage.Budget {
+	return usage.Budget{MaxTokens: p.MaxSessionTokens, MaxCost: p.MaxSessionCost}
+}
+
+// TurnTimeout returns the configured per-turn budget, or zero when unset.
+func (p ModelProfile) TurnTimeout() time.Duration {
+	if p.TurnTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(p.TurnTimeoutSeconds) * time.Second
+}
+
+// ToolTimeout returns the configured per-call tool budget, or zero when unset.
+func (p ModelProfile) ToolTimeout() time.Duration {
+	if p.ToolTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(p.ToolTimeoutSeconds) * time.Second
 }
 
 // Config captures all available model profiles and their defaults.
 type Config struct {
 	Default  string                   `json:"default"`
 	Profiles map[string]*ModelProfile `json:"profiles"`
+	// Prices maps model IDs to their per-million-token price.
+	Prices map[string]usage.Price `json:"prices"`
 }
 
 // Loader resolves model profiles using an on-disk config file and environment overrides.
@@ -117,6 +162,10 @@ func (l *Loader) Load(selection string) (ModelProfile, error) {
 	applyEnvOverrides(&profile, getenv)
 	applyDefaults(&profile)
 
+	if profile.Price == nil {
+		profile.Price = cfg.price(profile.Model)
+	}
+
 	// If the profile originated purely from config/env, ensure we still propagate the name.
 	if profile.Name == "" {
 		profile.Name = selection
@@ -161,6 +210,22 @@ func (c *Config) profile(name string) (ModelProfile, bool) {
 	return clone, true
 }
 
+func (c *Config) price(model string) *usage.Price {
+	if c == nil || len(c.Prices) == 0 {
+		return nil
+	}
+	if p, ok := c.Prices[model]; ok {
+		return &p
+	}
+	for name, p := range c.Prices {
+		if strings.EqualFold(name, model) {
+			p := p
+			return &p
+		}
+	}
+	return nil
+}
+
 func applyEnvOverrides(profile *ModelProfile, getenv func(string) string) {
 	if profile == nil {
 		return
@@ -204,6 +269,47 @@ func applyEnvOverrides(profile *ModelProfile, getenv func(string) string) {
 			profile.MaxTokens = parsed
 		}
 	}
+
+	// Loop limits precedence: config -> RODERIK_AI_MAX_ITERATIONS / RODERIK_AI_TURN_TIMEOUT / RODERIK_AI_TOOL_TIMEOUT
+	if raw := strings.TrimSpace(getenv("RODERIK_AI_MAX_ITERATIONS")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			profile.MaxIterations = parsed
+		}
+	}
+	if raw := strings.TrimSpace(getenv("RODERIK_AI_TURN_TIMEOUT")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			profile.TurnTimeoutSeconds = parsed
+		}
+	}
+	if raw := strings.TrimSpace(getenv("RODERIK_AI_TOOL_TIMEOUT")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			profile.ToolTimeoutSeconds = parsed
+		}
+	}
+
+	// Budget precedence: config -> RODERIK_AI_MAX_SESSION_TOKENS / RODERIK_AI_MAX_SESSION_COST
+	if raw := strings.TrimSpace(getenv("RODERIK_AI_MAX_SESSION_TOKENS")); raw != "" {
+		if parsed, err := strconv.ParseInt(raw, 10, 64); err == nil && parsed >= 0 {
+			profile.MaxSessionTokens = parsed
+		}
+	}
+	if raw := strings.TrimSpace(getenv("RODERIK_AI_MAX_SESSION_COST")); raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed >= 0 {
+			profile.MaxSessionCost = parsed
+		}
+	}
+
+	// Vision precedence: config -> RODERIK_AI_VISION / RODERIK_AI_VISION_MAX_DIMENSION
+	if raw := strings.TrimSpace(getenv("RODERIK_AI_VISION")); raw != "" {
+		if parsed, err := strconv.ParseBool(raw); err == nil {
+			profile.Vision = parsed
+		}
+	}
+	if raw := strings.TrimSpace(getenv("RODERIK_AI_VISION_MAX_DIMENSION")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			profile.VisionMaxDimension = parsed
+		}
+	}
 }
 
 func applyDefaults(profile *ModelProfile) {
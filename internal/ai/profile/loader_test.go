@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 type fakeEnv map[string]string
@@ -216,3 +217,112 @@ func TestDefaultConfigPathHonorsAppdirsBaseDir(t *testing.T) {
 		t.Fatalf("expected default config path %q, got %q", expect, path)
 	}
 }
+
+func TestLoaderReadsLoopLimits(t *testing.T) {
+	configPath := filepath.FromSlash("/tmp/config.json")
+	fs := fakeFS{files: map[string]string{
+		configPath: `{
+            "profiles": {
+                "alpha": {
+                    "model": "gpt-4",
+                    "max_iterations": 12,
+                    "turn_timeout_seconds": 180,
+                    "tool_timeout_seconds": 20
+                }
+            }
+        }`,
+	}}
+
+	loader := Loader{
+		ConfigPath: configPath,
+		Getenv:     fakeEnv{"RODERIK_AI_TOOL_TIMEOUT": "45"}.Get,
+		ReadFile:   fs.ReadFile,
+	}
+
+	prof, err := loader.Load("alpha")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if prof.MaxIterations != 12 {
+		t.Fatalf("expected max iterations 12, got %d", prof.MaxIterations)
+	}
+	if got := prof.TurnTimeout(); got != 180*time.Second {
+		t.Fatalf("expected turn timeout 180s, got %s", got)
+	}
+	if got := prof.ToolTimeout(); got != 45*time.Second {
+		t.Fatalf("expected env tool timeout override 45s, got %s", got)
+	}
+}
+
+func TestLoaderReadsVisionSettings(t *testing.T) {
+	configPath := filepath.FromSlash("/tmp/config.json")
+	fs := fakeFS{files: map[string]string{
+		configPath: `{
+            "profiles": {
+                "eyes": {
+                    "model": "gpt-4o",
+                    "vision": true,
+                    "vision_max_dimension": 768
+                }
+            }
+        }`,
+	}}
+
+	loader := Loader{
+		ConfigPath: configPath,
+		Getenv:     fakeEnv{"RODERIK_AI_VISION_MAX_DIMENSION": "512"}.Get,
+		ReadFile:   fs.ReadFile,
+	}
+
+	prof, err := loader.Load("eyes")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !prof.Vision {
+		t.Fatalf("expected vision to be enabled")
+	}
+	if prof.VisionMaxDimension != 512 {
+		t.Fatalf("expected env max dimension override 512, got %d", prof.VisionMaxDimension)
+	}
+}
+
+func TestLoaderResolvesPricesAndBudget(t *testing.T) {
+	configPath := filepath.FromSlash("/tmp/config.json")
+	fs := fakeFS{files: map[string]string{
+		configPath: `{
+            "prices": {
+                "gpt-5": {"input_per_million": 1.25, "output_per_million": 10}
+            },
+            "profiles": {
+                "main": {"model": "GPT-5", "max_session_tokens": 50000},
+                "custom": {"model": "gpt-5", "price": {"input_per_million": 3, "output_per_million": 15}}
+            }
+        }`,
+	}}
+
+	loader := Loader{
+		ConfigPath: configPath,
+		Getenv:     fakeEnv{"RODERIK_AI_MAX_SESSION_COST": "0.75"}.Get,
+		ReadFile:   fs.ReadFile,
+	}
+
+	main, err := loader.Load("main")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if main.Price == nil || main.Price.InputPerMillion != 1.25 {
+		t.Fatalf("expected price table entry for model, got %#v", main.Price)
+	}
+	if budget := main.Budget(); budget.MaxTokens != 50000 || budget.MaxCost != 0.75 {
+		t.Fatalf("unexpected budget %#v", budget)
+	}
+
+	custom, err := loader.Load("custom")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if custom.Price == nil || custom.Price.InputPerMillion != 3 {
+		t.Fatalf("expected profile price override, got %#v", custom.Price)
+	}
+}
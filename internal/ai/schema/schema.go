@@ -0,0 +1,471 @@
+// Package schema validates decoded JSON documents against a practical subset
+// of JSON Schema (draft 2020-12 / draft-07 keywords): type, enum, const,
+// properties, required, additionalProperties, items, min/max constraints,
+// pattern, allOf/anyOf/oneOf/not and local $ref pointers. Unknown keywords
+// such as format or description are ignored.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is a compiled JSON Schema document.
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// ValidationError describes one violation found in a document.
+type ValidationError struct {
+	// Path is a JSON pointer to the offending value ("" is the document root).
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Message)
+}
+
+// Errors is returned by Validate when the document does not conform.
+type Errors []ValidationError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, err := range e {
+		parts[i] = err.Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Compile parses a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]interface{}, bool:
+	default:
+		return nil, fmt.Errorf("schema must be a JSON object or boolean")
+	}
+	s := &Schema{root: root, patterns: map[string]*regexp.Regexp{}}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate checks a decoded JSON value (as produced by encoding/json with
+// interface{} targets) and returns Errors when it does not conform.
+func (s *Schema) Validate(doc interface{}) error {
+	var errs Errors
+	s.validate(s.root, doc, "", &errs, 0)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateJSON decodes raw JSON and validates it.
+func (s *Schema) ValidateJSON(raw []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the top-level value")
+	}
+	return doc, s.Validate(doc)
+}
+
+func (s *Schema) compilePatterns(node interface{}) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "pattern" {
+				if p, ok := child.(string); ok {
+					re, err := regexp.Compile(p)
+					if err != nil {
+						return fmt.Errorf("schema pattern %q: %w", p, err)
+					}
+					s.patterns[p] = re
+				}
+				continue
+			}
+			if key == "enum" || key == "const" {
+				continue
+			}
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+const maxRefDepth = 64
+
+func (s *Schema) validate(node interface{}, value interface{}, path string, errs *Errors, depth int) {
+	if depth > maxRefDepth {
+		s.fail(errs, path, "schema nesting too deep (recursive $ref?)")
+		return
+	}
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			s.fail(errs, path, "no value is allowed here")
+		}
+		return
+	case map[string]interface{}:
+		s.validateObjectSchema(n, value, path, errs, depth)
+	}
+}
+
+func (s *Schema) validateObjectSchema(n map[string]interface{}, value interface{}, path string, errs *Errors, depth int) {
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := s.resolveRef(ref)
+		if err != nil {
+			s.fail(errs, path, err.Error())
+			return
+		}
+		s.validate(target, value, path, errs, depth+1)
+	}
+
+	if t, ok := n["type"]; ok && !matchesType(t, value) {
+		s.fail(errs, path, fmt.Sprintf("expected %s, got %s", describeType(t), jsonType(value)))
+		return
+	}
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if equalJSON(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.fail(errs, path, fmt.Sprintf("value must be one of %s", compactJSON(enum)))
+		}
+	}
+	if c, ok := n["const"]; ok && !equalJSON(c, value) {
+		s.fail(errs, path, fmt.Sprintf("value must equal %s", compactJSON(c)))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(n, v, path, errs, depth)
+	case []interface{}:
+		s.validateArray(n, v, path, errs, depth)
+	case string:
+		s.validateString(n, v, path, errs)
+	default:
+		if f, ok := toFloat(value); ok {
+			s.validateNumber(n, f, path, errs)
+		}
+	}
+
+	if all, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			s.validate(sub, value, path, errs, depth+1)
+		}
+	}
+	if anyOf, ok := n["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if s.matches(sub, value, path, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			s.fail(errs, path, "value does not match any of the anyOf schemas")
+		}
+	}
+	if one, ok := n["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, sub := range one {
+			if s.matches(sub, value, path, depth) {
+				count++
+			}
+		}
+		if count != 1 {
+			s.fail(errs, path, fmt.Sprintf("value must match exactly one oneOf schema, matched %d", count))
+		}
+	}
+	if not, ok := n["not"]; ok && s.matches(not, value, path, depth) {
+		s.fail(errs, path, "value must not match the \"not\" schema")
+	}
+}
+
+func (s *Schema) validateObject(n map[string]interface{}, obj map[string]interface{}, path string, errs *Errors, depth int) {
+	if required, ok := n["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				s.fail(errs, path, fmt.Sprintf("missing required property %q", name))
+			}
+		}
+	}
+	if min, ok := toInt(n["minProperties"]); ok && len(obj) < min {
+		s.fail(errs, path, fmt.Sprintf("expected at least %d properties, got %d", min, len(obj)))
+	}
+	if max, ok := toInt(n["maxProperties"]); ok && len(obj) > max {
+		s.fail(errs, path, fmt.Sprintf("expected at most %d properties, got %d", max, len(obj)))
+	}
+
+	props, _ := n["properties"].(map[string]interface{})
+	additional, hasAdditional := n["additionalProperties"]
+	for _, key := range sortedKeys(obj) {
+		childPath := path + "/" + escapePointer(key)
+		if sub, ok := props[key]; ok {
+			s.validate(sub, obj[key], childPath, errs, depth+1)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			s.fail(errs, path, fmt.Sprintf("unexpected property %q", key))
+			continue
+		}
+		s.validate(additional, obj[key], childPath, errs, depth+1)
+	}
+}
+
+func (s *Schema) validateArray(n map[string]interface{}, arr []interface{}, path string, errs *Errors, depth int) {
+	if min, ok := toInt(n["minItems"]); ok && len(arr) < min {
+		s.fail(errs, path, fmt.Sprintf("expected at least %d items, got %d", min, len(arr)))
+	}
+	if max, ok := toInt(n["maxItems"]); ok && len(arr) > max {
+		s.fail(errs, path, fmt.Sprintf("expected at most %d items, got %d", max, len(arr)))
+	}
+	if unique, _ := n["uniqueItems"].(bool); unique {
+		for i := 0; i < len(arr); i++ {
+			for j := i + 1; j < len(arr); j++ {
+				if equalJSON(arr[i], arr[j]) {
+					s.fail(errs, path, fmt.Sprintf("items %d and %d are not unique", i, j))
+				}
+			}
+		}
+	}
+	if items, ok := n["items"]; ok {
+		if _, isList := items.([]interface{}); !isList {
+			for i, item := range arr {
+				s.validate(items, item, path+"/"+strconv.Itoa(i), errs, depth+1)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateString(n map[string]interface{}, str string, path string, errs *Errors) {
+	length := len([]rune(str))
+	if min, ok := toInt(n["minLength"]); ok && length < min {
+		s.fail(errs, path, fmt.Sprintf("expected at least %d characters, got %d", min, length))
+	}
+	if max, ok := toInt(n["maxLength"]); ok && length > max {
+		s.fail(errs, path, fmt.Sprintf("expected at most %d characters, got %d", max, length))
+	}
+	if p, ok := n["pattern"].(string); ok {
+		if re := s.patterns[p]; re != nil && !re.MatchString(str) {
+			s.fail(errs, path, fmt.Sprintf("value does not match pattern %q", p))
+		}
+	}
+}
+
+func (s *Schema) validateNumber(n map[string]interface{}, f float64, path string, errs *Errors) {
+	if min, ok := toFloat(n["minimum"]); ok && f < min {
+		s.fail(errs, path, fmt.Sprintf("value %v is below the minimum %v", f, min))
+	}
+	if max, ok := toFloat(n["maximum"]); ok && f > max {
+		s.fail(errs, path, fmt.Sprintf("value %v is above the maximum %v", f, max))
+	}
+	if min, ok := toFloat(n["exclusiveMinimum"]); ok && f <= min {
+		s.fail(errs, path, fmt.Sprintf("value %v must be greater than %v", f, min))
+	}
+	if max, ok := toFloat(n["exclusiveMaximum"]); ok && f >= max {
+		s.fail(errs, path, fmt.Sprintf("value %v must be less than %v", f, max))
+	}
+	if m, ok := toFloat(n["multipleOf"]); ok && m > 0 {
+		if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			s.fail(errs, path, fmt.Sprintf("value %v is not a multiple of %v", f, m))
+		}
+	}
+}
+
+func (s *Schema) matches(node interface{}, value interface{}, path string, depth int) bool {
+	var sub Errors
+	s.validate(node, value, path, &sub, depth+1)
+	return len(sub) == 0
+}
+
+func (s *Schema) resolveRef(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q (only local references are supported)", ref)
+	}
+	node := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func (s *Schema) fail(errs *Errors, path, msg string) {
+	*errs = append(*errs, ValidationError{Path: path, Message: msg})
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, value)
+	case []interface{}:
+		for _, candidate := range tt {
+			if name, ok := candidate.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(name string, value interface{}) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	}
+	return false
+}
+
+func describeType(t interface{}) string {
+	switch tt := t.(type) {
+	case string:
+		return tt
+	case []interface{}:
+		names := make([]string, 0, len(tt))
+		for _, candidate := range tt {
+			names = append(names, fmt.Sprint(candidate))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toInt(v interface{}) (int, bool) {
+	f, ok := toFloat(v)
+	if !ok {
+		return 0, false
+	}
+	return int(f), true
+}
+
+func equalJSON(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return compactJSON(a) == compactJSON(b)
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
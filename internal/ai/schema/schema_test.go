@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const productSchema = `{
+  "type": "object",
+  "required": ["name", "price", "tags"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "price": {"type": "number", "minimum": 0},
+    "currency": {"enum": ["EUR", "USD"]},
+    "sku": {"type": "string", "pattern": "^[A-Z]{3}-\\d+$"},
+    "tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "minItems": 1}
+  },
+  "$defs": {
+    "tag": {"type": "string", "maxLength": 10}
+  }
+}`
+
+func TestValidateAcceptsConformingDocument(t *testing.T) {
+	s, err := Compile([]byte(productSchema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	doc, err := s.ValidateJSON([]byte(`{"name":"Lamp","price":19.5,"currency":"EUR","sku":"LMP-42","tags":["home"]}`))
+	if err != nil {
+		t.Fatalf("ValidateJSON() error = %v", err)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		t.Fatalf("expected decoded object, got %T", doc)
+	}
+}
+
+func TestValidateReportsEveryViolation(t *testing.T) {
+	s, err := Compile([]byte(productSchema))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	_, err = s.ValidateJSON([]byte(`{"price":-1,"currency":"GBP","sku":"lamp","tags":["a-very-long-tag"],"extra":true}`))
+	var verrs Errors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`missing required property "name"`,
+		"/price: value -1 is below the minimum 0",
+		"/currency: value must be one of",
+		"/sku: value does not match pattern",
+		"/tags/0: expected at most 10 characters",
+		`unexpected property "extra"`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in %q", want, msg)
+		}
+	}
+}
+
+func TestValidateTypeUnionsAndCombinators(t *testing.T) {
+	s, err := Compile([]byte(`{
+	  "type": ["object", "null"],
+	  "properties": {
+	    "count": {"type": "integer"},
+	    "id": {"oneOf": [{"type": "string"}, {"type": "integer"}]},
+	    "status": {"not": {"const": "deleted"}}
+	  }
+	}`))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := s.ValidateJSON([]byte(`null`)); err != nil {
+		t.Fatalf("expected null to validate, got %v", err)
+	}
+	if _, err := s.ValidateJSON([]byte(`{"count": 3, "id": 7, "status": "ok"}`)); err != nil {
+		t.Fatalf("expected object to validate, got %v", err)
+	}
+	if _, err := s.ValidateJSON([]byte(`{"count": 3.5}`)); err == nil {
+		t.Fatalf("expected non-integer count to fail")
+	}
+	if _, err := s.ValidateJSON([]byte(`{"status": "deleted"}`)); err == nil {
+		t.Fatalf("expected not/const violation")
+	}
+	if _, err := s.ValidateJSON([]byte(`[]`)); err == nil {
+		t.Fatalf("expected array to fail the type union")
+	}
+}
+
+func TestValidateJSONRejectsTrailingData(t *testing.T) {
+	s, err := Compile([]byte(`true`))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := s.ValidateJSON([]byte(`{} {}`)); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Fatalf("expected invalid JSON error, got %v", err)
+	}
+}
+
+func TestCompileRejectsBadPattern(t *testing.T) {
+	if _, err := Compile([]byte(`{"pattern": "("}`)); err == nil {
+		t.Fatalf("expected invalid pattern to fail")
+	}
+	if _, err := Compile([]byte(`"string"`)); err == nil {
+		t.Fatalf("expected non-object schema to fail")
+	}
+}
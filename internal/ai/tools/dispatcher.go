@@ -14,6 +14,9 @@ type Result struct {
 	ContentType string
 	FilePath    string
 	InlineURI   string
+	// Data is an optional machine-readable form of Text, encoded as JSON
+	// for clients that would otherwise have to parse the prose.
+	Data any
 }
 
 // Handler executes a tool by name.
@@ -45,6 +48,14 @@ func Call(ctx context.Context, name string, args map[string]interface{}) (Result
 	return h(ctx, args)
 }
 
+// HasHandler reports whether a handler is registered for the tool name.
+func HasHandler(name string) bool {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	_, ok := handlers[name]
+	return ok
+}
+
 // ResetHandlersForTest clears registered handlers (test helper).
 func ResetHandlersForTest() {
 	handlersMu.Lock()
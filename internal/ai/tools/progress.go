@@ -0,0 +1,25 @@
+package tools
+
+import "context"
+
+// ProgressFunc receives progress reports from a running handler. total is
+// zero when the amount of work is not known in advance.
+type ProgressFunc func(progress, total float64, message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose handlers report progress to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress tells the caller of a long-running handler which stage it
+// reached. It is a no-op when nobody asked for progress.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if ctx == nil {
+		return
+	}
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(progress, total, message)
+	}
+}
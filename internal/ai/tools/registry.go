@@ -1,11 +1,27 @@
 package tools
 
-// Definition describes a tool that can be exposed to AI integrations and MCP clients.
+import "sync"
+
+// Definition describes a tool that can be exposed to AI integrations and MCP
+// clients. It is the single source of truth for the MCP tool list, the AI
+// tool list and the tools command's help.
 type Definition struct {
 	Name        string
 	Description string
 	Parameters  []Parameter
 	FocusAware  bool
+	// Independent marks tools that never touch the shared browser page (no
+	// withPage lock), so the AI agent may run them concurrently with other calls.
+	Independent bool
+	// Gate names a predicate registered with RegisterGate that must hold for
+	// the tool to be exposed over MCP; empty means always available.
+	Gate string
+	// MCPOnly marks tools that rely on MCP features (notifications, resources,
+	// server lifecycle) and are not offered to the AI agent.
+	MCPOnly bool
+	// Capability groups the tool for MCP capability profiles; it is one of
+	// Capabilities.
+	Capability string
 }
 
 type ParameterType string
@@ -13,7 +29,10 @@ type ParameterType string
 const (
 	ParamString  ParameterType = "string"
 	ParamNumber  ParameterType = "number"
+	ParamInteger ParameterType = "integer"
 	ParamBoolean ParameterType = "boolean"
+	ParamArray   ParameterType = "array"
+	ParamObject  ParameterType = "object"
 )
 
 type Parameter struct {
@@ -22,25 +41,151 @@ type Parameter struct {
 	Description string
 	Required    bool
 	Enum        []string
+	// Default is advertised to clients; handlers apply the same default.
+	Default interface{}
+	// Minimum and Maximum bound numeric parameters when set.
+	Minimum *float64
+	Maximum *float64
+	// Items is the element type of ParamArray parameters (string when empty).
+	Items ParameterType
+}
+
+// Bound returns a pointer for Parameter.Minimum and Parameter.Maximum.
+func Bound(v float64) *float64 {
+	return &v
 }
 
 func (pt ParameterType) JSONType() string {
 	switch pt {
-	case ParamString:
-		return "string"
-	case ParamNumber:
-		return "number"
-	case ParamBoolean:
-		return "boolean"
+	case ParamString, ParamNumber, ParamInteger, ParamBoolean, ParamArray, ParamObject:
+		return string(pt)
 	default:
 		return "string"
 	}
 }
 
+// Schema returns the JSON Schema of the parameter.
+func (p Parameter) Schema() map[string]interface{} {
+	prop := map[string]interface{}{
+		"type": p.Type.JSONType(),
+	}
+	if p.Description != "" {
+		prop["description"] = p.Description
+	}
+	if len(p.Enum) > 0 {
+		prop["enum"] = p.Enum
+	}
+	if p.Default != nil {
+		prop["default"] = p.Default
+	}
+	if p.Minimum != nil {
+		prop["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		prop["maximum"] = *p.Maximum
+	}
+	switch p.Type {
+	case ParamArray:
+		items := p.Items
+		if items == "" {
+			items = ParamString
+		}
+		prop["items"] = map[string]interface{}{"type": items.JSONType()}
+	case ParamObject:
+		prop["additionalProperties"] = true
+	}
+	return prop
+}
+
+// InputSchema returns the JSON Schema properties and required parameter
+// names of the tool.
+func (d Definition) InputSchema() (map[string]interface{}, []string) {
+	props := make(map[string]interface{}, len(d.Parameters))
+	var required []string
+	for _, param := range d.Parameters {
+		props[param.Name] = param.Schema()
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+	return props, required
+}
+
+// GateNavigation gates load_url and the DOM navigation tools, which MCP
+// deployments may switch off.
+const GateNavigation = "navigation"
+
+// Capabilities an MCP server can switch on and off as a group.
+const (
+	// CapInspect reads the current page and moves the element focus.
+	CapInspect = "inspect"
+	// CapNavigate loads URLs in the browser.
+	CapNavigate = "navigate"
+	// CapInteract clicks and types into the page.
+	CapInteract = "interact"
+	// CapScript runs arbitrary JavaScript in the page.
+	CapScript = "script"
+	// CapWeb queries search engines and fetches pages outside the current tab.
+	CapWeb = "web"
+	// CapNetwork reads, saves and watches the captured network log.
+	CapNetwork = "network"
+	// CapAdmin controls the MCP server itself.
+	CapAdmin = "admin"
+)
+
+// Capabilities lists every capability in a stable order.
+var Capabilities = []string{CapInspect, CapNavigate, CapInteract, CapScript, CapWeb, CapNetwork, CapAdmin}
+
+var (
+	gatesMu sync.RWMutex
+	gates   = make(map[string]func() bool)
+)
+
+// RegisterGate installs the predicate behind a Definition.Gate name.
+func RegisterGate(name string, enabled func() bool) {
+	gatesMu.Lock()
+	defer gatesMu.Unlock()
+	gates[name] = enabled
+}
+
+// Enabled reports whether the tool's gate, if any, currently allows it.
+// Gates without a registered predicate are open.
+func (d Definition) Enabled() bool {
+	if d.Gate == "" {
+		return true
+	}
+	gatesMu.RLock()
+	fn := gates[d.Gate]
+	gatesMu.RUnlock()
+	return fn == nil || fn()
+}
+
+// SearchEngines and TranscriptFormats are the accepted values of the engine
+// and format parameters; cmd tests keep them in step with the implementations.
+var (
+	SearchEngines     = []string{"auto", "ddg", "searxng", "brave", "ddg-browser"}
+	TranscriptFormats = []string{"text", "markdown", "json"}
+)
+
+// networkFilterParams are the NetworkLogFilter arguments shared by
+// network_list and network_watch.
+var networkFilterParams = []Parameter{
+	{Name: "mime", Type: ParamArray, Description: "optional MIME substrings to match"},
+	{Name: "suffix", Type: ParamArray, Description: "optional URL suffixes (e.g. .mp4)"},
+	{Name: "status", Type: ParamArray, Items: ParamInteger, Description: "optional HTTP status codes"},
+	{Name: "contains", Type: ParamArray, Description: "optional substrings to match in the URL"},
+	{Name: "method", Type: ParamArray, Description: "optional HTTP methods"},
+	{Name: "domain", Type: ParamArray, Description: "optional domain substrings"},
+	{Name: "type", Type: ParamArray, Description: "optional resource types (Document, Image, Media, etc.)"},
+	{Name: "graphql_op", Type: ParamArray, Description: "optional GraphQL operation name substrings"},
+}
+
 var definitions = []Definition{
 	{
 		Name:        "load_url",
+		Capability:  CapNavigate,
 		Description: "Load a webpage at the given URL and set it as the current page for subsequent tools.",
+		Gate:        GateNavigation,
 		Parameters: []Parameter{
 			{
 				Name:        "url",
@@ -51,7 +196,8 @@ var definitions = []Definition{
 		},
 	},
 	{
-		Name: "get_html",
+		Name:       "get_html",
+		Capability: CapInspect,
 		Description: "Get the raw HTML of the current element (or an optional URL). " +
 			"Beware: this returns the full source and can be very large. " +
 			"In most cases, use \"to_markdown\" for a more concise, token-efficient output.",
@@ -67,6 +213,7 @@ var definitions = []Definition{
 	},
 	{
 		Name:        "text",
+		Capability:  CapInspect,
 		Description: "Print the text of the current element, optionally truncating to a specified length.",
 		FocusAware:  true,
 		Parameters: []Parameter{
@@ -80,27 +227,29 @@ var definitions = []Definition{
 	},
 	{
 		Name:        "capture_screenshot",
+		Capability:  CapInspect,
 		Description: "Capture a screenshot of the current page or an optional URL.",
 		Parameters: []Parameter{
 			{Name: "url", Type: ParamString, Description: "optional URL to load before capturing the screenshot"},
 			{Name: "selector", Type: ParamString, Description: "optional CSS selector to capture a specific element"},
 			{Name: "full_page", Type: ParamBoolean, Description: "capture the entire page by resizing the viewport"},
 			{Name: "scroll", Type: ParamBoolean, Description: "scroll and stitch the entire page without resizing the viewport"},
-			{Name: "format", Type: ParamString, Description: "image format: png or jpeg (default png)"},
-			{Name: "quality", Type: ParamNumber, Description: "JPEG quality (0-100)"},
-			{Name: "return", Type: ParamString, Description: "delivery mode: binary (inline) or file (writes to disk)"},
+			{Name: "format", Type: ParamString, Description: "image format: png or jpeg (default png)", Enum: []string{"png", "jpeg", "jpg"}, Default: "png"},
+			{Name: "quality", Type: ParamInteger, Description: "JPEG quality (0-100)", Minimum: Bound(0), Maximum: Bound(100)},
+			{Name: "return", Type: ParamString, Description: "delivery mode: binary (inline) or file (writes to disk and returns resource)", Enum: []string{"binary", "file"}, Default: "binary"},
 			{Name: "output", Type: ParamString, Description: "optional path to save the capture on disk when return=file"},
 		},
 	},
 	{
 		Name:        "capture_pdf",
+		Capability:  CapInspect,
 		Description: "Render the current page or an optional URL to PDF.",
 		Parameters: []Parameter{
 			{Name: "url", Type: ParamString, Description: "optional URL to load before generating the PDF"},
 			{Name: "landscape", Type: ParamBoolean, Description: "render pages in landscape orientation"},
 			{Name: "header_footer", Type: ParamBoolean, Description: "display header and footer templates"},
 			{Name: "background", Type: ParamBoolean, Description: "print background graphics"},
-			{Name: "scale", Type: ParamNumber, Description: "scale factor for rendering (default 1.0)"},
+			{Name: "scale", Type: ParamNumber, Description: "scale factor for rendering (default 1.0)", Default: 1.0, Minimum: Bound(0.1), Maximum: Bound(2)},
 			{Name: "paper_width", Type: ParamNumber, Description: "paper width in inches"},
 			{Name: "paper_height", Type: ParamNumber, Description: "paper height in inches"},
 			{Name: "margin_top", Type: ParamNumber, Description: "top margin in inches"},
@@ -113,60 +262,121 @@ var definitions = []Definition{
 			{Name: "prefer_css_page_size", Type: ParamBoolean, Description: "prefer CSS-defined page size"},
 			{Name: "tagged", Type: ParamBoolean, Description: "generate tagged (accessible) PDF"},
 			{Name: "outline", Type: ParamBoolean, Description: "embed document outline in the PDF"},
-			{Name: "return", Type: ParamString, Description: "delivery mode: binary (embedded) or file (writes to disk)"},
+			{Name: "return", Type: ParamString, Description: "delivery mode: binary (embedded) or file (writes to disk)", Enum: []string{"binary", "file"}, Default: "binary"},
 			{Name: "output", Type: ParamString, Description: "optional path to save the PDF on disk when return=file"},
 		},
 	},
 	{
 		Name:        "box",
+		Capability:  CapInspect,
 		Description: "Get the bounding box of the current element.",
 	},
 	{
 		Name:        "computedstyles",
+		Capability:  CapInspect,
 		Description: "Output the computed styles of the current element in JSON format.",
 	},
 	{
 		Name:        "describe",
+		Capability:  CapInspect,
 		Description: "Describe the current element as formatted JSON.",
 	},
 	{
 		Name:        "xpath",
+		Capability:  CapInspect,
 		Description: "Get the optimized XPath of the current element.",
 	},
 	{
 		Name:        "shutdown",
+		Capability:  CapAdmin,
 		Description: "Shut down the MCP server.",
+		MCPOnly:     true,
+	},
+	{
+		Name:        "tool_capabilities",
+		Capability:  CapAdmin,
+		Description: "List the MCP server's tool capabilities, or switch one on or off for every client. Switching off admin removes this tool too.",
+		MCPOnly:     true,
+		Parameters: []Parameter{
+			{Name: "capability", Type: ParamString, Description: "capability to change; omit to only list the current state", Enum: Capabilities},
+			{Name: "enabled", Type: ParamBoolean, Description: "whether the capability's tools are offered (required with capability)"},
+		},
 	},
 	{
 		Name:        "duck",
-		Description: "Search DuckDuckGo and return top N results.",
+		Capability:  CapWeb,
+		Description: "Search the web (DuckDuckGo by default, failing over to other configured engines) and return top N results.",
+		Independent: true,
 		Parameters: []Parameter{
 			{Name: "query", Type: ParamString, Description: "the search terms", Required: true},
-			{Name: "num", Type: ParamNumber, Description: "how many results to return (default 20)"},
+			{Name: "num", Type: ParamInteger, Description: "how many results to return (default 20)", Default: 20, Minimum: Bound(1)},
+			{Name: "engine", Type: ParamString, Description: "search engine; auto follows the configured failover order", Enum: SearchEngines, Default: "auto"},
+			{Name: "region", Type: ParamString, Description: "region code such as us-en, de-de or wt-wt (no region)"},
+			{Name: "safe_search", Type: ParamString, Description: "safe search level", Enum: []string{"strict", "moderate", "off"}},
+			{Name: "time_range", Type: ParamString, Description: "only results from the past day, week, month or year", Enum: []string{"day", "week", "month", "year"}},
+			{Name: "include_ads", Type: ParamBoolean, Description: "keep sponsored results (dropped by default)"},
+		},
+	},
+	{
+		Name:       "research",
+		Capability: CapWeb,
+		Description: "Search the web, open the top results in parallel browser tabs and return their readable Markdown as one source-attributed bundle. " +
+			"Use this instead of duck followed by several load_url/to_markdown calls. Does not change the current page.",
+		Parameters: []Parameter{
+			{Name: "query", Type: ParamString, Description: "the search terms", Required: true},
+			{Name: "num", Type: ParamInteger, Description: "how many result pages to open (default 5, max 10)", Default: 5, Minimum: Bound(1), Maximum: Bound(10)},
+			{Name: "max_chars", Type: ParamInteger, Description: "truncate each page's Markdown to this many characters (default 4000, 0 = no limit)", Default: 4000, Minimum: Bound(0)},
+			{Name: "engine", Type: ParamString, Description: "search engine; auto follows the configured failover order", Enum: SearchEngines, Default: "auto"},
+		},
+	},
+	{
+		Name:        "yttrans",
+		Capability:  CapWeb,
+		Description: "Download a YouTube transcript (via yt-dlp, or the browser network log when yt-dlp is missing), cache it locally, and return deduplicated text, timestamped markdown or JSON segments.",
+		Parameters: []Parameter{
+			{Name: "url", Type: ParamString, Description: "YouTube video URL", Required: true},
+			{Name: "language", Type: ParamString, Description: "Transcript language code (default en)", Default: "en"},
+			{Name: "output_folder", Type: ParamString, Description: "Folder for cached transcripts (default ./yttrans-cache)"},
+			{Name: "format", Type: ParamString, Description: "output format: text (paragraphs), markdown ([mm:ss] paragraphs) or json (timed segments)", Enum: TranscriptFormats, Default: "text"},
+			{Name: "source", Type: ParamString, Description: "where to fetch captions: auto (yt-dlp, else browser), yt-dlp or browser", Enum: []string{"auto", "yt-dlp", "browser"}, Default: "auto"},
 		},
 	},
 	{
 		Name:        "network_list",
+		Capability:  CapNetwork,
 		Description: "List captured network activity entries with optional filters.",
+		Independent: true,
+		Parameters: []Parameter{
+			networkFilterParams[0], networkFilterParams[1], networkFilterParams[2], networkFilterParams[3],
+			networkFilterParams[4], networkFilterParams[5], networkFilterParams[6],
+			{Name: "limit", Type: ParamInteger, Description: "maximum number of entries to return (default 20, capped at 1000)", Default: 20, Minimum: Bound(0), Maximum: Bound(1000)},
+			{Name: "offset", Type: ParamInteger, Description: "number of matching entries to skip before returning results", Minimum: Bound(0)},
+			{Name: "tail", Type: ParamBoolean, Description: "when true (default) return the newest matching entries", Default: true},
+			{Name: "session", Type: ParamString, Description: "optional persisted session to query instead of the current page: current (every navigation of this run), latest, or a session ID"},
+			{Name: "since", Type: ParamString, Description: "optional: only persisted entries captured since a duration ago (e.g. 2h) or a time (2006-01-02 15:04)"},
+			networkFilterParams[7],
+		},
+	},
+	{
+		Name:        "network_get",
+		Capability:  CapNetwork,
+		Description: "Show one captured request in full: request and response headers, the request body (POST data) and the response body, with JSON pretty-printed and the GraphQL operation name when present.",
+		Independent: true,
 		Parameters: []Parameter{
-			{Name: "mime", Type: ParamString, Description: "optional comma-separated MIME substrings to match"},
-			{Name: "suffix", Type: ParamString, Description: "optional comma-separated URL suffixes (e.g. .mp4)"},
-			{Name: "status", Type: ParamString, Description: "optional comma-separated HTTP status codes"},
-			{Name: "contains", Type: ParamString, Description: "optional comma-separated substrings to match in the URL"},
-			{Name: "method", Type: ParamString, Description: "optional comma-separated HTTP methods"},
-			{Name: "domain", Type: ParamString, Description: "optional comma-separated domain substrings"},
-			{Name: "type", Type: ParamString, Description: "optional comma-separated resource types (Document, Image, Media, etc.)"},
-			{Name: "limit", Type: ParamNumber, Description: "maximum number of entries to return (default 20, capped at 1000)"},
-			{Name: "offset", Type: ParamNumber, Description: "number of matching entries to skip before returning results"},
-			{Name: "tail", Type: ParamBoolean, Description: "when true (default) return the newest matching entries"},
+			{Name: "request_id", Type: ParamString, Description: "request identifier returned by network_list", Required: true},
+			{Name: "session", Type: ParamString, Description: "optional persisted session the request was listed from (current, latest or a session ID)"},
+			{Name: "include_body", Type: ParamBoolean, Description: "include the response body (default true)", Default: true},
+			{Name: "max_chars", Type: ParamInteger, Description: "truncate each body to this many characters (default 8000, 0 = no limit)", Default: 8000, Minimum: Bound(0)},
 		},
 	},
 	{
 		Name:        "network_save",
+		Capability:  CapNetwork,
 		Description: "Retrieve or persist the response body for a captured network request.",
 		Parameters: []Parameter{
 			{Name: "request_id", Type: ParamString, Description: "request identifier returned by network_list", Required: true},
-			{Name: "return", Type: ParamString, Description: "delivery mode: file (default) saves on the server, binary streams the payload (save aliases file)", Enum: []string{"file", "binary", "save"}},
+			{Name: "session", Type: ParamString, Description: "optional persisted session the request was listed from (current, latest or a session ID)"},
+			{Name: "return", Type: ParamString, Description: "delivery mode: file (default) saves on the server, binary streams the payload (save aliases file)", Enum: []string{"file", "binary", "save"}, Default: "file"},
 			{Name: "save_dir", Type: ParamString, Description: "optional directory to write the file when return=file"},
 			{Name: "filename", Type: ParamString, Description: "optional filename override when saving to disk"},
 			{Name: "filename_prefix", Type: ParamString, Description: "optional prefix prepended to generated filenames"},
@@ -177,13 +387,65 @@ var definitions = []Definition{
 	},
 	{
 		Name:        "network_set_logging",
+		Capability:  CapNetwork,
 		Description: "Enable, disable, or query network activity logging without restarting Roderik.",
+		Independent: true,
 		Parameters: []Parameter{
 			{Name: "enabled", Type: ParamBoolean, Description: "optional flag; when provided sets logging state to the given value"},
 		},
 	},
 	{
-		Name: "to_markdown",
+		Name:        "network_ws_frames",
+		Capability:  CapNetwork,
+		Description: "List WebSocket and EventSource connections captured for the current page, or show the frames of one connection with JSON payloads decoded (Socket.IO packets included); optionally export the frames as NDJSON.",
+		Independent: true,
+		Parameters: []Parameter{
+			{Name: "conn", Type: ParamString, Description: "connection request ID from the listing; omit to list connections"},
+			{Name: "contains", Type: ParamString, Description: "optional comma-separated substrings every returned frame must contain"},
+			{Name: "limit", Type: ParamInteger, Description: "return at most this many of the newest matching frames (default 50)", Default: 50, Minimum: Bound(1)},
+			{Name: "decode_json", Type: ParamBoolean, Description: "decode JSON payloads (default true)", Default: true},
+			{Name: "export", Type: ParamBoolean, Description: "also write every matching frame to an NDJSON file"},
+			{Name: "save_dir", Type: ParamString, Description: "optional directory for the exported file"},
+		},
+	},
+	{
+		Name:        "network_watch",
+		Capability:  CapNetwork,
+		Description: "Watch network traffic matching a filter while other tools run. Each completed request is pushed as a notifications/message (logger roderik/netlog) and buffered for the returned netlog://watch/<id> resource, whose updates are announced with notifications/resources/updated.",
+		Independent: true,
+		MCPOnly:     true,
+		Parameters: append(append([]Parameter(nil), networkFilterParams...),
+			Parameter{Name: "notify", Type: ParamBoolean, Description: "push each entry as a notifications/message (default true); the resource is updated either way", Default: true}),
+	},
+	{
+		Name:        "network_unwatch",
+		Capability:  CapNetwork,
+		Description: "Stop a network watch created with network_watch.",
+		Independent: true,
+		MCPOnly:     true,
+		Parameters: []Parameter{
+			{Name: "watch_id", Type: ParamInteger, Description: "watch identifier returned by network_watch", Required: true},
+		},
+	},
+	{
+		Name:        "transcripts",
+		Capability:  CapNetwork,
+		Description: "Find caption/subtitle responses (VTT, SRT, timedtext JSON/XML) in the captured network log, convert them to transcripts with timings and save them to disk.",
+		Parameters: []Parameter{
+			{Name: "format", Type: ParamString, Description: "output format: markdown ([mm:ss] paragraphs, default), text or json (timed segments)", Enum: TranscriptFormats, Default: "markdown"},
+			{Name: "request_id", Type: ParamString, Description: "optional comma-separated request IDs (from network_list) to convert"},
+			{Name: "save", Type: ParamBoolean, Description: "write transcripts to disk (default true)", Default: true},
+			{Name: "save_dir", Type: ParamString, Description: "optional directory for saved transcripts"},
+			{Name: "filename_prefix", Type: ParamString, Description: "optional prefix prepended to generated filenames"},
+			{Name: "filename_suffix", Type: ParamString, Description: "optional suffix appended before the file extension"},
+			{Name: "filename_timestamp", Type: ParamBoolean, Description: "include a timestamp in the filename"},
+			{Name: "timestamp_format", Type: ParamString, Description: "Go time format used when filename_timestamp is true"},
+			{Name: "max_chars", Type: ParamInteger, Description: "truncate each returned transcript to this many characters (default 4000, 0 = no limit)", Default: 4000, Minimum: Bound(0)},
+		},
+	},
+	{
+		Name:       "to_markdown",
+		Capability: CapInspect,
 		Description: "Convert the current page/element (or an optional URL) into a structured Markdown document. " +
 			"This produces a well-formatted, token-efficient summary. " +
 			"Use this instead of \"get_html\" unless you specifically need raw HTML.",
@@ -191,67 +453,99 @@ var definitions = []Definition{
 	},
 	{
 		Name:        "search",
+		Capability:  CapInspect,
 		Description: "Search for elements matching a CSS selector, focus the first match, and return a numbered list for subsequent navigation commands.",
+		Gate:        GateNavigation,
 		Parameters: []Parameter{
 			{Name: "selector", Type: ParamString, Description: "CSS selector to query", Required: true},
 		},
 	},
 	{
 		Name:        "head",
+		Capability:  CapInspect,
 		Description: "List page headings (optionally by level), focus the first match, and return a numbered index.",
+		Gate:        GateNavigation,
 		Parameters: []Parameter{
 			{Name: "level", Type: ParamString, Description: "Heading level number (1-6)"},
 		},
 	},
 	{
 		Name:        "next",
+		Capability:  CapInspect,
 		Description: "Advance to the next element in the active search/head list or jump to a specific index.",
+		Gate:        GateNavigation,
 		Parameters: []Parameter{
 			{Name: "index", Type: ParamNumber, Description: "optional index to jump to"},
 		},
 	},
 	{
 		Name:        "prev",
+		Capability:  CapInspect,
 		Description: "Move to the previous element in the active search/head list or jump to a specific index.",
+		Gate:        GateNavigation,
 		Parameters: []Parameter{
 			{Name: "index", Type: ParamNumber, Description: "optional index to jump to"},
 		},
 	},
 	{
 		Name:        "elem",
+		Capability:  CapInspect,
 		Description: "Match elements by selector (scoped to the current element, falling back to the page), focus the best match, and return a numbered list.",
+		Gate:        GateNavigation,
 		Parameters: []Parameter{
 			{Name: "selector", Type: ParamString, Description: "CSS selector to resolve", Required: true},
 		},
 	},
 	{
 		Name:        "child",
+		Capability:  CapInspect,
 		Description: "Focus the first child element of the current selection.",
+		Gate:        GateNavigation,
 	},
 	{
 		Name:        "parent",
+		Capability:  CapInspect,
 		Description: "Focus the parent element of the current selection.",
+		Gate:        GateNavigation,
 	},
 	{
 		Name:        "html",
+		Capability:  CapInspect,
 		Description: "Return the outer HTML of the current element that prior navigation selected.",
+		Gate:        GateNavigation,
 		FocusAware:  true,
 	},
 	{
 		Name:        "click",
+		Capability:  CapInteract,
 		Description: "Click the currently focused element; falls back to href navigation or synthetic click on failure.",
+		Gate:        GateNavigation,
 	},
 	{
 		Name:        "type",
+		Capability:  CapInteract,
 		Description: "Type text into the currently focused element; trims optional quotes and falls back to JavaScript value injection.",
+		Gate:        GateNavigation,
 		Parameters: []Parameter{
 			{Name: "text", Type: ParamString, Description: "Text to type", Required: true},
 		},
 	},
 	{
-		Name:        "run_js",
-		Description: "Execute JavaScript on the current page and return the result as JSON.",
-		FocusAware:  true,
+		Name:       "run_js",
+		Capability: CapScript,
+		Description: `Execute JavaScript on the current page and return the result as JSON.
+Wrap your code in an IIFE that returns a JSON‐serializable value. Example:
+
+  (() => {
+    // Extract all anchor links
+    const links = Array.from(document.querySelectorAll('a')).map(a => ({
+      href: a.href,
+      text: a.textContent.trim(),
+    }));
+    return links;
+  })()
+`,
+		FocusAware: true,
 		Parameters: []Parameter{
 			{Name: "script", Type: ParamString, Description: "JavaScript code to execute in the page context", Required: true},
 			{Name: "showErrors", Type: ParamBoolean, Description: "if true, return any evaluation errors in the tool result text"},
@@ -266,6 +560,23 @@ func List() []Definition {
 	return out
 }
 
+// Available returns the definitions whose gate currently allows them.
+func Available() []Definition {
+	out := make([]Definition, 0, len(definitions))
+	for _, def := range definitions {
+		if def.Enabled() {
+			out = append(out, def)
+		}
+	}
+	return out
+}
+
+// IsIndependent reports whether the named tool can run outside the shared page lock.
+func IsIndependent(name string) bool {
+	def, ok := Lookup(name)
+	return ok && def.Independent
+}
+
 // Lookup finds a tool definition by name.
 func Lookup(name string) (Definition, bool) {
 	for _, def := range definitions {
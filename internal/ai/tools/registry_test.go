@@ -1,6 +1,9 @@
 package tools_test
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"roderik/internal/ai/tools"
@@ -58,6 +61,18 @@ func TestLookupHasDescriptions(t *testing.T) {
 	}
 }
 
+func TestEveryToolHasCapability(t *testing.T) {
+	known := make(map[string]bool)
+	for _, c := range tools.Capabilities {
+		known[c] = true
+	}
+	for _, def := range tools.List() {
+		if !known[def.Capability] {
+			t.Fatalf("tool %q has unknown capability %q", def.Name, def.Capability)
+		}
+	}
+}
+
 func TestParameterMetadata(t *testing.T) {
 	def, ok := tools.Lookup("load_url")
 	if !ok {
@@ -132,3 +147,54 @@ func TestParameterMetadata(t *testing.T) {
 		t.Fatalf("network_set_logging enabled parameter mismatch: %#v", lp)
 	}
 }
+
+func TestParameterSchema(t *testing.T) {
+	p := tools.Parameter{Name: "quality", Type: tools.ParamInteger, Default: 80, Minimum: tools.Bound(0), Maximum: tools.Bound(100)}
+	s := p.Schema()
+	if s["type"] != "integer" || s["default"] != 80 || s["minimum"] != 0.0 || s["maximum"] != 100.0 {
+		t.Fatalf("unexpected schema: %#v", s)
+	}
+	arr := tools.Parameter{Name: "status", Type: tools.ParamArray, Items: tools.ParamInteger}.Schema()
+	if items, _ := arr["items"].(map[string]interface{}); items["type"] != "integer" {
+		t.Fatalf("unexpected array schema: %#v", arr)
+	}
+	enum := tools.Parameter{Name: "format", Type: tools.ParamString, Enum: []string{"png", "jpeg"}}.Schema()
+	if _, ok := enum["enum"]; !ok {
+		t.Fatalf("enum missing: %#v", enum)
+	}
+}
+
+func TestGatesFilterAvailable(t *testing.T) {
+	open := true
+	tools.RegisterGate(tools.GateNavigation, func() bool { return open })
+	t.Cleanup(func() { tools.RegisterGate(tools.GateNavigation, nil) })
+	has := func(name string) bool {
+		for _, def := range tools.Available() {
+			if def.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("load_url") {
+		t.Fatal("load_url hidden while gate is open")
+	}
+	open = false
+	if has("load_url") || !has("to_markdown") {
+		t.Fatal("navigation gate did not hide only gated tools")
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	tools.ReportProgress(context.Background(), 1, 2, "ignored without a listener")
+
+	var got []string
+	ctx := tools.WithProgress(context.Background(), func(progress, total float64, message string) {
+		got = append(got, fmt.Sprintf("%g/%g %s", progress, total, message))
+	})
+	tools.ReportProgress(ctx, 1, 3, "navigating")
+	tools.ReportProgress(ctx, 2, 3, "waiting")
+	if strings.Join(got, ";") != "1/3 navigating;2/3 waiting" {
+		t.Fatalf("unexpected reports: %v", got)
+	}
+}
@@ -16,31 +16,22 @@ func SanitizeName(server, tool string) string {
 }
 
 // LLMTools returns sanitized tool definitions and a mapping back to originals.
+// MCP-only tools are left out.
 func LLMTools(server string) ([]llm.Tool, map[string]Definition) {
-    defs := List()
+    defs := Available()
     out := make([]llm.Tool, 0, len(defs))
     mapping := make(map[string]Definition, len(defs))
 
     for _, def := range defs {
+        if def.MCPOnly {
+            continue
+        }
         sanitized := SanitizeName(server, def.Name)
+        props, required := def.InputSchema()
         schema := llm.Schema{
             Type:       "object",
-            Properties: map[string]interface{}{},
-        }
-        for _, param := range def.Parameters {
-            prop := map[string]interface{}{
-                "type": param.Type.JSONType(),
-            }
-            if param.Description != "" {
-                prop["description"] = param.Description
-            }
-            if len(param.Enum) > 0 {
-                prop["enum"] = param.Enum
-            }
-            schema.Properties[param.Name] = prop
-            if param.Required {
-                schema.Required = append(schema.Required, param.Name)
-            }
+            Properties: props,
+            Required:   required,
         }
         out = append(out, llm.Tool{
             Name:        sanitized,
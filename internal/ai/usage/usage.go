@@ -0,0 +1,202 @@
+// Package usage tracks LLM token consumption and estimated cost, persisting
+// one JSON record per model call so spend can be reviewed across runs.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"roderik/internal/appdirs"
+)
+
+// Price is the cost of a model in currency units per million tokens.
+type Price struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// Cost returns the estimated cost of a call with the given token counts.
+func (p Price) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.InputPerMillion + float64(completionTokens)*p.OutputPerMillion) / 1_000_000
+}
+
+// Budget caps what a single chat session may consume. Zero values disable a cap.
+type Budget struct {
+	MaxTokens int64
+	MaxCost   float64
+}
+
+// Exceeded reports whether the totals reached a cap, with a human readable reason.
+func (b Budget) Exceeded(tokens int64, cost float64) (string, bool) {
+	if b.MaxTokens > 0 && tokens >= b.MaxTokens {
+		return fmt.Sprintf("session token budget of %d reached (%d used)", b.MaxTokens, tokens), true
+	}
+	if b.MaxCost > 0 && cost >= b.MaxCost {
+		return fmt.Sprintf("session cost budget of %s reached (%s spent)", FormatCost(b.MaxCost), FormatCost(cost)), true
+	}
+	return "", false
+}
+
+// Record is one persisted model call.
+type Record struct {
+	Time             time.Time `json:"time"`
+	SessionID        string    `json:"session_id"`
+	Profile          string    `json:"profile,omitempty"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	// Cost is the estimate at record time; zero when Priced is false.
+	Cost   float64 `json:"cost"`
+	Priced bool    `json:"priced"`
+}
+
+// DefaultPath returns the standard location of the usage log.
+func DefaultPath() string {
+	dir, err := appdirs.LogsDir()
+	if err != nil || strings.TrimSpace(dir) == "" {
+		return ""
+	}
+	return filepath.Join(dir, "ai-usage.jsonl")
+}
+
+// Append adds rec to the JSONL log at path, creating it when needed.
+func Append(path string, rec Record) error {
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("usage log path is empty")
+	}
+	if err := appdirs.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open usage log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write usage log: %w", err)
+	}
+	return nil
+}
+
+// Read loads the records at path recorded at or after since. A missing file
+// yields no records; malformed lines are skipped.
+func Read(path string, since time.Time) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open usage log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		if !since.IsZero() && rec.Time.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read usage log: %w", err)
+	}
+	return records, nil
+}
+
+// Summary aggregates records sharing the same group key.
+type Summary struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	Sessions         int     `json:"sessions"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	// Unpriced counts calls whose model had no price configured.
+	Unpriced int `json:"unpriced,omitempty"`
+}
+
+// GroupKeys lists the supported Summarize groupings.
+var GroupKeys = []string{"day", "profile", "model", "session"}
+
+// Summarize groups records by day, profile, model or session, sorted by key.
+func Summarize(records []Record, groupBy string) ([]Summary, error) {
+	keyFn, err := groupKeyFunc(groupBy)
+	if err != nil {
+		return nil, err
+	}
+	byKey := map[string]*Summary{}
+	sessions := map[string]map[string]struct{}{}
+	for _, rec := range records {
+		key := keyFn(rec)
+		sum, ok := byKey[key]
+		if !ok {
+			sum = &Summary{Key: key}
+			byKey[key] = sum
+			sessions[key] = map[string]struct{}{}
+		}
+		sum.Calls++
+		sum.PromptTokens += int64(rec.PromptTokens)
+		sum.CompletionTokens += int64(rec.CompletionTokens)
+		sum.Cost += rec.Cost
+		if !rec.Priced {
+			sum.Unpriced++
+		}
+		sessions[key][rec.SessionID] = struct{}{}
+	}
+
+	out := make([]Summary, 0, len(byKey))
+	for key, sum := range byKey {
+		sum.Sessions = len(sessions[key])
+		out = append(out, *sum)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func groupKeyFunc(groupBy string) (func(Record) string, error) {
+	orUnknown := func(s string) string {
+		if strings.TrimSpace(s) == "" {
+			return "(unknown)"
+		}
+		return s
+	}
+	switch strings.ToLower(strings.TrimSpace(groupBy)) {
+	case "", "day":
+		return func(r Record) string { return r.Time.Local().Format("2006-01-02") }, nil
+	case "profile":
+		return func(r Record) string { return orUnknown(r.Profile) }, nil
+	case "model":
+		return func(r Record) string { return orUnknown(r.Model) }, nil
+	case "session":
+		return func(r Record) string { return orUnknown(r.SessionID) }, nil
+	}
+	return nil, fmt.Errorf("unknown grouping %q (use one of %s)", groupBy, strings.Join(GroupKeys, ", "))
+}
+
+// FormatCost renders a cost estimate with enough precision for small calls.
+func FormatCost(cost float64) string {
+	if cost > 0 && cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
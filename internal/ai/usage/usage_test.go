@@ -0,0 +1,82 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPriceCost(t *testing.T) {
+	p := Price{InputPerMillion: 2, OutputPerMillion: 8}
+	if got := p.Cost(500_000, 250_000); got != 3 {
+		t.Fatalf("expected cost 3, got %v", got)
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	b := Budget{MaxTokens: 1000, MaxCost: 0.5}
+	if _, over := b.Exceeded(999, 0.49); over {
+		t.Fatalf("expected budget not to be exceeded")
+	}
+	if reason, over := b.Exceeded(1000, 0); !over || !strings.Contains(reason, "token budget") {
+		t.Fatalf("expected token budget hit, got %q", reason)
+	}
+	if reason, over := b.Exceeded(10, 0.5); !over || !strings.Contains(reason, "cost budget") {
+		t.Fatalf("expected cost budget hit, got %q", reason)
+	}
+	if _, over := (Budget{}).Exceeded(1<<40, 1e9); over {
+		t.Fatalf("zero budget should never trip")
+	}
+}
+
+func TestAppendReadAndSummarize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "ai-usage.jsonl")
+	day1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+	records := []Record{
+		{Time: day1, SessionID: "a", Profile: "fast", Model: "gpt-5-mini", PromptTokens: 100, CompletionTokens: 10, Cost: 0.01, Priced: true},
+		{Time: day1, SessionID: "a", Profile: "fast", Model: "gpt-5-mini", PromptTokens: 200, CompletionTokens: 20, Cost: 0.02, Priced: true},
+		{Time: day2, SessionID: "b", Profile: "local", Model: "llama", PromptTokens: 50, CompletionTokens: 5},
+	}
+	for _, rec := range records {
+		if err := Append(path, rec); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	all, err := Read(path, time.Time{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Read() = %d records, err %v", len(all), err)
+	}
+	recent, err := Read(path, day2)
+	if err != nil || len(recent) != 1 {
+		t.Fatalf("Read(since) = %d records, err %v", len(recent), err)
+	}
+
+	byDay, err := Summarize(all, "day")
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if len(byDay) != 2 || byDay[0].Calls != 2 || byDay[0].Sessions != 1 || byDay[0].PromptTokens != 300 {
+		t.Fatalf("unexpected day summary %#v", byDay)
+	}
+	if byDay[1].Unpriced != 1 {
+		t.Fatalf("expected unpriced call to be counted, got %#v", byDay[1])
+	}
+	if _, err := Summarize(all, "weekday"); err == nil {
+		t.Fatalf("expected unknown grouping to fail")
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.jsonl")
+	records, err := Read(path, time.Time{})
+	if err != nil || len(records) != 0 {
+		t.Fatalf("expected no records and no error, got %d, %v", len(records), err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Read must not create the file")
+	}
+}
@@ -0,0 +1,116 @@
+// Package vision prepares images (page screenshots) for multimodal models.
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // register PNG decoder for screenshots
+	"strings"
+
+	"roderik/internal/ai/llm"
+)
+
+// DefaultMaxDimension is the longest side, in pixels, used when a profile
+// enables vision without setting vision_max_dimension.
+const DefaultMaxDimension = 1024
+
+const jpegQuality = 85
+
+// IsImage reports whether a MIME type is a raster image the models accept.
+func IsImage(mimeType string) bool {
+	switch strings.ToLower(strings.TrimSpace(mimeType)) {
+	case "image/png", "image/jpeg", "image/jpg", "image/webp", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Downscale shrinks img so its longest side is at most maxDim pixels,
+// re-encoding it as JPEG. Images already within bounds, or in formats the
+// standard library cannot decode, are returned unchanged.
+func Downscale(img llm.Image, maxDim int) (llm.Image, error) {
+	if maxDim <= 0 {
+		maxDim = DefaultMaxDimension
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img.Data))
+	if err != nil {
+		if err == image.ErrFormat {
+			return img, nil
+		}
+		return img, fmt.Errorf("decode image header: %w", err)
+	}
+	if cfg.Width <= maxDim && cfg.Height <= maxDim {
+		return img, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return img, fmt.Errorf("decode image: %w", err)
+	}
+	width, height := scaledSize(cfg.Width, cfg.Height, maxDim)
+	dst := resize(src, width, height)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return img, fmt.Errorf("encode image: %w", err)
+	}
+	return llm.Image{MIMEType: "image/jpeg", Data: buf.Bytes()}, nil
+}
+
+func scaledSize(width, height, maxDim int) (int, int) {
+	if width >= height {
+		h := height * maxDim / width
+		if h < 1 {
+			h = 1
+		}
+		return maxDim, h
+	}
+	w := width * maxDim / height
+	if w < 1 {
+		w = 1
+	}
+	return w, maxDim
+}
+
+// resize downsamples src with a box filter: every destination pixel averages
+// the source pixels it covers, which keeps small text legible.
+func resize(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := bounds.Min.Y + (y+1)*srcH/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := bounds.Min.X + (x+1)*srcW/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,67 @@
+package vision
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"roderik/internal/ai/llm"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownscaleShrinksLongestSide(t *testing.T) {
+	src := llm.Image{MIMEType: "image/png", Data: encodePNG(t, 1600, 800)}
+
+	out, err := Downscale(src, 400)
+	if err != nil {
+		t.Fatalf("Downscale() error = %v", err)
+	}
+	if out.MIMEType != "image/jpeg" {
+		t.Fatalf("expected jpeg output, got %s", out.MIMEType)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(out.Data))
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if cfg.Width != 400 || cfg.Height != 200 {
+		t.Fatalf("expected 400x200, got %dx%d", cfg.Width, cfg.Height)
+	}
+}
+
+func TestDownscaleKeepsSmallImages(t *testing.T) {
+	data := encodePNG(t, 300, 500)
+	out, err := Downscale(llm.Image{MIMEType: "image/png", Data: data}, 1024)
+	if err != nil {
+		t.Fatalf("Downscale() error = %v", err)
+	}
+	if out.MIMEType != "image/png" || !bytes.Equal(out.Data, data) {
+		t.Fatalf("expected image within bounds to be returned unchanged")
+	}
+}
+
+func TestDownscalePassesThroughUnknownFormats(t *testing.T) {
+	src := llm.Image{MIMEType: "image/webp", Data: []byte("RIFF....WEBP")}
+	out, err := Downscale(src, 100)
+	if err != nil {
+		t.Fatalf("Downscale() error = %v", err)
+	}
+	if !bytes.Equal(out.Data, src.Data) {
+		t.Fatalf("expected undecodable image to pass through")
+	}
+}
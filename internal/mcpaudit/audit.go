@@ -0,0 +1,351 @@
+// Package mcpaudit records MCP server sessions as structured JSONL audit
+// logs, one file per client session, so agent runs can be reviewed and their
+// tool calls replayed later.
+package mcpaudit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"roderik/internal/appdirs"
+)
+
+// Record kinds.
+const (
+	// KindSession describes the client, written once it has initialized.
+	KindSession = "session"
+	// KindCall is one tool call.
+	KindCall = "call"
+	// KindEnd marks the end of the session.
+	KindEnd = "end"
+)
+
+// ext is the extension of audit logs.
+const ext = ".jsonl"
+
+// Client identifies the MCP client of a session.
+type Client struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Record is one line of an audit log. Which fields are set depends on Kind.
+type Record struct {
+	Kind    string    `json:"kind"`
+	Time    time.Time `json:"time"`
+	Session string    `json:"session,omitempty"`
+
+	// Session records.
+	Transport string  `json:"transport,omitempty"`
+	Client    *Client `json:"client,omitempty"`
+	Protocol  string  `json:"protocol,omitempty"`
+
+	// Call records. Seq orders calls by when they started, which is not the
+	// order of the lines when calls overlap.
+	Seq         int                    `json:"seq,omitempty"`
+	Tool        string                 `json:"tool,omitempty"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	DurationMS  int64                  `json:"duration_ms,omitempty"`
+	ResultBytes int                    `json:"result_bytes,omitempty"`
+	IsError     bool                   `json:"is_error,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	URLBefore   string                 `json:"url_before,omitempty"`
+	URLAfter    string                 `json:"url_after,omitempty"`
+	// Thumbnail is the path of a screenshot taken after the call, relative
+	// to the audit directory.
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+// DefaultDir returns the standard audit log directory.
+func DefaultDir() string {
+	dir, err := appdirs.LogsDir()
+	if err != nil || strings.TrimSpace(dir) == "" {
+		return ""
+	}
+	return filepath.Join(dir, "mcp-audit")
+}
+
+// Log is the open audit log of one session. It is safe for concurrent use.
+type Log struct {
+	mu      sync.Mutex
+	f       *os.File
+	dir     string
+	name    string
+	session string
+	seq     int
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// maxSessionInName caps how much of the session ID goes into a file name.
+const maxSessionInName = 16
+
+// Create opens a new audit log for session in dir, named after the start
+// time and the session ID.
+func Create(dir, session string, start time.Time) (*Log, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("audit directory is empty")
+	}
+	// Logs hold tool arguments and page images, so only the owner may read them.
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create audit directory: %w", err)
+	}
+	id := strings.Trim(unsafeNameChars.ReplaceAllString(session, "-"), "-")
+	if len(id) > maxSessionInName {
+		id = id[:maxSessionInName]
+	}
+	if id == "" {
+		id = "session"
+	}
+	base := start.Local().Format("20060102-150405") + "-" + id
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name+ext), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create audit log: %w", err)
+		}
+		return &Log{f: f, dir: dir, name: name, session: session}, nil
+	}
+}
+
+// Name is the name the log is listed and looked up by.
+func (l *Log) Name() string { return l.name }
+
+// Path is the file the log is written to.
+func (l *Log) Path() string { return filepath.Join(l.dir, l.name+ext) }
+
+// NextSeq reserves the sequence number of a call that is starting.
+func (l *Log) NextSeq() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	return l.seq
+}
+
+// Append writes rec, stamping the time and session when unset.
+func (l *Log) Append(rec Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if rec.Session == "" {
+		rec.Session = l.session
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return fmt.Errorf("audit log %s is closed", l.name)
+	}
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// SaveThumbnail stores the JPEG screenshot of call seq next to the log and
+// returns its path relative to the audit directory.
+func (l *Log) SaveThumbnail(seq int, jpeg []byte) (string, error) {
+	rel := filepath.Join(l.name, fmt.Sprintf("%04d.jpg", seq))
+	if err := os.MkdirAll(filepath.Join(l.dir, l.name), 0o700); err != nil {
+		return "", fmt.Errorf("create thumbnail directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(l.dir, rel), jpeg, 0o600); err != nil {
+		return "", fmt.Errorf("write thumbnail: %w", err)
+	}
+	return rel, nil
+}
+
+// Close ends the log with an end record.
+func (l *Log) Close() error {
+	err := l.Append(Record{Kind: KindEnd})
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// Load reads the records of the log at path. Malformed lines, such as a line
+// cut short by a crash, are skipped.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return records, nil
+}
+
+// Calls returns the call records in the order the calls started.
+func Calls(records []Record) []Record {
+	var calls []Record
+	for _, rec := range records {
+		if rec.Kind == KindCall {
+			calls = append(calls, rec)
+		}
+	}
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].Seq < calls[j].Seq })
+	return calls
+}
+
+// Summary describes one audit log.
+type Summary struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Session   string    `json:"session"`
+	Transport string    `json:"transport,omitempty"`
+	Client    Client    `json:"client"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Calls     int       `json:"calls"`
+	Errors    int       `json:"errors"`
+	// Ended is false while the session runs or when the server died.
+	Ended bool `json:"ended"`
+}
+
+// Summarize condenses the records of the log at path.
+func Summarize(path string, records []Record) Summary {
+	sum := Summary{Name: strings.TrimSuffix(filepath.Base(path), ext), Path: path}
+	for _, rec := range records {
+		if sum.Start.IsZero() || rec.Time.Before(sum.Start) {
+			sum.Start = rec.Time
+		}
+		if rec.Time.After(sum.End) {
+			sum.End = rec.Time
+		}
+		if sum.Session == "" {
+			sum.Session = rec.Session
+		}
+		switch rec.Kind {
+		case KindSession:
+			sum.Transport = rec.Transport
+			if rec.Client != nil {
+				sum.Client = *rec.Client
+			}
+		case KindCall:
+			sum.Calls++
+			if rec.IsError {
+				sum.Errors++
+			}
+		case KindEnd:
+			sum.Ended = true
+		}
+	}
+	return sum
+}
+
+// List summarizes the logs in dir, newest first. A missing directory holds
+// no logs.
+func List(dir string) ([]Summary, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list audit logs: %w", err)
+	}
+	var out []Summary
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ext) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		records, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Summarize(path, records))
+	}
+	// Names start with the start time, so they sort chronologically.
+	sort.Slice(out, func(i, j int) bool { return out[i].Name > out[j].Name })
+	return out, nil
+}
+
+// Resolve finds the log ref names in dir: "last" for the newest, a full
+// name or a prefix matching exactly one log. A path to an existing file is
+// returned as is.
+func Resolve(dir, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("no audit session given")
+	}
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return ref, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("list audit logs: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ext) {
+			names = append(names, strings.TrimSuffix(entry.Name(), ext))
+		}
+	}
+	sort.Strings(names)
+	if ref == "last" {
+		if len(names) == 0 {
+			return "", fmt.Errorf("no audit logs in %s", dir)
+		}
+		return filepath.Join(dir, names[len(names)-1]+ext), nil
+	}
+	ref = strings.TrimSuffix(ref, ext)
+	var matches []string
+	for _, name := range names {
+		if name == ref {
+			return filepath.Join(dir, name+ext), nil
+		}
+		if strings.HasPrefix(name, ref) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no audit log %q in %s", ref, dir)
+	case 1:
+		return filepath.Join(dir, matches[0]+ext), nil
+	default:
+		return "", fmt.Errorf("audit log %q is ambiguous: %s", ref, strings.Join(matches, ", "))
+	}
+}
@@ -0,0 +1,95 @@
+package mcpaudit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogWriteLoadAndSummarize(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	l, err := Create(dir, "3f1c9a77-0e3b-4bd2-a8a0-6a7a2b1d9e01", start)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(l.Name(), "20260301-120000-3f1c9a77-0e3b-4b") {
+		t.Fatalf("Name() = %q", l.Name())
+	}
+	if err := l.Append(Record{Kind: KindSession, Transport: "http", Client: &Client{Name: "agent", Version: "2.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	first, second := l.NextSeq(), l.NextSeq()
+	// The second call finishes first.
+	if err := l.Append(Record{Kind: KindCall, Seq: second, Tool: "get_html", IsError: true, Error: "no page"}); err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := l.SaveThumbnail(first, []byte("jpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Append(Record{Kind: KindCall, Seq: first, Tool: "load_url", Arguments: map[string]interface{}{"url": "https://example.com"}, URLAfter: "https://example.com/", Thumbnail: thumb}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	for _, path := range []string{l.Path(), filepath.Join(dir, thumb)} {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+			t.Fatalf("%s not saved private: %v", path, err)
+		}
+	}
+
+	records, err := Load(l.Path())
+	if err != nil || len(records) != 4 {
+		t.Fatalf("Load() = %d records, err %v", len(records), err)
+	}
+	calls := Calls(records)
+	if len(calls) != 2 || calls[0].Tool != "load_url" || calls[0].Arguments["url"] != "https://example.com" {
+		t.Fatalf("Calls() = %+v", calls)
+	}
+	sum := Summarize(l.Path(), records)
+	if sum.Client.Name != "agent" || sum.Calls != 2 || sum.Errors != 1 || !sum.Ended || sum.Session == "" {
+		t.Fatalf("Summarize() = %+v", sum)
+	}
+}
+
+func TestListAndResolve(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	var names []string
+	for i, session := range []string{"stdio", "stdio", "abc"} {
+		l, err := Create(dir, session, start.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, l.Name())
+		l.Close()
+	}
+
+	list, err := List(dir)
+	if err != nil || len(list) != 3 {
+		t.Fatalf("List() = %+v, err %v", list, err)
+	}
+	if list[0].Name != names[2] {
+		t.Fatalf("List() not newest first: %+v", list)
+	}
+
+	if path, err := Resolve(dir, "last"); err != nil || filepath.Base(path) != names[2]+".jsonl" {
+		t.Fatalf("Resolve(last) = %q, %v", path, err)
+	}
+	if path, err := Resolve(dir, names[0]); err != nil || filepath.Base(path) != names[0]+".jsonl" {
+		t.Fatalf("Resolve(name) = %q, %v", path, err)
+	}
+	if path, err := Resolve(dir, "20260301-14"); err != nil || filepath.Base(path) != names[2]+".jsonl" {
+		t.Fatalf("Resolve(prefix) = %q, %v", path, err)
+	}
+	if _, err := Resolve(dir, "20260301"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("Resolve(ambiguous) error = %v", err)
+	}
+	if _, err := Resolve(dir, "nope"); err == nil {
+		t.Fatal("Resolve(missing) succeeded")
+	}
+}
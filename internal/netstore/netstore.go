@@ -0,0 +1,628 @@
+// Package netstore persists captured network activity across runs. Entries
+// are appended as JSON lines to per-session segment files and response bodies
+// are stored once as content-addressed blobs:
+//
+//	<dir>/segments/<session>-<seq>.jsonl
+//	<dir>/blobs/<sha256[:2]>/<sha256>
+//
+// Nothing is rewritten in place; retention removes whole segments (oldest
+// first) and then any blob no remaining segment refers to. Files are private
+// to the user, since entries carry URLs and headers of the browsing session.
+package netstore
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"roderik/internal/appdirs"
+)
+
+// Record kinds. A body record only carries the blob reference for an entry
+// written earlier in the same session and navigation.
+const (
+	KindEntry = "entry"
+	KindBody  = "body"
+)
+
+// Retention and rotation defaults used when Options leaves a field zero.
+const (
+	DefaultMaxAge       = 7 * 24 * time.Hour
+	DefaultMaxBytes     = 256 << 20
+	DefaultSegmentBytes = 4 << 20
+)
+
+// orphanGrace protects blobs that another process may have written but not
+// yet referenced.
+const orphanGrace = time.Minute
+
+// Record is one persisted network entry (or body reference).
+type Record struct {
+	Kind              string            `json:"kind"`
+	Session           string            `json:"session"`
+	Navigation        int               `json:"navigation"`
+	RequestID         string            `json:"request_id"`
+	Time              time.Time         `json:"time"`
+	URL               string            `json:"url,omitempty"`
+	Method            string            `json:"method,omitempty"`
+	ResourceType      string            `json:"resource_type,omitempty"`
+	DocumentURL       string            `json:"document_url,omitempty"`
+	FrameID           string            `json:"frame_id,omitempty"`
+	LoaderID          string            `json:"loader_id,omitempty"`
+	InitiatorType     string            `json:"initiator_type,omitempty"`
+	RequestHeaders    map[string]string `json:"request_headers,omitempty"`
+	HasPostData       bool              `json:"has_post_data,omitempty"`
+	PostData          []byte            `json:"post_data,omitempty"`
+	GraphQLOperation  string            `json:"graphql_operation,omitempty"`
+	Status            int               `json:"status,omitempty"`
+	StatusText        string            `json:"status_text,omitempty"`
+	MIMEType          string            `json:"mime_type,omitempty"`
+	ResponseHeaders   map[string]string `json:"response_headers,omitempty"`
+	EncodedDataLength float64           `json:"encoded_data_length,omitempty"`
+	FromCache         bool              `json:"from_cache,omitempty"`
+	Failure           string            `json:"failure,omitempty"`
+	Canceled          bool              `json:"canceled,omitempty"`
+	Done              time.Time         `json:"done"`
+	Body              string            `json:"body,omitempty"`
+	BodySize          int               `json:"body_size,omitempty"`
+}
+
+// Options controls retention and segment rotation. Negative MaxAge or
+// MaxBytes disable that limit.
+type Options struct {
+	MaxAge       time.Duration
+	MaxBytes     int64
+	SegmentBytes int64
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAge == 0 {
+		o.MaxAge = DefaultMaxAge
+	}
+	if o.MaxBytes == 0 {
+		o.MaxBytes = DefaultMaxBytes
+	}
+	if o.SegmentBytes <= 0 {
+		o.SegmentBytes = DefaultSegmentBytes
+	}
+	return o
+}
+
+// DefaultDir returns <base>/netlog, or "" when the base directory cannot be
+// determined.
+func DefaultDir() string {
+	base, err := appdirs.BaseDir()
+	if err != nil || strings.TrimSpace(base) == "" {
+		return ""
+	}
+	return filepath.Join(base, "netlog")
+}
+
+// Store appends records for one session. It is safe for concurrent use.
+type Store struct {
+	dir     string
+	opts    Options
+	session string
+
+	mu         sync.Mutex
+	seg        *os.File
+	segSeq     int
+	segSize    int64
+	navigation int
+
+	// pruning is set while a background retention pass runs.
+	pruning atomic.Bool
+	pruneWG sync.WaitGroup
+}
+
+// Open prepares dir, starts a new session and applies retention in the
+// background.
+func Open(dir string, opts Options) (*Store, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("netstore: empty directory")
+	}
+	for _, sub := range []string{segmentsDir(dir), blobsDir(dir)} {
+		if err := os.MkdirAll(sub, 0o700); err != nil {
+			return nil, fmt.Errorf("netstore: %w", err)
+		}
+	}
+	s := &Store{dir: dir, opts: opts.withDefaults(), session: newSessionID(time.Now())}
+	s.pruneAsync()
+	return s, nil
+}
+
+// pruneAsync enforces retention without holding up appends: a pass scans
+// every segment, so it runs in the background, and a rotation while one is
+// running skips its own.
+func (s *Store) pruneAsync() {
+	if !s.pruning.CompareAndSwap(false, true) {
+		return
+	}
+	s.pruneWG.Add(1)
+	go func() {
+		defer s.pruneWG.Done()
+		defer s.pruning.Store(false)
+		_, _ = Prune(s.dir, s.opts, time.Now(), s.session)
+	}()
+}
+
+// Dir returns the store directory.
+func (s *Store) Dir() string { return s.dir }
+
+// Session returns the ID records written by this store carry.
+func (s *Store) Session() string { return s.session }
+
+// NextNavigation starts a new navigation and returns its ID (1-based).
+func (s *Store) NextNavigation() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.navigation++
+	return s.navigation
+}
+
+// Append writes rec to the current segment, filling in the session and kind.
+func (s *Store) Append(rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLocked(rec)
+}
+
+// AppendBody stores data as a blob and appends a body record referencing it
+// for rec's request. It returns the blob name for ReadBlob.
+func (s *Store) AppendBody(rec Record, data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, err := s.putBlobLocked(data)
+	if err != nil {
+		return "", err
+	}
+	return name, s.appendLocked(Record{
+		Kind:       KindBody,
+		Navigation: rec.Navigation,
+		RequestID:  rec.RequestID,
+		Time:       time.Now(),
+		Body:       name,
+		BodySize:   len(data),
+	})
+}
+
+// Close waits for a running retention pass and closes the current segment.
+func (s *Store) Close() error {
+	s.pruneWG.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seg == nil {
+		return nil
+	}
+	err := s.seg.Close()
+	s.seg = nil
+	return err
+}
+
+func (s *Store) appendLocked(rec Record) error {
+	rec.Session = s.session
+	if rec.Kind == "" {
+		rec.Kind = KindEntry
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("netstore: encode record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if s.seg != nil && s.segSize+int64(len(line)) > s.opts.SegmentBytes {
+		_ = s.seg.Close()
+		s.seg = nil
+		s.pruneAsync()
+	}
+	if s.seg == nil {
+		s.segSeq++
+		path := filepath.Join(segmentsDir(s.dir), fmt.Sprintf("%s-%04d.jsonl", s.session, s.segSeq))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("netstore: open segment: %w", err)
+		}
+		s.seg, s.segSize = f, 0
+	}
+	n, err := s.seg.Write(line)
+	s.segSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("netstore: write segment: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) putBlobLocked(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])
+	path := blobPath(s.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+		return name, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("netstore: %w", err)
+	}
+	// CreateTemp makes the blob 0600.
+	tmp, err := os.CreateTemp(filepath.Dir(path), name+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("netstore: write blob: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("netstore: write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("netstore: write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("netstore: write blob: %w", err)
+	}
+	return name, nil
+}
+
+// ReadBlob returns the body stored under name in dir.
+func ReadBlob(dir, name string) ([]byte, error) {
+	if !isBlobName(name) {
+		return nil, fmt.Errorf("netstore: invalid blob name %q", name)
+	}
+	data, err := os.ReadFile(blobPath(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("netstore: read blob: %w", err)
+	}
+	return data, nil
+}
+
+// Query selects records for Load. Zero fields match everything.
+type Query struct {
+	Session string
+	Since   time.Time
+}
+
+// Load reads the records matching q in write order, with body records folded
+// into the entry they belong to.
+func Load(dir string, q Query) ([]Record, error) {
+	segs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		session    string
+		navigation int
+		requestID  string
+	}
+	var out []Record
+	index := make(map[key]int)
+	for _, seg := range segs {
+		if q.Session != "" && seg.session != q.Session {
+			continue
+		}
+		if !q.Since.IsZero() && seg.modTime.Before(q.Since) {
+			continue
+		}
+		err := scanSegment(seg.path, func(rec Record) {
+			k := key{rec.Session, rec.Navigation, rec.RequestID}
+			if rec.Kind == KindBody {
+				if i, ok := index[k]; ok {
+					out[i].Body, out[i].BodySize = rec.Body, rec.BodySize
+				}
+				return
+			}
+			if !q.Since.IsZero() && rec.Time.Before(q.Since) {
+				return
+			}
+			if i, ok := index[k]; ok {
+				body, size := out[i].Body, out[i].BodySize
+				out[i] = rec
+				if rec.Body == "" {
+					out[i].Body, out[i].BodySize = body, size
+				}
+				return
+			}
+			index[k] = len(out)
+			out = append(out, rec)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// SessionInfo summarises the segments written by one session.
+type SessionInfo struct {
+	ID       string
+	Started  time.Time
+	Updated  time.Time
+	Segments int
+	Bytes    int64
+}
+
+// Sessions lists the sessions present in dir, oldest first.
+func Sessions(dir string) ([]SessionInfo, error) {
+	segs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*SessionInfo)
+	var out []*SessionInfo
+	for _, seg := range segs {
+		info, ok := byID[seg.session]
+		if !ok {
+			info = &SessionInfo{ID: seg.session, Started: sessionStart(seg.session)}
+			byID[seg.session] = info
+			out = append(out, info)
+		}
+		info.Segments++
+		info.Bytes += seg.size
+		if seg.modTime.After(info.Updated) {
+			info.Updated = seg.modTime
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	res := make([]SessionInfo, len(out))
+	for i, info := range out {
+		res[i] = *info
+	}
+	return res, nil
+}
+
+// Prune enforces opts on dir: segments last written before now-MaxAge are
+// removed, then the oldest segments until segments plus blobs fit MaxBytes,
+// then blobs no remaining segment references. The newest segment of
+// keepSession is never removed. It returns the number of segments removed.
+func Prune(dir string, opts Options, now time.Time, keepSession string) (int, error) {
+	opts = opts.withDefaults()
+	segs, err := listSegments(dir)
+	if err != nil {
+		return 0, err
+	}
+	keep := ""
+	for _, seg := range segs {
+		if keepSession != "" && seg.session == keepSession {
+			keep = seg.path
+		}
+	}
+
+	refs := make(map[string]int)
+	segRefs := make(map[string][]string, len(segs))
+	var total int64
+	for _, seg := range segs {
+		total += seg.size
+		var names []string
+		err := scanSegment(seg.path, func(rec Record) {
+			if rec.Body != "" {
+				names = append(names, rec.Body)
+				refs[rec.Body]++
+			}
+		})
+		if err != nil {
+			return 0, err
+		}
+		segRefs[seg.path] = names
+	}
+	blobs, err := listBlobs(dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range blobs {
+		total += b.size
+	}
+	blobSize := make(map[string]int64, len(blobs))
+	for _, b := range blobs {
+		blobSize[b.name] = b.size
+	}
+
+	removed := 0
+	remove := func(seg segment) error {
+		if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("netstore: prune: %w", err)
+		}
+		removed++
+		total -= seg.size
+		for _, name := range segRefs[seg.path] {
+			refs[name]--
+			if refs[name] == 0 {
+				total -= blobSize[name]
+			}
+		}
+		return nil
+	}
+
+	// oldest first by modification time
+	sort.SliceStable(segs, func(i, j int) bool { return segs[i].modTime.Before(segs[j].modTime) })
+	var live []segment
+	for _, seg := range segs {
+		if seg.path != keep && opts.MaxAge > 0 && now.Sub(seg.modTime) > opts.MaxAge {
+			if err := remove(seg); err != nil {
+				return removed, err
+			}
+			continue
+		}
+		live = append(live, seg)
+	}
+	for _, seg := range live {
+		if opts.MaxBytes < 0 || total <= opts.MaxBytes {
+			break
+		}
+		if seg.path == keep {
+			continue
+		}
+		if err := remove(seg); err != nil {
+			return removed, err
+		}
+	}
+
+	for _, b := range blobs {
+		if refs[b.name] > 0 || now.Sub(b.modTime) < orphanGrace {
+			continue
+		}
+		if err := os.Remove(b.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("netstore: prune blob: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+type segment struct {
+	path    string
+	session string
+	seq     int
+	size    int64
+	modTime time.Time
+}
+
+// listSegments returns the segments in dir ordered by session, then sequence.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(segmentsDir(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("netstore: list segments: %w", err)
+	}
+	var segs []segment
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		stem := strings.TrimSuffix(name, ".jsonl")
+		cut := strings.LastIndexByte(stem, '-')
+		if cut <= 0 {
+			continue
+		}
+		seq, err := strconv.Atoi(stem[cut+1:])
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		segs = append(segs, segment{
+			path:    filepath.Join(segmentsDir(dir), name),
+			session: stem[:cut],
+			seq:     seq,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.Slice(segs, func(i, j int) bool {
+		if segs[i].session != segs[j].session {
+			return segs[i].session < segs[j].session
+		}
+		return segs[i].seq < segs[j].seq
+	})
+	return segs, nil
+}
+
+// scanSegment calls fn for every decodable record; a torn final line from an
+// interrupted write is skipped.
+func scanSegment(path string, fn func(Record)) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("netstore: read segment: %w", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for sc.Scan() {
+		var rec Record
+		if json.Unmarshal(sc.Bytes(), &rec) != nil {
+			continue
+		}
+		fn(rec)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("netstore: read segment %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+type blob struct {
+	name    string
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func listBlobs(dir string) ([]blob, error) {
+	var out []blob
+	err := filepath.WalkDir(blobsDir(dir), func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !isBlobName(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		out = append(out, blob{name: d.Name(), path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("netstore: list blobs: %w", err)
+	}
+	return out, nil
+}
+
+func segmentsDir(dir string) string { return filepath.Join(dir, "segments") }
+func blobsDir(dir string) string    { return filepath.Join(dir, "blobs") }
+
+func blobPath(dir, name string) string {
+	return filepath.Join(blobsDir(dir), name[:2], name)
+}
+
+func isBlobName(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+const sessionTimeLayout = "20060102T150405"
+
+// newSessionID returns a sortable ID: start time plus a random suffix so
+// concurrent processes never share segments.
+func newSessionID(now time.Time) string {
+	var b [3]byte
+	_, _ = rand.Read(b[:])
+	return now.UTC().Format(sessionTimeLayout) + "-" + hex.EncodeToString(b[:])
+}
+
+func sessionStart(id string) time.Time {
+	if len(id) < len(sessionTimeLayout) {
+		return time.Time{}
+	}
+	t, err := time.Parse(sessionTimeLayout, id[:len(sessionTimeLayout)])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
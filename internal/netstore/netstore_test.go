@@ -0,0 +1,125 @@
+package netstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendLoadAndBodies(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	nav := s.NextNavigation()
+	start := time.Now().Add(-time.Hour)
+	if err := s.Append(Record{Navigation: nav, RequestID: "1", Time: start, URL: "https://example.com/", Status: 200, MIMEType: "text/html"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if _, err := s.AppendBody(Record{Navigation: nav, RequestID: "1"}, []byte("<html></html>")); err != nil {
+		t.Fatalf("AppendBody() error = %v", err)
+	}
+	// same request ID in the next navigation is a different entry
+	nav2 := s.NextNavigation()
+	if err := s.Append(Record{Navigation: nav2, RequestID: "1", Time: start.Add(time.Second), URL: "https://example.com/next"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	recs, err := Load(dir, Query{Session: s.Session()})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(recs) != 2 || recs[0].Navigation != 1 || recs[1].Navigation != 2 {
+		t.Fatalf("unexpected records %#v", recs)
+	}
+	if recs[0].Body == "" || recs[0].BodySize != 13 || recs[1].Body != "" {
+		t.Fatalf("body not folded into its entry: %#v", recs)
+	}
+	data, err := ReadBlob(dir, recs[0].Body)
+	if err != nil || string(data) != "<html></html>" {
+		t.Fatalf("ReadBlob() = %q, %v", data, err)
+	}
+	segs, _ := listSegments(dir)
+	blobs, _ := listBlobs(dir)
+	for _, path := range []string{segs[0].path, blobs[0].path} {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+			t.Fatalf("%s is not private: %v %v", filepath.Base(path), info.Mode(), err)
+		}
+	}
+
+	recs, err = Load(dir, Query{Since: start.Add(500 * time.Millisecond)})
+	if err != nil || len(recs) != 1 || recs[0].URL != "https://example.com/next" {
+		t.Fatalf("Load(since) = %#v, %v", recs, err)
+	}
+	if recs, _ := Load(dir, Query{Session: "nope"}); len(recs) != 0 {
+		t.Fatalf("expected no records for unknown session, got %d", len(recs))
+	}
+
+	sessions, err := Sessions(dir)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != s.Session() || sessions[0].Segments != 1 {
+		t.Fatalf("Sessions() = %#v, %v", sessions, err)
+	}
+	if sessions[0].Started.IsZero() {
+		t.Fatalf("expected session start parsed from ID %q", s.Session())
+	}
+}
+
+func TestRotationAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 600, MaxBytes: -1, MaxAge: -1})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		rec := Record{Navigation: 1, RequestID: string(rune('a' + i)), Time: time.Now(), URL: "https://example.com/" + strings.Repeat("x", 80)}
+		if err := s.Append(rec); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if _, err := s.AppendBody(rec, []byte(strings.Repeat(string(rune('a'+i)), 100))); err != nil {
+			t.Fatalf("AppendBody() error = %v", err)
+		}
+	}
+	s.Close()
+	segs, _ := listSegments(dir)
+	if len(segs) < 3 {
+		t.Fatalf("expected segment rotation, got %d segments", len(segs))
+	}
+
+	// age out the first segment and make every blob older than the grace period
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(segs[0].path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	blobs, _ := listBlobs(dir)
+	for _, b := range blobs {
+		_ = os.Chtimes(b.path, old, old)
+	}
+	removed, err := Prune(dir, Options{MaxAge: 24 * time.Hour, MaxBytes: -1}, time.Now(), "")
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune(age) removed=%d err=%v", removed, err)
+	}
+	recs, _ := Load(dir, Query{})
+	if len(recs) >= 6 {
+		t.Fatalf("expected aged-out records to be gone, got %d", len(recs))
+	}
+	remaining, _ := listBlobs(dir)
+	if len(remaining) >= len(blobs) {
+		t.Fatalf("expected unreferenced blobs to be collected: before=%d after=%d", len(blobs), len(remaining))
+	}
+
+	// a tiny size cap keeps only the protected newest segment of the session
+	segs, _ = listSegments(dir)
+	last := segs[len(segs)-1]
+	if _, err := Prune(dir, Options{MaxAge: -1, MaxBytes: 1}, time.Now(), s.Session()); err != nil {
+		t.Fatalf("Prune(size) error = %v", err)
+	}
+	segs, _ = listSegments(dir)
+	if len(segs) != 1 || segs[0].path != last.path {
+		t.Fatalf("expected only %s to survive, got %#v", filepath.Base(last.path), segs)
+	}
+}
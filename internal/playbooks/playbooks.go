@@ -0,0 +1,346 @@
+// Package playbooks loads multi-step browsing recipes that the MCP server
+// publishes as prompts and the CLI lists with "roderik playbooks".
+//
+// A playbook is a Markdown file whose name (without .md) is the playbook
+// name. An optional front matter block declares its description and typed
+// arguments:
+//
+//	---
+//	description: Summarise a page
+//	argument: url (url, required) Page to summarise
+//	argument: words (integer, default 150) Target length in words
+//	argument: style (enum bullets|prose, default bullets) Output style
+//	---
+//	Load {{.url}} with load_url, then ...
+//
+// The body is a text/template rendered with the argument values.
+package playbooks
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"roderik/internal/appdirs"
+)
+
+// Argument types accepted in front matter.
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeURL     = "url"
+	TypeEnum    = "enum"
+)
+
+// BuiltinSource is the Source of playbooks shipped with roderik.
+const BuiltinSource = "builtin"
+
+//go:embed builtin/*.md
+var builtinFS embed.FS
+
+// Argument is a typed playbook parameter. MCP prompt arguments are strings,
+// so values are validated against Type when the playbook is rendered.
+type Argument struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// Playbook is a parsed recipe.
+type Playbook struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Arguments   []Argument `json:"arguments,omitempty"`
+	Body        string     `json:"body"`
+	// Source is BuiltinSource or the file the playbook was read from.
+	Source string `json:"source"`
+
+	tmpl *template.Template
+}
+
+// DefaultDir returns <base>/playbooks.
+func DefaultDir() string {
+	base, err := appdirs.BaseDir()
+	if err != nil || strings.TrimSpace(base) == "" {
+		return ""
+	}
+	return filepath.Join(base, "playbooks")
+}
+
+// Load returns the built-in playbooks overridden and extended by the *.md
+// files in dir, sorted by name. Files that fail to parse are skipped and
+// reported in the returned error alongside the playbooks that did load.
+func Load(dir string) ([]Playbook, error) {
+	byName := make(map[string]Playbook)
+	var errs []error
+
+	builtin, _ := fs.Glob(builtinFS, "builtin/*.md")
+	for _, path := range builtin {
+		data, err := builtinFS.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pb, err := Parse(strings.TrimSuffix(filepath.Base(path), ".md"), data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("builtin %s: %w", path, err))
+			continue
+		}
+		pb.Source = BuiltinSource
+		byName[pb.Name] = pb
+	}
+
+	if strings.TrimSpace(dir) != "" {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			pb, err := Parse(strings.TrimSuffix(filepath.Base(path), ".md"), data)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			pb.Source = path
+			byName[pb.Name] = pb
+		}
+	}
+
+	out := make([]Playbook, 0, len(byName))
+	for _, pb := range byName {
+		out = append(out, pb)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, errors.Join(errs...)
+}
+
+// Find returns the named playbook from list.
+func Find(list []Playbook, name string) (Playbook, bool) {
+	for _, pb := range list {
+		if pb.Name == name {
+			return pb, true
+		}
+	}
+	return Playbook{}, false
+}
+
+// Parse reads a playbook file.
+func Parse(name string, data []byte) (Playbook, error) {
+	pb := Playbook{Name: name}
+	if name == "" || strings.ContainsAny(name, " \t/\\") {
+		return pb, fmt.Errorf("invalid playbook name %q", name)
+	}
+	body := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if rest, ok := strings.CutPrefix(body, "---\n"); ok {
+		header, after, found := strings.Cut(rest, "\n---\n")
+		if !found {
+			header, found = strings.CutSuffix(rest, "\n---")
+			after = ""
+		}
+		if !found {
+			return pb, fmt.Errorf("front matter is not closed with ---")
+		}
+		if err := pb.parseHeader(header); err != nil {
+			return pb, err
+		}
+		body = after
+	}
+	pb.Body = strings.TrimSpace(body)
+	if pb.Body == "" {
+		return pb, fmt.Errorf("playbook has no instructions")
+	}
+	if pb.Description == "" {
+		pb.Description = firstLine(pb.Body)
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(pb.Body)
+	if err != nil {
+		return pb, err
+	}
+	pb.tmpl = tmpl
+	return pb, nil
+}
+
+func (pb *Playbook) parseHeader(header string) error {
+	sc := bufio.NewScanner(strings.NewReader(header))
+	seen := make(map[string]bool)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("front matter line %d: expected key: value", n)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "description":
+			pb.Description = value
+		case "argument":
+			arg, err := parseArgument(value)
+			if err != nil {
+				return fmt.Errorf("front matter line %d: %w", n, err)
+			}
+			if seen[arg.Name] {
+				return fmt.Errorf("front matter line %d: duplicate argument %q", n, arg.Name)
+			}
+			seen[arg.Name] = true
+			pb.Arguments = append(pb.Arguments, arg)
+		default:
+			return fmt.Errorf("front matter line %d: unknown key %q", n, key)
+		}
+	}
+	return nil
+}
+
+// parseArgument reads "name (type[, required][, default value]) description".
+func parseArgument(spec string) (Argument, error) {
+	name, rest, _ := strings.Cut(spec, " ")
+	arg := Argument{Name: strings.TrimSpace(name), Type: TypeString}
+	if arg.Name == "" {
+		return arg, fmt.Errorf("argument without a name")
+	}
+	rest = strings.TrimSpace(rest)
+	if inner, ok := strings.CutPrefix(rest, "("); ok {
+		opts, desc, found := strings.Cut(inner, ")")
+		if !found {
+			return arg, fmt.Errorf("argument %s: unclosed (", arg.Name)
+		}
+		rest = strings.TrimSpace(desc)
+		for i, opt := range strings.Split(opts, ",") {
+			opt = strings.TrimSpace(opt)
+			switch {
+			case opt == "required":
+				arg.Required = true
+			case strings.HasPrefix(opt, "default "):
+				arg.Default = strings.TrimSpace(strings.TrimPrefix(opt, "default "))
+			case i == 0 && strings.HasPrefix(opt, TypeEnum+" "):
+				arg.Type = TypeEnum
+				arg.Enum = strings.Split(strings.TrimSpace(strings.TrimPrefix(opt, TypeEnum+" ")), "|")
+			case i == 0:
+				switch opt {
+				case TypeString, TypeInteger, TypeNumber, TypeBoolean, TypeURL:
+					arg.Type = opt
+				default:
+					return arg, fmt.Errorf("argument %s: unknown type %q", arg.Name, opt)
+				}
+			default:
+				return arg, fmt.Errorf("argument %s: unknown option %q", arg.Name, opt)
+			}
+		}
+	}
+	arg.Description = rest
+	if arg.Default != "" {
+		if err := arg.check(arg.Default); err != nil {
+			return arg, fmt.Errorf("default: %w", err)
+		}
+	}
+	return arg, nil
+}
+
+// check validates a value against the argument type.
+func (a Argument) check(value string) error {
+	switch a.Type {
+	case TypeInteger:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", a.Name, value)
+		}
+	case TypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s must be a number, got %q", a.Name, value)
+		}
+	case TypeBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", a.Name, value)
+		}
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s must be an absolute URL, got %q", a.Name, value)
+		}
+	case TypeEnum:
+		for _, v := range a.Enum {
+			if v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s, got %q", a.Name, strings.Join(a.Enum, ", "), value)
+	}
+	return nil
+}
+
+// Summary describes the argument for prompt listings, e.g.
+// "integer, default 150: Target length in words".
+func (a Argument) Summary() string {
+	var b strings.Builder
+	b.WriteString(a.Type)
+	if a.Type == TypeEnum {
+		b.WriteString(" " + strings.Join(a.Enum, "|"))
+	}
+	if a.Default != "" {
+		b.WriteString(", default " + a.Default)
+	}
+	if a.Description != "" {
+		b.WriteString(": " + a.Description)
+	}
+	return b.String()
+}
+
+// Render validates args, fills in defaults and executes the body template.
+func (pb Playbook) Render(args map[string]string) (string, error) {
+	values := make(map[string]string, len(pb.Arguments))
+	known := make(map[string]bool, len(pb.Arguments))
+	for _, arg := range pb.Arguments {
+		known[arg.Name] = true
+		value := strings.TrimSpace(args[arg.Name])
+		if value == "" {
+			if arg.Required {
+				return "", fmt.Errorf("playbook %s: argument %s is required", pb.Name, arg.Name)
+			}
+			value = arg.Default
+		} else if err := arg.check(value); err != nil {
+			return "", fmt.Errorf("playbook %s: %w", pb.Name, err)
+		}
+		if arg.Type == TypeBoolean && value != "" {
+			// templates compare against "true"/"false"
+			b, _ := strconv.ParseBool(value)
+			value = strconv.FormatBool(b)
+		}
+		values[arg.Name] = value
+	}
+	for name := range args {
+		if !known[name] {
+			return "", fmt.Errorf("playbook %s: unknown argument %s", pb.Name, name)
+		}
+	}
+	if pb.tmpl == nil {
+		return "", fmt.Errorf("playbook %s was not parsed", pb.Name)
+	}
+	var buf bytes.Buffer
+	if err := pb.tmpl.Execute(&buf, values); err != nil {
+		return "", fmt.Errorf("playbook %s: %w", pb.Name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(strings.TrimLeft(line, "# "))
+}
@@ -0,0 +1,105 @@
+package playbooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const samplePlaybook = `---
+description: Sample
+argument: url (url, required) Page to open
+argument: words (integer, default 150) Length
+argument: style (enum bullets|prose, default bullets) Style
+argument: verbose (boolean) Explain each step
+---
+Open {{.url}} in {{.words}} words as {{.style}}{{if eq .verbose "true"}}, verbosely{{end}}.
+`
+
+func TestParseAndRender(t *testing.T) {
+	pb, err := Parse("sample", []byte(samplePlaybook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pb.Description != "Sample" || len(pb.Arguments) != 4 {
+		t.Fatalf("unexpected playbook: %+v", pb)
+	}
+	if a := pb.Arguments[2]; a.Type != TypeEnum || a.Default != "bullets" || len(a.Enum) != 2 {
+		t.Fatalf("unexpected enum argument: %+v", a)
+	}
+
+	got, err := pb.Render(map[string]string{"url": "https://example.com", "verbose": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Open https://example.com in 150 words as bullets, verbosely."; got != want {
+		t.Fatalf("Render() = %q, want %q", got, want)
+	}
+
+	for _, args := range []map[string]string{
+		{},
+		{"url": "example.com"},
+		{"url": "https://example.com", "words": "many"},
+		{"url": "https://example.com", "style": "haiku"},
+		{"url": "https://example.com", "extra": "x"},
+	} {
+		if _, err := pb.Render(args); err == nil {
+			t.Fatalf("Render(%v) should fail", args)
+		}
+	}
+}
+
+func TestParseRejectsBadFrontMatter(t *testing.T) {
+	for _, src := range []string{
+		"---\ndescription: x\nbody without closing",
+		"---\ncolour: blue\n---\nbody",
+		"---\nargument: n (integer, default ten) x\n---\nbody",
+		"---\nargument: n (date) x\n---\nbody",
+		"---\ndescription: only a header\n---\n",
+	} {
+		if _, err := Parse("bad", []byte(src)); err == nil {
+			t.Fatalf("Parse(%q) should fail", src)
+		}
+	}
+}
+
+func TestLoadMergesDirectoryOverBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "summarise-page.md"), []byte("# Custom summary\nDo it my way."), 0o644)
+	os.WriteFile(filepath.Join(dir, "mine.md"), []byte(samplePlaybook), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.md"), []byte("---\nnope\n---\nx"), 0o644)
+
+	list, err := Load(dir)
+	if err == nil || !strings.Contains(err.Error(), "broken.md") {
+		t.Fatalf("expected an error naming broken.md, got %v", err)
+	}
+	custom, ok := Find(list, "summarise-page")
+	if !ok || custom.Source != filepath.Join(dir, "summarise-page.md") || custom.Description != "Custom summary" {
+		t.Fatalf("directory playbook did not override the builtin: %+v", custom)
+	}
+	if _, ok := Find(list, "mine"); !ok {
+		t.Fatal("directory playbook missing")
+	}
+	if pb, ok := Find(list, "download-media"); !ok || pb.Source != BuiltinSource {
+		t.Fatal("builtin playbook missing")
+	}
+}
+
+func TestBuiltinsRenderWithDefaults(t *testing.T) {
+	list, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pb := range list {
+		args := map[string]string{}
+		for _, a := range pb.Arguments {
+			if a.Required {
+				args[a.Name] = "someone"
+			}
+		}
+		if _, err := pb.Render(args); err != nil {
+			t.Fatalf("%s: %v", pb.Name, err)
+		}
+	}
+}
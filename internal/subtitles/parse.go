@@ -0,0 +1,310 @@
+// Package subtitles parses caption files (WebVTT, SRT and YouTube's timedtext
+// JSON/XML formats) into timed cues and renders them as plain text,
+// timestamped Markdown or JSON segments.
+package subtitles
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cue is one timed caption.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Source formats recognised by Sniff and Parse.
+const (
+	KindVTT          = "vtt"
+	KindSRT          = "srt"
+	KindJSON3        = "json3"
+	KindTimedTextXML = "timedtext-xml"
+)
+
+// ErrUnknownFormat is returned by Parse when the data is not a caption file.
+var ErrUnknownFormat = errors.New("subtitles: unrecognised caption format")
+
+// Sniff guesses the caption format of data, returning "" when it does not
+// look like captions at all.
+func Sniff(data []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("WEBVTT")):
+		return KindVTT
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			Events []json.RawMessage `json:"events"`
+		}
+		if json.Unmarshal(trimmed, &probe) == nil && len(probe.Events) > 0 {
+			return KindJSON3
+		}
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		head := trimmed
+		if len(head) > 512 {
+			head = head[:512]
+		}
+		if bytes.Contains(head, []byte("<transcript")) || bytes.Contains(head, []byte("<timedtext")) {
+			return KindTimedTextXML
+		}
+	case srtTimingRe.Match(trimmed):
+		return KindSRT
+	}
+	return ""
+}
+
+// Parse detects the format of data and parses it.
+func Parse(data []byte) ([]Cue, error) {
+	switch Sniff(data) {
+	case KindVTT:
+		return ParseVTT(string(data))
+	case KindSRT:
+		return ParseSRT(string(data))
+	case KindJSON3:
+		return ParseJSON3(data)
+	case KindTimedTextXML:
+		return ParseTimedTextXML(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+var (
+	// 00:01.000 --> 00:04.000 or 00:00:01,000 --> 00:00:04,000 plus settings
+	timingRe    = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s+-->\s+((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})`)
+	srtTimingRe = regexp.MustCompile(`(?m)^\s*\d{1,2}:\d{2}:\d{2},\d{1,3}\s+-->`)
+	tagRe       = regexp.MustCompile(`<[^>]*>`)
+	spaceRe     = regexp.MustCompile(`[ \t]+`)
+)
+
+// ParseVTT parses a WebVTT document. NOTE, STYLE and REGION blocks are skipped.
+func ParseVTT(data string) ([]Cue, error) {
+	return parseBlocks(data, true)
+}
+
+// ParseSRT parses a SubRip document.
+func ParseSRT(data string) ([]Cue, error) {
+	return parseBlocks(data, false)
+}
+
+func parseBlocks(data string, vtt bool) ([]Cue, error) {
+	data = strings.TrimPrefix(data, "\ufeff")
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+
+	var cues []Cue
+	for _, block := range strings.Split(data, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 0 || strings.TrimSpace(lines[0]) == "" {
+			continue
+		}
+		if vtt {
+			first := strings.TrimSpace(lines[0])
+			if strings.HasPrefix(first, "WEBVTT") || strings.HasPrefix(first, "NOTE") ||
+				strings.HasPrefix(first, "STYLE") || strings.HasPrefix(first, "REGION") {
+				continue
+			}
+		}
+		// optional cue identifier / SRT sequence number before the timing line
+		timing := -1
+		for i := 0; i < len(lines) && i < 2; i++ {
+			if timingRe.MatchString(lines[i]) {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+		m := timingRe.FindStringSubmatch(lines[timing])
+		start, err := parseTimestamp(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimestamp(m[2])
+		if err != nil {
+			return nil, err
+		}
+		var text []string
+		for _, line := range lines[timing+1:] {
+			if line = cleanText(line); line != "" {
+				text = append(text, line)
+			}
+		}
+		cues = append(cues, Cue{Start: start, End: end, Text: strings.Join(text, "\n")})
+	}
+	if len(cues) == 0 && !vtt && strings.TrimSpace(data) != "" {
+		return nil, fmt.Errorf("subtitles: no SRT cues found")
+	}
+	return cues, nil
+}
+
+// parseTimestamp accepts [hh:]mm:ss.mmm with either '.' or ',' before the
+// milliseconds.
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("subtitles: bad timestamp %q", s)
+	}
+	var total float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("subtitles: bad timestamp %q", s)
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total * float64(time.Second)).Round(time.Millisecond), nil
+}
+
+// cleanText strips inline markup (<c>, <v Speaker>, karaoke timestamps),
+// decodes entities and collapses whitespace.
+func cleanText(s string) string {
+	s = tagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	return strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+}
+
+// ParseJSON3 parses YouTube's timedtext "json3" format.
+func ParseJSON3(data []byte) ([]Cue, error) {
+	var doc struct {
+		Events []struct {
+			StartMs    int64 `json:"tStartMs"`
+			DurationMs int64 `json:"dDurationMs"`
+			Segs       []struct {
+				UTF8 string `json:"utf8"`
+			} `json:"segs"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("subtitles: decode json3: %w", err)
+	}
+	cues := make([]Cue, 0, len(doc.Events))
+	for _, ev := range doc.Events {
+		var sb strings.Builder
+		for _, seg := range ev.Segs {
+			sb.WriteString(seg.UTF8)
+		}
+		var lines []string
+		for _, line := range strings.Split(sb.String(), "\n") {
+			if line = cleanText(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		start := time.Duration(ev.StartMs) * time.Millisecond
+		cues = append(cues, Cue{
+			Start: start,
+			End:   start + time.Duration(ev.DurationMs)*time.Millisecond,
+			Text:  strings.Join(lines, "\n"),
+		})
+	}
+	return cues, nil
+}
+
+// ParseTimedTextXML parses YouTube's XML timedtext formats: the classic
+// <transcript><text start= dur=> and srv3 <timedtext><body><p t= d=>.
+func ParseTimedTextXML(data []byte) ([]Cue, error) {
+	type span struct {
+		Text string `xml:",chardata"`
+	}
+	var doc struct {
+		Texts []struct {
+			Start string `xml:"start,attr"`
+			Dur   string `xml:"dur,attr"`
+			Body  string `xml:",innerxml"`
+		} `xml:"text"`
+		Paras []struct {
+			T     int64  `xml:"t,attr"`
+			D     int64  `xml:"d,attr"`
+			Text  string `xml:",chardata"`
+			Spans []span `xml:"s"`
+		} `xml:"body>p"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("subtitles: decode timedtext xml: %w", err)
+	}
+	var cues []Cue
+	for _, t := range doc.Texts {
+		start, _ := strconv.ParseFloat(t.Start, 64)
+		dur, _ := strconv.ParseFloat(t.Dur, 64)
+		// the classic format double-escapes entities inside the element
+		text := cleanText(html.UnescapeString(t.Body))
+		if text == "" {
+			continue
+		}
+		s := time.Duration(start * float64(time.Second)).Round(time.Millisecond)
+		cues = append(cues, Cue{Start: s, End: s + time.Duration(dur*float64(time.Second)).Round(time.Millisecond), Text: text})
+	}
+	for _, p := range doc.Paras {
+		text := p.Text
+		for _, s := range p.Spans {
+			text += s.Text
+		}
+		if text = cleanText(text); text == "" {
+			continue
+		}
+		start := time.Duration(p.T) * time.Millisecond
+		cues = append(cues, Cue{Start: start, End: start + time.Duration(p.D)*time.Millisecond, Text: text})
+	}
+	return cues, nil
+}
+
+// Dedupe collapses YouTube-style rolling auto-captions, where each cue
+// repeats the previous line before adding a new one and short "bridge" cues
+// repeat text verbatim. Cues are merged so every spoken line appears once,
+// keeping the time it was first shown.
+func Dedupe(cues []Cue) []Cue {
+	var out []Cue
+	var prevLines []string
+	for _, c := range cues {
+		lines := strings.Split(c.Text, "\n")
+		var fresh []string
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" || containsLine(prevLines, line) {
+				continue
+			}
+			fresh = append(fresh, line)
+		}
+		prevLines = lines
+		if len(fresh) == 0 {
+			if n := len(out); n > 0 && c.End > out[n-1].End {
+				out[n-1].End = c.End
+			}
+			continue
+		}
+		text := strings.Join(fresh, " ")
+		if n := len(out); n > 0 && strings.HasPrefix(text, out[n-1].Text) {
+			// the caption grew word by word; keep the longest version
+			out[n-1].Text = text
+			if c.End > out[n-1].End {
+				out[n-1].End = c.End
+			}
+			continue
+		}
+		out = append(out, Cue{Start: c.Start, End: c.End, Text: text})
+	}
+	return out
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package subtitles
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Output formats accepted by Render.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// Formats lists the values accepted by Render.
+var Formats = []string{FormatText, FormatMarkdown, FormatJSON}
+
+// Paragraph grouping: a new paragraph starts after a pause of ParagraphGap
+// or once a paragraph reaches ParagraphChars characters.
+const (
+	ParagraphGap   = 2 * time.Second
+	ParagraphChars = 600
+)
+
+// Segment is the JSON form of a cue; times are in seconds.
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Segments converts cues to JSON-friendly segments.
+func Segments(cues []Cue) []Segment {
+	segs := make([]Segment, 0, len(cues))
+	for _, c := range cues {
+		segs = append(segs, Segment{
+			Start: c.Start.Seconds(),
+			End:   c.End.Seconds(),
+			Text:  strings.ReplaceAll(c.Text, "\n", " "),
+		})
+	}
+	return segs
+}
+
+// Paragraphs merges consecutive cues into readable paragraphs.
+func Paragraphs(cues []Cue) []Cue {
+	var out []Cue
+	for _, c := range cues {
+		text := strings.ReplaceAll(strings.TrimSpace(c.Text), "\n", " ")
+		if text == "" {
+			continue
+		}
+		if n := len(out); n > 0 {
+			last := &out[n-1]
+			if c.Start-last.End < ParagraphGap && len(last.Text)+len(text) < ParagraphChars {
+				last.Text += " " + text
+				if c.End > last.End {
+					last.End = c.End
+				}
+				continue
+			}
+		}
+		out = append(out, Cue{Start: c.Start, End: c.End, Text: text})
+	}
+	return out
+}
+
+// Render formats cues as plain text, "[mm:ss]" Markdown paragraphs or JSON
+// segments.
+func Render(cues []Cue, format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatText, "txt":
+		paras := Paragraphs(cues)
+		texts := make([]string, 0, len(paras))
+		for _, p := range paras {
+			texts = append(texts, p.Text)
+		}
+		return strings.Join(texts, "\n\n"), nil
+	case FormatMarkdown, "md":
+		var sb strings.Builder
+		for i, p := range Paragraphs(cues) {
+			if i > 0 {
+				sb.WriteString("\n\n")
+			}
+			fmt.Fprintf(&sb, "[%s] %s", Timestamp(p.Start), p.Text)
+		}
+		return sb.String(), nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(Segments(cues), "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("subtitles: unknown format %q (expected %s)", format, strings.Join(Formats, ", "))
+}
+
+// Extension returns the file extension for a Render format.
+func Extension(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatMarkdown, "md":
+		return ".md"
+	case FormatJSON:
+		return ".json"
+	}
+	return ".txt"
+}
+
+// Timestamp formats d as mm:ss, or h:mm:ss past the hour.
+func Timestamp(d time.Duration) string {
+	total := int(d / time.Second)
+	h, m, s := total/3600, (total/60)%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
@@ -0,0 +1,106 @@
+package subtitles
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const rollingVTT = `WEBVTT
+Kind: captions
+Language: en
+
+00:00:00.000 --> 00:00:02.500 align:start position:0%
+hello<00:00:00.500><c> world</c>
+
+00:00:02.500 --> 00:00:02.510 align:start position:0%
+hello world
+
+00:00:02.510 --> 00:00:05.000 align:start position:0%
+hello world
+this is<00:00:03.000><c> a test</c>
+
+00:00:05.000 --> 00:00:05.010
+this is a test
+
+00:00:09.000 --> 00:00:11.000
+after &amp; a pause
+`
+
+func TestParseVTTAndDedupe(t *testing.T) {
+	cues, err := Parse([]byte(rollingVTT))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(cues) != 5 || cues[2].Start != 2510*time.Millisecond || cues[2].Text != "hello world\nthis is a test" {
+		t.Fatalf("unexpected cues %#v", cues)
+	}
+
+	deduped := Dedupe(cues)
+	want := []string{"hello world", "this is a test", "after & a pause"}
+	if len(deduped) != len(want) {
+		t.Fatalf("Dedupe() = %#v", deduped)
+	}
+	for i, w := range want {
+		if deduped[i].Text != w {
+			t.Fatalf("cue %d = %q, want %q", i, deduped[i].Text, w)
+		}
+	}
+	if deduped[1].Start != 2510*time.Millisecond {
+		t.Fatalf("expected first-shown time to be kept, got %v", deduped[1].Start)
+	}
+
+	md, _ := Render(deduped, FormatMarkdown)
+	if md != "[00:00] hello world this is a test\n\n[00:09] after & a pause" {
+		t.Fatalf("unexpected markdown %q", md)
+	}
+	text, _ := Render(deduped, FormatText)
+	if text != "hello world this is a test\n\nafter & a pause" {
+		t.Fatalf("unexpected text %q", text)
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	srt := "1\r\n00:00:01,000 --> 00:00:03,200\r\n<i>First</i> line\r\n\r\n2\r\n01:02:03,400 --> 01:02:05,000\r\nSecond\r\nline two\r\n"
+	if kind := Sniff([]byte(srt)); kind != KindSRT {
+		t.Fatalf("Sniff() = %q, want srt", kind)
+	}
+	cues, err := Parse([]byte(srt))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(cues) != 2 || cues[0].Text != "First line" || cues[0].End != 3200*time.Millisecond {
+		t.Fatalf("unexpected cues %#v", cues)
+	}
+	if Timestamp(cues[1].Start) != "1:02:03" {
+		t.Fatalf("unexpected hour timestamp %q", Timestamp(cues[1].Start))
+	}
+	out, _ := Render(cues, FormatJSON)
+	if !strings.Contains(out, `"start": 3723.4`) || !strings.Contains(out, `"text": "Second line two"`) {
+		t.Fatalf("unexpected json %s", out)
+	}
+}
+
+func TestParseYouTubeTimedText(t *testing.T) {
+	json3 := `{"events":[{"tStartMs":0,"dDurationMs":1500,"segs":[{"utf8":"hi"},{"utf8":" there"}]},{"tStartMs":1500,"dDurationMs":10,"aAppend":1,"segs":[{"utf8":"\n"}]},{"tStartMs":2000,"dDurationMs":1000,"segs":[{"utf8":"bye"}]}]}`
+	cues, err := Parse([]byte(json3))
+	if err != nil || len(cues) != 2 || cues[0].Text != "hi there" || cues[1].Start != 2*time.Second {
+		t.Fatalf("json3: cues=%#v err=%v", cues, err)
+	}
+
+	classic := `<?xml version="1.0" encoding="utf-8" ?><transcript><text start="0.5" dur="1.25">it&amp;#39;s here</text></transcript>`
+	cues, err = Parse([]byte(classic))
+	if err != nil || len(cues) != 1 || cues[0].Text != "it's here" || cues[0].End != 1750*time.Millisecond {
+		t.Fatalf("classic xml: cues=%#v err=%v", cues, err)
+	}
+
+	srv3 := `<timedtext format="3"><body><p t="1000" d="2000">one <s>two</s></p></body></timedtext>`
+	cues, err = Parse([]byte(srv3))
+	if err != nil || len(cues) != 1 || cues[0].Text != "one two" || cues[0].Start != time.Second {
+		t.Fatalf("srv3: cues=%#v err=%v", cues, err)
+	}
+
+	if _, err := Parse([]byte("<html></html>")); err != ErrUnknownFormat {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}